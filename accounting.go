@@ -0,0 +1,233 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// ErrResponseTooLarge is returned by the response writer when the response body exceeds the MaxBytes limit.
+var ErrResponseTooLarge = errors.New("http: response body too large")
+
+// BytesRead returns the number of bytes read from the request body so far.
+func (c *RouteContext) BytesRead() int64 {
+	return c.body.n.Load()
+}
+
+// BytesWritten returns the number of bytes written in the response body so far.
+func (c *RouteContext) BytesWritten() int64 {
+	return c.writer.n.Load()
+}
+
+// accounting wraps the request body and the response writer to count the bytes,
+// the wrappers are embedded in the pooled route context to avoid allocations.
+func (c *RouteContext) accounting(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	if nil != r.Body && http.NoBody != r.Body {
+		c.body.ReadCloser = r.Body
+		r.Body = &c.body
+	}
+	c.writer.ResponseWriter = w
+	return c.optionals.expose(&c.writer)
+}
+
+// countingBody counts the bytes read from the request body.
+type countingBody struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	return n, err
+}
+
+// countingWriter counts the bytes written in the response body.
+type countingWriter struct {
+	http.ResponseWriter
+	n atomic.Int64
+}
+
+func (w *countingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.n.Add(int64(n))
+	return n, err
+}
+
+func (w *countingWriter) ReadFrom(r io.Reader) (int64, error) {
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err := rf.ReadFrom(r)
+		w.n.Add(n)
+		return n, err
+	}
+	return io.Copy(struct{ io.Writer }{w}, r)
+}
+
+// MaxBytes returns a middleware limiting the request body read to ingress bytes, and the response body
+// written to egress bytes, zero means no limit. Reading beyond the limit fails with *http.MaxBytesError
+// that's rendered as 413 Request Entity Too Large by JsonRender, writing beyond the limit fails with
+// ErrResponseTooLarge.
+//
+//	router.Group("/upload", func(r web.Router) {
+//		r.Use(web.MaxBytes(10<<20, 0))
+//	})
+func MaxBytes(ingress, egress int64) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if ingress > 0 && nil != request.Body {
+				request.Body = http.MaxBytesReader(writer, request.Body, ingress)
+			}
+			if egress > 0 {
+				writer = new(optionalWriters).expose(&limitedWriter{ResponseWriter: writer, remaining: egress})
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// MaxBodyBytesKey is the route metadata key of the request body limit of the route.
+const MaxBodyBytesKey = "web.maxBodyBytes"
+
+// MaxBodyBytes limits the request body read by the handlers of the route to n bytes, overriding
+// RouterOptions.MaxBodyBytes, zero means no limit. The bodies beyond the limit fail to read or bind
+// with *http.MaxBytesError, which is rendered as 413 Request Entity Too Large by the Renderer.
+//
+//	router.Post("/documents", Upload).Apply(web.MaxBodyBytes(1 << 20))
+func MaxBodyBytes(n int64) RouteOption {
+	if n < 0 {
+		panic(fmt.Sprintf("invalid max body bytes: %d", n))
+	}
+	return func(e Endpoint) {
+		e.Meta(MaxBodyBytesKey, n)
+	}
+}
+
+// limitBody wraps the request body with http.MaxBytesReader by the limit of the route or the router,
+// before any handler of the route reads it.
+func limitBody(rg *routerGroup, ctx *RouteContext, w http.ResponseWriter, r *http.Request) {
+	var limit int64
+	if nil != rg.opts {
+		limit = rg.opts.maxBodyBytes
+	}
+	if n, ok := ctx.routeMetadata[MaxBodyBytesKey].(int64); ok {
+		limit = n
+	}
+	if limit > 0 && nil != r.Body && http.NoBody != r.Body {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+}
+
+// limitedWriter fails the writes beyond the limit of the response body.
+type limitedWriter struct {
+	http.ResponseWriter
+	remaining int64
+}
+
+func (w *limitedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= w.remaining {
+		n, err := w.ResponseWriter.Write(p)
+		w.remaining -= int64(n)
+		return n, err
+	}
+
+	n, err := w.ResponseWriter.Write(p[:w.remaining])
+	w.remaining -= int64(n)
+	if nil == err {
+		err = ErrResponseTooLarge
+	}
+	return n, err
+}
+
+func (w *limitedWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{w}, r)
+}
+
+// wrappedWriter is a response writer wrapping another one.
+type wrappedWriter interface {
+	http.ResponseWriter
+	io.ReaderFrom
+	Unwrap() http.ResponseWriter
+}
+
+// optionalWriters exposes http.Flusher and http.Hijacker on the wrapped writer only if the writer
+// it wraps supports them, so that the type assertions of the handlers don't succeed and then fail
+// at runtime. The variants are kept in the struct to avoid allocations in the pooled route context.
+type optionalWriters struct {
+	flush       flushWriter
+	hijack      hijackWriter
+	flushHijack flushHijackWriter
+}
+
+func (o *optionalWriters) expose(w wrappedWriter) http.ResponseWriter {
+	_, flusher := w.Unwrap().(http.Flusher)
+	_, hijacker := w.Unwrap().(http.Hijacker)
+	switch {
+	case flusher && hijacker:
+		o.flushHijack.wrappedWriter = w
+		return &o.flushHijack
+	case flusher:
+		o.flush.wrappedWriter = w
+		return &o.flush
+	case hijacker:
+		o.hijack.wrappedWriter = w
+		return &o.hijack
+	default:
+		return w
+	}
+}
+
+type flushWriter struct {
+	wrappedWriter
+}
+
+func (w *flushWriter) Flush() {
+	_ = http.NewResponseController(w.wrappedWriter).Flush()
+}
+
+type hijackWriter struct {
+	wrappedWriter
+}
+
+func (w *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.wrappedWriter).Hijack()
+}
+
+type flushHijackWriter struct {
+	wrappedWriter
+}
+
+func (w *flushHijackWriter) Flush() {
+	_ = http.NewResponseController(w.wrappedWriter).Flush()
+}
+
+func (w *flushHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.wrappedWriter).Hijack()
+}
@@ -0,0 +1,131 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytesAccounting(t *testing.T) {
+	var read, written int64
+
+	r := NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			ctx := FromRouteContext(r.Context())
+			read, written = ctx.BytesRead(), ctx.BytesWritten()
+		})
+	})
+	r.Group("/api", func(r Router) {
+		r.Post("/echo", func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(w, r.Body)
+			w.Write([]byte("!"))
+		})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/echo", strings.NewReader("hello")))
+	assert.Equal(t, "hello!", w.Body.String())
+	assert.Equal(t, int64(5), read)
+	assert.Equal(t, int64(6), written)
+
+	// the counting writer keeps the optional interfaces of the underlying writer, and only them.
+	var hijacker bool
+	r = NewRouter()
+	r.Get("/flush", func(w http.ResponseWriter, r *http.Request) {
+		_, hijacker = w.(http.Hijacker)
+		w.Write([]byte("data"))
+		w.(http.Flusher).Flush()
+	})
+	r.With(MaxBytes(0, 16)).Get("/limited", func(w http.ResponseWriter, r *http.Request) {
+		_, hijacker = w.(http.Hijacker)
+		w.(http.Flusher).Flush()
+	})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/flush", nil))
+	assert.True(t, w.Flushed)
+	assert.False(t, hijacker)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	assert.True(t, w.Flushed)
+	assert.False(t, hijacker)
+
+	_, flusher := new(optionalWriters).expose(&countingWriter{ResponseWriter: struct{ http.ResponseWriter }{w}}).(http.Flusher)
+	assert.False(t, flusher)
+}
+
+func TestMaxBytes(t *testing.T) {
+	var writeErr error
+
+	r := NewRouter()
+	r.Group("/limited", func(r Router) {
+		r.Use(MaxBytes(8, 0))
+		r.Post("/upload", func(ctx context.Context, req struct {
+			Name string `json:"name"`
+		}) string {
+			return req.Name
+		})
+		r.With(MaxBytes(0, 4)).Get("/download", func(w http.ResponseWriter, r *http.Request) {
+			_, writeErr = w.Write([]byte("too large"))
+		})
+	})
+
+	w := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/limited/upload", strings.NewReader(`{"name":"a very long name"}`))
+	request.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, request)
+	assert.Contains(t, w.Body.String(), `"code":413`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited/download", nil))
+	assert.Equal(t, "too ", w.Body.String())
+	assert.Equal(t, ErrResponseTooLarge, writeErr)
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	type doc struct {
+		Text string `json:"text"`
+	}
+
+	r := NewRouterWith(RouterOptions{MaxBodyBytes: 16})
+	handler := func(ctx context.Context, req *doc) int { return len(req.Text) }
+	r.Post("/notes", handler)
+	r.Post("/documents", handler).Apply(MaxBodyBytes(64))
+	r.Post("/unlimited", handler).Apply(MaxBodyBytes(0))
+	r.Post("/context", func(ctx context.Context) {
+		var req doc
+		webCtx := FromContext(ctx)
+		if err := webCtx.Bind(&req); nil != err {
+			_ = webCtx.String(http.StatusRequestEntityTooLarge, "%v", err)
+			return
+		}
+		_ = webCtx.String(http.StatusOK, "%d", len(req.Text))
+	})
+
+	post := func(path string, size int) string {
+		req := httptest.NewRequest("POST", path, strings.NewReader(`{"text":"`+strings.Repeat("x", size)+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	assert.Equal(t, "{\"code\":0,\"data\":4}\n", post("/notes", 4))
+	assert.Contains(t, post("/notes", 8), "{\"code\":413,\"message\":\"binding failed: http: request body too large\"")
+	assert.Equal(t, "{\"code\":0,\"data\":40}\n", post("/documents", 40))
+	assert.Contains(t, post("/documents", 60), "{\"code\":413,")
+	assert.Equal(t, "{\"code\":0,\"data\":100}\n", post("/unlimited", 100))
+
+	// the bodies bound by Context.Bind are limited as well.
+	assert.Equal(t, "4", post("/context", 4))
+	assert.Equal(t, "binding failed: http: request body too large", post("/context", 8))
+
+	assert.Panics(t, func() { MaxBodyBytes(-1) })
+}
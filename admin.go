@@ -0,0 +1,201 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Admin exposes the runtime controls of the server under a mount protected by the authenticators,
+// the controls of the nil subsystems are not available.
+//
+//	admin := &web.Admin{Auth: []web.Authenticator{web.BearerAuth(verifyAdmin)}, LogLevel: level, Server: server}
+//	router.Use(admin.Maintenance())
+//	...
+//	admin.Mount(router, "/admin")
+//
+// The endpoints of the mount accept and return JSON:
+//
+//	GET /            the status of the controls
+//	PUT /log-level   {"level": "DEBUG"}
+//	PUT /maintenance {"enabled": true, "message": "back at 10:00"}
+//	PUT /features/{name} {"enabled": true}
+//	PUT /limits      {"method": "GET", "pattern": "/reports", "limit": 5}
+//	PUT /draining    {"enabled": true}
+type Admin struct {
+	// Auth authenticates the admin requests, at least one authenticator is required.
+	Auth []Authenticator
+
+	// LogLevel is the level of the application logger, e.g. set as slog.HandlerOptions.Level.
+	LogLevel *slog.LevelVar
+
+	// Features are the feature flags of the routes, see Features.Require.
+	Features *Features
+
+	// Routes are the routes whose MaxInflight limits are overridden, the router of the mount by default.
+	Routes Routes
+
+	// Server is the server draining its connections, see Server.SetDraining.
+	Server *Server
+
+	prefix      string
+	maintenance atomic.Pointer[string]
+}
+
+// AdminStatus is the status of the runtime controls.
+type AdminStatus struct {
+	LogLevel    string          `json:"logLevel,omitempty"`
+	Maintenance bool            `json:"maintenance"`
+	Message     string          `json:"message,omitempty"`
+	Features    map[string]bool `json:"features,omitempty"`
+	Draining    bool            `json:"draining"`
+}
+
+// Maintenance returns a middleware rejecting the requests with 503 Service Unavailable while the
+// maintenance mode is enabled, except the requests of the admin mount so it can be disabled again.
+func (a *Admin) Maintenance() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			message := a.maintenance.Load()
+			if nil == message || a.admits(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, *message, http.StatusServiceUnavailable)
+		})
+	}
+}
+
+// admits reports whether the path is served by the admin mount.
+func (a *Admin) admits(path string) bool {
+	return len(a.prefix) > 0 && (path == a.prefix || strings.HasPrefix(path, a.prefix+"/"))
+}
+
+// SetMaintenance enables or disables the maintenance mode, the message is sent to the rejected requests.
+func (a *Admin) SetMaintenance(enabled bool, message string) {
+	if !enabled {
+		a.maintenance.Store(nil)
+		return
+	}
+	if 0 == len(message) {
+		message = "503 service unavailable"
+	}
+	a.maintenance.Store(&message)
+}
+
+// Status returns the status of the runtime controls.
+func (a *Admin) Status() AdminStatus {
+	var status AdminStatus
+	if nil != a.LogLevel {
+		status.LogLevel = a.LogLevel.Level().String()
+	}
+	if message := a.maintenance.Load(); nil != message {
+		status.Maintenance, status.Message = true, *message
+	}
+	if nil != a.Features {
+		status.Features = a.Features.All()
+	}
+	if nil != a.Server {
+		status.Draining = a.Server.Draining()
+	}
+	return status
+}
+
+// Mount registers the admin endpoints on the router under the pattern, the pattern must be static
+// so that the Maintenance middleware is able to let the admin requests through.
+func (a *Admin) Mount(r Router, pattern string) {
+	if 0 == len(a.Auth) {
+		panic("admin endpoints require at least one authenticator")
+	}
+	if strings.ContainsAny(pattern, "{*") {
+		panic("admin pattern must be static: " + pattern)
+	}
+
+	a.prefix = strings.TrimSuffix(pattern, "/")
+	if nil == a.Routes {
+		a.Routes = r
+	}
+
+	r.Group(pattern, func(r Router) {
+		r.Use(AuthChain(a.Auth...))
+		r.Renderer(JsonRender())
+
+		r.Get("/", func(ctx context.Context) AdminStatus {
+			return a.Status()
+		})
+
+		r.Put("/log-level", func(ctx context.Context, req struct {
+			Level string `json:"level"`
+		}) (AdminStatus, error) {
+			if nil == a.LogLevel {
+				return AdminStatus{}, Error(http.StatusNotFound, "log level is not configured")
+			}
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(req.Level)); nil != err {
+				return AdminStatus{}, Error(http.StatusBadRequest, "%v", err)
+			}
+			a.LogLevel.Set(level)
+			return a.Status(), nil
+		})
+
+		r.Put("/maintenance", func(ctx context.Context, req struct {
+			Enabled bool   `json:"enabled"`
+			Message string `json:"message"`
+		}) AdminStatus {
+			a.SetMaintenance(req.Enabled, req.Message)
+			return a.Status()
+		})
+
+		r.Put("/features/{name}", func(ctx context.Context, req struct {
+			Name    string `path:"name"`
+			Enabled bool   `json:"enabled"`
+		}) (AdminStatus, error) {
+			if nil == a.Features || !a.Features.Set(req.Name, req.Enabled) {
+				return AdminStatus{}, Error(http.StatusNotFound, "feature %q is not defined", req.Name)
+			}
+			return a.Status(), nil
+		})
+
+		r.Put("/limits", func(ctx context.Context, req struct {
+			Method  string `json:"method"`
+			Pattern string `json:"pattern"`
+			Limit   int    `json:"limit"`
+		}) (AdminStatus, error) {
+			if req.Limit <= 0 {
+				return AdminStatus{}, Error(http.StatusBadRequest, "invalid max inflight limit: %d", req.Limit)
+			}
+			if !SetMaxInflight(a.Routes, req.Method, req.Pattern, req.Limit) {
+				return AdminStatus{}, Error(http.StatusNotFound, "route %s %s has no max inflight limit", req.Method, req.Pattern)
+			}
+			return a.Status(), nil
+		})
+
+		r.Put("/draining", func(ctx context.Context, req struct {
+			Enabled bool `json:"enabled"`
+		}) (AdminStatus, error) {
+			if nil == a.Server {
+				return AdminStatus{}, Error(http.StatusNotFound, "server is not configured")
+			}
+			a.Server.SetDraining(req.Enabled)
+			return a.Status(), nil
+		})
+	})
+}
@@ -0,0 +1,126 @@
+package web
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmin(t *testing.T) {
+	level := &slog.LevelVar{}
+	features := NewFeatures(map[string]bool{"beta": false})
+	server := NewServer(Options{})
+	admin := &Admin{
+		Auth: []Authenticator{APIKeyAuth("X-Admin-Key", func(ctx context.Context, key string) (interface{}, error) {
+			if "secret" != key {
+				return nil, Error(http.StatusForbidden, "invalid key")
+			}
+			return "admin", nil
+		})},
+		LogLevel: level,
+		Features: features,
+		Server:   server,
+	}
+
+	r := NewRouter()
+	r.Use(admin.Maintenance())
+	r.With(features.Require("beta")).Get("/beta", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/reports", func(w http.ResponseWriter, r *http.Request) {}).Apply(MaxInflight(1))
+	admin.Mount(r, "/admin")
+
+	serve := func(method, path, body string) (int, string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Admin-Key", "secret")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code, w.Body.String()
+	}
+
+	// the admin endpoints require the authentication.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	_, body := serve(http.MethodGet, "/admin/", "")
+	assert.Equal(t, `{"code":0,"data":{"logLevel":"INFO","maintenance":false,"features":{"beta":false},"draining":false}}`+"\n", body)
+
+	_, body = serve(http.MethodPut, "/admin/log-level", `{"level":"debug"}`)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+	assert.Contains(t, body, `"logLevel":"DEBUG"`)
+	_, body = serve(http.MethodPut, "/admin/log-level", `{"level":"verbose"}`)
+	assert.Contains(t, body, `"code":400`)
+
+	code, _ := serve(http.MethodGet, "/beta", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	serve(http.MethodPut, "/admin/features/beta", `{"enabled":true}`)
+	code, _ = serve(http.MethodGet, "/beta", "")
+	assert.Equal(t, http.StatusOK, code)
+	_, body = serve(http.MethodPut, "/admin/features/gamma", `{"enabled":true}`)
+	assert.Contains(t, body, `"code":404`)
+
+	// the maintenance mode lets the admin requests through.
+	serve(http.MethodPut, "/admin/maintenance", `{"enabled":true,"message":"back soon"}`)
+	code, body = serve(http.MethodGet, "/reports", "")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "back soon\n", body)
+	_, body = serve(http.MethodPut, "/admin/maintenance", `{"enabled":false}`)
+	assert.Contains(t, body, `"maintenance":false`)
+	code, _ = serve(http.MethodGet, "/reports", "")
+	assert.Equal(t, http.StatusOK, code)
+
+	_, body = serve(http.MethodPut, "/admin/limits", `{"method":"GET","pattern":"/reports","limit":3}`)
+	assert.Contains(t, body, `"code":0`)
+	assert.Equal(t, 3, lookupInflight(r, http.MethodGet, "/reports", "").limit)
+	_, body = serve(http.MethodPut, "/admin/limits", `{"method":"GET","pattern":"/beta","limit":3}`)
+	assert.Contains(t, body, `"code":404`)
+
+	_, body = serve(http.MethodPut, "/admin/draining", `{"enabled":true}`)
+	assert.Contains(t, body, `"draining":true`)
+	assert.True(t, server.Draining())
+	assert.False(t, server.Ready())
+
+	assert.Panics(t, func() { (&Admin{}).Mount(NewRouter(), "/admin") })
+}
+
+func TestSetMaxInflight(t *testing.T) {
+	entered, unblock := make(chan struct{}), make(chan struct{})
+	r := NewRouter()
+	r.Group("/api", func(r Router) {
+		r.Get("/reports", func(w http.ResponseWriter, r *http.Request) {
+			entered <- struct{}{}
+			<-unblock
+		}).Apply(MaxInflight(1), MaxQueue(1, time.Second))
+	})
+
+	assert.False(t, SetMaxInflight(r, http.MethodGet, "/reports", 2))
+	assert.False(t, SetMaxInflight(r, http.MethodPost, "/api/reports", 2))
+
+	done := make(chan int, 2)
+	serve := func() {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/reports", nil))
+		done <- w.Code
+	}
+	go serve()
+	<-entered
+	go serve()
+
+	// the waiting request is admitted as soon as the limit is raised.
+	l := lookupInflight(r, http.MethodGet, "/api/reports", "")
+	for l.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.True(t, SetMaxInflight(r, http.MethodGet, "/api/reports", 2))
+	<-entered
+
+	close(unblock)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, http.StatusOK, <-done)
+}
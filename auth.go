@@ -0,0 +1,149 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrNoCredentials is returned by the Authenticator if the request carries no credentials of its kind,
+// so that the AuthChain tries the next authenticator.
+var ErrNoCredentials = errors.New("web: no credentials")
+
+// Authenticator authenticates the credentials of a kind carried by the request.
+type Authenticator interface {
+	// Name identifies the authenticator, it's recorded by the AuthChain once it succeeds.
+	Name() string
+
+	// Authenticate returns the principal of the request credentials, ErrNoCredentials if the
+	// request carries no credentials of its kind, or another error if they are invalid.
+	Authenticate(r *http.Request) (principal interface{}, err error)
+}
+
+// NewAuthenticator returns an Authenticator of the name with the authenticate function.
+func NewAuthenticator(name string, authenticate func(r *http.Request) (interface{}, error)) Authenticator {
+	return &authenticator{name: name, authenticate: authenticate}
+}
+
+type authenticator struct {
+	name         string
+	authenticate func(r *http.Request) (interface{}, error)
+}
+
+func (a *authenticator) Name() string {
+	return a.name
+}
+
+func (a *authenticator) Authenticate(r *http.Request) (interface{}, error) {
+	return a.authenticate(r)
+}
+
+// BearerAuth returns the "bearer" Authenticator verifying the token of the `Authorization: Bearer` header,
+// e.g. a JWT.
+func BearerAuth(verify func(ctx context.Context, token string) (interface{}, error)) Authenticator {
+	return NewAuthenticator("bearer", func(r *http.Request) (interface{}, error) {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || 0 == len(strings.TrimSpace(token)) {
+			return nil, ErrNoCredentials
+		}
+		return verify(r.Context(), strings.TrimSpace(token))
+	})
+}
+
+// BasicAuth returns the "basic" Authenticator verifying the user and password of the `Authorization: Basic` header.
+func BasicAuth(verify func(ctx context.Context, username, password string) (interface{}, error)) Authenticator {
+	return NewAuthenticator("basic", func(r *http.Request) (interface{}, error) {
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			return nil, ErrNoCredentials
+		}
+		return verify(r.Context(), username, password)
+	})
+}
+
+// APIKeyAuth returns the "apikey" Authenticator verifying the API key of the request header,
+// the verify function should compare the keys in constant time.
+func APIKeyAuth(header string, verify func(ctx context.Context, key string) (interface{}, error)) Authenticator {
+	return NewAuthenticator("apikey", func(r *http.Request) (interface{}, error) {
+		key := r.Header.Get(header)
+		if 0 == len(key) {
+			return nil, ErrNoCredentials
+		}
+		return verify(r.Context(), key)
+	})
+}
+
+// Anonymous returns the "anonymous" Authenticator accepting any request with a nil principal,
+// it's the last one of the AuthChain of the public APIs.
+func Anonymous() Authenticator {
+	return NewAuthenticator("anonymous", func(r *http.Request) (interface{}, error) {
+		return nil, nil
+	})
+}
+
+// Authentication records the authenticator succeeded in the AuthChain and the principal it returned.
+type Authentication struct {
+	Authenticator string
+	Principal     interface{}
+}
+
+// AuthenticationOf returns the authentication of the request recorded by the AuthChain.
+func AuthenticationOf(ctx context.Context) (Authentication, bool) {
+	return Value[Authentication](ctx)
+}
+
+// AuthChain returns a middleware that tries the authenticators in order until one of them succeeds,
+// and records its name and principal on the request context, see AuthenticationOf.
+//
+// The authenticators returning ErrNoCredentials are skipped, but the invalid credentials reject the
+// request with 401 Unauthorized without trying the rest, so they can't fall back to Anonymous.
+// The HttpError of the authenticator is written with its status and message instead.
+//
+//	router.Use(web.AuthChain(web.BearerAuth(verifyJWT), web.APIKeyAuth("X-API-Key", verifyKey), web.Anonymous()))
+func AuthChain(authenticators ...Authenticator) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			for _, auth := range authenticators {
+				principal, err := auth.Authenticate(request)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if nil != err {
+					unauthorized(writer, err)
+					return
+				}
+
+				ctx := WithValue(request.Context(), Authentication{Authenticator: auth.Name(), Principal: principal})
+				next.ServeHTTP(writer, request.WithContext(ctx))
+				return
+			}
+			unauthorized(writer, ErrNoCredentials)
+		})
+	}
+}
+
+func unauthorized(writer http.ResponseWriter, err error) {
+	var httpErr HttpError
+	if errors.As(err, &httpErr) {
+		http.Error(writer, httpErr.Message, httpErr.Code)
+		return
+	}
+	http.Error(writer, "401 unauthorized", http.StatusUnauthorized)
+}
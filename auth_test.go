@@ -0,0 +1,70 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthChain(t *testing.T) {
+	jwt := BearerAuth(func(ctx context.Context, token string) (interface{}, error) {
+		if "valid" != token {
+			return nil, errors.New("invalid token")
+		}
+		return "alice", nil
+	})
+	apikey := APIKeyAuth("X-API-Key", func(ctx context.Context, key string) (interface{}, error) {
+		if "k1" != key {
+			return nil, Error(http.StatusForbidden, "revoked key")
+		}
+		return "service", nil
+	})
+	basic := BasicAuth(func(ctx context.Context, username, password string) (interface{}, error) {
+		return username, nil
+	})
+
+	r := NewRouter()
+	r.Use(AuthChain(jwt, apikey, basic, Anonymous()))
+	r.Get("/whoami", func(ctx context.Context) string {
+		auth, ok := AuthenticationOf(ctx)
+		return fmt.Sprintf("%v %s %v", ok, auth.Authenticator, auth.Principal)
+	})
+
+	serve := func(header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/whoami", nil)
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, "{\"code\":0,\"data\":\"true bearer alice\"}\n", serve("Authorization", "Bearer valid").Body.String())
+	assert.Equal(t, "{\"code\":0,\"data\":\"true apikey service\"}\n", serve("X-API-Key", "k1").Body.String())
+	assert.Equal(t, "{\"code\":0,\"data\":\"true basic bob\"}\n", serve("Authorization", "Basic Ym9iOnNlY3JldA==").Body.String())
+	assert.Equal(t, "{\"code\":0,\"data\":\"true anonymous \\u003cnil\\u003e\"}\n", serve().Body.String())
+
+	// the invalid credentials don't fall back to the next authenticators.
+	w := serve("Authorization", "Bearer forged", "X-API-Key", "k1")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "401 unauthorized\n", w.Body.String())
+
+	w = serve("X-API-Key", "k2")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "revoked key\n", w.Body.String())
+
+	// no authenticator succeeded.
+	r = NewRouter()
+	r.Use(AuthChain(jwt, apikey))
+	r.Get("/whoami", func(ctx context.Context) string { return "" })
+	assert.Equal(t, http.StatusUnauthorized, serve().Code)
+
+	_, ok := AuthenticationOf(context.Background())
+	assert.False(t, ok)
+}
@@ -18,12 +18,16 @@ package web
 
 import (
 	"context"
+	"encoding"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
 
 	"go-spring.dev/web/binding"
+	"go-spring.dev/web/render"
 )
 
 type Renderer interface {
@@ -36,6 +40,27 @@ func (fn RendererFunc) Render(ctx *Context, err error, result interface{}) {
 	fn(ctx, err, result)
 }
 
+// ResultChecker is an optional interface implemented by a Renderer,
+// it reports whether the handler result type can be rendered so that
+// mistakes are surfaced when the handler is bound instead of at request time.
+type ResultChecker interface {
+	CheckResult(t reflect.Type) error
+}
+
+// Invoke calls the next interceptor in the chain, or the handler itself
+// when it is the last one, with the bound request.
+type Invoke func(ctx context.Context, req interface{}) (interface{}, error)
+
+// HandlerInterceptor intercepts the invocation of a typed handler, it runs after
+// the request has been bound and validated and before the result is rendered.
+//
+// The req is the bound request value accepted by the handler, or nil if the
+// handler does not accept a request, the interceptor may short-circuit the
+// invocation by returning without calling next. The request passed to next must be
+// assignable to the request type of the handler, or the invocation fails with an
+// error, and the result returned is rendered even if the handler returns nothing.
+type HandlerInterceptor func(ctx context.Context, req interface{}, next Invoke) (interface{}, error)
+
 // Bind convert fn to HandlerFunc.
 //
 // func(ctx context.Context)
@@ -51,7 +76,13 @@ func (fn RendererFunc) Render(ctx *Context, err error, result interface{}) {
 // func(ctx context.Context, req T) (R, error)
 //
 // func(writer http.ResponseWriter, request *http.Request)
-func Bind(fn interface{}, render Renderer) http.HandlerFunc {
+//
+// The handlers returning a receive-only channel `<-chan R` stream the records instead of rendering
+// the result, as newline delimited JSON, or as server-sent events if the route produces
+// `text/event-stream`, e.g. `Produces("application/x-ndjson", "text/event-stream")`.
+//
+// The interceptors are applied to typed handlers in the order they are given.
+func Bind(fn interface{}, render Renderer, interceptors ...HandlerInterceptor) http.HandlerFunc {
 
 	fnValue := reflect.ValueOf(fn)
 	fnType := fnValue.Type()
@@ -68,9 +99,52 @@ func Bind(fn interface{}, render Renderer) http.HandlerFunc {
 		if err := validMappingFunc(fnType); nil != err {
 			panic(err)
 		}
+		// valid result type
+		if err := validResultType(fnType, render); nil != err {
+			panic(err)
+		}
 	}
 
 	firstOutIsErrorType := 1 == fnType.NumOut() && isErrorType(fnType.Out(0))
+	streaming := fnType.NumOut() > 0 && isStreamType(fnType.Out(0))
+
+	// invoke the handler with bound request.
+	var invoke Invoke = func(ctx context.Context, req interface{}) (result interface{}, err error) {
+		var args = []reflect.Value{reflect.ValueOf(ctx)}
+		if 2 == fnType.NumIn() {
+			if nil == req {
+				args = append(args, reflect.Zero(fnType.In(1)))
+			} else if reqType := reflect.TypeOf(req); !reqType.AssignableTo(fnType.In(1)) {
+				// an interceptor replaced the request with a value the handler can't accept.
+				return nil, fmt.Errorf("%s: input param type (%s) is expected, but the interceptors passed %s", fnType.String(), fnType.In(1).String(), reqType.String())
+			} else {
+				args = append(args, reflect.ValueOf(req))
+			}
+		}
+
+		returnValues := fnValue.Call(args)
+		switch len(returnValues) {
+		case 0:
+			// nothing
+		case 1:
+			if firstOutIsErrorType {
+				err, _ = returnValues[0].Interface().(error)
+			} else {
+				result = returnValues[0].Interface()
+			}
+		case 2:
+			// check error
+			result = returnValues[0].Interface()
+			err, _ = returnValues[1].Interface().(error)
+		default:
+			panic("unreachable here")
+		}
+		return
+	}
+
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		invoke = chainInterceptor(interceptors[i], invoke)
+	}
 
 	return func(writer http.ResponseWriter, request *http.Request) {
 
@@ -78,21 +152,24 @@ func Bind(fn interface{}, render Renderer) http.HandlerFunc {
 		webCtx := &Context{Writer: writer, Request: request}
 		ctx := WithContext(request.Context(), webCtx)
 
+		// render the error panics by the Recovery middleware.
+		if slot, ok := request.Context().Value(recoveryKey{}).(*recoverySlot); ok {
+			slot.ctx, slot.render = webCtx, render
+		}
+
 		defer func() {
 			if nil != request.MultipartForm {
 				_ = request.MultipartForm.RemoveAll()
 			}
-			_ = request.Body.Close()
+			if nil != request.Body {
+				_ = request.Body.Close()
+			}
 		}()
 
-		var returnValues []reflect.Value
-		var err error
-
-		ctxValue := reflect.ValueOf(ctx)
+		var req interface{}
 
 		switch fnType.NumIn() {
 		case 1:
-			returnValues = fnValue.Call([]reflect.Value{ctxValue})
 		case 2:
 			paramType := fnType.In(1)
 			pointer := false
@@ -104,36 +181,42 @@ func Bind(fn interface{}, render Renderer) http.HandlerFunc {
 			// new param instance with paramType.
 			paramValue := reflect.New(paramType)
 			// bind paramValue with request
-			if err = binding.Bind(paramValue.Interface(), webCtx); nil != err {
-				break
+			if err := binding.Bind(paramValue.Interface(), webCtx); nil != err {
+				render.Render(webCtx, err, nil)
+				return
 			}
 			if !pointer {
 				paramValue = paramValue.Elem()
 			}
-			returnValues = fnValue.Call([]reflect.Value{ctxValue, paramValue})
+			req = paramValue.Interface()
 		default:
 			panic("unreachable here")
 		}
 
-		var result interface{}
+		result, err := invoke(ctx, req)
+		if nil == err && nil == result && 0 == fnType.NumOut() {
+			// nothing, the results of the interceptors short-circuiting the handler are rendered below.
+			return
+		}
 
-		if nil == err {
-			switch len(returnValues) {
-			case 0:
-				// nothing
+		// serve the content result by itself, e.g. the byte ranges.
+		if content, ok := result.(*ContentResult); ok && nil != content {
+			if nil == err {
+				content.ServeHTTP(webCtx.Writer, webCtx.Request)
 				return
-			case 1:
-				if firstOutIsErrorType {
-					err, _ = returnValues[0].Interface().(error)
-				} else {
-					result = returnValues[0].Interface()
+			}
+			_ = content.Close()
+			result = nil // the content can't be rendered with the error.
+		}
+
+		// stream the records of the channel or iterator result.
+		if streaming {
+			if records := reflect.ValueOf(result); records.IsValid() && isStreamType(records.Type()) {
+				if nil == err {
+					renderStream(webCtx, records)
+					return
 				}
-			case 2:
-				// check error
-				result = returnValues[0].Interface()
-				err, _ = returnValues[1].Interface().(error)
-			default:
-				panic("unreachable here")
+				result = nil // the records can't be rendered with the error.
 			}
 		}
 
@@ -142,6 +225,12 @@ func Bind(fn interface{}, render Renderer) http.HandlerFunc {
 	}
 }
 
+func chainInterceptor(interceptor HandlerInterceptor, next Invoke) Invoke {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return interceptor(ctx, req, next)
+	}
+}
+
 func validMappingFunc(fnType reflect.Type) error {
 	// func(ctx context.Context)
 	// func(ctx context.Context) R
@@ -167,8 +256,11 @@ func validMappingFunc(fnType reflect.Type) error {
 
 	if fnType.NumIn() > 1 {
 		argType := fnType.In(1)
-		if !(reflect.Struct == argType.Kind() || (reflect.Ptr == argType.Kind() && reflect.Struct == argType.Elem().Kind())) {
-			return fmt.Errorf("%s: input param type (%s) must be struct/*struct", fnType.String(), argType.String())
+		if reflect.Ptr == argType.Kind() {
+			argType = argType.Elem()
+		}
+		if !(reflect.Struct == argType.Kind() || reflect.Slice == argType.Kind() || reflect.Map == argType.Kind()) {
+			return fmt.Errorf("%s: input param type (%s) must be struct/*struct, slice or map", fnType.String(), fnType.In(1).String())
 		}
 	}
 
@@ -188,6 +280,89 @@ func validMappingFunc(fnType reflect.Type) error {
 	return nil
 }
 
+func validResultType(fnType reflect.Type, render Renderer) error {
+	if fnType.NumOut() > 0 && isStreamType(fnType.Out(0)) {
+		if err := checkJsonType(streamRecordType(fnType.Out(0)), map[reflect.Type]bool{}); nil != err {
+			return fmt.Errorf("%s: result type can't be streamed: %w", fnType.String(), err)
+		}
+		return nil
+	}
+
+	checker, ok := resultCheckerOf(render)
+	if !ok || 0 == fnType.NumOut() || isErrorType(fnType.Out(0)) || contentResultType == fnType.Out(0) {
+		return nil
+	}
+	if err := checker.CheckResult(fnType.Out(0)); nil != err {
+		return fmt.Errorf("%s: result type can't be rendered: %w", fnType.String(), err)
+	}
+	return nil
+}
+
+// validBindMethod rejects handlers whose request struct declares body fields, or whose request
+// is a slice or map bound from the body as a whole, while the route only accepts methods without request body.
+func validBindMethod(method methodTyp, handler interface{}) error {
+	if 0 != method&^(mGET|mHEAD) {
+		return nil
+	}
+
+	fnType := reflect.TypeOf(handler)
+	if !isFuncType(fnType) || 2 != fnType.NumIn() {
+		return nil
+	}
+
+	argType := fnType.In(1)
+	if reflect.Ptr == argType.Kind() {
+		argType = argType.Elem()
+	}
+	if reflect.Slice == argType.Kind() || reflect.Map == argType.Kind() {
+		return fmt.Errorf("%s: input param type (%s) is bound from request body, but the route only accepts %s", fnType.String(), fnType.In(1).String(), strings.Join(methodTypStrings(method), "/"))
+	}
+	if reflect.Struct != argType.Kind() {
+		return nil
+	}
+
+	if name, ok := lookupBodyField(argType); ok {
+		return fmt.Errorf("%s: field %s is bound from request body, but the route only accepts %s", fnType.String(), name, strings.Join(methodTypStrings(method), "/"))
+	}
+	return nil
+}
+
+// nonBodyTags are the tags binding the fields from the request parts other than the body,
+// the fields tagged by one of them are not bound from the body even if they declare a body tag.
+var nonBodyTags = []string{"path", "query", "form", "header", "cookie", "tlscert", "ctx", "request", "lang"}
+
+// lookupBodyField returns the name of the first field that bound from request body only.
+func lookupBodyField(t reflect.Type) (string, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && reflect.Struct == field.Type.Kind() {
+			if name, ok := lookupBodyField(field.Type); ok {
+				return name, true
+			}
+			continue
+		}
+		if hasAnyTag(field, nonBodyTags) {
+			continue
+		}
+		for _, tag := range []string{"json", "xml", "ndjson"} {
+			if name, ok := field.Tag.Lookup(tag); ok && name != "-" {
+				return field.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// hasAnyTag returns whether the field declares one of the tags.
+func hasAnyTag(field reflect.StructField, tags []string) bool {
+	for _, tag := range tags {
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func warpContext(handler http.HandlerFunc) http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		webCtx := &Context{Writer: writer, Request: request}
@@ -216,32 +391,184 @@ func isContextType(t reflect.Type) bool {
 	return t == contextType || t.Implements(contextType)
 }
 
-// JsonRender is default Render
-func JsonRender() RendererFunc {
-	return func(ctx *Context, err error, result interface{}) {
-		var code = 0
-		var message = ""
-		if nil != err {
-			var e HttpError
-			if errors.As(err, &e) {
-				code = e.Code
-				message = e.Message
-			} else {
-				code = http.StatusInternalServerError
-				message = err.Error()
+// JsonRender is default Render, the results that can't be encoded as JSON, e.g. the values of
+// unsupported types or cyclic data, are rendered as an internal server error instead of a partial
+// body, and the observers are notified of the encoding error. The handlers whose result type can
+// never be encoded as JSON are rejected at registration time as well.
+//
+//	router.Renderer(web.JsonRender(func(ctx *web.Context, err error) {
+//		log.Printf("%s %s: %v", ctx.Request.Method, ctx.Request.URL.Path, err)
+//	}))
+func JsonRender(observers ...func(ctx *Context, err error)) RendererFunc {
+	return jsonRender{observers: observers}.renderFunc
+}
 
-				if errors.Is(err, binding.ErrBinding) || errors.Is(err, binding.ErrValidate) {
-					code = http.StatusBadRequest
-				}
-			}
+// errJsonRenderProbe asks the RendererFunc returned by JsonRender for its jsonRender.
+var errJsonRenderProbe = errors.New("json render probe")
+
+// jsonRenderCode is the code of the RendererFunc returned by JsonRender, shared by all of them.
+var jsonRenderCode = reflect.ValueOf(RendererFunc(jsonRender{}.renderFunc)).Pointer()
+
+// renderFunc is the RendererFunc returned by JsonRender, it hands the jsonRender to jsonRenderOf.
+func (j jsonRender) renderFunc(ctx *Context, err error, result interface{}) {
+	if probe, ok := result.(*jsonRender); ok && errJsonRenderProbe == err {
+		*probe = j
+		return
+	}
+	j.Render(ctx, err, result)
+}
+
+// jsonRenderOf returns the jsonRender of the renderer, i.e. the default renderer, IndentedJsonRender
+// or the RendererFunc returned by JsonRender, or false for the other renderers.
+func jsonRenderOf(renderer Renderer) (jsonRender, bool) {
+	switch r := renderer.(type) {
+	case jsonRender:
+		return r, true
+	case RendererFunc:
+		if nil != r && jsonRenderCode == reflect.ValueOf(r).Pointer() {
+			var j jsonRender
+			r(nil, errJsonRenderProbe, &j)
+			return j, true
 		}
+	}
+	return jsonRender{}, false
+}
+
+// resultCheckerOf returns the ResultChecker of the renderer, the RendererFunc returned by JsonRender
+// checks the result types as JSON like the other JSON renderers.
+func resultCheckerOf(renderer Renderer) (ResultChecker, bool) {
+	if j, ok := jsonRenderOf(renderer); ok {
+		return j, true
+	}
+	checker, ok := renderer.(ResultChecker)
+	return checker, ok
+}
+
+// IndentedJsonRender is JsonRender indenting the responses by the indent, e.g. for the humans reading
+// them in the development environments. JsonRender indents the responses by RouterOptions.IndentJSON
+// of the router as well, so that the production stays compact without changing the renderer.
+//
+//	router.Renderer(web.IndentedJsonRender("  "))
+func IndentedJsonRender(indent string, observers ...func(ctx *Context, err error)) Renderer {
+	return jsonRender{indent: indent, observers: observers}
+}
+
+type jsonRender struct {
+	indent    string
+	observers []func(ctx *Context, err error)
+}
+
+// CheckResult reports whether the result type can be encoded as JSON.
+func (jsonRender) CheckResult(t reflect.Type) error {
+	return checkJsonType(t, map[reflect.Type]bool{})
+}
+
+type jsonResponse struct {
+	Code    int                   `json:"code"`
+	Message string                `json:"message,omitempty"`
+	Data    interface{}           `json:"data"`
+	Errors  binding.BindingErrors `json:"errors,omitempty"`
+}
 
-		type JsonResponse struct {
-			Code    int         `json:"code"`
-			Message string      `json:"message,omitempty"`
-			Data    interface{} `json:"data"`
+func (j jsonRender) Render(ctx *Context, err error, result interface{}) {
+	code, message := responseStatus(err)
+
+	indent := j.indent
+	if len(indent) == 0 {
+		indent = jsonIndentOf(ctx.Request.Context())
+	}
+	marshal := json.Marshal
+	if len(indent) > 0 {
+		marshal = func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", indent) }
+	}
+
+	// encode the response before writing the status, so that the encoding error can still be rendered.
+	data, encodeErr := marshal(jsonResponse{Code: code, Message: message, Data: result, Errors: bindingErrorsOf(err)})
+	if nil != encodeErr {
+		for _, observe := range j.observers {
+			observe(ctx, encodeErr)
 		}
+		data, _ = marshal(jsonResponse{Code: http.StatusInternalServerError, Message: encodeErr.Error()})
+	}
+
+	_ = ctx.Data(http.StatusOK, render.JsonRenderer{}.ContentType(), append(data, '\n'))
+}
+
+// bindingErrorsOf returns the failures of the fields if the err is of binding or validation, or nil.
+func bindingErrorsOf(err error) binding.BindingErrors {
+	var errs binding.BindingErrors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	return nil
+}
+
+// responseStatus returns the code and message of the response envelope rendering the err.
+func responseStatus(err error) (code int, message string) {
+	if nil == err {
+		return 0, ""
+	}
+
+	var e HttpError
+	if errors.As(err, &e) {
+		return e.Code, e.Message
+	}
 
-		_ = ctx.JSON(http.StatusOK, JsonResponse{Code: code, Message: message, Data: result})
+	code = http.StatusInternalServerError
+	var maxBytesErr *http.MaxBytesError
+	var multipartLimitErr *binding.MultipartLimitError
+	if errors.As(err, &maxBytesErr) || errors.As(err, &multipartLimitErr) {
+		code = http.StatusRequestEntityTooLarge
+	} else if errors.Is(err, binding.ErrBinding) || errors.Is(err, binding.ErrValidate) {
+		code = http.StatusBadRequest
 	}
+	return code, err.Error()
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// checkJsonType returns an error if values of type `t` can never be encoded by encoding/json.
+func checkJsonType(t reflect.Type, visited map[reflect.Type]bool) error {
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PtrTo(t).Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return fmt.Errorf("json: unsupported type: %s", t.String())
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return checkJsonType(t.Elem(), visited)
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !t.Key().Implements(textMarshalerType) {
+				return fmt.Errorf("json: unsupported map key type: %s", t.String())
+			}
+		}
+		return checkJsonType(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			if name, ok := field.Tag.Lookup("json"); ok && name == "-" {
+				continue
+			}
+			if err := checkJsonType(field.Type, visited); nil != err {
+				return err
+			}
+		}
+	}
+	return nil
 }
@@ -2,6 +2,7 @@ package web
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go-spring.dev/web/binding"
 )
 
 func TestIsErrorType(t *testing.T) {
@@ -151,6 +153,8 @@ func TestValidMappingFunc(t *testing.T) {
 		{Fn: func(ctx context.Context, req struct{}) error { return nil }, Expected: nil},
 		{Fn: func(ctx context.Context, req struct{}) string { return "ok" }, Expected: nil},
 		{Fn: func(ctx context.Context, req *struct{}) (string, error) { return "ok", nil }, Expected: nil},
+		{Fn: func(ctx context.Context, req []struct{}) error { return nil }, Expected: nil},
+		{Fn: func(ctx context.Context, req map[string]int) error { return nil }, Expected: nil},
 
 		{Fn: func() {}, Expected: "func(): expect func(ctx context.Context, [T]) [R, error]"},
 		{Fn: func(ctx context.Context) (error, string) { return nil, "" }, Expected: "func(context.Context) (error, string): expect func(...) (R, error)"},
@@ -172,3 +176,311 @@ func TestValidMappingFunc(t *testing.T) {
 	}
 
 }
+
+func TestBindResultChecker(t *testing.T) {
+	assert.PanicsWithError(t, "func(context.Context) chan int: result type can't be rendered: json: unsupported type: chan int", func() {
+		Bind(func(ctx context.Context) chan int { return nil }, defaultRenderer)
+	})
+
+	assert.Panics(t, func() {
+		Bind(func(ctx context.Context) (map[string]func(), error) { return nil, nil }, defaultRenderer)
+	})
+
+	assert.Panics(t, func() {
+		NewRouter().Get("/", func(ctx context.Context) (chan int, error) { return nil, nil })
+	})
+
+	// JsonRender keeps returning a RendererFunc for the callers assigning it, which checks the results as JSON.
+	var renderer RendererFunc = JsonRender()
+	assert.NotPanics(t, func() {
+		Bind(func(ctx context.Context) (map[int]string, error) { return nil, nil }, renderer)
+		Bind(func(ctx context.Context) error { return nil }, renderer)
+		Bind(func(ctx context.Context) chan int { return nil }, RendererFunc(func(ctx *Context, err error, result interface{}) {}))
+	})
+	assert.Panics(t, func() {
+		Bind(func(ctx context.Context) chan int { return nil }, renderer)
+	})
+	assert.Panics(t, func() {
+		Bind(func(ctx context.Context) chan int { return nil }, IndentedJsonRender("  "))
+	})
+
+	router := NewRouter()
+	router.Renderer(JsonRender(func(ctx *Context, err error) {}))
+	assert.Panics(t, func() {
+		router.Get("/", func(ctx context.Context) (map[string]func(), error) { return nil, nil })
+	})
+}
+
+func TestValidBindMethod(t *testing.T) {
+	type Body struct {
+		Name string `json:"name"`
+	}
+	type Query struct {
+		Name string `query:"name"`
+	}
+	type Both struct {
+		Name string `query:"name" json:"name"`
+	}
+
+	assert.ErrorContains(t, validBindMethod(mGET, func(ctx context.Context, req Body) {}), "field Name is bound from request body, but the route only accepts GET")
+	assert.ErrorContains(t, validBindMethod(mGET|mHEAD, func(ctx context.Context, req *struct{ Body }) {}), "only accepts GET/HEAD")
+	assert.NoError(t, validBindMethod(mGET, func(ctx context.Context, req Query) {}))
+	assert.NoError(t, validBindMethod(mGET, func(ctx context.Context, req *Both) {}))
+	assert.NoError(t, validBindMethod(mPOST, func(ctx context.Context, req Body) {}))
+	assert.NoError(t, validBindMethod(mALL, func(ctx context.Context, req Body) {}))
+	assert.ErrorContains(t, validBindMethod(mGET, func(ctx context.Context, req []Query) {}), "input param type ([]web.Query) is bound from request body, but the route only accepts GET")
+	assert.NoError(t, validBindMethod(mPOST, func(ctx context.Context, req *map[string]Query) {}))
+
+	router := NewRouter()
+	assert.Panics(t, func() {
+		router.Get("/user", func(ctx context.Context, req Body) {})
+	})
+	assert.NotPanics(t, func() {
+		router.Get("/users", func(ctx context.Context, req *Both) {})
+	})
+}
+
+func TestBindWithInterceptors(t *testing.T) {
+	type Req struct {
+		ID int `query:"id"`
+	}
+
+	var trace []string
+	audit := func(ctx context.Context, req interface{}, next Invoke) (interface{}, error) {
+		trace = append(trace, fmt.Sprintf("audit:%d", req.(Req).ID))
+		return next(ctx, req)
+	}
+	authorize := func(ctx context.Context, req interface{}, next Invoke) (interface{}, error) {
+		if req.(Req).ID > 100 {
+			return nil, Error(403, "forbidden id")
+		}
+		result, err := next(ctx, req)
+		trace = append(trace, fmt.Sprintf("result:%v", result))
+		return result, err
+	}
+
+	handler := Bind(func(ctx context.Context, req Req) string {
+		trace = append(trace, "handler")
+		return fmt.Sprintf("id=%d", req.ID)
+	}, JsonRender(), audit, authorize)
+
+	response := httptest.NewRecorder()
+	handler(response, httptest.NewRequest(http.MethodGet, "/?id=7", nil))
+	assert.Equal(t, "{\"code\":0,\"data\":\"id=7\"}\n", response.Body.String())
+	assert.Equal(t, []string{"audit:7", "handler", "result:id=7"}, trace)
+
+	trace = nil
+	response = httptest.NewRecorder()
+	handler(response, httptest.NewRequest(http.MethodGet, "/?id=101", nil))
+	assert.Equal(t, "{\"code\":403,\"message\":\"forbidden id\",\"data\":null}\n", response.Body.String())
+	assert.Equal(t, []string{"audit:101"}, trace)
+
+	// interceptor errors are rendered even if the handler has no result.
+	response = httptest.NewRecorder()
+	Bind(func(ctx context.Context) {}, JsonRender(), func(ctx context.Context, req interface{}, next Invoke) (interface{}, error) {
+		assert.Nil(t, req)
+		return nil, Error(401, "")
+	})(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "{\"code\":401,\"message\":\"Unauthorized\",\"data\":null}\n", response.Body.String())
+
+	// interceptor results are rendered even if the handler has no result.
+	response = httptest.NewRecorder()
+	Bind(func(ctx context.Context) {}, JsonRender(), func(ctx context.Context, req interface{}, next Invoke) (interface{}, error) {
+		return "cached", nil
+	})(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "{\"code\":0,\"data\":\"cached\"}\n", response.Body.String())
+
+	// the request replaced by the interceptor must be accepted by the handler.
+	response = httptest.NewRecorder()
+	Bind(func(ctx context.Context, req Req) {}, JsonRender(), func(ctx context.Context, req interface{}, next Invoke) (interface{}, error) {
+		return next(ctx, &Req{})
+	})(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, response.Body.String(), "\"code\":500")
+	assert.Contains(t, response.Body.String(), "input param type (web.Req) is expected, but the interceptors passed *web.Req")
+}
+
+func TestBindWildcard(t *testing.T) {
+	type request struct {
+		Path string `path:"*"`
+		Rest string `path:"rest"`
+	}
+
+	r := NewRouter()
+	r.Renderer(RendererFunc(func(ctx *Context, err error, result interface{}) {
+		_ = ctx.String(200, "%v", result)
+	}))
+	r.Get("/files/*", func(ctx context.Context, req request) string {
+		return req.Path
+	})
+	r.Get("/assets/{rest...}", func(ctx context.Context, req request) string {
+		return req.Path + "|" + req.Rest
+	})
+	r.Group("/mounted", func(r Router) {
+		r.Get("/docs/{rest...}", func(ctx context.Context, req request) string {
+			return req.Rest
+		})
+	})
+
+	tests := map[string]string{
+		"/files/a/b/c.txt":          "a/b/c.txt",
+		"/assets/css/site.css":      "css/site.css|css/site.css",
+		"/assets/":                  "|",
+		"/mounted/docs/guide/intro": "guide/intro",
+	}
+	for path, expected := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, expected, w.Body.String(), path)
+	}
+}
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("broken value")
+}
+
+func TestJsonRenderEncodeError(t *testing.T) {
+	type node struct {
+		Name string `json:"name"`
+		Next *node  `json:"next"`
+	}
+	cyclic := &node{Name: "a"}
+	cyclic.Next = &node{Name: "b", Next: cyclic}
+
+	tests := map[string]interface{}{
+		"unsupported": map[string]interface{}{"fn": func() {}},
+		"cyclic":      cyclic,
+		// the encoding fails after the leading records are encoded.
+		"mid-response": []interface{}{"a", "b", failingMarshaler{}},
+	}
+	for name, result := range tests {
+		var observed []error
+		renderer := JsonRender(func(ctx *Context, err error) {
+			observed = append(observed, err)
+		})
+
+		response := httptest.NewRecorder()
+		Bind(func(ctx context.Context) interface{} { return result }, renderer)(response, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Len(t, observed, 1, name)
+		assert.Equal(t, http.StatusOK, response.Code, name)
+		assert.Equal(t, "application/json; charset=utf-8", response.Header().Get("Content-Type"), name)
+
+		var body struct {
+			Code    int         `json:"code"`
+			Message string      `json:"message"`
+			Data    interface{} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &body), name)
+		assert.Equal(t, http.StatusInternalServerError, body.Code, name)
+		assert.Equal(t, observed[0].Error(), body.Message, name)
+		assert.Nil(t, body.Data, name)
+	}
+
+	// the renderers without observers render the error as well.
+	response := httptest.NewRecorder()
+	Bind(func(ctx context.Context) interface{} { return failingMarshaler{} }, JsonRender())(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "{\"code\":500,\"message\":\"json: error calling MarshalJSON for type *web.failingMarshaler: broken value\",\"data\":null}\n", response.Body.String())
+}
+
+func TestJsonRenderBindingErrors(t *testing.T) {
+	type request struct {
+		Page int `query:"page"`
+		Size int `query:"size"`
+	}
+
+	r := NewRouter()
+	r.Get("/items", func(ctx context.Context, req *request) int { return req.Page * req.Size })
+
+	_, body := testHandler(t, r, "GET", "/items?page=2&size=10", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":20}\n", body)
+
+	_, body = testHandler(t, r, "GET", "/items?page=first&size=ten", nil)
+	assert.JSONEq(t, `{
+		"code": 400,
+		"message": "binding failed: page: strconv.ParseInt: parsing \"first\": invalid syntax; size: strconv.ParseInt: parsing \"ten\": invalid syntax",
+		"data": null,
+		"errors": [
+			{"field": "page", "source": "query", "reason": "strconv.ParseInt: parsing \"first\": invalid syntax"},
+			{"field": "size", "source": "query", "reason": "strconv.ParseInt: parsing \"ten\": invalid syntax"}
+		]
+	}`, body)
+}
+
+func TestBindBodyAsWhole(t *testing.T) {
+	type Item struct {
+		SKU   string `json:"sku"`
+		Count int    `json:"count"`
+	}
+
+	binding.RegisterValidator(func(i interface{}) error {
+		if item, ok := i.(*Item); ok && item.Count <= 0 {
+			return binding.BindingErrors{{Field: "count", Source: "validate", Reason: "must be positive"}}
+		}
+		return nil
+	})
+	defer binding.RegisterValidator(nil)
+
+	router := NewRouter()
+	router.Post("/items", func(ctx context.Context, items []Item) int { return len(items) })
+	router.Put("/items", func(ctx context.Context, items *map[string]Item) int { return len(*items) })
+
+	request := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`[{"sku":"a","count":1},{"sku":"b","count":2}]`))
+	request.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	assert.JSONEq(t, `{"code":0,"data":2}`, w.Body.String())
+
+	// the elements are validated one by one, the failures are prefixed by the index or key.
+	request = httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`[{"sku":"a","count":1},{"sku":"b","count":0}]`))
+	request.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	assert.Contains(t, w.Body.String(), `"code":400`)
+	assert.Contains(t, w.Body.String(), `"errors":[{"field":"[1].count","source":"validate","reason":"must be positive"}]`)
+
+	request = httptest.NewRequest(http.MethodPut, "/items", strings.NewReader(`{"x":{"sku":"a","count":-1}}`))
+	request.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	assert.Contains(t, w.Body.String(), `"field":"[x].count"`)
+}
+
+func TestBindRawBody(t *testing.T) {
+	type Webhook struct {
+		Event     string `form:"event"`
+		Signature string `header:"X-Signature"`
+		Payload   []byte `body:"raw"`
+	}
+
+	router := NewRouter()
+	router.Post("/hooks", func(ctx context.Context, hook Webhook) string {
+		return fmt.Sprintf("%s:%s:%s", hook.Event, hook.Signature, hook.Payload)
+	})
+
+	// the form is parsed from the body replayed after it's captured.
+	request := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader("event=push&ref=main"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("X-Signature", "sha256=abc")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	assert.JSONEq(t, `{"code":0,"data":"push:sha256=abc:event=push&ref=main"}`, w.Body.String())
+}
+
+func TestBindHeaderWildcard(t *testing.T) {
+	type Param struct {
+		Meta map[string]string `header:"X-Meta-*"`
+	}
+
+	router := NewRouter()
+	router.Get("/meta", func(ctx context.Context, p Param) map[string]string { return p.Meta })
+
+	request := httptest.NewRequest(http.MethodGet, "/meta", nil)
+	request.Header.Set("x-meta-trace-id", "abc")
+	request.Header.Set("X-Meta-Region", "eu")
+	request.Header.Set("X-Other", "x")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	assert.JSONEq(t, `{"code":0,"data":{"Trace-Id":"abc","Region":"eu"}}`, w.Body.String())
+}
@@ -18,6 +18,9 @@
 package binding
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding"
 	"errors"
 	"fmt"
 	"io"
@@ -34,11 +37,16 @@ var ErrBinding = errors.New("binding failed")
 var ErrValidate = errors.New("validate failed")
 
 const (
-	MIMEApplicationJSON = "application/json"
-	MIMEApplicationXML  = "application/xml"
-	MIMETextXML         = "text/xml"
-	MIMEApplicationForm = "application/x-www-form-urlencoded"
-	MIMEMultipartForm   = "multipart/form-data"
+	MIMEApplicationJSON    = "application/json"
+	MIMEApplicationXML     = "application/xml"
+	MIMETextXML            = "text/xml"
+	MIMEApplicationForm    = "application/x-www-form-urlencoded"
+	MIMEMultipartForm      = "multipart/form-data"
+	MIMEApplicationNDJSON  = "application/x-ndjson"
+	MIMEApplicationYAML    = "application/yaml"
+	MIMETextYAML           = "text/yaml"
+	MIMEApplicationMsgPack = "application/msgpack"
+	MIMEApplicationCBOR    = "application/cbor"
 )
 
 type Request interface {
@@ -47,9 +55,14 @@ type Request interface {
 	Cookie(name string) (string, bool)
 	PathParam(name string) (string, bool)
 	QueryParam(name string) (string, bool)
+	QueryParams(name string) ([]string, bool)
 	FormParams() (url.Values, error)
 	MultipartParams(maxMemory int64) (*multipart.Form, error)
 	RequestBody() io.Reader
+
+	// PeerCertificate returns the leaf certificate of the client verified over TLS, nil if none or
+	// the certificate presented by the client isn't verified, i.e. not in the verified chains.
+	PeerCertificate() *x509.Certificate
 }
 
 type FieldConverter func(v reflect.Value, val string) error
@@ -61,21 +74,32 @@ const (
 	BindScopeQuery
 	BindScopeHeader
 	BindScopeCookie
+	BindScopeTLSCert
+	BindScopeContext
+	BindScopeRequest
+	BindScopeLanguage
 	BindScopeBody
 )
 
 var scopeTags = map[BindScope]string{
-	BindScopeURI:    "path",
-	BindScopeQuery:  "query",
-	BindScopeHeader: "header",
-	BindScopeCookie: "cookie",
+	BindScopeURI:      "path",
+	BindScopeQuery:    "query",
+	BindScopeHeader:   "header",
+	BindScopeCookie:   "cookie",
+	BindScopeTLSCert:  "tlscert",
+	BindScopeContext:  "ctx",
+	BindScopeRequest:  "request",
+	BindScopeLanguage: "lang",
 }
 
 var scopeGetters = map[BindScope]func(r Request, name string) (string, bool){
-	BindScopeURI:    Request.PathParam,
-	BindScopeQuery:  Request.QueryParam,
-	BindScopeHeader: Request.Header,
-	BindScopeCookie: Request.Cookie,
+	BindScopeURI:      Request.PathParam,
+	BindScopeQuery:    Request.QueryParam,
+	BindScopeHeader:   Request.Header,
+	BindScopeCookie:   Request.Cookie,
+	BindScopeTLSCert:  tlsCertValue,
+	BindScopeRequest:  metadataValue,
+	BindScopeLanguage: languageValue,
 }
 
 var fieldConverters = map[reflect.Type]FieldConverter{}
@@ -86,14 +110,29 @@ var validateStruct func(i interface{}) error
 type BodyBinder func(i interface{}, r Request) error
 
 var bodyBinders = map[string]BodyBinder{
-	MIMEApplicationForm: BindForm,
-	MIMEMultipartForm:   BindMultipartForm,
-	MIMEApplicationJSON: BindJSON,
-	MIMEApplicationXML:  BindXML,
-	MIMETextXML:         BindXML,
+	MIMEApplicationForm:     BindForm,
+	MIMEMultipartForm:       BindMultipartForm,
+	MIMEApplicationJSON:     BindJSON,
+	MIMEApplicationXML:      BindXML,
+	MIMETextXML:             BindXML,
+	MIMEApplicationNDJSON:   BindNDJSON,
+	MIMEApplicationYAML:     BindYAML,
+	MIMETextYAML:            BindYAML,
+	"application/x-yaml":    BindYAML,
+	MIMEApplicationMsgPack:  BindMsgPack,
+	"application/x-msgpack": BindMsgPack,
+	MIMEApplicationCBOR:     BindCBOR,
+	"application/*+cbor":    BindCBOR,
+	"application/*+yaml":    BindYAML,
+	"application/*+json":    BindJSON,
+	"application/*+xml":     BindXML,
 }
 
 // RegisterBodyBinder register body binder.
+//
+// The media type may be a wildcard that matches the media types without an exact binder,
+// `type/*+suffix` (e.g. `application/*+json` matches `application/vnd.api+json`) takes
+// precedence over `type/*` (e.g. `text/*`), which takes precedence over `*/*`.
 func RegisterBodyBinder(mime string, binder BodyBinder) {
 	bodyBinders[mime] = binder
 }
@@ -106,6 +145,7 @@ func RegisterValidator(validator func(i interface{}) error) {
 // RegisterConverter register custom field type converter.
 func RegisterConverter(typ reflect.Type, converter FieldConverter) {
 	fieldConverters[typ] = converter
+	resetPlans()
 }
 
 // Bind checks the Method and Content-Type to select a binding engine automatically,
@@ -113,74 +153,305 @@ func RegisterConverter(typ reflect.Type, converter FieldConverter) {
 //
 //	"application/json" --> JSON binding
 //	"application/xml"  --> XML binding
+//
+// The failures of all fields are collected into BindingErrors rather than stopping at the first one,
+// the struct is validated even if some fields fail to bind, unless the body fails to bind, so that
+// the clients can fix the entire request in one round trip. The error wraps ErrBinding if any field
+// fails to bind, or ErrValidate if the validation fails only. The `errmsg` tag of the field replaces
+// the reason of its failure, see applyErrorMessages. The requests providing a non-nil validator by the
+// `Validator() func(i interface{}) error` method, e.g. the routes of a web.Router with a validator,
+// are validated by it instead of the one registered by RegisterValidator. The BeforeBind and AfterBind
+// hooks of the struct are called before binding and after the successful validation respectively.
 func Bind(i interface{}, r Request) error {
-	if err := bindScope(i, r); err != nil {
+	if hook, ok := i.(BeforeBinder); ok {
+		if err := hook.BeforeBind(requestContext(r)); nil != err {
+			return fmt.Errorf("%w: %w", ErrBinding, hookErrors(err, "bind"))
+		}
+	}
+
+	var errs BindingErrors
+	if binder, ok := i.(ParamsBinder); ok {
+		errs = binder.BindParams(r)
+	} else if err := bindScope(i, r, &errs); err != nil {
 		return fmt.Errorf("%w: %v", ErrBinding, err)
 	}
 
-	if err := bindBody(i, r); err != nil {
+	rawBody, err := rawBodyPlanOf(reflect.TypeOf(i))
+	if nil != err {
 		return fmt.Errorf("%w: %v", ErrBinding, err)
 	}
+	var bodyErr error
+	if len(rawBody) > 0 {
+		bodyErr = bindRawBody(i, r, rawBody)
+	} else {
+		bodyErr = bindBody(i, r)
+	}
+	if nil != bodyErr {
+		errs = append(errs, &FieldError{Source: "body", Reason: bodyErr.Error(), Err: bodyErr})
+	}
+	bound := len(errs)
+
+	validator := validateStruct
+	if vr, ok := r.(interface {
+		Validator() func(i interface{}) error
+	}); ok {
+		if v := vr.Validator(); nil != v {
+			validator = v
+		}
+	}
+	if nil != validator && nil == bodyErr {
+		errs = append(errs, validate(validator, i)...)
+	}
 
-	if nil != validateStruct {
-		if err := validateStruct(i); nil != err {
-			return fmt.Errorf("%w: %v", ErrValidate, err)
+	switch {
+	case bound > 0:
+		removeSavedFiles(i)
+		return fmt.Errorf("%w: %w", ErrBinding, errs)
+	case len(errs) > 0:
+		removeSavedFiles(i)
+		return fmt.Errorf("%w: %w", ErrValidate, errs)
+	}
+
+	if hook, ok := i.(AfterBinder); ok {
+		if err := hook.AfterBind(requestContext(r)); nil != err {
+			removeSavedFiles(i)
+			return fmt.Errorf("%w: %w", ErrValidate, hookErrors(err, "validate"))
 		}
 	}
 	return nil
 }
 
+// BeforeBinder is implemented by the request structs preparing themselves before the params and
+// the body are bound, e.g. setting the defaults computed at runtime. The errors fail the binding.
+type BeforeBinder interface {
+	BeforeBind(ctx context.Context) error
+}
+
+// AfterBinder is implemented by the request structs normalizing the fields, e.g. trimming or
+// lowercasing the emails, or checking the fields against each other, after they are bound and
+// validated successfully. The errors fail the validation, the BindingErrors and the *FieldError
+// are reported as is, e.g. to name the fields failed the cross-field checks.
+type AfterBinder interface {
+	AfterBind(ctx context.Context) error
+}
+
+// hookErrors returns the failures reported by the BeforeBind or AfterBind hook.
+func hookErrors(err error, source string) BindingErrors {
+	var errs BindingErrors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return BindingErrors{fieldErr}
+	}
+	return BindingErrors{{Source: source, Reason: err.Error(), Err: err}}
+}
+
+// ParamsBinder is implemented by the request structs binding the path, query, header and cookie
+// params themselves, e.g. by the code generated by go-spring.dev/web/cmd/webgen, Bind calls it
+// instead of binding the params by reflection, the body is bound and validated as usual.
+type ParamsBinder interface {
+	BindParams(r Request) BindingErrors
+}
+
+// FieldError is the failure of binding or validating a field.
+type FieldError struct {
+	// Field is the name of the param, or of the struct field for the default values and the
+	// validation failures, empty if the failure isn't of a single field, e.g. the body can't be decoded.
+	Field string `json:"field,omitempty"`
+
+	// Source is where the value comes from, i.e. `path`, `query`, `header`, `cookie`, `form`,
+	// `body` or `default`, or `validate` for the validation failures, or `bind` for the failures
+	// of the BeforeBind hook.
+	Source string `json:"source"`
+
+	// Reason describes the failure.
+	Reason string `json:"reason"`
+
+	// Err is the underlying error.
+	Err error `json:"-"`
+}
+
+func (e *FieldError) Error() string {
+	if len(e.Field) > 0 {
+		return e.Field + ": " + e.Reason
+	}
+	return e.Reason
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// BindingErrors are the failures of all fields, the validators may return it to report
+// the failures of multiple fields, which are serialized by the renderers as an array.
+type BindingErrors []*FieldError
+
+func (errs BindingErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (errs BindingErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, err := range errs {
+		unwrapped[i] = err
+	}
+	return unwrapped
+}
+
 func bindBody(i interface{}, r Request) error {
 	mediaType, _, err := mime.ParseMediaType(r.ContentType())
 	if nil != err && !strings.Contains(err.Error(), "mime: no media type") {
 		return err
 	}
-	binder, ok := bodyBinders[mediaType]
+	binder, ok := lookupBodyBinder(mediaType)
 	if !ok {
 		binder = bodyBinders[MIMEApplicationForm]
 	}
 	return binder(i, r)
 }
 
-func bindScope(i interface{}, r Request) error {
+// lookupBodyBinder returns the body binder of the media type, the exact media type first,
+// then the wildcards `type/*+suffix`, `type/*` and `*/*`.
+func lookupBodyBinder(mediaType string) (BodyBinder, bool) {
+	if binder, ok := bodyBinders[mediaType]; ok {
+		return binder, true
+	}
+
+	typ, subtype, found := strings.Cut(mediaType, "/")
+	if !found {
+		return nil, false
+	}
+	if i := strings.LastIndexByte(subtype, '+'); i >= 0 {
+		if binder, ok := bodyBinders[typ+"/*"+subtype[i:]]; ok {
+			return binder, true
+		}
+	}
+	if binder, ok := bodyBinders[typ+"/*"]; ok {
+		return binder, true
+	}
+	binder, ok := bodyBinders["*/*"]
+	return binder, ok
+}
+
+// bindScope binds the fields from the params of the request by the plan of the struct, the failures
+// of the fields are collected into errs, the error is returned only if i isn't a struct, slice or map pointer.
+func bindScope(i interface{}, r Request, errs *BindingErrors) error {
 	t := reflect.TypeOf(i)
 	if t.Kind() != reflect.Ptr {
 		return fmt.Errorf("%s: is not pointer", t.String())
 	}
 
 	et := t.Elem()
+	if et.Kind() == reflect.Slice || et.Kind() == reflect.Map {
+		// the slices and maps are bound from the body as a whole, e.g. the items created in bulk.
+		return nil
+	}
 	if et.Kind() != reflect.Struct {
 		return fmt.Errorf("%s: is not a struct pointer", t.String())
 	}
 
+	plan, err := scopePlanOf(et)
+	if nil != err {
+		return err
+	}
+
+	policy := scopePolicyOf(r)
 	ev := reflect.ValueOf(i).Elem()
-	for j := 0; j < ev.NumField(); j++ {
-		fv := ev.Field(j)
-		ft := et.Field(j)
-		if ft.Anonymous {
-			if err := bindScope(fv.Addr().Interface(), r); nil != err {
-				return err
-			}
+	for _, f := range plan {
+		fv := ev.FieldByIndex(f.index)
+		if err := bindDefault(fv, f); err != nil {
+			*errs = append(*errs, err)
 			continue
 		}
-		for scope := BindScopeURI; scope < BindScopeBody; scope++ {
-			if err := bindScopeField(scope, fv, ft, r); err != nil {
-				return err
+		params := policy.order(f.params)
+		if policy.Strict && len(params) > 1 {
+			if err := checkScopeConflicts(params, r); nil != err {
+				*errs = append(*errs, err)
+				continue
+			}
+		}
+		for _, param := range params {
+			if err := bindScopeField(param, fv, f.field, r); err != nil {
+				reason := err.Error()
+				if f.hasErrmsg {
+					reason = f.errmsg
+				}
+				*errs = append(*errs, &FieldError{Field: param.name, Source: param.tag, Reason: reason, Err: err})
+				break
 			}
 		}
 	}
 	return nil
 }
 
-func bindScopeField(scope BindScope, v reflect.Value, field reflect.StructField, r Request) error {
-	if tag, loaded := scopeTags[scope]; loaded {
-		if name, ok := field.Tag.Lookup(tag); ok && name != "-" {
-			if val, exists := scopeGetters[scope](r, name); exists {
-				if err := bindData(v, val); err != nil {
-					return err
-				}
-			}
+// bindDefault sets the field to the value of its `default` tag, which is overridden by the value
+// bound from the request afterward, the comma separated values are the default of a slice field.
+//
+//	Page int `query:"page" default:"1"`
+func bindDefault(v reflect.Value, f *scopeField) *FieldError {
+	if !f.hasDefault {
+		return nil
+	}
+	bind := bindFormField
+	if nil != f.decode {
+		bind = func(v reflect.Value, _ reflect.Type, values []string) error { return bindBytes(v, values[0], f.decode) }
+	}
+	if err := bind(v, f.field.Type, f.defaults); nil != err {
+		val := f.field.Tag.Get("default")
+		return &FieldError{Field: f.field.Name, Source: "default", Reason: fmt.Sprintf("invalid default %q: %v", val, err), Err: err}
+	}
+	return nil
+}
+
+// queryValues returns all the query values of the request, the map and nested fields bound from the
+// query are left unset for the requests without the `Query() url.Values` method.
+func queryValues(r Request) url.Values {
+	if qr, ok := r.(interface{ Query() url.Values }); ok {
+		return qr.Query()
+	}
+	return nil
+}
+
+func bindScopeField(param scopeParam, v reflect.Value, field reflect.StructField, r Request) error {
+	switch param.mode {
+	case bindMap:
+		// the prefixed query params, e.g. `?filter[color]=red`, are bound into the map fields.
+		return bindMapField(v, field.Type, param.name, queryValues(r))
+	case bindNested:
+		// the query params in the dot or bracket notation, e.g. `?address.city=Paris`, are bound into the nested structs.
+		return bindNestedField(v, field.Type, param.tag, param.name, queryValues(r))
+	case bindPrefixed:
+		// the headers prefixed by the wildcard name, e.g. `X-Meta-*`, are bound into the map fields.
+		return bindHeaderMap(v, field.Type, strings.TrimSuffix(param.name, "*"), r)
+	case bindDecoded:
+		// the values of the []byte fields are decoded by the `encoding` tag, e.g. the base64 signatures.
+		if val, exists := scopeGetters[param.scope](r, param.name); exists {
+			return bindBytes(v, val, param.decode)
 		}
+		return nil
+	case bindContext:
+		// the values placed into the request context by the upstream middlewares are bound as is.
+		if val, exists := contextValue(r, param.name); exists {
+			return bindContextValue(v, val)
+		}
+		return nil
+	case bindSlice:
+		// the repeated query params, e.g. `?status=a&status=b`, and the multi-valued attributes of the client
+		// certificate, e.g. `DNSNames`, are bound into the slice fields, the values are split by the `split`
+		// or `explode:"false"` tag, e.g. `?ids=1,2,3`.
+		if values, exists := paramValues(param, r); exists && len(values) > 0 {
+			return bindFormField(v, field.Type, values)
+		}
+		return nil
+	}
+	if val, exists := scopeGetters[param.scope](r, param.name); exists {
+		return bindData(v, val)
 	}
 	return nil
 }
@@ -191,6 +462,21 @@ func bindData(v reflect.Value, val string) error {
 		return fn(v, val)
 	}
 
+	// the pointee is allocated only if the value is present, so the absent params keep the pointer nil.
+	if reflect.Ptr == v.Kind() {
+		ev := reflect.New(v.Type().Elem())
+		if err := bindData(ev.Elem(), val); err != nil {
+			return err
+		}
+		v.Set(ev)
+		return nil
+	}
+
+	// the types implementing encoding.TextUnmarshaler, e.g. netip.Addr, parse the value themselves.
+	if isTextUnmarshaler(v.Type()) && v.CanAddr() {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+
 	switch v.Kind() {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		u, err := strconv.ParseUint(val, 0, 0)
@@ -228,6 +514,17 @@ func bindData(v reflect.Value, val string) error {
 	}
 }
 
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// isTextUnmarshaler reports whether the type or the pointer to it implements encoding.TextUnmarshaler,
+// the values of such types are bound as a whole even if they're slices or structs.
+func isTextUnmarshaler(t reflect.Type) bool {
+	if reflect.Ptr == t.Kind() {
+		return t.Implements(textUnmarshalerType)
+	}
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
 func parseDuration(v reflect.Value, val string) error {
 	du, err := time.ParseDuration(val)
 	if nil != err {
@@ -17,10 +17,20 @@
 package binding_test
 
 import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"mime/multipart"
+	"net"
+	"net/http"
+	"net/netip"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -33,10 +43,12 @@ type MockRequest struct {
 	contentType string
 	headers     map[string]string
 	queryParams map[string]string
+	queryValues map[string][]string
 	pathParams  map[string]string
 	cookies     map[string]string
 	formParams  url.Values
 	requestBody string
+	peerCert    *x509.Certificate
 }
 
 var _ binding.Request = &MockRequest{}
@@ -50,6 +62,14 @@ func (r *MockRequest) Header(key string) (string, bool) {
 	return value, ok
 }
 
+func (r *MockRequest) Headers() http.Header {
+	headers := http.Header{}
+	for key, value := range r.headers {
+		headers.Add(key, value)
+	}
+	return headers
+}
+
 func (r *MockRequest) Cookie(name string) (string, bool) {
 	value, ok := r.cookies[name]
 	return value, ok
@@ -60,6 +80,27 @@ func (r *MockRequest) QueryParam(name string) (string, bool) {
 	return value, ok
 }
 
+func (r *MockRequest) QueryParams(name string) ([]string, bool) {
+	if values, ok := r.queryValues[name]; ok {
+		return values, true
+	}
+	if value, ok := r.queryParams[name]; ok {
+		return []string{value}, true
+	}
+	return nil, false
+}
+
+func (r *MockRequest) Query() url.Values {
+	values := url.Values{}
+	for name, value := range r.queryParams {
+		values.Set(name, value)
+	}
+	for name, value := range r.queryValues {
+		values[name] = value
+	}
+	return values
+}
+
 func (r *MockRequest) PathParam(name string) (string, bool) {
 	value, ok := r.pathParams[name]
 	return value, ok
@@ -77,6 +118,10 @@ func (r *MockRequest) RequestBody() io.Reader {
 	return strings.NewReader(r.requestBody)
 }
 
+func (r *MockRequest) PeerCertificate() *x509.Certificate {
+	return r.peerCert
+}
+
 type NestParam struct {
 	A1 string `path:"a"`
 	B1 int    `path:"b"`
@@ -138,3 +183,867 @@ func TestScopeBind(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, expect, p)
 }
+
+func TestBindDefault(t *testing.T) {
+	type Param struct {
+		Page   int           `query:"page" default:"1"`
+		Size   int           `query:"size" default:"20"`
+		Sort   string        `header:"X-Sort" default:"name,asc"`
+		Tags   []string      `form:"tags" default:"a,b"`
+		Wait   time.Duration `query:"wait" default:"5s"`
+		Name   string        `json:"name" default:"guest"`
+		Filter string        `query:"filter"`
+	}
+
+	ctx := &MockRequest{
+		contentType: binding.MIMEApplicationJSON,
+		queryParams: map[string]string{"size": "50"},
+		requestBody: `{}`,
+	}
+
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, Param{Page: 1, Size: 50, Sort: "name,asc", Tags: []string{"a", "b"}, Wait: 5 * time.Second, Name: "guest"}, p)
+
+	// the body overrides the default as well.
+	ctx.requestBody = `{"name": "admin"}`
+	p = Param{}
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, "admin", p.Name)
+
+	var invalid struct {
+		Page int `query:"page" default:"first"`
+	}
+	err := binding.Bind(&invalid, &MockRequest{})
+	assert.ErrorIs(t, err, binding.ErrBinding)
+	assert.ErrorContains(t, err, `Page: invalid default "first"`)
+}
+
+func TestBindQuerySlice(t *testing.T) {
+	type Param struct {
+		Status []string `query:"status"`
+		IDs    []int    `query:"id"`
+		Tags   []string `query:"tag" default:"all"`
+		Page   int      `query:"page"`
+	}
+
+	ctx := &MockRequest{
+		queryValues: map[string][]string{
+			"status": {"a", "b"},
+			"id":     {"1", "2", "3"},
+			"page":   {"2", "3"},
+		},
+		queryParams: map[string]string{"page": "2"},
+	}
+
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, Param{Status: []string{"a", "b"}, IDs: []int{1, 2, 3}, Tags: []string{"all"}, Page: 2}, p)
+
+	ctx.queryValues["id"] = []string{"1", "x"}
+	assert.ErrorIs(t, binding.Bind(&Param{}, ctx), binding.ErrBinding)
+}
+
+func TestBindQueryMap(t *testing.T) {
+	type Param struct {
+		Filter map[string]string   `query:"filter"`
+		Tags   map[string][]string `query:"tags"`
+	}
+
+	ctx := &MockRequest{
+		queryValues: map[string][]string{
+			"filter[color]": {"red"},
+			"filter[size]":  {"xl", "l"},
+			"tags[a]":       {"1", "2"},
+		},
+	}
+
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, Param{
+		Filter: map[string]string{"color": "red", "size": "xl"},
+		Tags:   map[string][]string{"a": {"1", "2"}},
+	}, p)
+
+	// the requests without the Query method have no map fields bound from the query.
+	p = Param{}
+	assert.Nil(t, binding.Bind(&p, struct{ binding.Request }{ctx}))
+	assert.Equal(t, Param{}, p)
+}
+
+func TestBindQueryNested(t *testing.T) {
+	type Page struct {
+		Number int `query:"number"`
+		Size   int `query:"size"`
+	}
+	type Sort struct {
+		Field string `query:"field"`
+		Desc  bool   `query:"desc"`
+	}
+	type Param struct {
+		Page    Page              `query:"page"`
+		Sort    []Sort            `query:"sort"`
+		Since   time.Time         `query:"since"`
+		Filters map[string]string `query:"filter"`
+	}
+
+	ctx := &MockRequest{
+		queryValues: map[string][]string{
+			"page[number]":  {"2"},
+			"page.size":     {"20"},
+			"sort[0].field": {"name"},
+			"sort[1].field": {"age"},
+			"sort[1].desc":  {"true"},
+			"filter[color]": {"red"},
+		},
+		queryParams: map[string]string{"since": "2023-01-02"},
+	}
+
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, Param{
+		Page:    Page{Number: 2, Size: 20},
+		Sort:    []Sort{{Field: "name"}, {Field: "age", Desc: true}},
+		Since:   time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		Filters: map[string]string{"color": "red"},
+	}, p)
+}
+
+func TestBindBodyWildcard(t *testing.T) {
+	type param struct {
+		A string `json:"a" xml:"a"`
+		B string `json:"-" xml:"-"`
+	}
+
+	binding.RegisterBodyBinder("text/*", func(i interface{}, r binding.Request) error {
+		body, err := io.ReadAll(r.RequestBody())
+		i.(*param).B = r.ContentType() + ":" + string(body)
+		return err
+	})
+
+	tests := []struct {
+		contentType string
+		body        string
+		expect      param
+	}{
+		{"application/vnd.api+json; charset=utf-8", `{"a":"json"}`, param{A: "json"}},
+		{"application/atom+xml", `<param><a>xml</a></param>`, param{A: "xml"}},
+		{"text/csv", "1,2", param{B: "text/csv:1,2"}},
+		{"text/xml", `<param><a>exact</a></param>`, param{A: "exact"}},
+	}
+	for _, tt := range tests {
+		var p param
+		err := binding.Bind(&p, &MockRequest{contentType: tt.contentType, requestBody: tt.body})
+		assert.Nil(t, err, tt.contentType)
+		assert.Equal(t, tt.expect, p, tt.contentType)
+	}
+}
+
+type idList []string
+
+func (l *idList) UnmarshalText(text []byte) error {
+	*l = strings.Split(string(text), "|")
+	return nil
+}
+
+func TestBindTextUnmarshaler(t *testing.T) {
+	type Param struct {
+		Addr   netip.Addr   `query:"addr"`
+		Prefix netip.Prefix `header:"X-Prefix"`
+		IDs    idList       `query:"ids"`
+		Peer   netip.Addr   `form:"peer"`
+	}
+
+	ctx := &MockRequest{
+		contentType: binding.MIMEApplicationForm,
+		queryValues: map[string][]string{"ids": {"a|b", "c"}},
+		queryParams: map[string]string{"addr": "10.0.0.1", "ids": "a|b"},
+		headers:     map[string]string{"X-Prefix": "192.168.0.0/16"},
+		formParams:  url.Values{"peer": {"::1"}},
+	}
+
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, Param{
+		Addr:   netip.MustParseAddr("10.0.0.1"),
+		Prefix: netip.MustParsePrefix("192.168.0.0/16"),
+		IDs:    idList{"a", "b"},
+		Peer:   netip.MustParseAddr("::1"),
+	}, p)
+
+	ctx.queryParams["addr"] = "10.0.0"
+	assert.ErrorIs(t, binding.Bind(&Param{}, ctx), binding.ErrBinding)
+}
+
+func TestBindPointer(t *testing.T) {
+	type Param struct {
+		Page  *int           `query:"page"`
+		Name  *string        `query:"name"`
+		Since *time.Time     `query:"since"`
+		Size  *int           `query:"size" default:"10"`
+		Debug *bool          `header:"X-Debug"`
+		Limit *time.Duration `query:"limit"`
+	}
+
+	ctx := &MockRequest{
+		queryParams: map[string]string{"page": "2", "since": "2023-01-02"},
+	}
+
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	page, size := 2, 10
+	assert.Equal(t, Param{
+		Page:  &page,
+		Since: &[]time.Time{time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)}[0],
+		Size:  &size,
+	}, p)
+
+	ctx.queryParams["page"] = "two"
+	assert.ErrorIs(t, binding.Bind(&Param{}, ctx), binding.ErrBinding)
+}
+
+func TestBindErrors(t *testing.T) {
+	type Param struct {
+		ID    int    `path:"id"`
+		Page  int    `query:"page"`
+		Size  int    `query:"size" default:"ten"`
+		Token int    `header:"X-Token"`
+		Name  string `json:"name"`
+	}
+
+	ctx := &MockRequest{
+		contentType: binding.MIMEApplicationJSON,
+		pathParams:  map[string]string{"id": "x"},
+		queryParams: map[string]string{"page": "first"},
+		headers:     map[string]string{"X-Token": "abc"},
+		requestBody: `{"name": ""}`,
+	}
+
+	binding.RegisterValidator(func(i interface{}) error {
+		if len(i.(*Param).Name) == 0 {
+			return binding.BindingErrors{{Field: "Name", Source: "validate", Reason: "is required"}}
+		}
+		return nil
+	})
+	defer binding.RegisterValidator(nil)
+
+	err := binding.Bind(&Param{}, ctx)
+	assert.ErrorIs(t, err, binding.ErrBinding)
+
+	var errs binding.BindingErrors
+	if assert.ErrorAs(t, err, &errs) {
+		var fields []string
+		for _, e := range errs {
+			fields = append(fields, e.Source+":"+e.Field)
+		}
+		assert.Equal(t, []string{"path:id", "query:page", "default:Size", "header:X-Token", "validate:Name"}, fields)
+	}
+	assert.ErrorContains(t, err, `Size: invalid default "ten"`)
+	assert.ErrorContains(t, err, "; Name: is required")
+
+	// the validation failures only wrap ErrValidate.
+	ctx = &MockRequest{contentType: binding.MIMEApplicationJSON, requestBody: `{}`}
+	type Valid struct {
+		Name string `json:"name"`
+	}
+	binding.RegisterValidator(func(i interface{}) error { return fmt.Errorf("invalid") })
+	err = binding.Bind(&Valid{}, ctx)
+	assert.ErrorIs(t, err, binding.ErrValidate)
+	assert.NotErrorIs(t, err, binding.ErrBinding)
+	assert.EqualError(t, err, "validate failed: invalid")
+
+	// the struct isn't validated if the body fails to bind.
+	ctx.requestBody = `{`
+	err = binding.Bind(&Valid{}, ctx)
+	assert.ErrorIs(t, err, binding.ErrBinding)
+	assert.NotContains(t, err.Error(), "invalid")
+}
+
+func BenchmarkBind(b *testing.B) {
+	type Common struct {
+		Token string `header:"X-Token"`
+		Trace string `header:"X-Trace"`
+	}
+	type Param struct {
+		Common
+		ID     int      `path:"id"`
+		Page   int      `query:"page" default:"1"`
+		Size   int      `query:"size" default:"20"`
+		Sort   string   `query:"sort"`
+		Status []string `query:"status"`
+		Debug  bool     `cookie:"debug"`
+		Name   string   `form:"name"`
+	}
+
+	ctx := &MockRequest{
+		pathParams:  map[string]string{"id": "7"},
+		queryParams: map[string]string{"page": "2", "sort": "name"},
+		queryValues: map[string][]string{"page": {"2"}, "sort": {"name"}, "status": {"a", "b"}},
+		headers:     map[string]string{"X-Token": "token"},
+		cookies:     map[string]string{"debug": "true"},
+		formParams:  url.Values{"name": {"bench"}},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var p Param
+		if err := binding.Bind(&p, ctx); nil != err {
+			b.Fatal(err)
+		}
+	}
+}
+
+type csvTags []string
+
+func TestBindPlanConverter(t *testing.T) {
+	type Param struct {
+		Tags csvTags `query:"tags"`
+	}
+	ctx := &MockRequest{
+		queryParams: map[string]string{"tags": "a,b"},
+		queryValues: map[string][]string{"tags": {"a,b"}},
+	}
+
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, csvTags{"a,b"}, p.Tags)
+
+	// the compiled plan is dropped, so the converter applies to the types bound already.
+	binding.RegisterConverter(reflect.TypeOf(csvTags{}), func(v reflect.Value, val string) error {
+		v.Set(reflect.ValueOf(csvTags(strings.Split(val, ","))))
+		return nil
+	})
+
+	p = Param{}
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, csvTags{"a", "b"}, p.Tags)
+}
+
+type paramsBinderParam struct {
+	ID   int    `path:"id"`
+	Name string `json:"name"`
+}
+
+func (p *paramsBinderParam) BindParams(r binding.Request) binding.BindingErrors {
+	val, _ := r.PathParam("id")
+	if "x" == val {
+		return binding.BindingErrors{{Field: "id", Source: "path", Reason: "not a number"}}
+	}
+	p.ID = len(val)
+	return nil
+}
+
+func TestBindParamsBinder(t *testing.T) {
+	ctx := &MockRequest{
+		contentType: binding.MIMEApplicationJSON,
+		pathParams:  map[string]string{"id": "abc"},
+		requestBody: `{"name": "go"}`,
+	}
+
+	// the params are bound by BindParams rather than by reflection, the body as usual.
+	var p paramsBinderParam
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, paramsBinderParam{ID: 3, Name: "go"}, p)
+
+	ctx.pathParams["id"] = "x"
+	err := binding.Bind(&paramsBinderParam{}, ctx)
+	assert.ErrorIs(t, err, binding.ErrBinding)
+	assert.EqualError(t, err, "binding failed: id: not a number")
+}
+
+func TestBindTLSCert(t *testing.T) {
+	type Param struct {
+		CN       string    `tlscert:"CommonName"`
+		Serial   string    `tlscert:"SerialNumber"`
+		Org      string    `tlscert:"Organization"`
+		DNSNames []string  `tlscert:"DNSNames"`
+		IPs      []string  `tlscert:"IPAddresses"`
+		NotAfter time.Time `tlscert:"NotAfter"`
+		Emails   []string  `tlscert:"EmailAddresses" default:"nobody@example.com"`
+	}
+
+	cert := &x509.Certificate{
+		Subject:      pkix.Name{CommonName: "client-1", Organization: []string{"Acme", "Corp"}},
+		SerialNumber: big.NewInt(0x1f2e),
+		DNSNames:     []string{"a.example.com", "b.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+		NotAfter:     time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	var p Param
+	assert.Nil(t, binding.Bind(&p, &MockRequest{peerCert: cert}))
+	assert.Equal(t, Param{
+		CN:       "client-1",
+		Serial:   "1f2e",
+		Org:      "Acme",
+		DNSNames: []string{"a.example.com", "b.example.com"},
+		IPs:      []string{"10.0.0.1"},
+		NotAfter: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		Emails:   []string{"nobody@example.com"},
+	}, p)
+
+	// the fields are left as is without the client certificate.
+	p = Param{}
+	assert.Nil(t, binding.Bind(&p, &MockRequest{}))
+	assert.Equal(t, Param{Emails: []string{"nobody@example.com"}}, p)
+
+	type Unknown struct {
+		Name string `tlscert:"Name"`
+	}
+	err := binding.Bind(&Unknown{}, &MockRequest{peerCert: cert})
+	assert.ErrorContains(t, err, `Name: unknown tlscert attribute "Name"`)
+}
+
+type ctxRequest struct {
+	MockRequest
+	ctx context.Context
+}
+
+func (r *ctxRequest) Context() context.Context {
+	return r.ctx
+}
+
+type tenantKey struct{}
+
+type tenantID int
+
+func TestBindContext(t *testing.T) {
+	binding.RegisterContextKey("tenant", tenantKey{})
+
+	type Param struct {
+		Tenant  tenantID      `ctx:"tenant"`
+		Retries int64         `ctx:"retries"`
+		Timeout time.Duration `ctx:"timeout"`
+		Addr    netip.Addr    `ctx:"addr"`
+		Locale  *string       `ctx:"locale"`
+		User    string        `header:"X-User" ctx:"user"`
+	}
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, tenantID(3))
+	ctx = context.WithValue(ctx, "retries", 5)
+	ctx = context.WithValue(ctx, "timeout", "2s")
+	ctx = context.WithValue(ctx, "addr", netip.MustParseAddr("10.0.0.1"))
+	ctx = context.WithValue(ctx, "user", "ctx-user")
+
+	r := &ctxRequest{MockRequest: MockRequest{headers: map[string]string{"X-User": "header-user"}}, ctx: ctx}
+	var p Param
+	assert.Nil(t, binding.Bind(&p, r))
+	assert.Equal(t, tenantID(3), p.Tenant)
+	assert.Equal(t, int64(5), p.Retries)
+	assert.Equal(t, 2*time.Second, p.Timeout)
+	assert.Equal(t, netip.MustParseAddr("10.0.0.1"), p.Addr)
+	assert.Nil(t, p.Locale)
+	// the context value overrides the params bound before.
+	assert.Equal(t, "ctx-user", p.User)
+
+	r.ctx = context.WithValue(ctx, "retries", struct{}{})
+	err := binding.Bind(&Param{}, r)
+	assert.ErrorIs(t, err, binding.ErrBinding)
+	assert.ErrorContains(t, err, `retries: context value of type "struct {}" is not assignable to "int64"`)
+
+	// the requests without the context have no context values.
+	p = Param{}
+	assert.Nil(t, binding.Bind(&p, &MockRequest{}))
+	assert.Equal(t, Param{}, p)
+}
+
+type metadataRequest struct {
+	MockRequest
+	metadata map[string]string
+}
+
+func (r *metadataRequest) Metadata(name string) (string, bool) {
+	value, ok := r.metadata[name]
+	return value, ok
+}
+
+func TestBindRequestMetadata(t *testing.T) {
+	type Param struct {
+		ClientIP  netip.Addr `request:"clientip"`
+		Method    string     `request:"method"`
+		RequestID string     `request:"requestid" default:"none"`
+	}
+
+	r := &metadataRequest{metadata: map[string]string{"clientip": "10.0.0.1", "method": "POST"}}
+	var p Param
+	assert.Nil(t, binding.Bind(&p, r))
+	assert.Equal(t, Param{ClientIP: netip.MustParseAddr("10.0.0.1"), Method: "POST", RequestID: "none"}, p)
+
+	// the requests without the metadata leave the fields as is.
+	p = Param{}
+	assert.Nil(t, binding.Bind(&p, &MockRequest{}))
+	assert.Equal(t, Param{RequestID: "none"}, p)
+
+	type Unknown struct {
+		Agent string `request:"useragent"`
+	}
+	err := binding.Bind(&Unknown{}, r)
+	assert.ErrorContains(t, err, `Agent: unknown request metadata "useragent"`)
+}
+
+func TestBindEncoding(t *testing.T) {
+	type Param struct {
+		Signature []byte `header:"X-Signature" encoding:"hex"`
+		Token     []byte `query:"token" encoding:"base64url"`
+		Nonce     []byte `cookie:"nonce" encoding:"base64" default:"AAE="`
+		Payload   []byte `form:"payload" encoding:"base64"`
+	}
+
+	ctx := &MockRequest{
+		contentType: binding.MIMEApplicationForm,
+		headers:     map[string]string{"X-Signature": "cafe"},
+		queryParams: map[string]string{"token": "-_8"},
+		queryValues: map[string][]string{"token": {"-_8"}},
+		formParams:  url.Values{"payload": {"aGk="}},
+	}
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, Param{
+		Signature: []byte{0xca, 0xfe},
+		Token:     []byte{0xfb, 0xff},
+		Nonce:     []byte{0x00, 0x01},
+		Payload:   []byte("hi"),
+	}, p)
+
+	ctx.headers["X-Signature"] = "xyz"
+	err := binding.Bind(&Param{}, ctx)
+	assert.ErrorIs(t, err, binding.ErrBinding)
+	assert.ErrorContains(t, err, "X-Signature: invalid hex value: encoding/hex: invalid byte")
+
+	type Unknown struct {
+		Key []byte `query:"key" encoding:"base32"`
+	}
+	assert.ErrorContains(t, binding.Bind(&Unknown{}, ctx), `Key: unknown encoding "base32"`)
+
+	type NotBytes struct {
+		Key string `query:"key" encoding:"hex"`
+	}
+	assert.ErrorContains(t, binding.Bind(&NotBytes{}, ctx), `Key: encoding "hex" requires a []byte field, got "string"`)
+
+	type Form struct {
+		Key string `form:"key" encoding:"hex"`
+	}
+	assert.ErrorContains(t, binding.Bind(&Form{}, ctx), `Key: encoding "hex" requires a []byte field`)
+}
+
+func TestBindSplit(t *testing.T) {
+	type Param struct {
+		IDs    []int    `query:"ids" explode:"false"`
+		Tags   []string `header:"X-Tags" split:";"`
+		Status []string `query:"status" explode:"true"`
+		Sizes  []int    `query:"sizes" split:"|" default:"1|2"`
+		Empty  []int    `query:"empty" explode:"false"`
+	}
+
+	ctx := &MockRequest{
+		queryValues: map[string][]string{"ids": {"1,2", "3"}, "status": {"a,b"}, "empty": {""}},
+		headers:     map[string]string{"X-Tags": "x;y"},
+	}
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, Param{
+		IDs:    []int{1, 2, 3},
+		Tags:   []string{"x", "y"},
+		Status: []string{"a,b"},
+		Sizes:  []int{1, 2},
+	}, p)
+
+	ctx.queryValues["ids"] = []string{"1,x"}
+	assert.ErrorContains(t, binding.Bind(&Param{}, ctx), `ids: strconv.ParseInt: parsing "x": invalid syntax`)
+
+	type NotSlice struct {
+		ID int `query:"id" explode:"false"`
+	}
+	assert.ErrorContains(t, binding.Bind(&NotSlice{}, ctx), `ID: split values require a slice field, got "int"`)
+
+	type Invalid struct {
+		IDs []int `query:"ids" explode:"no"`
+	}
+	assert.ErrorContains(t, binding.Bind(&Invalid{}, ctx), `IDs: invalid explode "no"`)
+}
+
+type rejectKey struct{}
+
+type hooksParam struct {
+	Email    string `json:"email" query:"email"`
+	Password string `json:"password"`
+	Confirm  string `json:"confirm"`
+	Tenant   string `query:"tenant"`
+}
+
+func (p *hooksParam) BeforeBind(ctx context.Context) error {
+	if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+		p.Tenant = tenant
+	} else if nil != ctx.Value(rejectKey{}) {
+		return fmt.Errorf("rejected")
+	}
+	return nil
+}
+
+func (p *hooksParam) AfterBind(ctx context.Context) error {
+	p.Email = strings.ToLower(strings.TrimSpace(p.Email))
+	if p.Password != p.Confirm {
+		return &binding.FieldError{Field: "confirm", Source: "validate", Reason: "must match the password"}
+	}
+	if p.Email == "root@localhost" {
+		return fmt.Errorf("reserved email")
+	}
+	return nil
+}
+
+func TestBindHooks(t *testing.T) {
+	binding.RegisterValidator(func(i interface{}) error {
+		if p, ok := i.(*hooksParam); ok && len(p.Password) < 3 {
+			return binding.BindingErrors{{Field: "password", Source: "validate", Reason: "too short"}}
+		}
+		return nil
+	})
+	defer binding.RegisterValidator(nil)
+
+	request := func(ctx context.Context, body string) *ctxRequest {
+		return &ctxRequest{
+			MockRequest: MockRequest{
+				contentType: binding.MIMEApplicationJSON,
+				queryParams: map[string]string{"tenant": "query"},
+				requestBody: body,
+			},
+			ctx: ctx,
+		}
+	}
+
+	// the BeforeBind defaults are overridden by the params, the AfterBind normalizes the fields.
+	var p hooksParam
+	ctx := context.WithValue(context.Background(), tenantKey{}, "ctx")
+	err := binding.Bind(&p, request(ctx, `{"email":" Bob@Example.COM ","password":"abc","confirm":"abc"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, hooksParam{Email: "bob@example.com", Password: "abc", Confirm: "abc", Tenant: "query"}, p)
+
+	err = binding.Bind(&hooksParam{}, request(context.WithValue(context.Background(), rejectKey{}, true), `{}`))
+	assert.ErrorIs(t, err, binding.ErrBinding)
+	assert.Equal(t, `binding failed: rejected`, err.Error())
+
+	// the AfterBind is called only if the validation succeeds.
+	err = binding.Bind(&hooksParam{}, request(context.Background(), `{"password":"a","confirm":"b"}`))
+	assert.ErrorIs(t, err, binding.ErrValidate)
+	assert.Equal(t, `validate failed: password: too short`, err.Error())
+
+	err = binding.Bind(&hooksParam{}, request(context.Background(), `{"password":"abc","confirm":"abd"}`))
+	assert.ErrorIs(t, err, binding.ErrValidate)
+	var errs binding.BindingErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, binding.BindingErrors{{Field: "confirm", Source: "validate", Reason: "must match the password"}}, errs)
+
+	err = binding.Bind(&hooksParam{}, request(context.Background(), `{"email":"ROOT@localhost","password":"abc","confirm":"abc"}`))
+	assert.ErrorIs(t, err, binding.ErrValidate)
+	assert.Equal(t, `validate failed: reserved email`, err.Error())
+}
+
+type policyRequest struct {
+	MockRequest
+	policy *binding.ScopePolicy
+}
+
+func (r *policyRequest) ScopePolicy() *binding.ScopePolicy {
+	return r.policy
+}
+
+func TestBindScopePolicy(t *testing.T) {
+	type Param struct {
+		Tenant string   `path:"tenant" query:"tenant" header:"X-Tenant"`
+		IDs    []string `query:"ids" header:"X-Ids" split:","`
+		Trace  string   `query:"trace" header:"X-Trace"`
+	}
+
+	request := func(policy *binding.ScopePolicy) *policyRequest {
+		return &policyRequest{
+			MockRequest: MockRequest{
+				pathParams:  map[string]string{"tenant": "path"},
+				queryParams: map[string]string{"tenant": "query", "ids": "1,2", "trace": "t1"},
+				queryValues: map[string][]string{"ids": {"1,2"}},
+				headers:     map[string]string{"X-Tenant": "header", "X-Ids": "1,2"},
+			},
+			policy: policy,
+		}
+	}
+
+	// the later scope overrides the earlier one by default.
+	var p Param
+	assert.Nil(t, binding.Bind(&p, request(nil)))
+	assert.Equal(t, Param{Tenant: "header", IDs: []string{"1", "2"}, Trace: "t1"}, p)
+
+	// the listed scopes take precedence over the unlisted ones, the absent ones are skipped.
+	p = Param{}
+	policy := &binding.ScopePolicy{Precedence: []binding.BindScope{binding.BindScopeQuery}}
+	assert.Nil(t, binding.Bind(&p, request(policy)))
+	assert.Equal(t, Param{Tenant: "query", IDs: []string{"1", "2"}, Trace: "t1"}, p)
+
+	p = Param{}
+	policy = &binding.ScopePolicy{Precedence: []binding.BindScope{binding.BindScopeURI, binding.BindScopeHeader}}
+	assert.Nil(t, binding.Bind(&p, request(policy)))
+	assert.Equal(t, Param{Tenant: "path", IDs: []string{"1", "2"}, Trace: "t1"}, p)
+
+	// the strict policy fails the conflicting values only, the same values are fine.
+	err := binding.Bind(&Param{}, request(&binding.ScopePolicy{Strict: true}))
+	assert.ErrorIs(t, err, binding.ErrBinding)
+	assert.ErrorIs(t, err, binding.ErrScopeConflict)
+	var errs binding.BindingErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, "X-Tenant", errs[0].Field)
+	assert.Equal(t, `conflicting values in header "X-Tenant" and query "tenant"`, errs[0].Reason)
+
+	// the package-level policy applies to the requests without their own.
+	binding.SetScopePolicy(binding.ScopePolicy{Precedence: []binding.BindScope{binding.BindScopeURI}})
+	defer binding.SetScopePolicy(binding.ScopePolicy{})
+	p = Param{}
+	assert.Nil(t, binding.Bind(&p, request(nil)))
+	assert.Equal(t, "path", p.Tenant)
+}
+
+func TestBindRawBody(t *testing.T) {
+	type Common struct {
+		Raw []byte `body:"raw"`
+	}
+	type Param struct {
+		Common
+		Event   string          `json:"event"`
+		Payload json.RawMessage `json:"payload" body:"raw"`
+		Token   string          `header:"X-Token"`
+	}
+
+	body := `{"event": "push", "payload": {"ref": "main"}}`
+	ctx := &MockRequest{
+		contentType: binding.MIMEApplicationJSON,
+		headers:     map[string]string{"X-Token": "t"},
+		requestBody: body,
+	}
+
+	// the raw body overrides the value decoded into the field.
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, Param{Common: Common{Raw: []byte(body)}, Event: "push", Payload: json.RawMessage(body), Token: "t"}, p)
+
+	p = Param{}
+	assert.Nil(t, binding.Bind(&p, &MockRequest{}))
+	assert.Nil(t, p.Raw)
+
+	type Unknown struct {
+		Raw []byte `body:"json"`
+	}
+	assert.ErrorContains(t, binding.Bind(&Unknown{}, ctx), `Raw: unknown body tag "json"`)
+
+	type String struct {
+		Raw string `body:"raw"`
+	}
+	assert.ErrorContains(t, binding.Bind(&String{}, ctx), `Raw: raw body must be an exported []byte field`)
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	supported := []string{"en", "fr", "zh-CN"}
+	assert.Equal(t, "en", binding.NegotiateLanguage("", supported))
+	assert.Equal(t, "fr", binding.NegotiateLanguage("fr-CA,fr;q=0.9,en;q=0.8", supported))
+	assert.Equal(t, "zh-CN", binding.NegotiateLanguage("zh-cn", supported))
+	assert.Equal(t, "zh-CN", binding.NegotiateLanguage("de;q=0.9, zh-TW;q=0.8", supported))
+	assert.Equal(t, "fr", binding.NegotiateLanguage("en;q=0.1, fr", supported))
+	assert.Equal(t, "en", binding.NegotiateLanguage("fr;q=0, *", supported))
+
+	assert.Equal(t, []string{"fr-CA", "fr", "*"}, binding.AcceptedLanguages("fr;q=0.9, fr-CA, *;q=0.1, de;q=0"))
+}
+
+type languageTag string
+
+func (tag *languageTag) UnmarshalText(text []byte) error {
+	*tag = languageTag(strings.ToLower(string(text)))
+	return nil
+}
+
+func TestBindLanguage(t *testing.T) {
+	type Param struct {
+		Locale    string      `lang:"zh-CN, en-US"`
+		Tag       languageTag `lang:"zh-CN,en-US"`
+		Preferred string      `lang:""`
+	}
+
+	var p Param
+	r := &MockRequest{headers: map[string]string{"Accept-Language": "de, zh-TW;q=0.8, en;q=0.5"}}
+	assert.Nil(t, binding.Bind(&p, r))
+	assert.Equal(t, Param{Locale: "zh-CN", Tag: "zh-cn", Preferred: "de"}, p)
+
+	// the first supported tag is the default.
+	p = Param{}
+	assert.Nil(t, binding.Bind(&p, &MockRequest{}))
+	assert.Equal(t, Param{Locale: "zh-CN", Tag: "zh-cn"}, p)
+}
+
+func TestBindHeaderWildcard(t *testing.T) {
+	type Param struct {
+		Meta  map[string]string   `header:"X-Meta-*"`
+		Nums  map[string]int      `header:"x-num-*"`
+		Lists map[string][]string `header:"X-List-*"`
+		None  map[string]string   `header:"X-None-*"`
+	}
+
+	r := &MockRequest{headers: map[string]string{
+		"X-Meta-Trace-Id": "abc",
+		"X-Meta-User":     "bob",
+		"X-Meta-":         "empty",
+		"X-Num-A":         "1",
+		"X-List-Tags":     "a",
+		"X-Other":         "x",
+	}}
+	var p Param
+	assert.Nil(t, binding.Bind(&p, r))
+	assert.Equal(t, Param{
+		Meta:  map[string]string{"Trace-Id": "abc", "User": "bob"},
+		Nums:  map[string]int{"A": 1},
+		Lists: map[string][]string{"Tags": {"a"}},
+	}, p)
+
+	r = &MockRequest{headers: map[string]string{"X-Num-A": "one"}}
+	err := binding.Bind(&Param{}, r)
+	assert.ErrorIs(t, err, binding.ErrBinding)
+	assert.ErrorContains(t, err, "x-num-*: ")
+
+	type Invalid struct {
+		Meta string `header:"X-Meta-*"`
+	}
+	assert.ErrorContains(t, binding.Bind(&Invalid{}, r), `Meta: header wildcard "X-Meta-*" requires a map field of string keys`)
+}
+
+func TestBindAliases(t *testing.T) {
+	type Param struct {
+		PageSize int      `query:"page_size, pageSize,limit" default:"20"`
+		Token    string   `header:"X-Token,X-Auth-Token" cookie:"token"`
+		Tags     []string `form:"tags,tag"`
+	}
+
+	bind := func(query map[string]string, headers map[string]string) Param {
+		var p Param
+		err := binding.Bind(&p, &MockRequest{
+			contentType: binding.MIMEApplicationForm,
+			queryParams: query,
+			headers:     headers,
+			formParams:  url.Values{"tag": {"a", "b"}},
+		})
+		assert.Nil(t, err)
+		return p
+	}
+
+	assert.Equal(t, Param{PageSize: 20, Tags: []string{"a", "b"}}, bind(nil, nil))
+	assert.Equal(t, 50, bind(map[string]string{"limit": "50"}, nil).PageSize)
+	assert.Equal(t, 30, bind(map[string]string{"limit": "50", "pageSize": "30"}, nil).PageSize)
+	assert.Equal(t, 10, bind(map[string]string{"limit": "50", "page_size": "10", "pageSize": "30"}, nil).PageSize)
+	assert.Equal(t, "b", bind(nil, map[string]string{"X-Auth-Token": "b"}).Token)
+	assert.Equal(t, "a", bind(nil, map[string]string{"X-Auth-Token": "b", "X-Token": "a"}).Token)
+
+	// the failures are of the alias present.
+	err := binding.Bind(&Param{}, &MockRequest{queryParams: map[string]string{"limit": "x"}})
+	assert.ErrorContains(t, err, "limit: ")
+
+	// the strict policy fails the conflicting aliases.
+	r := &policyRequest{
+		MockRequest: MockRequest{queryParams: map[string]string{"limit": "50", "page_size": "10"}},
+		policy:      &binding.ScopePolicy{Strict: true},
+	}
+	assert.ErrorIs(t, binding.Bind(&Param{}, r), binding.ErrScopeConflict)
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding_test
+
+import (
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"go-spring.dev/web/binding"
+)
+
+type CBORBindParam struct {
+	A string   `json:"a"`
+	B []string `cbor:"b"`
+	C int      `json:"c"`
+}
+
+func TestBindCBOR(t *testing.T) {
+	data, err := cbor.Marshal(map[string]interface{}{
+		"a": "1",
+		"b": []string{"2", "3"},
+		"c": 4,
+	})
+	assert.Nil(t, err)
+
+	for _, contentType := range []string{binding.MIMEApplicationCBOR, "application/senml+cbor"} {
+		r := &MockRequest{
+			contentType: contentType,
+			requestBody: string(data),
+		}
+
+		var p CBORBindParam
+		err = binding.Bind(&p, r)
+		assert.Nil(t, err, contentType)
+		assert.Equal(t, CBORBindParam{A: "1", B: []string{"2", "3"}, C: 4}, p, contentType)
+	}
+
+	err = binding.Bind(&CBORBindParam{}, &MockRequest{contentType: binding.MIMEApplicationCBOR, requestBody: "\xff"})
+	assert.ErrorIs(t, err, binding.ErrBinding)
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// contextKeys are the keys of the request context values named by the `ctx` tag.
+var contextKeys sync.Map
+
+// RegisterContextKey registers the key of the request context values bound into the fields
+// tagged by `ctx:"name"`, e.g. the values placed by the upstream middlewares:
+//
+//	binding.RegisterContextKey("tenant", tenantKey{})
+//
+//	type Request struct {
+//		Tenant string `ctx:"tenant"`
+//	}
+//
+// The unregistered names are used as the keys themselves.
+func RegisterContextKey(name string, key interface{}) {
+	contextKeys.Store(name, key)
+}
+
+// contextValue returns the request context value named, the requests without the
+// `Context() context.Context` method have no context values.
+func contextValue(r Request, name string) (interface{}, bool) {
+	cr, ok := r.(interface{ Context() context.Context })
+	if !ok {
+		return nil, false
+	}
+	key, ok := contextKeys.Load(name)
+	if !ok {
+		key = name
+	}
+	val := cr.Context().Value(key)
+	return val, nil != val
+}
+
+// requestContext returns the context of the request, or the background context for the
+// requests without the `Context() context.Context` method.
+func requestContext(r Request) context.Context {
+	if cr, ok := r.(interface{ Context() context.Context }); ok {
+		return cr.Context()
+	}
+	return context.Background()
+}
+
+// bindContextValue binds the request context value into the field, the values assignable or,
+// between the numeric types, convertible to the field type are set as is, the strings and the
+// fmt.Stringer values are parsed like the params.
+func bindContextValue(v reflect.Value, val interface{}) error {
+	rv := reflect.ValueOf(val)
+	if reflect.Ptr == v.Kind() && !rv.Type().AssignableTo(v.Type()) {
+		ev := reflect.New(v.Type().Elem())
+		if err := bindContextValue(ev.Elem(), val); err != nil {
+			return err
+		}
+		v.Set(ev)
+		return nil
+	}
+
+	switch {
+	case rv.Type().AssignableTo(v.Type()):
+		v.Set(rv)
+		return nil
+	case isNumeric(rv.Kind()) && isNumeric(v.Kind()):
+		v.Set(rv.Convert(v.Type()))
+		return nil
+	case reflect.String == rv.Kind():
+		return bindData(v, rv.String())
+	}
+	if s, ok := val.(fmt.Stringer); ok {
+		return bindData(v, s.String())
+	}
+	return fmt.Errorf("context value of type %q is not assignable to %q", rv.Type().String(), v.Type().String())
+}
+
+func isNumeric(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// bytesDecoder decodes the value of a []byte field.
+type bytesDecoder func(val string) ([]byte, error)
+
+// bytesDecoders are the decoders of the []byte fields tagged by `encoding:"name"`, e.g. the
+// signatures and tokens, the base64 values are decoded with or without the padding.
+//
+//	Signature []byte `header:"X-Signature" encoding:"hex"`
+var bytesDecoders = map[string]bytesDecoder{
+	"base64":    decodeBase64(base64.RawStdEncoding),
+	"base64url": decodeBase64(base64.RawURLEncoding),
+	"hex":       hex.DecodeString,
+}
+
+func decodeBase64(enc *base64.Encoding) bytesDecoder {
+	return func(val string) ([]byte, error) {
+		return enc.DecodeString(strings.TrimRight(val, "="))
+	}
+}
+
+// bytesDecoderOf returns the decoder of the field by its `encoding` tag, nil if it has no such tag.
+func bytesDecoderOf(field reflect.StructField) (bytesDecoder, error) {
+	name, ok := field.Tag.Lookup("encoding")
+	if !ok {
+		return nil, nil
+	}
+	decode, ok := bytesDecoders[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown encoding %q", field.Name, name)
+	}
+	if reflect.Slice != field.Type.Kind() || reflect.Uint8 != field.Type.Elem().Kind() {
+		return nil, fmt.Errorf("%s: encoding %q requires a []byte field, got %q", field.Name, name, field.Type.String())
+	}
+	return func(val string) ([]byte, error) {
+		b, err := decode(val)
+		if nil != err {
+			return nil, fmt.Errorf("invalid %s value: %w", name, err)
+		}
+		return b, nil
+	}, nil
+}
+
+func bindBytes(v reflect.Value, val string, decode bytesDecoder) error {
+	b, err := decode(val)
+	if nil != err {
+		return err
+	}
+	v.SetBytes(b)
+	return nil
+}
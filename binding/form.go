@@ -17,12 +17,21 @@
 package binding
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"mime/multipart"
 	"net/url"
+	"os"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+var multipartStreamType = reflect.TypeOf(MultipartStream{})
 
 func BindForm(i interface{}, r Request) error {
 	params, err := r.FormParams()
@@ -42,57 +51,347 @@ func BindForm(i interface{}, r Request) error {
 }
 
 func bindFormStruct(v reflect.Value, t reflect.Type, params url.Values) error {
-	for j := 0; j < t.NumField(); j++ {
-		ft := t.Field(j)
-		fv := v.Field(j)
-		if ft.Anonymous {
-			if ft.Type.Kind() != reflect.Struct {
-				continue
+	return bindValuesStruct(v, t, "form", params)
+}
+
+// bindValuesStruct binds the params into the fields of the struct named by the tag by the plan of the struct.
+func bindValuesStruct(v reflect.Value, t reflect.Type, tag string, params url.Values) error {
+	plan, err := valuesPlanOf(t, tag)
+	if nil != err {
+		return err
+	}
+	for _, f := range plan {
+		fv := v.FieldByIndex(f.index)
+		switch f.mode {
+		case bindDecoded:
+			if values := params[f.name]; len(values) > 0 {
+				err = bindBytes(fv, values[0], f.decode)
 			}
-			if err := bindFormStruct(fv, ft.Type, params); nil != err {
+		case bindMap:
+			err = bindMapField(fv, fv.Type(), f.name, params)
+		case bindNested:
+			err = bindNestedField(fv, fv.Type(), tag, f.name, params)
+		default:
+			if values := params[f.name]; len(values) > 0 {
+				err = bindFormField(fv, fv.Type(), values)
+			}
+		}
+		if nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func bindValuesField(v reflect.Value, t reflect.Type, tag, name string, params url.Values) error {
+	if reflect.Map == v.Kind() {
+		return bindMapField(v, t, name, params)
+	}
+	if isNestedType(t) {
+		return bindNestedField(v, t, tag, name, params)
+	}
+	values := params[name]
+	if len(values) == 0 {
+		return nil
+	}
+	return bindFormField(v, t, values)
+}
+
+func bindFormField(v reflect.Value, t reflect.Type, values []string) error {
+	if v.Kind() == reflect.Slice && !isTextUnmarshaler(t) {
+		// the elements are bound in place, the slice keeps the elements bound before a failure.
+		slice := reflect.MakeSlice(t, len(values), len(values))
+		for j, value := range values {
+			if err := bindData(slice.Index(j), value); nil != err {
+				v.Set(slice.Slice(0, j))
 				return err
 			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return bindData(v, values[0])
+}
+
+// bindMapField binds the params prefixed by the name into the map field keyed by the string in the
+// brackets, e.g. `filter[color]=red&filter[size]=xl` into `Filter map[string]string`, the map values
+// are bound like the fields, so `map[string][]string` keeps the repeated params.
+func bindMapField(v reflect.Value, t reflect.Type, name string, params url.Values) error {
+	if reflect.String != t.Key().Kind() {
+		return fmt.Errorf("unsupported binding type %q", t.String())
+	}
+
+	prefix := name + "["
+	for key, values := range params {
+		if len(values) == 0 || !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, "]") || len(key) == len(prefix)+1 {
 			continue
 		}
-		name, ok := ft.Tag.Lookup("form")
-		if !ok || !fv.CanInterface() {
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		ev := reflect.New(t.Elem()).Elem()
+		if err := bindFormField(ev, t.Elem(), values); nil != err {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key[len(prefix):len(key)-1]).Convert(t.Key()), ev)
+	}
+	return nil
+}
+
+// isNestedType reports whether the params of the type are bound with the dot or bracket
+// notation, i.e. a struct, a pointer to a struct or a slice of them without a converter.
+func isNestedType(t reflect.Type) bool {
+	if reflect.Slice == t.Kind() {
+		t = t.Elem()
+	}
+	if reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	_, converted := fieldConverters[t]
+	return reflect.Struct == t.Kind() && !converted && !isTextUnmarshaler(t)
+}
+
+// bindNestedField binds the params prefixed by the name in the dot or bracket notation into the nested
+// struct field, e.g. `address.city=Paris` or `address[city]=Paris` into `Address struct{ City string }`,
+// and `items[0].sku=A1` into `Items []struct{ SKU string }`. The fields of the nested struct are named
+// by the same tag as the field.
+func bindNestedField(v reflect.Value, t reflect.Type, tag, name string, params url.Values) error {
+	nested := url.Values{}
+	for key, values := range params {
+		if !strings.HasPrefix(key, name) {
 			continue
 		}
-		values := params[name]
-		if len(values) == 0 {
+		if seg, rest, ok := cutParamKey(key[len(name):]); ok {
+			nested[seg+rest] = values
+		}
+	}
+	if len(nested) == 0 {
+		return nil
+	}
+	if reflect.Slice == t.Kind() {
+		return bindNestedSlice(v, t, tag, nested)
+	}
+	return bindNestedStruct(v, t, tag, nested)
+}
+
+// bindNestedSlice binds the params grouped by the leading index into the elements of the slice,
+// the elements are ordered by the indexes, but the gaps between the indexes are not kept.
+func bindNestedSlice(v reflect.Value, t reflect.Type, tag string, params url.Values) error {
+	groups := map[int]url.Values{}
+	for key, values := range params {
+		i := strings.IndexAny(key, ".[")
+		if i <= 0 {
 			continue
 		}
-		err := bindFormField(fv, ft.Type, values)
-		if err != nil {
-			return err
+		index, err := strconv.Atoi(key[:i])
+		if nil != err || index < 0 {
+			continue
+		}
+		if seg, rest, ok := cutParamKey(key[i:]); ok {
+			if nil == groups[index] {
+				groups[index] = url.Values{}
+			}
+			groups[index][seg+rest] = values
+		}
+	}
+
+	indexes := make([]int, 0, len(groups))
+	for index := range groups {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	slice := reflect.MakeSlice(t, len(indexes), len(indexes))
+	for i, index := range indexes {
+		if err := bindNestedStruct(slice.Index(i), t.Elem(), tag, groups[index]); nil != err {
+			return fmt.Errorf("%d: %w", index, err)
 		}
 	}
+	v.Set(slice)
 	return nil
 }
 
-func bindFormField(v reflect.Value, t reflect.Type, values []string) error {
-	if v.Kind() == reflect.Slice {
-		slice := reflect.MakeSlice(t, 0, len(values))
-		defer func() { v.Set(slice) }()
-		et := t.Elem()
-		for _, value := range values {
-			ev := reflect.New(et).Elem()
-			if err := bindData(ev, value); nil != err {
-				return err
+func bindNestedStruct(v reflect.Value, t reflect.Type, tag string, params url.Values) error {
+	if reflect.Ptr == t.Kind() {
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		v, t = v.Elem(), t.Elem()
+	}
+	return bindValuesStruct(v, t, tag, params)
+}
+
+// cutParamKey cuts the first segment of the dot or bracket notation, e.g. `.city[zip]` or `[city].zip`
+// into `city` and `[zip]` or `.zip`, the segment joined with the rest is the key of the nested params.
+func cutParamKey(key string) (seg, rest string, ok bool) {
+	switch {
+	case strings.HasPrefix(key, "."):
+		key = key[1:]
+		if i := strings.IndexAny(key, ".["); i >= 0 {
+			return key[:i], key[i:], i > 0
+		}
+		return key, "", len(key) > 0
+	case strings.HasPrefix(key, "["):
+		if i := strings.IndexByte(key, ']'); i > 1 {
+			return key[1:i], key[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// MultipartLimits limits the multipart forms bound by BindMultipartForm, the zero fields fall back to
+// the package-level limits set by SetMultipartLimits, and a zero package-level limit means unlimited.
+type MultipartLimits struct {
+	// MaxMemory is the maximum bytes of the file parts stored in memory, the remainder
+	// is stored on disk in temporary files, 32 MB by default.
+	MaxMemory int64
+
+	// MaxFileSize is the maximum size of every file in the form.
+	MaxFileSize int64
+
+	// MaxFiles is the maximum number of the files in the form.
+	MaxFiles int
+}
+
+// merge returns the limits with the zero fields set to the fallback ones.
+func (l MultipartLimits) merge(fallback MultipartLimits) MultipartLimits {
+	if l.MaxMemory <= 0 {
+		l.MaxMemory = fallback.MaxMemory
+	}
+	if l.MaxFileSize <= 0 {
+		l.MaxFileSize = fallback.MaxFileSize
+	}
+	if l.MaxFiles <= 0 {
+		l.MaxFiles = fallback.MaxFiles
+	}
+	return l
+}
+
+var multipartLimits = MultipartLimits{MaxMemory: 32 << 20} // 32 MB
+
+// SetMultipartLimits sets the package-level limits of the multipart forms, the zero fields keep the defaults.
+func SetMultipartLimits(limits MultipartLimits) {
+	multipartLimits = limits.merge(MultipartLimits{MaxMemory: 32 << 20})
+}
+
+// MultipartLimitError is returned when the multipart form exceeds the MultipartLimits,
+// the web renderers respond it with 413 Request Entity Too Large.
+type MultipartLimitError struct {
+	Field string // the form field of the file exceeding MaxFileSize, empty if MaxFiles is exceeded
+	File  string // the name of the file exceeding MaxFileSize
+	Limit int64  // the exceeded limit
+	Size  int64  // the size of the file, or the number of the files
+}
+
+func (e *MultipartLimitError) Error() string {
+	if len(e.Field) > 0 {
+		return fmt.Sprintf("multipart file %q of field %q is too large: %d bytes exceeds the limit of %d bytes", e.File, e.Field, e.Size, e.Limit)
+	}
+	return fmt.Sprintf("too many multipart files: %d files exceeds the limit of %d files", e.Size, e.Limit)
+}
+
+// checkMultipartLimits returns a MultipartLimitError if the files of the form exceed the limits.
+func checkMultipartLimits(form *multipart.Form, limits MultipartLimits) error {
+	var count int
+	for field, files := range form.File {
+		count += len(files)
+		if limits.MaxFileSize <= 0 {
+			continue
+		}
+		for _, file := range files {
+			if file.Size > limits.MaxFileSize {
+				return &MultipartLimitError{Field: field, File: file.Filename, Limit: limits.MaxFileSize, Size: file.Size}
 			}
-			slice = reflect.Append(slice, ev)
 		}
-		return nil
 	}
-	return bindData(v, values[0])
+	if limits.MaxFiles > 0 && count > limits.MaxFiles {
+		return &MultipartLimitError{Limit: int64(limits.MaxFiles), Size: int64(count)}
+	}
+	return nil
 }
 
+// limitMultipartBody returns the body copying the parts of the multipart body, which fails with
+// a MultipartLimitError as soon as the file parts exceed the limits, so that the parts beyond the
+// limits are neither parsed nor spooled to disk. The body must be closed once it's parsed.
+func limitMultipartBody(body io.Reader, boundary string, limits MultipartLimits) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(copyMultipartParts(pw, multipart.NewReader(body, boundary), boundary, limits))
+	}()
+	return pr
+}
+
+// copyMultipartParts copies the parts read from the reader into the writer within the limits.
+func copyMultipartParts(w io.Writer, mr *multipart.Reader, boundary string, limits MultipartLimits) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); nil != err {
+		return err
+	}
+
+	var files int
+	for {
+		part, err := mr.NextRawPart()
+		if io.EOF == err {
+			return mw.Close()
+		}
+		if nil != err {
+			return err
+		}
+
+		var src io.Reader = part
+		isFile := len(part.FileName()) > 0
+		if isFile {
+			files++
+			if limits.MaxFiles > 0 && files > limits.MaxFiles {
+				return &MultipartLimitError{Limit: int64(limits.MaxFiles), Size: int64(files)}
+			}
+			if limits.MaxFileSize > 0 {
+				src = io.LimitReader(part, limits.MaxFileSize+1)
+			}
+		}
+
+		dst, err := mw.CreatePart(part.Header)
+		if nil != err {
+			return err
+		}
+		n, err := io.Copy(dst, src)
+		if nil != err {
+			return err
+		}
+		if isFile && limits.MaxFileSize > 0 && n > limits.MaxFileSize {
+			return &MultipartLimitError{Field: part.FormName(), File: part.FileName(), Limit: limits.MaxFileSize, Size: n}
+		}
+	}
+}
+
+// BindMultipartForm binds the multipart form within the MultipartLimits, the requests providing
+// `MultipartLimits() MultipartLimits` override the package-level limits, e.g. by the route.
+// The requests replacing the body by the `ReplaceBody(body io.Reader)` method, e.g. web.Context,
+// enforce the limits while the form is parsed, the others once the whole form is parsed.
+// The structs with a MultipartStream field are bound with the stream of the parts instead,
+// see MultipartStream.
 func BindMultipartForm(i interface{}, r Request) error {
-	const defaultMaxMemory = 32 << 20 // 32 MB
-	form, err := r.MultipartParams(defaultMaxMemory)
+	limits := multipartLimits
+	if l, ok := r.(interface{ MultipartLimits() MultipartLimits }); ok {
+		limits = l.MultipartLimits().merge(limits)
+	}
+	if stream, ok := multipartStreamOf(reflect.ValueOf(i)); ok {
+		return bindMultipartStream(stream, r, limits)
+	}
+	if rr, ok := r.(interface{ ReplaceBody(body io.Reader) }); ok && (limits.MaxFiles > 0 || limits.MaxFileSize > 0) {
+		if _, params, err := mime.ParseMediaType(r.ContentType()); nil == err && len(params["boundary"]) > 0 {
+			body := limitMultipartBody(r.RequestBody(), params["boundary"], limits)
+			defer body.Close()
+			rr.ReplaceBody(body)
+		}
+	}
+	form, err := r.MultipartParams(limits.MaxMemory)
 	if nil != err {
 		return err
 	}
+	if err = checkMultipartLimits(form, limits); nil != err {
+		return err
+	}
 
 	t := reflect.TypeOf(i)
 	if t.Kind() != reflect.Ptr {
@@ -103,10 +402,17 @@ func BindMultipartForm(i interface{}, r Request) error {
 		return nil
 	}
 	ev := reflect.ValueOf(i).Elem()
-	return bindMultipartFormStruct(ev, et, form)
+	var saved []string
+	if err = bindMultipartFormStruct(ev, et, form, &saved); nil != err {
+		for _, path := range saved {
+			_ = os.Remove(path)
+		}
+		return err
+	}
+	return nil
 }
 
-func bindMultipartFormStruct(v reflect.Value, t reflect.Type, form *multipart.Form) error {
+func bindMultipartFormStruct(v reflect.Value, t reflect.Type, form *multipart.Form, saved *[]string) error {
 	for j := 0; j < t.NumField(); j++ {
 		ft := t.Field(j)
 		fv := v.Field(j)
@@ -114,38 +420,41 @@ func bindMultipartFormStruct(v reflect.Value, t reflect.Type, form *multipart.Fo
 			if ft.Type.Kind() != reflect.Struct {
 				continue
 			}
-			if err := bindMultipartFormStruct(fv, ft.Type, form); nil != err {
+			if err := bindMultipartFormStruct(fv, ft.Type, form, saved); nil != err {
 				return err
 			}
 			continue
 		}
-		name, ok := ft.Tag.Lookup("form")
+		value, ok := ft.Tag.Lookup("form")
 		if !ok || !fv.CanInterface() {
 			continue
 		}
-
-		if ft.Type == fileHeaderType || (reflect.Slice == ft.Type.Kind() && ft.Type.Elem() == fileHeaderType) {
-			files := form.File[name]
-			if len(files) == 0 {
-				continue
-			}
-			if err := bindMultipartFormFiles(fv, ft.Type, files); nil != err {
-				return err
-			}
-		} else {
-			values := form.Value[name]
-			if len(values) == 0 {
-				continue
-			}
-			if err := bindFormField(fv, ft.Type, values); nil != err {
+		for _, name := range aliasesOf(value) {
+			if err := bindMultipartFormField(fv, ft, name, form, saved); nil != err {
 				return err
 			}
 		}
-
 	}
 	return nil
 }
 
+// bindMultipartFormField binds the files or the values of the form named into the field.
+func bindMultipartFormField(fv reflect.Value, ft reflect.StructField, name string, form *multipart.Form, saved *[]string) error {
+	switch {
+	case isSavedFileType(ft.Type):
+		if files := form.File[name]; len(files) > 0 {
+			return bindSavedFiles(fv, ft.Tag.Get("save"), files, saved)
+		}
+		return nil
+	case ft.Type == fileHeaderType || (reflect.Slice == ft.Type.Kind() && ft.Type.Elem() == fileHeaderType):
+		if files := form.File[name]; len(files) > 0 {
+			return bindMultipartFormFiles(fv, ft.Type, files)
+		}
+		return nil
+	}
+	return bindValuesField(fv, ft.Type, "form", name, form.Value)
+}
+
 func bindMultipartFormFiles(v reflect.Value, t reflect.Type, files []*multipart.FileHeader) error {
 	if v.Kind() == reflect.Slice {
 		slice := reflect.MakeSlice(t, 0, len(files))
@@ -159,3 +468,87 @@ func bindMultipartFormFiles(v reflect.Value, t reflect.Type, files []*multipart.
 	v.Set(reflect.ValueOf(files[0]))
 	return nil
 }
+
+// MultipartStream reads the parts of the multipart/form-data request body one by one, so that the
+// large uploads are processed as they arrive rather than buffered in memory or temporary files:
+//
+//	type Upload struct {
+//		Bucket string               `path:"bucket"`
+//		Files  web.MultipartStream
+//	}
+//
+//	for {
+//		part, err := req.Files.NextPart()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//	}
+//
+// The request structs with a MultipartStream field, or in the embedded structs, aren't bound from the
+// form, all the parts including the form values are read from the stream instead. Only MaxFiles of the
+// MultipartLimits applies to the stream, the total size is limited by the request body limit, e.g.
+// web.MaxBodyBytes. The stream is valid until the handler returns.
+type MultipartStream struct {
+	reader *multipart.Reader
+	limits MultipartLimits
+	files  int
+}
+
+// NextPart returns the next part of the body, or io.EOF if there are no more parts. The data of
+// the previous part is discarded. A MultipartLimitError is returned if the files exceed MaxFiles.
+func (s *MultipartStream) NextPart() (*multipart.Part, error) {
+	if nil == s.reader {
+		return nil, errors.New("multipart stream isn't bound")
+	}
+	part, err := s.reader.NextPart()
+	if nil != err {
+		return nil, err
+	}
+	if len(part.FileName()) > 0 {
+		s.files++
+		if s.limits.MaxFiles > 0 && s.files > s.limits.MaxFiles {
+			_ = part.Close()
+			return nil, &MultipartLimitError{Limit: int64(s.limits.MaxFiles), Size: int64(s.files)}
+		}
+	}
+	return part, nil
+}
+
+// multipartStreamOf returns the MultipartStream field of the struct pointed by v.
+func multipartStreamOf(v reflect.Value) (reflect.Value, bool) {
+	if reflect.Ptr != v.Kind() || v.IsNil() {
+		return reflect.Value{}, false
+	}
+	v = v.Elem()
+	if reflect.Struct != v.Kind() {
+		return reflect.Value{}, false
+	}
+	t := v.Type()
+	for j := 0; j < t.NumField(); j++ {
+		ft := t.Field(j)
+		switch {
+		case ft.Type == multipartStreamType && ft.IsExported():
+			return v.Field(j), true
+		case ft.Anonymous && reflect.Struct == ft.Type.Kind():
+			if fv, ok := multipartStreamOf(v.Field(j).Addr()); ok {
+				return fv, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// bindMultipartStream sets the field to the stream of the parts of the request body.
+func bindMultipartStream(v reflect.Value, r Request, limits MultipartLimits) error {
+	_, params, err := mime.ParseMediaType(r.ContentType())
+	if nil != err {
+		return err
+	}
+	boundary, ok := params["boundary"]
+	if !ok {
+		return errors.New("no multipart boundary param in Content-Type")
+	}
+	v.Set(reflect.ValueOf(MultipartStream{reader: multipart.NewReader(r.RequestBody(), boundary), limits: limits}))
+	return nil
+}
@@ -18,6 +18,8 @@ package binding_test
 
 import (
 	"bytes"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -26,6 +28,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 	"go-spring.dev/web/binding"
@@ -69,6 +72,93 @@ func TestBindForm(t *testing.T) {
 	assert.Equal(t, expect, p)
 }
 
+func TestBindFormMap(t *testing.T) {
+	type Param struct {
+		Filter map[string]string   `form:"filter"`
+		Tags   map[string][]string `form:"tags"`
+		Limits map[string]int      `form:"limit"`
+		Empty  map[string]string   `form:"empty"`
+	}
+
+	ctx := &MockRequest{
+		formParams: url.Values{
+			"filter[color]": {"red"},
+			"filter[size]":  {"xl"},
+			"filter[]":      {"ignored"},
+			"filter":        {"ignored"},
+			"tags[a]":       {"1", "2"},
+			"limit[cpu]":    {"4"},
+		},
+	}
+
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, Param{
+		Filter: map[string]string{"color": "red", "size": "xl"},
+		Tags:   map[string][]string{"a": {"1", "2"}},
+		Limits: map[string]int{"cpu": 4},
+	}, p)
+
+	ctx.formParams = url.Values{"limit[cpu]": {"four"}}
+	assert.ErrorIs(t, binding.Bind(&Param{}, ctx), binding.ErrBinding)
+
+	var invalid struct {
+		Filter map[int]string `form:"filter"`
+	}
+	ctx.formParams = url.Values{"filter[1]": {"a"}}
+	assert.ErrorIs(t, binding.Bind(&invalid, ctx), binding.ErrBinding)
+}
+
+func TestBindFormNested(t *testing.T) {
+	type Address struct {
+		City string `form:"city"`
+		Zip  int    `form:"zip"`
+	}
+	type Item struct {
+		SKU  string   `form:"sku"`
+		Qty  int      `form:"qty"`
+		Tags []string `form:"tags"`
+	}
+	type Param struct {
+		Address  Address  `form:"address"`
+		Shipping *Address `form:"shipping"`
+		Billing  *Address `form:"billing"`
+		Items    []Item   `form:"items"`
+		Refs     []*Item  `form:"refs"`
+	}
+
+	ctx := &MockRequest{
+		formParams: url.Values{
+			"address.city":      {"Paris"},
+			"address[zip]":      {"75001"},
+			"shipping[city]":    {"Lyon"},
+			"items[1].sku":      {"B2"},
+			"items[1][qty]":     {"2"},
+			"items[0].sku":      {"A1"},
+			"items[0].tags":     {"x", "y"},
+			"items[x].sku":      {"ignored"},
+			"items[5][sku]":     {"C3"},
+			"refs.0.sku":        {"R1"},
+			"address":           {"ignored"},
+			"address.":          {"ignored"},
+			"addressee.city":    {"ignored"},
+			"address[city]more": {"ignored"},
+		},
+	}
+
+	var p Param
+	assert.Nil(t, binding.Bind(&p, ctx))
+	assert.Equal(t, Param{
+		Address:  Address{City: "Paris", Zip: 75001},
+		Shipping: &Address{City: "Lyon"},
+		Items:    []Item{{SKU: "A1", Tags: []string{"x", "y"}}, {SKU: "B2", Qty: 2}, {SKU: "C3"}},
+		Refs:     []*Item{{SKU: "R1"}},
+	}, p)
+
+	ctx.formParams = url.Values{"items[0].qty": {"two"}}
+	assert.ErrorIs(t, binding.Bind(&Param{}, ctx), binding.ErrBinding)
+}
+
 func TestBindMultipartForm(t *testing.T) {
 	buf := new(bytes.Buffer)
 	mw := multipart.NewWriter(buf)
@@ -186,6 +276,15 @@ func (r testRequest) QueryParam(name string) (string, bool) {
 	return "", false
 }
 
+func (r testRequest) QueryParams(name string) ([]string, bool) {
+	values, ok := r.Request.URL.Query()[name]
+	return values, ok
+}
+
+func (r testRequest) Query() url.Values {
+	return r.Request.URL.Query()
+}
+
 func (r testRequest) FormParams() (url.Values, error) {
 	if err := r.Request.ParseForm(); nil != err {
 		return nil, err
@@ -209,3 +308,76 @@ func (r testRequest) MultipartParams(maxMemory int64) (*multipart.Form, error) {
 func (r testRequest) RequestBody() io.Reader {
 	return r.Request.Body
 }
+
+func (r testRequest) ReplaceBody(body io.Reader) {
+	r.Request.Body = io.NopCloser(body)
+}
+
+func (r testRequest) PeerCertificate() *x509.Certificate {
+	if nil == r.Request.TLS || len(r.Request.TLS.VerifiedChains) == 0 {
+		return nil
+	}
+	return r.Request.TLS.VerifiedChains[0][0]
+}
+
+func TestBindMultipartFormLimits(t *testing.T) {
+	newRequest := func(sizes ...int) *http.Request {
+		buf := new(bytes.Buffer)
+		mw := multipart.NewWriter(buf)
+		for i, size := range sizes {
+			w, err := mw.CreateFormFile("file", fmt.Sprintf("test%d", i+1))
+			if assert.NoError(t, err) {
+				_, err = w.Write(bytes.Repeat([]byte("x"), size))
+				assert.NoError(t, err)
+			}
+		}
+		mw.Close()
+
+		request, err := http.NewRequest("POST", "/", buf)
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", mw.FormDataContentType())
+		return request
+	}
+
+	type param struct {
+		Files []*multipart.FileHeader `form:"file"`
+	}
+
+	binding.SetMultipartLimits(binding.MultipartLimits{MaxFileSize: 10, MaxFiles: 2})
+	defer binding.SetMultipartLimits(binding.MultipartLimits{})
+
+	assert.NoError(t, binding.BindMultipartForm(&param{}, testRequest{newRequest(10, 10)}))
+
+	var limitErr *binding.MultipartLimitError
+	err := binding.BindMultipartForm(&param{}, testRequest{newRequest(10, 11)})
+	if assert.ErrorAs(t, err, &limitErr) {
+		assert.Equal(t, binding.MultipartLimitError{Field: "file", File: "test2", Limit: 10, Size: 11}, *limitErr)
+	}
+
+	err = binding.BindMultipartForm(&param{}, testRequest{newRequest(1, 1, 1)})
+	if assert.ErrorAs(t, err, &limitErr) {
+		assert.Equal(t, binding.MultipartLimitError{Limit: 2, Size: 3}, *limitErr)
+		assert.Equal(t, "too many multipart files: 3 files exceeds the limit of 2 files", err.Error())
+	}
+
+	// the limits of the request override the package-level ones.
+	err = binding.BindMultipartForm(&param{}, limitedRequest{testRequest{newRequest(20, 1, 1)}, binding.MultipartLimits{MaxFileSize: 20, MaxFiles: 3}})
+	assert.NoError(t, err)
+
+	// the limits stop the parsing before the rest of the body is read.
+	for _, request := range []*http.Request{newRequest(1, 1, 1<<16), newRequest(1, 1<<16)} {
+		data, _ := io.ReadAll(request.Body)
+		request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data[:len(data)-100]), iotest.ErrReader(errors.New("read beyond the limits"))))
+		err = binding.BindMultipartForm(&param{}, testRequest{request})
+		assert.ErrorAs(t, err, &limitErr)
+	}
+}
+
+type limitedRequest struct {
+	testRequest
+	limits binding.MultipartLimits
+}
+
+func (r limitedRequest) MultipartLimits() binding.MultipartLimits {
+	return r.limits
+}
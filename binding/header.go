@@ -0,0 +1,56 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// HeadersRequest is implemented by the requests enumerating their headers, e.g. web.Context, the
+// headers matching a prefix wildcard are bound into the map fields, keyed by the rest of the names:
+//
+//	Meta map[string]string `header:"X-Meta-*"` // X-Meta-Trace-Id: 1 => {"Trace-Id": "1"}
+//
+// The prefix is matched case-insensitively, the map values of the slice types take all the values
+// of the repeated headers. The requests without the method bind no headers into the map fields.
+type HeadersRequest interface {
+	Headers() http.Header
+}
+
+// bindHeaderMap binds the headers prefixed by the prefix into the map field.
+func bindHeaderMap(v reflect.Value, t reflect.Type, prefix string, r Request) error {
+	hr, ok := r.(HeadersRequest)
+	if !ok {
+		return nil
+	}
+	for key, values := range hr.Headers() {
+		if len(values) == 0 || len(key) <= len(prefix) || !strings.EqualFold(key[:len(prefix)], prefix) {
+			continue
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		ev := reflect.New(t.Elem()).Elem()
+		if err := bindFormField(ev, t.Elem(), values); nil != err {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key[len(prefix):]).Convert(t.Key()), ev)
+	}
+	return nil
+}
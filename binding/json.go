@@ -18,9 +18,18 @@ package binding
 
 import (
 	"encoding/json"
+	"io"
+	"reflect"
 )
 
 func BindJSON(i interface{}, r Request) error {
+	if hasOneOf(reflect.TypeOf(i)) {
+		data, err := io.ReadAll(r.RequestBody())
+		if nil != err {
+			return err
+		}
+		return bindOneOf(i, data)
+	}
 	decoder := json.NewDecoder(r.RequestBody())
 	return decoder.Decode(i)
 }
@@ -66,3 +66,88 @@ func TestBindJSON(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, expect, p)
 }
+
+type Payment interface{ isPayment() }
+
+type CardPayment struct {
+	Type   string `json:"type"`
+	Number string `json:"number"`
+}
+
+func (*CardPayment) isPayment() {}
+
+type BankPayment struct {
+	Type string `json:"type"`
+	IBAN string `json:"iban"`
+}
+
+func (BankPayment) isPayment() {}
+
+type SplitPayment struct {
+	Type  string    `json:"type"`
+	Parts []Payment `json:"parts"`
+}
+
+func (*SplitPayment) isPayment() {}
+
+func init() {
+	binding.RegisterOneOf[Payment]("type", map[string]Payment{
+		"card":  &CardPayment{},
+		"bank":  BankPayment{},
+		"split": &SplitPayment{},
+	})
+}
+
+func TestBindJSONOneOf(t *testing.T) {
+
+	type Checkout struct {
+		Amount  int64     `json:"amount"`
+		Payment Payment   `json:"payment"`
+		Refunds []Payment `json:"refunds"`
+	}
+
+	ctx := &MockRequest{
+		contentType: binding.MIMEApplicationJSON,
+		requestBody: `{
+			"amount": 100,
+			"payment": {"type": "split", "parts": [
+				{"type": "card", "number": "4242"},
+				{"type": "bank", "iban": "DE89"}
+			]},
+			"refunds": [{"type": "bank", "iban": "FR76"}]
+		}`,
+	}
+
+	var p Checkout
+	err := binding.Bind(&p, ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, Checkout{
+		Amount: 100,
+		Payment: &SplitPayment{Type: "split", Parts: []Payment{
+			&CardPayment{Type: "card", Number: "4242"},
+			BankPayment{Type: "bank", IBAN: "DE89"},
+		}},
+		Refunds: []Payment{BankPayment{Type: "bank", IBAN: "FR76"}},
+	}, p)
+
+	var payments []Payment
+	ctx = &MockRequest{
+		contentType: binding.MIMEApplicationJSON,
+		requestBody: `[{"type": "card", "number": "4242"}]`,
+	}
+	assert.Nil(t, binding.Bind(&payments, ctx))
+	assert.Equal(t, []Payment{&CardPayment{Type: "card", Number: "4242"}}, payments)
+
+	for _, body := range []string{
+		`{"payment": {"type": "cash"}}`,
+		`{"payment": {"number": "4242"}}`,
+		`{"payment": {"type": 1}}`,
+	} {
+		ctx = &MockRequest{
+			contentType: binding.MIMEApplicationJSON,
+			requestBody: body,
+		}
+		err = binding.Bind(&Checkout{}, ctx)
+		assert.ErrorIs(t, err, binding.ErrBinding, body)
+	}
+}
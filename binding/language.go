@@ -0,0 +1,102 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AcceptedLanguages returns the language ranges of the `Accept-Language` header value in the order
+// of preference, the ranges of zero quality are dropped, e.g. `fr;q=0.9, fr-CA, *;q=0.1` is
+// `[fr-CA fr *]`.
+func AcceptedLanguages(acceptLanguage string) []string {
+	type languageRange struct {
+		tag     string
+		quality float64
+	}
+
+	var ranges []languageRange
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if 0 == len(tag) {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); nil == err {
+				quality = v
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, languageRange{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	tags := make([]string, len(ranges))
+	for i, r := range ranges {
+		tags[i] = r.tag
+	}
+	return tags
+}
+
+// NegotiateLanguage returns the supported language tag preferred by the `Accept-Language` header
+// value, the exact tag, then the tag of the same primary language, the first supported tag if none
+// is accepted.
+func NegotiateLanguage(acceptLanguage string, supported []string) string {
+	for _, accepted := range AcceptedLanguages(acceptLanguage) {
+		if "*" == accepted {
+			return supported[0]
+		}
+		for _, tag := range supported {
+			if strings.EqualFold(tag, accepted) {
+				return tag
+			}
+		}
+		primary, _, _ := strings.Cut(accepted, "-")
+		for _, tag := range supported {
+			if p, _, _ := strings.Cut(tag, "-"); strings.EqualFold(p, primary) {
+				return tag
+			}
+		}
+	}
+	return supported[0]
+}
+
+// languageValue returns the language tag negotiated by the `Accept-Language` header among the
+// comma separated tags supported by the field, e.g. `lang:"zh-CN,en-US"`, or the most preferred
+// tag of the header if the field supports any, i.e. `lang:""`:
+//
+//	Locale language.Tag `lang:"zh-CN,en-US"`
+func languageValue(r Request, name string) (string, bool) {
+	acceptLanguage, _ := r.Header("Accept-Language")
+	if 0 == len(name) {
+		for _, tag := range AcceptedLanguages(acceptLanguage) {
+			if "*" != tag {
+				return tag, true
+			}
+		}
+		return "", false
+	}
+	supported := strings.Split(name, ",")
+	for i := range supported {
+		supported[i] = strings.TrimSpace(supported[i])
+	}
+	return NegotiateLanguage(acceptLanguage, supported), true
+}
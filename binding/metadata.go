@@ -0,0 +1,46 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+// requestMetadata are the names of the request metadata bound by the `request` tag.
+var requestMetadata = map[string]bool{
+	"clientip":  true,
+	"method":    true,
+	"host":      true,
+	"path":      true,
+	"requestid": true,
+}
+
+// MetadataRequest is implemented by the requests providing the metadata bound into the fields tagged
+// by `request:"name"`, i.e. `clientip`, `method`, `host`, `path` and `requestid`, e.g.
+//
+//	type AuditRequest struct {
+//		ClientIP  string `request:"clientip"`
+//		RequestID string `request:"requestid"`
+//	}
+type MetadataRequest interface {
+	Metadata(name string) (string, bool)
+}
+
+// metadataValue returns the named metadata of the request, the requests not implementing
+// MetadataRequest have no metadata.
+func metadataValue(r Request, name string) (string, bool) {
+	if mr, ok := r.(MetadataRequest); ok {
+		return mr.Metadata(name)
+	}
+	return "", false
+}
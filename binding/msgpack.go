@@ -0,0 +1,28 @@
+/*
+ * Copyright 2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// BindMsgPack decodes the MessagePack body, the fields without a `msgpack` tag fall back to the `json` tag.
+func BindMsgPack(i interface{}, r Request) error {
+	decoder := msgpack.NewDecoder(r.RequestBody())
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(i)
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"go-spring.dev/web/binding"
+)
+
+type MsgPackBindParam struct {
+	A string   `json:"a"`
+	B []string `msgpack:"b"`
+	C int      `json:"c"`
+	D []int    `json:"d"`
+}
+
+func TestBindMsgPack(t *testing.T) {
+	data, err := msgpack.Marshal(map[string]interface{}{
+		"a": "1",
+		"b": []string{"2", "3"},
+		"c": 4,
+		"d": []int{5, 6},
+	})
+	assert.Nil(t, err)
+
+	for _, contentType := range []string{binding.MIMEApplicationMsgPack, "application/x-msgpack"} {
+		r := &MockRequest{
+			contentType: contentType,
+			requestBody: string(data),
+		}
+
+		var p MsgPackBindParam
+		err = binding.Bind(&p, r)
+		assert.Nil(t, err, contentType)
+		assert.Equal(t, MsgPackBindParam{A: "1", B: []string{"2", "3"}, C: 4, D: []int{5, 6}}, p, contentType)
+	}
+}
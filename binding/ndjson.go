@@ -0,0 +1,71 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// BindNDJSON decodes the newline delimited JSON records of the request body into the slice
+// pointed by i, or into the slice field of the struct tagged with `ndjson`, e.g.
+//
+//	type ImportRequest struct {
+//		Source  string   `query:"source"`
+//		Records []Record `ndjson:"records"`
+//	}
+func BindNDJSON(i interface{}, r Request) error {
+	v := reflect.ValueOf(i)
+	if reflect.Ptr != v.Kind() || v.IsNil() {
+		return fmt.Errorf("ndjson: expect a non-nil pointer, got %T", i)
+	}
+
+	v = v.Elem()
+	if reflect.Struct == v.Kind() {
+		t := v.Type()
+		field := -1
+		for j := 0; j < t.NumField(); j++ {
+			if _, ok := t.Field(j).Tag.Lookup("ndjson"); ok {
+				field = j
+				break
+			}
+		}
+		if field < 0 {
+			return nil
+		}
+		v = v.Field(field)
+	}
+
+	if reflect.Slice != v.Kind() {
+		return fmt.Errorf("ndjson: expect a slice to bind records, got %s", v.Type())
+	}
+
+	decoder := json.NewDecoder(r.RequestBody())
+	for {
+		record := reflect.New(v.Type().Elem())
+		if err := decoder.Decode(record.Interface()); nil != err {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		v.Set(reflect.Append(v, record.Elem()))
+	}
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-spring.dev/web/binding"
+)
+
+type NDJSONRecord struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type NDJSONBindParam struct {
+	Source  string         `query:"source"`
+	Records []NDJSONRecord `ndjson:"records"`
+}
+
+func TestBindNDJSON(t *testing.T) {
+	ctx := &MockRequest{
+		contentType: binding.MIMEApplicationNDJSON,
+		queryParams: map[string]string{"source": "crm"},
+		requestBody: "{\"id\":1,\"name\":\"foo\"}\n\n{\"id\":2,\"name\":\"bar\"}\n",
+	}
+
+	var p NDJSONBindParam
+	err := binding.Bind(&p, ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, NDJSONBindParam{Source: "crm", Records: []NDJSONRecord{{1, "foo"}, {2, "bar"}}}, p)
+
+	var records []NDJSONRecord
+	err = binding.BindNDJSON(&records, &MockRequest{requestBody: "{\"id\":3}\n{\"id\":4}"})
+	assert.Nil(t, err)
+	assert.Equal(t, []NDJSONRecord{{ID: 3}, {ID: 4}}, records)
+
+	err = binding.BindNDJSON(&records, &MockRequest{requestBody: "{\"id\":5}\n{"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,277 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// oneOf is the registered variants of an interface type.
+type oneOf struct {
+	discriminator string
+	variants      map[string]reflect.Type
+}
+
+var (
+	// oneOfs are the registered interface types.
+	oneOfs sync.Map // map[reflect.Type]*oneOf
+
+	// oneOfTypes caches whether the values of a type hold the registered interface types.
+	oneOfTypes sync.Map // map[reflect.Type]bool
+)
+
+// RegisterOneOf registers the concrete types of the interface type T decoded from the JSON
+// bodies, the variant is chosen by the string value of the discriminator property:
+//
+//	type Payment interface{ isPayment() }
+//
+//	binding.RegisterOneOf[Payment]("type", map[string]Payment{
+//		"card": &Card{},
+//		"bank": &Bank{},
+//	})
+//
+//	type Checkout struct {
+//		Amount  int64     `json:"amount"`
+//		Payment Payment   `json:"payment"`
+//		Refunds []Payment `json:"refunds"`
+//	}
+//
+// The fields, pointers, slices and arrays of T are resolved, in the params and in the variants
+// themselves; the maps of T are not. The variants are decoded as the pointers or the values
+// given, the unknown or missing discriminators are the binding errors.
+func RegisterOneOf[T any](discriminator string, variants map[string]T) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if reflect.Interface != typ.Kind() {
+		panic(fmt.Errorf("one-of type %s must be an interface", typ))
+	}
+	if len(discriminator) == 0 {
+		panic(fmt.Errorf("one-of type %s: empty discriminator", typ))
+	}
+
+	o := &oneOf{discriminator: discriminator, variants: make(map[string]reflect.Type, len(variants))}
+	for name, variant := range variants {
+		vt := reflect.TypeOf(variant)
+		if nil == vt {
+			panic(fmt.Errorf("one-of type %s: nil variant %q", typ, name))
+		}
+		o.variants[name] = vt
+	}
+	oneOfs.Store(typ, o)
+	oneOfTypes.Range(func(key, _ interface{}) bool {
+		oneOfTypes.Delete(key)
+		return true
+	})
+}
+
+// oneOfOf returns the registered variants of the interface type.
+func oneOfOf(t reflect.Type) (*oneOf, bool) {
+	o, ok := oneOfs.Load(t)
+	if !ok {
+		return nil, false
+	}
+	return o.(*oneOf), true
+}
+
+// hasOneOf reports whether the values of the type hold the registered interface types.
+func hasOneOf(t reflect.Type) bool {
+	if nil == t {
+		return false
+	}
+	if v, ok := oneOfTypes.Load(t); ok {
+		return v.(bool)
+	}
+	has := findOneOf(t, map[reflect.Type]bool{})
+	oneOfTypes.Store(t, has)
+	return has
+}
+
+func findOneOf(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Interface:
+		_, ok := oneOfOf(t)
+		return ok
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return findOneOf(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() || f.Anonymous {
+				if findOneOf(f.Type, visiting) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// oneOfValue is a resolved variant decoded as a pointer but declared as a value.
+type oneOfValue reflect.Value
+
+// resolveOneOf sets the registered interface values held by v to the new variants chosen by
+// the discriminators in the JSON data, so that the JSON decoder fills them in place.
+func resolveOneOf(v reflect.Value, data []byte, values *[]oneOfValue) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) || !hasOneOf(v.Type()) {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		return resolveVariant(v, data, values)
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return resolveOneOf(v.Elem(), data, values)
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); nil != err {
+			return nil // reported by the JSON decoder
+		}
+		if reflect.Slice == v.Kind() {
+			v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+		}
+		for i := 0; i < len(items) && i < v.Len(); i++ {
+			if err := resolveOneOf(v.Index(i), items[i], values); nil != err {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return nil
+	case reflect.Map:
+		return fmt.Errorf("map of %s isn't supported", v.Type().Elem())
+	case reflect.Struct:
+		var props map[string]json.RawMessage
+		if err := json.Unmarshal(data, &props); nil != err {
+			return nil // reported by the JSON decoder
+		}
+		return resolveStruct(v, props, values)
+	}
+	return nil
+}
+
+// resolveStruct resolves the fields of the struct by their JSON names, the untagged embedded
+// structs are flattened like the JSON decoder does.
+func resolveStruct(v reflect.Value, props map[string]json.RawMessage, values *[]oneOfValue) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !hasOneOf(f.Type) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && len(name) == 0 {
+			fv := v.Field(i)
+			if reflect.Ptr == fv.Kind() && reflect.Struct == fv.Type().Elem().Kind() && fv.CanSet() {
+				if fv.IsNil() {
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if reflect.Struct == fv.Kind() {
+				if err := resolveStruct(fv, props, values); nil != err {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		raw, ok := props[name]
+		if !ok {
+			for key, val := range props {
+				if strings.EqualFold(key, name) {
+					raw, ok = val, true
+					break
+				}
+			}
+		}
+		if ok {
+			if err := resolveOneOf(v.Field(i), raw, values); nil != err {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveVariant sets the interface value to the new variant chosen by the discriminator.
+func resolveVariant(v reflect.Value, data []byte, values *[]oneOfValue) error {
+	o, _ := oneOfOf(v.Type())
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(data, &props); nil != err {
+		return fmt.Errorf("%s must be an object", v.Type())
+	}
+	raw, ok := props[o.discriminator]
+	if !ok {
+		return fmt.Errorf("missing discriminator %q of %s", o.discriminator, v.Type())
+	}
+	var name string
+	if err := json.Unmarshal(raw, &name); nil != err {
+		return fmt.Errorf("discriminator %q of %s must be a string", o.discriminator, v.Type())
+	}
+	vt, ok := o.variants[name]
+	if !ok {
+		return fmt.Errorf("unknown %s %q of %s", o.discriminator, name, v.Type())
+	}
+
+	et := vt
+	if reflect.Ptr == vt.Kind() {
+		et = vt.Elem()
+	}
+	pv := reflect.New(et)
+	if err := resolveOneOf(pv.Elem(), data, values); nil != err {
+		return err
+	}
+	v.Set(pv)
+	if reflect.Ptr != vt.Kind() {
+		*values = append(*values, oneOfValue(v))
+	}
+	return nil
+}
+
+// bindOneOf decodes the JSON data into i, resolving the registered interface types first.
+func bindOneOf(i interface{}, data []byte) error {
+	var values []oneOfValue
+	if err := resolveOneOf(reflect.ValueOf(i), data, &values); nil != err {
+		return err
+	}
+	if err := json.Unmarshal(data, i); nil != err {
+		return err
+	}
+	// the nested variants come first, so they are set before their holders are copied.
+	for _, value := range values {
+		v := reflect.Value(value)
+		v.Set(v.Elem().Elem())
+	}
+	return nil
+}
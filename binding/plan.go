@@ -0,0 +1,280 @@
+/*
+ * Copyright 2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// bindMode is how the params are bound into a field, decided by the field type once.
+type bindMode int
+
+const (
+	bindValue    bindMode = iota // the single param
+	bindMap                      // the prefixed params, e.g. `filter[color]=red`
+	bindNested                   // the params in the dot or bracket notation, e.g. `address.city=Paris`
+	bindSlice                    // the repeated params, e.g. `status=a&status=b`
+	bindContext                  // the request context value
+	bindDecoded                  // the single param decoded by the `encoding` tag
+	bindPrefixed                 // the headers prefixed by the wildcard name, e.g. `X-Meta-*`
+)
+
+// scopeParam is a scope the field is bound from.
+type scopeParam struct {
+	scope  BindScope
+	tag    string
+	name   string
+	mode   bindMode
+	decode bytesDecoder
+	split  string
+}
+
+// scopeField is the binding plan of a field bound from the params of the request.
+type scopeField struct {
+	index      []int
+	field      reflect.StructField
+	defaults   []string
+	hasDefault bool
+	errmsg     string
+	hasErrmsg  bool
+	decode     bytesDecoder
+	params     []scopeParam
+}
+
+// valuesField is the binding plan of a field bound from the url.Values by a tag.
+type valuesField struct {
+	index  []int
+	name   string
+	mode   bindMode
+	decode bytesDecoder
+}
+
+type valuesPlanKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// the binding plans compiled from the struct types, keyed by reflect.Type for the scope plans,
+// and by valuesPlanKey for the values plans, they're reset when a converter is registered.
+var (
+	scopePlans  sync.Map
+	valuesPlans sync.Map
+)
+
+// resetPlans drops the compiled plans, since the modes of the fields depend on the converters.
+func resetPlans() {
+	scopePlans.Range(func(key, _ interface{}) bool { scopePlans.Delete(key); return true })
+	valuesPlans.Range(func(key, _ interface{}) bool { valuesPlans.Delete(key); return true })
+}
+
+// scopePlanOf returns the plan binding the params of the request into the fields of the struct,
+// the fields of the embedded structs are flattened into it.
+func scopePlanOf(t reflect.Type) ([]*scopeField, error) {
+	if plan, ok := scopePlans.Load(t); ok {
+		return plan.([]*scopeField), nil
+	}
+	plan, err := compileScopePlan(t, nil, false)
+	if nil != err {
+		return nil, err
+	}
+	scopePlans.Store(t, plan)
+	return plan, nil
+}
+
+// compileScopePlan compiles the plan of the struct, the fields of the unexported embedded structs
+// are readonly and skipped like the unexported fields.
+func compileScopePlan(t reflect.Type, index []int, readonly bool) ([]*scopeField, error) {
+	var plan []*scopeField
+	for j := 0; j < t.NumField(); j++ {
+		ft := t.Field(j)
+		fi := append(index[:len(index):len(index)], j)
+		if ft.Anonymous {
+			if reflect.Struct != ft.Type.Kind() {
+				return nil, fmt.Errorf("%s: is not a struct pointer", reflect.PointerTo(ft.Type).String())
+			}
+			embedded, err := compileScopePlan(ft.Type, fi, readonly || !ft.IsExported())
+			if nil != err {
+				return nil, err
+			}
+			plan = append(plan, embedded...)
+			continue
+		}
+
+		if readonly || !ft.IsExported() {
+			continue
+		}
+
+		decode, err := bytesDecoderOf(ft)
+		if nil != err {
+			return nil, err
+		}
+
+		sep, err := separatorOf(ft)
+		if nil != err {
+			return nil, err
+		}
+
+		f := &scopeField{index: fi, field: ft, decode: decode}
+		if val, ok := ft.Tag.Lookup("default"); ok {
+			f.defaults, f.hasDefault = []string{val}, true
+			if reflect.Slice == ft.Type.Kind() && !isTextUnmarshaler(ft.Type) && nil == decode {
+				f.defaults = strings.Split(val, ",")
+				if len(sep) > 0 {
+					f.defaults = strings.Split(val, sep)
+				}
+			}
+		}
+		f.errmsg, f.hasErrmsg = ft.Tag.Lookup("errmsg")
+		for scope := BindScopeURI; scope < BindScopeBody; scope++ {
+			tag := scopeTags[scope]
+			value, ok := ft.Tag.Lookup(tag)
+			if !ok || value == "-" {
+				continue
+			}
+			names := aliasesOf(value)
+			if BindScopeLanguage == scope {
+				// the `lang` tag lists the supported languages rather than the aliases.
+				names = []string{value}
+			}
+			for _, name := range names {
+				if _, known := tlsCertAttributes[name]; BindScopeTLSCert == scope && !known {
+					return nil, fmt.Errorf("%s: unknown tlscert attribute %q", ft.Name, name)
+				}
+				if BindScopeRequest == scope && !requestMetadata[name] {
+					return nil, fmt.Errorf("%s: unknown request metadata %q", ft.Name, name)
+				}
+				param := scopeParam{scope: scope, tag: tag, name: name, mode: scopeMode(scope, ft.Type)}
+				if BindScopeHeader == scope && strings.HasSuffix(name, "*") {
+					if reflect.Map != ft.Type.Kind() || reflect.String != ft.Type.Key().Kind() {
+						return nil, fmt.Errorf("%s: header wildcard %q requires a map field of string keys", ft.Name, name)
+					}
+					param.mode = bindPrefixed
+				}
+				switch {
+				case bindContext == param.mode, bindPrefixed == param.mode:
+				case nil != decode:
+					param.mode, param.decode = bindDecoded, decode
+				case len(sep) > 0:
+					param.mode, param.split = bindSlice, sep
+				}
+				f.params = append(f.params, param)
+			}
+		}
+		if f.hasDefault || len(f.params) > 0 {
+			plan = append(plan, f)
+		}
+	}
+	return plan, nil
+}
+
+// aliasesOf returns the names of the param in the order they're bound, the comma separated aliases,
+// e.g. `query:"page_size,pageSize,limit"`, are bound from the last, so that the value of the first
+// present name takes precedence.
+func aliasesOf(value string) []string {
+	if !strings.Contains(value, ",") {
+		return []string{value}
+	}
+	var names []string
+	aliases := strings.Split(value, ",")
+	for i := len(aliases) - 1; i >= 0; i-- {
+		if name := strings.TrimSpace(aliases[i]); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// scopeMode returns how the field of the type is bound from the scope, the query params are bound
+// into the maps, nested structs and slices, the attributes of the client certificate into the slices.
+func scopeMode(scope BindScope, t reflect.Type) bindMode {
+	if BindScopeContext == scope {
+		return bindContext
+	}
+	_, converted := fieldConverters[t]
+	if BindScopeTLSCert == scope && reflect.Slice == t.Kind() && !converted && !isTextUnmarshaler(t) {
+		return bindSlice
+	}
+	if BindScopeQuery != scope {
+		return bindValue
+	}
+	switch {
+	case reflect.Map == t.Kind():
+		return bindMap
+	case isNestedType(t):
+		return bindNested
+	case reflect.Slice == t.Kind() && !converted && !isTextUnmarshaler(t):
+		return bindSlice
+	}
+	return bindValue
+}
+
+// valuesPlanOf returns the plan binding the url.Values into the fields of the struct named by the tag,
+// the fields of the embedded structs are flattened into it, the unexported fields are skipped.
+func valuesPlanOf(t reflect.Type, tag string) ([]*valuesField, error) {
+	key := valuesPlanKey{t: t, tag: tag}
+	if plan, ok := valuesPlans.Load(key); ok {
+		return plan.([]*valuesField), nil
+	}
+	plan, err := compileValuesPlan(t, tag, nil, false)
+	if nil != err {
+		return nil, err
+	}
+	valuesPlans.Store(key, plan)
+	return plan, nil
+}
+
+func compileValuesPlan(t reflect.Type, tag string, index []int, readonly bool) ([]*valuesField, error) {
+	var plan []*valuesField
+	for j := 0; j < t.NumField(); j++ {
+		ft := t.Field(j)
+		fi := append(index[:len(index):len(index)], j)
+		if ft.Anonymous {
+			if reflect.Struct == ft.Type.Kind() {
+				embedded, err := compileValuesPlan(ft.Type, tag, fi, readonly || !ft.IsExported())
+				if nil != err {
+					return nil, err
+				}
+				plan = append(plan, embedded...)
+			}
+			continue
+		}
+		value, ok := ft.Tag.Lookup(tag)
+		if !ok || readonly || !ft.IsExported() {
+			continue
+		}
+		decode, err := bytesDecoderOf(ft)
+		if nil != err {
+			return nil, err
+		}
+		mode := bindValue
+		switch {
+		case nil != decode:
+			mode = bindDecoded
+		case reflect.Map == ft.Type.Kind():
+			mode = bindMap
+		case isNestedType(ft.Type):
+			mode = bindNested
+		}
+		for _, name := range aliasesOf(value) {
+			plan = append(plan, &valuesField{index: fi, name: name, mode: mode, decode: decode})
+		}
+	}
+	return plan, nil
+}
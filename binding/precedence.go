@@ -0,0 +1,136 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// ErrScopeConflict is the failure of the fields whose values differ between the scopes
+// in the strict ScopePolicy.
+var ErrScopeConflict = errors.New("conflicting values in multiple scopes")
+
+// ScopePolicy decides how the fields tagged with multiple scopes are bound, e.g.
+// `query:"tenant" header:"X-Tenant"`. By default, the value of the later scope in the order
+// path, query, header, cookie, tlscert, ctx, request and lang overrides the earlier one. The structs
+// implementing ParamsBinder bind the params themselves, regardless of the policy.
+type ScopePolicy struct {
+	// Precedence lists the scopes from the highest precedence, the value of the present scope with
+	// the highest precedence is bound. The unlisted scopes have lower precedence than the listed
+	// ones, in the default order.
+	Precedence []BindScope
+
+	// Strict fails the fields whose values differ between the present scopes with ErrScopeConflict,
+	// rather than binding the one with the highest precedence. The ctx scope isn't compared, nor are
+	// the params bound into the maps and nested structs.
+	Strict bool
+}
+
+var scopePolicy ScopePolicy
+
+// SetScopePolicy sets the package-level scope policy, which applies to the requests without their own,
+// the requests providing a non-nil policy by the `ScopePolicy() *ScopePolicy` method, e.g. the routes of
+// a web.Router with a scope policy, are bound by it instead.
+func SetScopePolicy(policy ScopePolicy) {
+	scopePolicy = policy
+}
+
+// scopePolicyOf returns the scope policy of the request.
+func scopePolicyOf(r Request) ScopePolicy {
+	if pr, ok := r.(interface{ ScopePolicy() *ScopePolicy }); ok {
+		if policy := pr.ScopePolicy(); nil != policy {
+			return *policy
+		}
+	}
+	return scopePolicy
+}
+
+// rank returns the precedence of the scope, the lower the higher.
+func (p ScopePolicy) rank(scope BindScope) int {
+	if i := slices.Index(p.Precedence, scope); i >= 0 {
+		return i
+	}
+	return len(p.Precedence) + int(BindScopeBody-scope)
+}
+
+// order returns the params in the order they're bound, from the lowest precedence, so that the
+// present params of the higher precedence override the lower ones.
+func (p ScopePolicy) order(params []scopeParam) []scopeParam {
+	if len(p.Precedence) == 0 || len(params) < 2 {
+		return params
+	}
+	ordered := slices.Clone(params)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return p.rank(ordered[i].scope) > p.rank(ordered[j].scope)
+	})
+	return ordered
+}
+
+// checkScopeConflicts returns the failure of the field if the values of its present params differ,
+// the params are in the order they're bound, the last one names the failure.
+func checkScopeConflicts(params []scopeParam, r Request) *FieldError {
+	var (
+		bound  *scopeParam
+		values []string
+	)
+	for i := len(params) - 1; i >= 0; i-- {
+		param := &params[i]
+		switch param.mode {
+		case bindContext, bindMap, bindNested, bindPrefixed:
+			continue
+		}
+		vals, exists := paramValues(*param, r)
+		if !exists {
+			continue
+		}
+		if nil == bound {
+			bound, values = param, vals
+			continue
+		}
+		if !slices.Equal(values, vals) {
+			reason := fmt.Sprintf("conflicting values in %s %q and %s %q", bound.tag, bound.name, param.tag, param.name)
+			return &FieldError{Field: bound.name, Source: bound.tag, Reason: reason, Err: ErrScopeConflict}
+		}
+	}
+	return nil
+}
+
+// paramValues returns the values of the param in the request, the repeated query params and the
+// multi-valued attributes of the client certificate are bound into the slices, split by the `split`
+// or `explode:"false"` tag.
+func paramValues(param scopeParam, r Request) ([]string, bool) {
+	var values []string
+	var exists bool
+	switch {
+	case bindSlice == param.mode && BindScopeQuery == param.scope:
+		values, exists = r.QueryParams(param.name)
+	case bindSlice == param.mode && BindScopeTLSCert == param.scope:
+		values, exists = tlsCertValues(r, param.name)
+	default:
+		var val string
+		if val, exists = scopeGetters[param.scope](r, param.name); exists {
+			values = []string{val}
+		}
+	}
+	if len(param.split) > 0 {
+		values = splitValues(values, param.split)
+	}
+	return values, exists
+}
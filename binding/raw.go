@@ -0,0 +1,119 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+// rawBodyPlans are the indexes of the fields tagged by `body:"raw"`, keyed by the struct type.
+var rawBodyPlans sync.Map
+
+// rawBodyPlanOf returns the indexes of the fields of the struct pointed by t capturing the raw body,
+// i.e. the `[]byte` or `json.RawMessage` fields tagged by `body:"raw"`:
+//
+//	type Webhook struct {
+//		Signature string          `header:"X-Signature"`
+//		Event     string          `json:"event"`
+//		Payload   json.RawMessage `json:"-" body:"raw"`
+//	}
+//
+// The fields are set to the untouched body after the body is bound, e.g. to verify the HMAC signature.
+func rawBodyPlanOf(t reflect.Type) ([][]int, error) {
+	if reflect.Ptr != t.Kind() || reflect.Struct != t.Elem().Kind() {
+		return nil, nil
+	}
+	t = t.Elem()
+	if plan, ok := rawBodyPlans.Load(t); ok {
+		return plan.([][]int), nil
+	}
+	plan, err := compileRawBodyPlan(t, nil)
+	if nil != err {
+		return nil, err
+	}
+	rawBodyPlans.Store(t, plan)
+	return plan, nil
+}
+
+func compileRawBodyPlan(t reflect.Type, index []int) ([][]int, error) {
+	var plan [][]int
+	for j := 0; j < t.NumField(); j++ {
+		ft := t.Field(j)
+		fi := append(index[:len(index):len(index)], j)
+		if ft.Anonymous && reflect.Struct == ft.Type.Kind() {
+			embedded, err := compileRawBodyPlan(ft.Type, fi)
+			if nil != err {
+				return nil, err
+			}
+			plan = append(plan, embedded...)
+			continue
+		}
+		val, ok := ft.Tag.Lookup("body")
+		if !ok {
+			continue
+		}
+		if val != "raw" {
+			return nil, fmt.Errorf("%s: unknown body tag %q", ft.Name, val)
+		}
+		if reflect.Slice != ft.Type.Kind() || !bytesType.ConvertibleTo(ft.Type) || !ft.IsExported() {
+			return nil, fmt.Errorf("%s: raw body must be an exported []byte field", ft.Name)
+		}
+		plan = append(plan, fi)
+	}
+	return plan, nil
+}
+
+// replayRequest replays the body read already to the body binders.
+type replayRequest struct {
+	Request
+	body []byte
+}
+
+func (r replayRequest) RequestBody() io.Reader {
+	return bytes.NewReader(r.body)
+}
+
+// bindRawBody reads the entire body, binds it as usual and captures it into the raw body fields.
+// The requests replaying the body by the `ReplayBody(body []byte)` method, e.g. web.Context, provide
+// it to the form and multipart binders as well, which read the underlying request.
+func bindRawBody(i interface{}, r Request, plan [][]int) error {
+	data, err := io.ReadAll(r.RequestBody())
+	if nil != err {
+		return err
+	}
+	if rr, ok := r.(interface{ ReplayBody(body []byte) }); ok {
+		rr.ReplayBody(data)
+	} else {
+		r = replayRequest{Request: r, body: data}
+	}
+
+	err = bindBody(i, r)
+	if len(data) > 0 {
+		v := reflect.ValueOf(i).Elem()
+		for _, index := range plan {
+			fv := v.FieldByIndex(index)
+			fv.Set(reflect.ValueOf(data).Convert(fv.Type()))
+		}
+	}
+	return err
+}
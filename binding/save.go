@@ -0,0 +1,173 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// SavedFile is an uploaded file saved to the directory named by the `save` tag of the field while
+// the multipart form is bound, the fields of the SavedFile, *SavedFile and []SavedFile types are
+// supported:
+//
+//	type Profile struct {
+//		Avatar *web.SavedFile `form:"avatar" save:"/var/uploads"`
+//	}
+//
+// The files are saved under the random names keeping the extensions of the uploaded ones, into the
+// temporary directory if the `save` tag is empty or absent, the directory is created if not exists.
+// The saved files are owned by the handler, they're removed if the request fails to bind or validate.
+type SavedFile struct {
+	// Path is the path of the saved file.
+	Path string
+
+	// Filename is the name of the uploaded file sent by the client, which mustn't be trusted.
+	Filename string
+
+	// Size is the size of the saved file in bytes.
+	Size int64
+
+	// ContentType is the content type detected from the content, see http.DetectContentType,
+	// rather than the one sent by the client.
+	ContentType string
+}
+
+var savedFileType = reflect.TypeOf(SavedFile{})
+
+// isSavedFileType reports whether the field of the type is bound by saving the uploaded files.
+func isSavedFileType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return t.Elem() == savedFileType
+	}
+	return t == savedFileType
+}
+
+// bindSavedFiles saves the uploaded files into the directory and binds them into the field,
+// the paths of the saved files are appended to saved, so that they're removed on failures.
+func bindSavedFiles(v reflect.Value, dir string, files []*multipart.FileHeader, saved *[]string) error {
+	if len(dir) == 0 {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0o755); nil != err {
+		return err
+	}
+	if reflect.Slice != v.Kind() {
+		files = files[:1]
+	}
+
+	slice := reflect.MakeSlice(reflect.SliceOf(savedFileType), 0, len(files))
+	for _, file := range files {
+		f, err := saveFile(dir, file)
+		if nil != err {
+			return err
+		}
+		*saved = append(*saved, f.Path)
+		slice = reflect.Append(slice, reflect.ValueOf(f))
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		v.Set(slice)
+	case reflect.Ptr:
+		v.Set(slice.Index(0).Addr())
+	default:
+		v.Set(slice.Index(0))
+	}
+	return nil
+}
+
+// saveFile copies the uploaded file into a new file of the directory.
+func saveFile(dir string, file *multipart.FileHeader) (SavedFile, error) {
+	src, err := file.Open()
+	if nil != err {
+		return SavedFile{}, err
+	}
+	defer src.Close()
+
+	// the extension is kept only if it's plain, the rest of the uploaded name is never used.
+	ext := filepath.Ext(filepath.Base(file.Filename))
+	if strings.ContainsAny(ext, `/\:*?"<>|`) || len(ext) > 16 {
+		ext = ""
+	}
+	dst, err := os.CreateTemp(dir, "upload-*"+ext)
+	if nil != err {
+		return SavedFile{}, err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if nil != err && io.EOF != err && io.ErrUnexpectedEOF != err {
+		_ = dst.Close()
+		_ = os.Remove(dst.Name())
+		return SavedFile{}, err
+	}
+	head = head[:n]
+
+	size, err := io.Copy(dst, io.MultiReader(bytes.NewReader(head), src))
+	if closeErr := dst.Close(); nil == err {
+		err = closeErr
+	}
+	if nil != err {
+		_ = os.Remove(dst.Name())
+		return SavedFile{}, fmt.Errorf("save file %q: %w", file.Filename, err)
+	}
+	return SavedFile{Path: dst.Name(), Filename: file.Filename, Size: size, ContentType: http.DetectContentType(head)}, nil
+}
+
+// removeSavedFiles removes the files saved into the fields of the request struct, so that the files
+// of the requests failing to validate after the form is bound aren't left behind without an owner.
+func removeSavedFiles(i interface{}) {
+	v := reflect.ValueOf(i)
+	if reflect.Ptr != v.Kind() || v.IsNil() || reflect.Struct != v.Elem().Kind() {
+		return
+	}
+	removeSavedStructFiles(v.Elem())
+}
+
+func removeSavedStructFiles(v reflect.Value) {
+	t := v.Type()
+	for j := 0; j < t.NumField(); j++ {
+		ft := t.Field(j)
+		fv := v.Field(j)
+		switch {
+		case ft.Anonymous && reflect.Struct == ft.Type.Kind():
+			removeSavedStructFiles(fv)
+		case !isSavedFileType(ft.Type) || !fv.CanInterface():
+		case reflect.Slice == fv.Kind():
+			for k := 0; k < fv.Len(); k++ {
+				_ = os.Remove(fv.Index(k).Interface().(SavedFile).Path)
+			}
+		case reflect.Ptr == fv.Kind():
+			if !fv.IsNil() {
+				_ = os.Remove(fv.Elem().Interface().(SavedFile).Path)
+			}
+		default:
+			if path := fv.Interface().(SavedFile).Path; len(path) > 0 {
+				_ = os.Remove(path)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// separatorOf returns the separator of the values of the slice field bound from a single param, i.e.
+// the `split` tag, or the comma for the `explode:"false"` tag of the OpenAPI parameter styles, empty
+// if the values aren't split.
+//
+//	IDs []int `query:"ids" explode:"false"` // ?ids=1,2,3
+//	Tags []string `header:"X-Tags" split:";"`
+func separatorOf(field reflect.StructField) (string, error) {
+	sep, hasSplit := field.Tag.Lookup("split")
+	explode, hasExplode := field.Tag.Lookup("explode")
+	switch {
+	case !hasSplit && !hasExplode:
+		return "", nil
+	case hasSplit && len(sep) == 0:
+		return "", fmt.Errorf("%s: empty split separator", field.Name)
+	case hasExplode && "true" != explode && "false" != explode:
+		return "", fmt.Errorf("%s: invalid explode %q", field.Name, explode)
+	case !hasSplit && "true" == explode:
+		return "", nil
+	case !hasSplit:
+		sep = ","
+	}
+	if reflect.Slice != field.Type.Kind() || isTextUnmarshaler(field.Type) {
+		return "", fmt.Errorf("%s: split values require a slice field, got %q", field.Name, field.Type.String())
+	}
+	if _, converted := fieldConverters[field.Type]; converted {
+		return "", fmt.Errorf("%s: split values require a slice field without converter", field.Name)
+	}
+	return sep, nil
+}
+
+// splitValues splits the values by the separator, the empty values are dropped, so `?ids=` binds no id.
+func splitValues(values []string, sep string) []string {
+	var split []string
+	for _, value := range values {
+		if len(value) > 0 {
+			split = append(split, strings.Split(value, sep)...)
+		}
+	}
+	return split
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"time"
+)
+
+// tlsCertAttributes are the attributes of the client certificate bound by the `tlscert` tag,
+// the multi-valued ones are bound into the slice fields, or their first value into the others.
+var tlsCertAttributes = map[string]func(cert *x509.Certificate) []string{
+	"CommonName":         func(cert *x509.Certificate) []string { return []string{cert.Subject.CommonName} },
+	"SerialNumber":       func(cert *x509.Certificate) []string { return []string{cert.SerialNumber.Text(16)} },
+	"Subject":            func(cert *x509.Certificate) []string { return []string{cert.Subject.String()} },
+	"Issuer":             func(cert *x509.Certificate) []string { return []string{cert.Issuer.String()} },
+	"IssuerCommonName":   func(cert *x509.Certificate) []string { return []string{cert.Issuer.CommonName} },
+	"Organization":       func(cert *x509.Certificate) []string { return cert.Subject.Organization },
+	"OrganizationalUnit": func(cert *x509.Certificate) []string { return cert.Subject.OrganizationalUnit },
+	"Country":            func(cert *x509.Certificate) []string { return cert.Subject.Country },
+	"DNSNames":           func(cert *x509.Certificate) []string { return cert.DNSNames },
+	"EmailAddresses":     func(cert *x509.Certificate) []string { return cert.EmailAddresses },
+	"IPAddresses": func(cert *x509.Certificate) []string {
+		values := make([]string, len(cert.IPAddresses))
+		for i, ip := range cert.IPAddresses {
+			values[i] = ip.String()
+		}
+		return values
+	},
+	"URIs": func(cert *x509.Certificate) []string {
+		values := make([]string, len(cert.URIs))
+		for i, uri := range cert.URIs {
+			values[i] = uri.String()
+		}
+		return values
+	},
+	"NotBefore": func(cert *x509.Certificate) []string { return []string{cert.NotBefore.Format(time.RFC3339)} },
+	"NotAfter":  func(cert *x509.Certificate) []string { return []string{cert.NotAfter.Format(time.RFC3339)} },
+	"Fingerprint": func(cert *x509.Certificate) []string {
+		sum := sha256.Sum256(cert.Raw)
+		return []string{hex.EncodeToString(sum[:])}
+	},
+}
+
+// tlsCertValues returns the values of the named attribute of the verified client certificate,
+// it reports false if the request has no verified client certificate or the attribute has no value.
+func tlsCertValues(r Request, name string) ([]string, bool) {
+	cert := r.PeerCertificate()
+	if nil == cert {
+		return nil, false
+	}
+	values := tlsCertAttributes[name](cert)
+	return values, len(values) > 0
+}
+
+// tlsCertValue returns the first value of the named attribute of the client certificate.
+func tlsCertValue(r Request, name string) (string, bool) {
+	if values, ok := tlsCertValues(r, name); ok {
+		return values[0], true
+	}
+	return "", false
+}
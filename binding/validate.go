@@ -0,0 +1,160 @@
+/*
+ * Copyright 2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// validate validates the struct by the validator, the elements of the slices and the values
+// of the maps bound from the body as a whole are validated one by one if they're structs, the fields
+// of their failures are prefixed by the index or key, e.g. `[1].Name`.
+func validate(validator func(i interface{}) error, i interface{}) BindingErrors {
+	v := reflect.ValueOf(i)
+	if reflect.Ptr != v.Kind() || v.IsNil() {
+		return validateValue(validator, i)
+	}
+
+	var errs BindingErrors
+	switch v = v.Elem(); v.Kind() {
+	case reflect.Slice:
+		for j := 0; j < v.Len(); j++ {
+			errs = append(errs, validateElem(validator, fmt.Sprintf("[%d]", j), v.Index(j))...)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			errs = append(errs, validateElem(validator, fmt.Sprintf("[%v]", key), v.MapIndex(key))...)
+		}
+	default:
+		return validateValue(validator, i)
+	}
+	return errs
+}
+
+// validateElem validates the element of the slice or map if it's a struct or a struct pointer.
+func validateElem(validator func(i interface{}) error, prefix string, v reflect.Value) BindingErrors {
+	for reflect.Ptr == v.Kind() || reflect.Interface == v.Kind() {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if reflect.Struct != v.Kind() {
+		return nil
+	}
+	if !v.CanAddr() {
+		// the values of the maps aren't addressable, the validators expect the struct pointers.
+		pv := reflect.New(v.Type())
+		pv.Elem().Set(v)
+		v = pv.Elem()
+	}
+
+	errs := validateValue(validator, v.Addr().Interface())
+	for _, err := range errs {
+		if len(err.Field) > 0 {
+			err.Field = prefix + "." + err.Field
+		} else {
+			err.Field = prefix
+		}
+	}
+	return errs
+}
+
+func validateValue(validator func(i interface{}) error, i interface{}) BindingErrors {
+	if err := validator(i); nil != err {
+		errs := validationErrors(err)
+		applyErrorMessages(reflect.TypeOf(i), errs)
+		return errs
+	}
+	return nil
+}
+
+// validationErrors converts the error of the validator into the failures of the fields, the errors
+// of the popular validator libraries are recognized without depending on them, i.e. the maps keyed
+// by the field names like gopkg.in/validator.v2 ErrorMap, and the slices of the errors providing
+// `StructField() string` like github.com/go-playground/validator ValidationErrors.
+func validationErrors(err error) BindingErrors {
+	var errs BindingErrors
+	if errors.As(err, &errs) {
+		return errs
+	}
+
+	v := reflect.ValueOf(err)
+	switch {
+	case reflect.Map == v.Kind() && reflect.String == v.Type().Key().Kind():
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			errs = append(errs, &FieldError{Field: key.String(), Source: "validate", Reason: fmt.Sprint(v.MapIndex(key).Interface())})
+		}
+	case reflect.Slice == v.Kind():
+		for j := 0; j < v.Len(); j++ {
+			fieldErr, ok := v.Index(j).Interface().(interface {
+				error
+				StructField() string
+			})
+			if !ok {
+				return BindingErrors{{Source: "validate", Reason: err.Error(), Err: err}}
+			}
+			errs = append(errs, &FieldError{Field: fieldErr.StructField(), Source: "validate", Reason: fieldErr.Error(), Err: fieldErr})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return BindingErrors{{Source: "validate", Reason: err.Error(), Err: err}}
+}
+
+// applyErrorMessages replaces the reasons of the validation failures by the `errmsg` tags of the
+// fields, so the raw output of the validator leaking the Go field names isn't returned to clients.
+//
+//	Password string `form:"password" validate:"min=10,max=20" errmsg:"password must be 10-20 characters"`
+func applyErrorMessages(t reflect.Type, errs BindingErrors) {
+	for _, err := range errs {
+		if field, ok := lookupField(t, err.Field); ok {
+			if msg, ok := field.Tag.Lookup("errmsg"); ok {
+				err.Reason = msg
+			}
+		}
+	}
+}
+
+// lookupField returns the struct field of the name, the fields of the nested structs are
+// named with the dot notation, e.g. `Address.City`.
+func lookupField(t reflect.Type, name string) (reflect.StructField, bool) {
+	var field reflect.StructField
+	for _, part := range strings.Split(name, ".") {
+		for reflect.Ptr == t.Kind() || reflect.Slice == t.Kind() || reflect.Array == t.Kind() || reflect.Map == t.Kind() {
+			t = t.Elem()
+		}
+		if reflect.Struct != t.Kind() || len(part) == 0 {
+			return field, false
+		}
+		f, ok := t.FieldByName(part)
+		if !ok {
+			return field, false
+		}
+		field, t = f, f.Type
+	}
+	return field, len(name) > 0
+}
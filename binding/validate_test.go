@@ -0,0 +1,90 @@
+/*
+ * Copyright 2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-spring.dev/web/binding"
+)
+
+// errorMap mimics gopkg.in/validator.v2 ErrorMap.
+type errorMap map[string]error
+
+func (m errorMap) Error() string { return "validation failed" }
+
+// fieldError mimics github.com/go-playground/validator FieldError.
+type fieldError struct{ field, tag string }
+
+func (e fieldError) Error() string {
+	return "Key: '" + e.field + "' Error:Field validation failed on the '" + e.tag + "' tag"
+}
+func (e fieldError) StructField() string { return e.field }
+
+type validationErrors []fieldError
+
+func (errs validationErrors) Error() string { return "validation failed" }
+
+func TestBindErrorMessages(t *testing.T) {
+	type Address struct {
+		City string `json:"city" errmsg:"city is required"`
+	}
+	type Param struct {
+		Page     int     `query:"page" errmsg:"page must be a number"`
+		Password string  `json:"password" errmsg:"password must be 10-20 characters"`
+		Email    string  `json:"email"`
+		Address  Address `json:"address"`
+	}
+
+	tests := map[string]error{
+		"ErrorMap": errorMap{
+			"Password":     errors.New("less than min"),
+			"Email":        errors.New("zero value"),
+			"Address.City": errors.New("zero value"),
+		},
+		"ValidationErrors": validationErrors{{"Email", "required"}, {"Password", "min"}, {"Address.City", "required"}},
+	}
+	for name, validateErr := range tests {
+		binding.RegisterValidator(func(i interface{}) error { return validateErr })
+
+		ctx := &MockRequest{
+			contentType: binding.MIMEApplicationJSON,
+			requestBody: `{"password": "short"}`,
+		}
+		err := binding.Bind(&Param{}, ctx)
+		assert.ErrorIs(t, err, binding.ErrValidate, name)
+
+		var errs binding.BindingErrors
+		if assert.ErrorAs(t, err, &errs, name) {
+			reasons := map[string]string{}
+			for _, e := range errs {
+				reasons[e.Field] = e.Reason
+			}
+			assert.Equal(t, "password must be 10-20 characters", reasons["Password"], name)
+			assert.Equal(t, "city is required", reasons["Address.City"], name)
+			assert.NotEmpty(t, reasons["Email"], name)
+		}
+	}
+	binding.RegisterValidator(nil)
+
+	// the message replaces the reason of the binding failure as well.
+	err := binding.Bind(&Param{}, &MockRequest{queryParams: map[string]string{"page": "first"}})
+	assert.ErrorIs(t, err, binding.ErrBinding)
+	assert.EqualError(t, err, "binding failed: page: page must be a number")
+}
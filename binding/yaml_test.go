@@ -0,0 +1,53 @@
+/*
+ * Copyright 2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package binding_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-spring.dev/web/binding"
+)
+
+type YAMLBindParam struct {
+	A string   `yaml:"a"`
+	B []string `yaml:"b"`
+	C int      `yaml:"c"`
+	D struct {
+		E bool `yaml:"e"`
+	} `yaml:"d"`
+}
+
+func TestBindYAML(t *testing.T) {
+	for _, contentType := range []string{binding.MIMEApplicationYAML, binding.MIMETextYAML, "application/x-yaml"} {
+		r := &MockRequest{
+			contentType: contentType,
+			requestBody: "a: \"1\"\nb: [\"2\", \"3\"]\nc: 4\nd:\n  e: true\n",
+		}
+
+		var p YAMLBindParam
+		err := binding.Bind(&p, r)
+		assert.Nil(t, err, contentType)
+		assert.Equal(t, "1", p.A, contentType)
+		assert.Equal(t, []string{"2", "3"}, p.B, contentType)
+		assert.Equal(t, 4, p.C, contentType)
+		assert.True(t, p.D.E, contentType)
+	}
+
+	err := binding.Bind(&YAMLBindParam{}, &MockRequest{contentType: binding.MIMEApplicationYAML, requestBody: "c: four"})
+	assert.ErrorIs(t, err, binding.ErrBinding)
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// BotClass is the class of the client sending the request, see BotDetector.
+type BotClass int
+
+const (
+	// BotHuman is a browser used by a person.
+	BotHuman BotClass = iota
+
+	// BotCrawler is a well-known crawler, e.g. of the search engines and link previews.
+	BotCrawler
+
+	// BotAutomated is an automated client, e.g. a command line tool, an HTTP library or a headless browser.
+	BotAutomated
+
+	// BotBad is a known bad bot, e.g. a vulnerability scanner.
+	BotBad
+)
+
+func (c BotClass) String() string {
+	switch c {
+	case BotHuman:
+		return "human"
+	case BotCrawler:
+		return "crawler"
+	case BotAutomated:
+		return "automated"
+	case BotBad:
+		return "bad"
+	default:
+		return "unknown"
+	}
+}
+
+// BotVerdict is the classification of the request.
+type BotVerdict struct {
+	Class BotClass
+
+	// Name is the matched User-Agent signature or the heuristic deciding the class.
+	Name string
+}
+
+// IsBot reports whether the request is not sent by a person.
+func (v BotVerdict) IsBot() bool {
+	return BotHuman != v.Class
+}
+
+// BotVerdictOf returns the verdict of the request recorded by the BotDetector.
+func BotVerdictOf(ctx context.Context) (BotVerdict, bool) {
+	return Value[BotVerdict](ctx)
+}
+
+// DefaultCrawlers are the User-Agent signatures of the well-known crawlers.
+var DefaultCrawlers = []string{
+	"Googlebot", "bingbot", "Slurp", "DuckDuckBot", "Baiduspider", "YandexBot", "Applebot",
+	"facebookexternalhit", "Twitterbot", "LinkedInBot", "Slackbot", "Discordbot",
+}
+
+// DefaultBadBots are the User-Agent signatures of the vulnerability scanners.
+var DefaultBadBots = []string{
+	"sqlmap", "nikto", "nmap", "masscan", "zgrab", "nuclei", "acunetix", "wpscan", "dirbuster", "gobuster",
+}
+
+// automatedSignatures are the User-Agent signatures of the generic automated clients.
+var automatedSignatures = []string{
+	"bot", "crawler", "spider", "curl/", "wget/", "python-", "go-http-client", "java/", "okhttp",
+	"httpclient", "scrapy", "headlesschrome", "phantomjs", "puppeteer", "playwright",
+}
+
+// BotDetector classifies the requests by their User-Agent and headers, the verdict is recorded on the
+// request context, see BotVerdictOf. The User-Agent is chosen by the client, so the verdict is a hint
+// for the well-behaved clients, it doesn't prove that a crawler is who it claims to be.
+//
+//	router.Use(web.BotDetector{Block: true, Limit: web.FairQueue{Capacity: 4, MaxQueue: 8}.Middleware()}.Middleware())
+type BotDetector struct {
+	// Crawlers are the User-Agent signatures of the crawlers, DefaultCrawlers if nil.
+	Crawlers []string
+
+	// BadBots are the User-Agent signatures of the bad bots, DefaultBadBots if nil.
+	BadBots []string
+
+	// Block rejects the bad bots with 403 Forbidden.
+	Block bool
+
+	// Limit is applied to the requests of the bots only, e.g. a FairQueue sharing a small capacity between them.
+	Limit MiddlewareFunc
+
+	// Classify classifies the request before the User-Agent signatures, the requests it doesn't
+	// classify are classified by the signatures and heuristics.
+	Classify func(r *http.Request) (BotVerdict, bool)
+}
+
+// Middleware returns the middleware classifying the requests.
+func (d BotDetector) Middleware() MiddlewareFunc {
+	if nil == d.Crawlers {
+		d.Crawlers = DefaultCrawlers
+	}
+	if nil == d.BadBots {
+		d.BadBots = DefaultBadBots
+	}
+	crawlers, badBots := lowerAll(d.Crawlers), lowerAll(d.BadBots)
+
+	return func(next http.Handler) http.Handler {
+		limited := next
+		if nil != d.Limit {
+			limited = d.Limit(next)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			verdict := d.classify(r, crawlers, badBots)
+			if BotBad == verdict.Class && d.Block {
+				http.Error(w, "403 forbidden", http.StatusForbidden)
+				return
+			}
+
+			r = r.WithContext(WithValue(r.Context(), verdict))
+			if verdict.IsBot() {
+				limited.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (d BotDetector) classify(r *http.Request, crawlers, badBots []string) BotVerdict {
+	if nil != d.Classify {
+		if verdict, ok := d.Classify(r); ok {
+			return verdict
+		}
+	}
+
+	ua := r.UserAgent()
+	if 0 == len(ua) {
+		return BotVerdict{Class: BotAutomated, Name: "empty user agent"}
+	}
+
+	lower := strings.ToLower(ua)
+	if i := matchSignature(lower, badBots); i >= 0 {
+		return BotVerdict{Class: BotBad, Name: d.BadBots[i]}
+	}
+	if i := matchSignature(lower, crawlers); i >= 0 {
+		return BotVerdict{Class: BotCrawler, Name: d.Crawlers[i]}
+	}
+	if i := matchSignature(lower, automatedSignatures); i >= 0 {
+		return BotVerdict{Class: BotAutomated, Name: automatedSignatures[i]}
+	}
+
+	// the browsers always send the languages of the user.
+	if strings.HasPrefix(ua, "Mozilla/") && 0 == len(r.Header.Get("Accept-Language")) {
+		return BotVerdict{Class: BotAutomated, Name: "missing accept-language"}
+	}
+	return BotVerdict{Class: BotHuman}
+}
+
+// matchSignature returns the index of the first signature contained in the lower cased User-Agent.
+func matchSignature(ua string, signatures []string) int {
+	for i, signature := range signatures {
+		if strings.Contains(ua, signature) {
+			return i
+		}
+	}
+	return -1
+}
+
+func lowerAll(values []string) []string {
+	lower := make([]string, len(values))
+	for i, v := range values {
+		lower[i] = strings.ToLower(v)
+	}
+	return lower
+}
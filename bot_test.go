@@ -0,0 +1,65 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBotDetector(t *testing.T) {
+	var limited int
+	detector := BotDetector{
+		Block: true,
+		Limit: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				limited++
+				next.ServeHTTP(w, r)
+			})
+		},
+		Classify: func(r *http.Request) (BotVerdict, bool) {
+			if "monitor" == r.Header.Get("X-Client") {
+				return BotVerdict{Class: BotCrawler, Name: "uptime monitor"}, true
+			}
+			return BotVerdict{}, false
+		},
+	}
+
+	var verdict BotVerdict
+	h := detector.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verdict, _ = BotVerdictOf(r.Context())
+	}))
+
+	browser := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+	tests := []struct {
+		headers map[string]string
+		verdict BotVerdict
+	}{
+		{map[string]string{"User-Agent": browser, "Accept-Language": "en"}, BotVerdict{Class: BotHuman}},
+		{map[string]string{"User-Agent": browser}, BotVerdict{Class: BotAutomated, Name: "missing accept-language"}},
+		{map[string]string{"User-Agent": "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"}, BotVerdict{Class: BotCrawler, Name: "Googlebot"}},
+		{map[string]string{"User-Agent": "curl/8.4.0"}, BotVerdict{Class: BotAutomated, Name: "curl/"}},
+		{map[string]string{"User-Agent": ""}, BotVerdict{Class: BotAutomated, Name: "empty user agent"}},
+		{map[string]string{"User-Agent": "curl/8.4.0", "X-Client": "monitor"}, BotVerdict{Class: BotCrawler, Name: "uptime monitor"}},
+	}
+	for i, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Del("User-Agent")
+		for k, v := range test.headers {
+			r.Header.Set(k, v)
+		}
+		verdict = BotVerdict{Class: -1}
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Equal(t, test.verdict, verdict, i)
+	}
+	assert.Equal(t, 5, limited)
+
+	// the bad bots are blocked.
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("User-Agent", "sqlmap/1.7 (https://sqlmap.org)")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "bad", BotBad.String())
+}
@@ -0,0 +1,466 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResultCache returns a HandlerInterceptor that caches the handler results for ttl.
+//
+// The cache is keyed by the request method, the matched route pattern and the bound request value
+// with all of its fields, so requests differing only in parameters the handler does not bind share
+// the same result, the requests holding channels or funcs aren't cached.
+// Only successful results are cached, errors are always passed through.
+// The cache is process-local and holds up to MaxCacheEntries results.
+//
+//	router.Group("/reports", func(r web.Router) {
+//		r.Intercept(web.ResultCache(time.Minute))
+//		r.Get("/daily", DailyReport)
+//	})
+func ResultCache(ttl time.Duration) HandlerInterceptor {
+	c := &resultCache{ttl: ttl, entries: map[string]resultCacheEntry{}}
+	return c.intercept
+}
+
+type resultCacheEntry struct {
+	result  interface{}
+	expires time.Time
+}
+
+type resultCache struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	entries   map[string]resultCacheEntry
+	nextSweep time.Time
+}
+
+func (c *resultCache) intercept(ctx context.Context, req interface{}, next Invoke) (interface{}, error) {
+	key, ok := resultCacheKey(ctx, req)
+	if !ok {
+		return next(ctx, req)
+	}
+
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	c.mu.Unlock()
+
+	clock := ClockOf(ctx)
+	if found && clock.Now().Before(entry.expires) {
+		return entry.result, nil
+	}
+
+	result, err := next(ctx, req)
+	if kind := reflect.ValueOf(result).Kind(); nil != err || reflect.Chan == kind || reflect.Func == kind || contentResultType == reflect.TypeOf(result) {
+		// the streamed records and contents can't be replayed.
+		return result, err
+	}
+
+	now := clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.After(c.nextSweep) {
+		sweepCacheEntries(c.entries, now, func(e resultCacheEntry) time.Time { return e.expires })
+		c.nextSweep = now.Add(c.ttl)
+	}
+	evictCacheEntries(c.entries, now, func(e resultCacheEntry) time.Time { return e.expires })
+	c.entries[key] = resultCacheEntry{result: result, expires: now.Add(c.ttl)}
+	return result, nil
+}
+
+// resultCacheKey returns the cache key of the bound request, the request value is hashed entirely by
+// reflection, including the fields bound from the headers, cookies and context values or ignored by
+// JSON, so that the results of a user or tenant are never served to another. It reports false if the
+// request can't be hashed, e.g. it holds a channel or a func.
+func resultCacheKey(ctx context.Context, req interface{}) (string, bool) {
+	webCtx := FromContext(ctx)
+	if nil == webCtx {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	if !writeCacheKey(&buf, reflect.ValueOf(req), 0) {
+		return "", false
+	}
+
+	route := webCtx.Request.URL.Path
+	if rctx := FromRouteContext(ctx); nil != rctx && len(rctx.routePatterns) > 0 {
+		route = strings.Join(rctx.routePatterns, "")
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	return webCtx.Request.Method + " " + route + " " + hex.EncodeToString(sum[:]), true
+}
+
+// writeCacheKey writes the unambiguous encoding of the value into the buffer, the exported and
+// unexported fields alike, reports false if the value can't be encoded.
+func writeCacheKey(buf *bytes.Buffer, v reflect.Value, depth int) bool {
+	if depth > 32 {
+		return false // cyclic or too deep.
+	}
+	if !v.IsValid() {
+		buf.WriteString("nil;")
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		buf.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Complex64, reflect.Complex128:
+		buf.WriteString(strconv.FormatComplex(v.Complex(), 'g', -1, 128))
+	case reflect.String:
+		buf.WriteString(strconv.Quote(v.String()))
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("nil")
+			break
+		}
+		if reflect.Interface == v.Kind() {
+			buf.WriteString(v.Elem().Type().String())
+		}
+		buf.WriteByte('&')
+		if !writeCacheKey(buf, v.Elem(), depth+1) {
+			return false
+		}
+	case reflect.Slice, reflect.Array:
+		if reflect.Slice == v.Kind() && v.IsNil() {
+			buf.WriteString("nil")
+			break
+		}
+		buf.WriteString("[" + strconv.Itoa(v.Len()) + ":")
+		if reflect.Slice == v.Kind() && reflect.Uint8 == v.Type().Elem().Kind() {
+			buf.Write(v.Bytes())
+		} else {
+			for i := 0; i < v.Len(); i++ {
+				if !writeCacheKey(buf, v.Index(i), depth+1) {
+					return false
+				}
+				buf.WriteByte(',')
+			}
+		}
+		buf.WriteByte(']')
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteString("nil")
+			break
+		}
+		entries := make([]string, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			var key, value bytes.Buffer
+			if !writeCacheKey(&key, iter.Key(), depth+1) || !writeCacheKey(&value, iter.Value(), depth+1) {
+				return false
+			}
+			entries = append(entries, key.String()+":"+value.String())
+		}
+		sort.Strings(entries)
+		buf.WriteString("{" + strings.Join(entries, ",") + "}")
+	case reflect.Struct:
+		buf.WriteByte('{')
+		for i := 0; i < v.NumField(); i++ {
+			buf.WriteString(v.Type().Field(i).Name + "=")
+			if !writeCacheKey(buf, v.Field(i), depth+1) {
+				return false
+			}
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('}')
+	default:
+		return false
+	}
+	return true
+}
+
+// MaxCacheEntries is the maximum number of the entries kept by each cache of ResultCache and CacheHTML,
+// the expired entries are evicted first once the cache is full, then the arbitrary ones.
+var MaxCacheEntries = 10000
+
+// CacheHTML returns a middleware that caches the rendered HTML output of the routes for ttl.
+//
+// Successful GET responses with a `text/html` content type are stored both as is and
+// gzip compressed, later GET and HEAD requests are served directly from the cache with
+// the representation selected by `Accept-Encoding` and `Vary: Accept-Encoding` set.
+// The responses varying by the other request headers, e.g. `Vary: Accept-Language` set by
+// Locale, are cached per the values of these headers in the request.
+// The responses personalized for the client aren't cached, i.e. the ones setting cookies,
+// marked by `Cache-Control: private` or `no-store`, or varying by `Cookie` or `Authorization`.
+// The cache is process-local and holds up to MaxCacheEntries entries.
+//
+//	router.With(web.CacheHTML(time.Hour)).Get("/", LandingPage)
+func CacheHTML(ttl time.Duration) MiddlewareFunc {
+	c := &htmlCache{ttl: ttl, entries: map[string]*htmlCacheEntry{}, varies: map[string]htmlCacheVary{}}
+	return c.middleware
+}
+
+type htmlCacheEntry struct {
+	header  http.Header
+	plain   []byte
+	gzipped []byte
+	expires time.Time
+}
+
+// htmlCacheVary records the request headers the responses of the URL vary by.
+type htmlCacheVary struct {
+	names   []string
+	expires time.Time
+}
+
+type htmlCache struct {
+	ttl       time.Duration
+	mu        sync.RWMutex
+	entries   map[string]*htmlCacheEntry
+	varies    map[string]htmlCacheVary
+	nextSweep time.Time
+}
+
+func (c *htmlCache) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet && request.Method != http.MethodHead {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		url := request.Host + request.URL.RequestURI()
+		clock := ClockOf(request.Context())
+
+		c.mu.RLock()
+		entry, ok := c.entries[url+variantKey(request, c.varies[url].names)]
+		c.mu.RUnlock()
+
+		if ok && clock.Now().Before(entry.expires) {
+			entry.serve(writer, request)
+			return
+		}
+
+		if request.Method != http.MethodGet {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		rec := &bufferedWriter{header: http.Header{}, code: http.StatusOK}
+		next.ServeHTTP(rec, request)
+
+		contentType := rec.header.Get("Content-Type")
+		if rec.code != http.StatusOK || len(rec.header.Get("Content-Encoding")) > 0 || !strings.HasPrefix(contentType, "text/html") ||
+			!sharedCacheable(rec.header) || !sharedCacheable(writer.Header()) {
+			rec.flushTo(writer)
+			return
+		}
+
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(rec.body.Bytes()); nil != err || nil != gz.Close() {
+			rec.flushTo(writer)
+			return
+		}
+
+		entry = &htmlCacheEntry{
+			header:  rec.header.Clone(),
+			plain:   rec.body.Bytes(),
+			gzipped: buf.Bytes(),
+			expires: clock.Now().Add(c.ttl),
+		}
+		entry.header.Del("Content-Length")
+		entry.header.Del("Set-Cookie")
+
+		// the varying headers are set by the handler, or by the middlewares running before the cache.
+		vary := htmlCacheVary{names: varyNames(writer.Header(), rec.header), expires: entry.expires}
+
+		now := clock.Now()
+		c.mu.Lock()
+		// the expired entries are swept once per ttl rather than on every miss.
+		if now.After(c.nextSweep) {
+			sweepCacheEntries(c.entries, now, func(e *htmlCacheEntry) time.Time { return e.expires })
+			sweepCacheEntries(c.varies, now, func(v htmlCacheVary) time.Time { return v.expires })
+			c.nextSweep = now.Add(c.ttl)
+		}
+		evictCacheEntries(c.entries, now, func(e *htmlCacheEntry) time.Time { return e.expires })
+		evictCacheEntries(c.varies, now, func(v htmlCacheVary) time.Time { return v.expires })
+		c.varies[url] = vary
+		c.entries[url+variantKey(request, vary.names)] = entry
+		c.mu.Unlock()
+
+		entry.serve(writer, request)
+	})
+}
+
+// sharedCacheable reports whether the response of the header can be served to other clients, i.e. it
+// neither sets cookies, is private to the client nor varies by the credentials of the client.
+func sharedCacheable(header http.Header) bool {
+	if len(header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if strings.EqualFold(name, "private") || strings.EqualFold(name, "no-store") {
+				return false
+			}
+		}
+	}
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "cookie", "authorization", "*":
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// varyNames returns the sorted names of the request headers the response varies by,
+// except Accept-Encoding that's negotiated by the cache itself.
+func varyNames(headers ...http.Header) []string {
+	var names []string
+	for _, header := range headers {
+		for _, value := range header.Values("Vary") {
+			for _, name := range strings.Split(value, ",") {
+				name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+				if len(name) > 0 && "Accept-Encoding" != name && !slices.Contains(names, name) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// variantKey returns the cache key suffix of the request values of the varying headers.
+func variantKey(request *http.Request, names []string) string {
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString("\x00" + name + "=" + strconv.Quote(strings.Join(request.Header.Values(name), ",")))
+	}
+	return sb.String()
+}
+
+// sweepCacheEntries deletes the expired entries of the cache.
+func sweepCacheEntries[E any](entries map[string]E, now time.Time, expires func(E) time.Time) {
+	for k, e := range entries {
+		if !now.Before(expires(e)) {
+			delete(entries, k)
+		}
+	}
+}
+
+// evictCacheEntries makes room for a new entry of the cache holding MaxCacheEntries entries,
+// the expired entries are evicted first, then the arbitrary ones.
+func evictCacheEntries[E any](entries map[string]E, now time.Time, expires func(E) time.Time) {
+	if MaxCacheEntries <= 0 || len(entries) < MaxCacheEntries {
+		return
+	}
+	sweepCacheEntries(entries, now, expires)
+	for k := range entries {
+		if len(entries) < MaxCacheEntries {
+			break
+		}
+		delete(entries, k)
+	}
+}
+
+func (e *htmlCacheEntry) serve(writer http.ResponseWriter, request *http.Request) {
+	header := writer.Header()
+	for k, v := range e.header {
+		header[k] = append([]string(nil), v...)
+	}
+	header.Add("Vary", "Accept-Encoding")
+
+	body := e.plain
+	if acceptsGzip(request.Header.Get("Accept-Encoding")) {
+		body = e.gzipped
+		header.Set("Content-Encoding", "gzip")
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	writer.WriteHeader(http.StatusOK)
+
+	if request.Method != http.MethodHead {
+		_, _ = writer.Write(body)
+	}
+}
+
+// acceptsGzip reports whether the `Accept-Encoding` header value accepts gzip encoding.
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.TrimSpace(coding)
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); nil == err && v <= 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// bufferedWriter is a http.ResponseWriter that buffers the whole response in memory.
+type bufferedWriter struct {
+	header http.Header
+	code   int
+	wrote  bool
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if !w.wrote {
+		w.code = code
+		w.wrote = true
+	}
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+// flushTo writes the buffered response to writer.
+func (w *bufferedWriter) flushTo(writer http.ResponseWriter) {
+	header := writer.Header()
+	for k, v := range w.header {
+		header[k] = v
+	}
+	writer.WriteHeader(w.code)
+	_, _ = writer.Write(w.body.Bytes())
+}
@@ -0,0 +1,253 @@
+package web
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultCache(t *testing.T) {
+	type Req struct {
+		Name string `query:"name"`
+	}
+
+	var calls int
+	r := NewRouter()
+	r.Intercept(ResultCache(50 * time.Millisecond))
+	r.Get("/greeting", func(ctx context.Context, req Req) string {
+		calls++
+		return fmt.Sprintf("hello %s #%d", req.Name, calls)
+	})
+	r.Get("/hello", func(ctx context.Context, req Req) string {
+		calls++
+		return fmt.Sprintf("hi %s #%d", req.Name, calls)
+	})
+	r.Get("/fail", func(ctx context.Context, req Req) (string, error) {
+		calls++
+		return "", Error(http.StatusConflict, "")
+	})
+
+	_, body := testHandler(t, r, "GET", "/greeting?name=a", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"hello a #1\"}\n", body)
+
+	// unbound query params are ignored by the cache key.
+	_, body = testHandler(t, r, "GET", "/greeting?name=a&ts=1", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"hello a #1\"}\n", body)
+
+	_, body = testHandler(t, r, "GET", "/greeting?name=b", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"hello b #2\"}\n", body)
+
+	// same request value on another route.
+	_, body = testHandler(t, r, "GET", "/hello?name=a", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"hi a #3\"}\n", body)
+
+	// errors are not cached.
+	testHandler(t, r, "GET", "/fail?name=a", nil)
+	testHandler(t, r, "GET", "/fail?name=a", nil)
+	assert.Equal(t, 5, calls)
+
+	time.Sleep(60 * time.Millisecond)
+	_, body = testHandler(t, r, "GET", "/greeting?name=a", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"hello a #6\"}\n", body)
+}
+
+func TestResultCacheMaxEntries(t *testing.T) {
+	defer func(n int) { MaxCacheEntries = n }(MaxCacheEntries)
+	MaxCacheEntries = 2
+
+	c := &resultCache{ttl: time.Minute, entries: map[string]resultCacheEntry{}}
+	r := NewRouter()
+	r.Intercept(c.intercept)
+	r.Get("/greeting", func(ctx context.Context, req struct {
+		Name string `query:"name"`
+	}) string {
+		return "hello " + req.Name
+	})
+	for i := 0; i < 5; i++ {
+		testHandler(t, r, "GET", "/greeting?name="+strconv.Itoa(i), nil)
+	}
+	assert.Len(t, c.entries, 2)
+}
+
+func TestResultCacheKeyedByAllFields(t *testing.T) {
+	type Req struct {
+		ID     int    `query:"id"`
+		Tenant string `header:"X-Tenant" json:"-"`
+		User   string `cookie:"user" json:"-"`
+	}
+
+	var calls int
+	r := NewRouter()
+	r.Intercept(ResultCache(time.Minute))
+	r.Get("/orders", func(ctx context.Context, req Req) string {
+		calls++
+		return fmt.Sprintf("%s/%s #%d", req.Tenant, req.User, calls)
+	})
+	r.Get("/stream", func(ctx context.Context, req struct {
+		Done chan struct{} `json:"-"`
+	}) string {
+		calls++
+		return strconv.Itoa(calls)
+	})
+
+	get := func(path, tenant, user string) string {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-Tenant", tenant)
+		req.AddCookie(&http.Cookie{Name: "user", Value: user})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	// the requests differing only by the header or cookie bound fields aren't shared.
+	assert.Equal(t, "{\"code\":0,\"data\":\"acme/alice #1\"}\n", get("/orders?id=1", "acme", "alice"))
+	assert.Equal(t, "{\"code\":0,\"data\":\"globex/alice #2\"}\n", get("/orders?id=1", "globex", "alice"))
+	assert.Equal(t, "{\"code\":0,\"data\":\"acme/bob #3\"}\n", get("/orders?id=1", "acme", "bob"))
+	assert.Equal(t, "{\"code\":0,\"data\":\"acme/alice #1\"}\n", get("/orders?id=1", "acme", "alice"))
+
+	// the requests which can't be hashed aren't cached.
+	get("/stream", "", "")
+	get("/stream", "", "")
+	assert.Equal(t, 5, calls)
+}
+
+func TestCacheHTML(t *testing.T) {
+	var calls int
+	r := NewRouter()
+	r.With(CacheHTML(time.Minute)).Get("/", func(ctx context.Context) {
+		calls++
+		FromContext(ctx).Data(200, "text/html; charset=utf-8", []byte("<h1>welcome</h1>"))
+	})
+	r.With(CacheHTML(time.Minute)).Get("/api", func(ctx context.Context) string {
+		calls++
+		return "json"
+	})
+
+	resp, body := testHandler(t, r, "GET", "/", nil)
+	assert.Equal(t, "<h1>welcome</h1>", body)
+	assert.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+	assert.Equal(t, "", resp.Header.Get("Content-Encoding"))
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, strconv.Itoa(w.Body.Len()), w.Header().Get("Content-Length"))
+
+	zr, err := gzip.NewReader(w.Body)
+	assert.Nil(t, err)
+	data, _ := io.ReadAll(zr)
+	assert.Equal(t, "<h1>welcome</h1>", string(data))
+
+	assert.Equal(t, 1, calls)
+
+	// non html responses are passed through.
+	testHandler(t, r, "GET", "/api", nil)
+	_, body = testHandler(t, r, "GET", "/api", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"json\"}\n", body)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCacheHTMLPersonalized(t *testing.T) {
+	var calls int
+	page := func(header, value string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			calls++
+			webCtx := FromContext(ctx)
+			webCtx.SetHeader(header, value)
+			_ = webCtx.Data(200, "text/html; charset=utf-8", []byte("<h1>welcome "+strconv.Itoa(calls)+"</h1>"))
+		}
+	}
+
+	r := NewRouter()
+	r.Use(CacheHTML(time.Minute))
+	r.Get("/cookie", page("Set-Cookie", "session=alice"))
+	r.Get("/private", page("Cache-Control", "private, max-age=60"))
+	r.Get("/no-store", page("Cache-Control", "no-store"))
+	r.Get("/vary", page("Vary", "Accept-Language, Cookie"))
+	r.Get("/auth", page("Vary", "Authorization"))
+
+	for _, path := range []string{"/cookie", "/private", "/no-store", "/vary", "/auth"} {
+		_, first := testHandler(t, r, "GET", path, nil)
+		resp, second := testHandler(t, r, "GET", path, nil)
+		assert.NotEqual(t, first, second, path)
+		if "/cookie" == path {
+			assert.Equal(t, "session=alice", resp.Header.Get("Set-Cookie"))
+		}
+	}
+	assert.Equal(t, 10, calls)
+
+	// the public responses varying by the other headers stay cacheable.
+	assert.True(t, sharedCacheable(http.Header{"Cache-Control": {"public, max-age=60"}, "Vary": {"Accept-Language"}}))
+}
+
+func TestCacheHTMLVary(t *testing.T) {
+	var calls int
+	page := func(ctx context.Context) {
+		calls++
+		_ = FromContext(ctx).Data(200, "text/html; charset=utf-8", []byte("<h1>"+LocaleOf(ctx)+"</h1>"))
+	}
+
+	r := NewRouter()
+	r.Group("/outer", func(r Router) {
+		r.Use(Locale("en", "fr"), CacheHTML(time.Minute))
+		r.Get("/", page)
+	})
+	r.Group("/inner", func(r Router) {
+		r.Use(CacheHTML(time.Minute), Locale("en", "fr"))
+		r.Get("/", page)
+	})
+
+	get := func(path, language string) string {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Language", language)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	for _, path := range []string{"/outer/", "/inner/"} {
+		calls = 0
+		assert.Equal(t, "<h1>fr</h1>", get(path, "fr"), path)
+		assert.Equal(t, "<h1>en</h1>", get(path, "en"), path)
+		assert.Equal(t, "<h1>fr</h1>", get(path, "fr"), path)
+		assert.Equal(t, "<h1>en</h1>", get(path, "en"), path)
+		assert.Equal(t, 2, calls, path)
+	}
+}
+
+func TestCacheHTMLMaxEntries(t *testing.T) {
+	defer func(n int) { MaxCacheEntries = n }(MaxCacheEntries)
+	MaxCacheEntries = 2
+
+	c := &htmlCache{ttl: time.Minute, entries: map[string]*htmlCacheEntry{}, varies: map[string]htmlCacheVary{}}
+	r := NewRouter()
+	r.With(c.middleware).Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<h1>welcome</h1>"))
+	})
+	for i := 0; i < 5; i++ {
+		testHandler(t, r, "GET", "/?page="+strconv.Itoa(i), nil)
+	}
+	assert.Len(t, c.entries, 2)
+	assert.Len(t, c.varies, 2)
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	assert.True(t, acceptsGzip("gzip"))
+	assert.True(t, acceptsGzip("deflate, gzip;q=1.0, *;q=0.5"))
+	assert.True(t, acceptsGzip("*"))
+	assert.False(t, acceptsGzip("gzip;q=0"))
+	assert.False(t, acceptsGzip("br, deflate"))
+	assert.False(t, acceptsGzip(""))
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	"go-spring.dev/web/render"
+)
+
+// CBORRender renders the results of the typed handlers as CBOR in the same envelope of JsonRender,
+// i.e. the `code`, `message` and `data` keys, for the clients already speaking CBOR, e.g. the IoT
+// devices. The results that can't be encoded are rendered as an internal server error, and the
+// observers are notified of the encoding error.
+//
+//	router.Renderer(web.CBORRender())
+func CBORRender(observers ...func(ctx *Context, err error)) Renderer {
+	return envelopeRender{
+		contentType: render.CBORRenderer{}.ContentType(),
+		marshal:     cbor.Marshal,
+		observers:   observers,
+	}
+}
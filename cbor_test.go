@@ -0,0 +1,52 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCBORRender(t *testing.T) {
+	type reading struct {
+		Sensor string  `json:"sensor"`
+		Value  float64 `json:"value"`
+	}
+
+	r := NewRouter().Renderer(CBORRender())
+	r.Post("/readings", func(ctx context.Context, req *reading) (*reading, error) {
+		if len(req.Sensor) == 0 {
+			return nil, Error(http.StatusBadRequest, "sensor required")
+		}
+		return req, nil
+	})
+
+	type envelope struct {
+		Code    int      `cbor:"code"`
+		Message string   `cbor:"message"`
+		Data    *reading `cbor:"data"`
+	}
+	serve := func(body interface{}) envelope {
+		data, err := cbor.Marshal(body)
+		assert.Nil(t, err)
+		req := httptest.NewRequest("POST", "/readings", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/cbor")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, "application/cbor", w.Header().Get("Content-Type"))
+
+		var resp envelope
+		assert.Nil(t, cbor.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	resp := serve(map[string]interface{}{"sensor": "t1", "value": 21.5})
+	assert.Equal(t, envelope{Data: &reading{Sensor: "t1", Value: 21.5}}, resp)
+
+	resp = serve(map[string]interface{}{"value": 21.5})
+	assert.Equal(t, envelope{Code: http.StatusBadRequest, Message: "sensor required"}, resp)
+}
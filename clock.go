@@ -0,0 +1,115 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Clock provides the current time to the time dependent features of the router, e.g. the
+// expiries of ResultCache and CacheHTML, so that tests can run deterministically with a fake
+// clock instead of sleeping.
+//
+//	now := time.Now()
+//	router.Clock(web.ClockFunc(func() time.Time { return now }))
+//	...
+//	now = now.Add(time.Hour) // expires the cached results
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to allow the use of ordinary functions as Clock.
+type ClockFunc func() time.Time
+
+func (fn ClockFunc) Now() time.Time {
+	return fn()
+}
+
+// Rand provides the random numbers to the router features, so that they are reproducible in tests.
+type Rand interface {
+	// Int63n returns a non-negative pseudo-random number in [0,n), it panics if n <= 0.
+	Int63n(n int64) int64
+}
+
+// RandFunc is an adapter to allow the use of ordinary functions as Rand.
+type RandFunc func(n int64) int64
+
+func (fn RandFunc) Int63n(n int64) int64 {
+	return fn(n)
+}
+
+// SystemClock is the Clock reading the system time, used unless the router sets another one.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// SystemRand is the Rand of the shared source of math/rand, used unless the router sets another one.
+var SystemRand Rand = RandFunc(rand.Int63n)
+
+// Clock sets the clock of the router, the routers without a clock inherit the clock of their parent.
+// The inline routers share the clock of the router they derive from.
+func (rg *routerGroup) Clock(clock Clock) Router {
+	if rg.inline {
+		panic("the clock can't be set on inline routers, set it on the router they derive from")
+	}
+	rg.clock = clock
+	return rg
+}
+
+// Rand sets the random source of the router, the routers without one inherit the source of their parent.
+// The inline routers share the random source of the router they derive from.
+func (rg *routerGroup) Rand(rand Rand) Router {
+	if rg.inline {
+		panic("the random source can't be set on inline routers, set it on the router they derive from")
+	}
+	rg.rand = rand
+	return rg
+}
+
+// ClockOf returns the clock of the router serving the request, or SystemClock.
+func ClockOf(ctx context.Context) Clock {
+	for g := servingRouter(ctx); nil != g; g = g.parent {
+		if nil != g.clock {
+			return g.clock
+		}
+	}
+	return SystemClock
+}
+
+// RandOf returns the random source of the router serving the request, or SystemRand.
+func RandOf(ctx context.Context) Rand {
+	for g := servingRouter(ctx); nil != g; g = g.parent {
+		if nil != g.rand {
+			return g.rand
+		}
+	}
+	return SystemRand
+}
+
+// servingRouter returns the router the request is routed to, or the router receiving
+// the request if it's not routed yet, e.g. in the middlewares of the router.
+func servingRouter(ctx context.Context) *routerGroup {
+	rctx := FromRouteContext(ctx)
+	if nil == rctx {
+		return nil
+	}
+	if nil != rctx.router {
+		return rctx.router
+	}
+	rg, _ := rctx.Routes.(*routerGroup)
+	return rg
+}
@@ -0,0 +1,93 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	clock := ClockFunc(func() time.Time { return now })
+
+	var calls int
+	r := NewRouter().Clock(clock)
+	r.Group("/reports", func(r Router) {
+		r.Intercept(ResultCache(time.Minute))
+		r.Get("/daily", func(ctx context.Context) string {
+			calls++
+			return fmt.Sprintf("report #%d", calls)
+		})
+	})
+	r.With(CacheHTML(time.Hour)).Get("/", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<p>%d</p>", calls)
+	})
+
+	_, body := testHandler(t, r, "GET", "/reports/daily", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"report #1\"}\n", body)
+
+	now = now.Add(59 * time.Second)
+	_, body = testHandler(t, r, "GET", "/reports/daily", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"report #1\"}\n", body)
+
+	now = now.Add(time.Second)
+	_, body = testHandler(t, r, "GET", "/reports/daily", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"report #2\"}\n", body)
+
+	_, body = testHandler(t, r, "GET", "/", nil)
+	assert.Equal(t, "<p>3</p>", body)
+
+	now = now.Add(time.Hour)
+	_, body = testHandler(t, r, "GET", "/", nil)
+	assert.Equal(t, "<p>4</p>", body)
+
+	// the clock of the clone is changed without affecting the router.
+	cp := r.Clone().Clock(ClockFunc(func() time.Time { return now.Add(2 * time.Minute) }))
+	_, body = testHandler(t, r, "GET", "/reports/daily", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"report #5\"}\n", body)
+	_, body = testHandler(t, cp, "GET", "/reports/daily", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"report #6\"}\n", body)
+	_, body = testHandler(t, r, "GET", "/reports/daily", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":\"report #6\"}\n", body)
+}
+
+func TestClockOf(t *testing.T) {
+	assert.Equal(t, fmt.Sprint(SystemClock), fmt.Sprint(ClockOf(context.Background())))
+	assert.Equal(t, fmt.Sprint(SystemRand), fmt.Sprint(RandOf(context.Background())))
+
+	var seen []int64
+	r := NewRouter().Rand(RandFunc(func(n int64) int64 { return n - 1 }))
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the middlewares of the router use the random source before the routing.
+			seen = append(seen, RandOf(r.Context()).Int63n(10))
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Group("/api", func(r Router) {
+		r.Get("/inherited", func(ctx context.Context) {
+			seen = append(seen, RandOf(ctx).Int63n(10))
+		})
+		r.Group("/own", func(r Router) {
+			r.Rand(RandFunc(func(n int64) int64 { return 0 }))
+			r.Get("/", func(ctx context.Context) {
+				seen = append(seen, RandOf(ctx).Int63n(10))
+			})
+		})
+	})
+
+	for _, path := range []string{"/api/inherited", "/api/own/"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	assert.Equal(t, []int64{9, 9, 9, 0}, seen)
+
+	assert.Panics(t, func() { r.With().Rand(SystemRand) })
+	assert.Panics(t, func() { r.With().Clock(SystemClock) })
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// Clone returns a deep copy of the router, the routing tree, the middlewares, the renderer and the
+// mounted subrouters are copied, so that the copy can be changed, e.g. NotFound handlers replaced or
+// fakes mounted in tests, without affecting the original router. The handlers, middlewares and the
+// values of the route metadata themselves are shared by the copy.
+//
+//	func TestPets(t *testing.T) {
+//		router := petstore.Router.Clone()
+//		router.NotFound(failOnNotFound(t))
+//		...
+//	}
+func (rg *routerGroup) Clone() Router {
+	if rg.inline {
+		panic("inline routers can't be cloned, clone the router they derive from")
+	}
+	return rg.clone(rg.parent, &sync.Pool{New: func() interface{} { return &RouteContext{} }})
+}
+
+func (rg *routerGroup) clone(parent *routerGroup, pool *sync.Pool) *routerGroup {
+	if nil != rg.mu {
+		rg.mu.RLock()
+		defer rg.mu.RUnlock()
+	}
+
+	cp := &routerGroup{
+		parent:            parent,
+		middlewares:       slices.Clone(rg.middlewares),
+		renderer:          rg.renderer,
+		interceptors:      slices.Clone(rg.interceptors),
+		clock:             rg.clock,
+		rand:              rg.rand,
+		validator:         rg.validator,
+		notFoundHandler:   rg.notFoundHandler,
+		notAllowedHandler: rg.notAllowedHandler,
+		pool:              pool,
+		warmup:            rg.warmup,
+		warmups:           slices.Clone(rg.warmups),
+		opts:              rg.opts,
+	}
+	if nil != rg.mu {
+		cp.mu = &sync.RWMutex{}
+	}
+
+	// the mounted subrouters are cloned once, and remounted on the copy.
+	mounts := map[*mountHandler]*mountHandler{}
+	subrouters := map[*routerGroup]*routerGroup{}
+	cloneSubrouter := func(sub *routerGroup) *routerGroup {
+		if c, ok := subrouters[sub]; ok {
+			return c
+		}
+		subParent := sub.parent
+		if subParent == rg {
+			subParent = cp
+		}
+		c := sub.clone(subParent, pool)
+		subrouters[sub] = c
+		return c
+	}
+
+	cp.tree = rg.tree.clone(func(h http.Handler) http.Handler {
+		m, ok := h.(*mountHandler)
+		if !ok {
+			return h
+		}
+		if c, ok := mounts[m]; ok {
+			return c
+		}
+		c := &mountHandler{rg: cp, handler: m.handler}
+		if sub, ok := m.handler.(*routerGroup); ok && !sub.inline {
+			c.handler = cloneSubrouter(sub)
+		}
+		mounts[m] = c
+		return c
+	}, func(routes Routes) Routes {
+		if sub, ok := routes.(*routerGroup); ok && !sub.inline {
+			return cloneSubrouter(sub)
+		}
+		return routes
+	})
+
+	cp.tree.reindexStatic()
+
+	if nil != rg.handler {
+		cp.updateRouteHandler()
+	}
+	return cp
+}
+
+// clone returns a deep copy of the subtree, the handlers and subroutes of the leaf nodes are mapped.
+func (n *node) clone(handler func(http.Handler) http.Handler, subroutes func(Routes) Routes) *node {
+	cp := *n
+	if nil != n.order {
+		order := *n.order
+		cp.order = &order
+	}
+	if nil != n.subroutes {
+		cp.subroutes = subroutes(n.subroutes)
+	}
+	if nil != n.endpoints {
+		cp.endpoints = make(endpoints, len(n.endpoints))
+		for m, ep := range n.endpoints {
+			e := *ep
+			if nil != ep.handler {
+				e.handler = handler(ep.handler)
+			}
+			e.meta = maps.Clone(ep.meta)
+			cp.endpoints[m] = &e
+		}
+	}
+	for typ, nds := range n.children {
+		if nil == nds {
+			continue
+		}
+		cp.children[typ] = make(nodes, len(nds))
+		for i, child := range nds {
+			cp.children[typ][i] = child.clone(handler, subroutes)
+		}
+	}
+	return &cp
+}
@@ -0,0 +1,62 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterClone(t *testing.T) {
+	r := NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Middleware", "on")
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Get("/ping", func(ctx context.Context) string { return "pong" })
+	api := r.Group("/api", func(r Router) {
+		r.Get("/users/{id}", func(ctx context.Context, req struct {
+			ID string `path:"id"`
+		}) string {
+			return req.ID
+		})
+	})
+
+	cp := r.Clone()
+	cp.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	cp.(*routerGroup).Mount("/fake", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("fake"))
+	}))
+	cp.Renderer(RendererFunc(func(ctx *Context, err error, result interface{}) {
+		_ = ctx.String(http.StatusOK, "clone:%v", result)
+	}))
+	api.Get("/later", func(ctx context.Context) string { return "later" })
+
+	serve := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := serve(cp, "/api/users/7")
+	assert.Equal(t, "on", w.Header().Get("X-Middleware"))
+	assert.Equal(t, "clone:7", w.Body.String())
+	assert.Equal(t, "clone:pong", serve(cp, "/ping").Body.String())
+	assert.Equal(t, "fake", serve(cp, "/fake").Body.String())
+	assert.Equal(t, http.StatusTeapot, serve(cp, "/api/later").Code)
+	assert.Equal(t, http.StatusTeapot, serve(cp, "/missing").Code)
+
+	// the original router is not affected by the changes of the clone, and vice versa.
+	assert.Equal(t, `{"code":0,"data":"7"}`+"\n", serve(r, "/api/users/7").Body.String())
+	assert.Equal(t, http.StatusNotFound, serve(r, "/fake").Code)
+	assert.Equal(t, http.StatusNotFound, serve(r, "/missing").Code)
+	assert.Equal(t, `{"code":0,"data":"later"}`+"\n", serve(r, "/api/later").Body.String())
+
+	assert.Panics(t, func() { r.With().Clone() })
+}
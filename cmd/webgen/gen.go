@@ -0,0 +1,413 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// annotation marks the request structs to generate the binders of.
+const annotation = "//webgen:bind"
+
+// scopes are the struct tags of the params in the binding order of binding.Bind,
+// with the binding.Request methods reading them.
+var scopes = []struct{ tag, getter string }{
+	{"path", "PathParam"},
+	{"query", "QueryParam"},
+	{"header", "Header"},
+	{"cookie", "Cookie"},
+}
+
+// basicType is a type of the fields supported by the generated binders.
+type basicType struct {
+	parse string // the statement parsing `val` into `v` and `err`, empty if `val` is used as is
+	conv  string // the conversion of `v` into the field type, e.g. `int32(%s)`
+}
+
+var basicTypes = map[string]basicType{
+	"string":        {"", "%s"},
+	"bool":          {"v, err := strconv.ParseBool(val)", "%s"},
+	"int":           {"v, err := strconv.ParseInt(val, 0, 0)", "int(%s)"},
+	"int8":          {"v, err := strconv.ParseInt(val, 0, 8)", "int8(%s)"},
+	"int16":         {"v, err := strconv.ParseInt(val, 0, 16)", "int16(%s)"},
+	"int32":         {"v, err := strconv.ParseInt(val, 0, 32)", "int32(%s)"},
+	"int64":         {"v, err := strconv.ParseInt(val, 0, 64)", "%s"},
+	"uint":          {"v, err := strconv.ParseUint(val, 0, 0)", "uint(%s)"},
+	"uint8":         {"v, err := strconv.ParseUint(val, 0, 8)", "uint8(%s)"},
+	"uint16":        {"v, err := strconv.ParseUint(val, 0, 16)", "uint16(%s)"},
+	"uint32":        {"v, err := strconv.ParseUint(val, 0, 32)", "uint32(%s)"},
+	"uint64":        {"v, err := strconv.ParseUint(val, 0, 64)", "%s"},
+	"float32":       {"v, err := strconv.ParseFloat(val, 32)", "float32(%s)"},
+	"float64":       {"v, err := strconv.ParseFloat(val, 64)", "%s"},
+	"time.Duration": {"v, err := time.ParseDuration(val)", "%s"},
+}
+
+// field is a field of the request struct bound from the params.
+type field struct {
+	Name     string   // the Go name of the field
+	Type     string   // the basic type of the field, or of its element
+	Pointer  bool     // the field is a pointer to the type
+	Slice    bool     // the field is a slice of the type bound from the repeated query params
+	Defaults []string // the Go literals of the default values
+	Errmsg   *string  // the errmsg tag replacing the reason of the failures
+	Params   []param
+}
+
+// param is a scope the field is bound from.
+type param struct {
+	Source string // the struct tag of the scope
+	Getter string // the binding.Request method reading the param
+	Name   string // the name of the param
+}
+
+type request struct {
+	Name   string
+	Fields []*field
+}
+
+// generate returns the source code of the binders of the structs named, or the annotated structs
+// if no name is given, in the source file.
+func generate(filename string, src []byte, names []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if nil != err {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[strings.TrimSpace(name)] = true
+	}
+
+	var requests []*request
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || token.TYPE != gen.Tok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			if len(names) > 0 && !wanted[ts.Name.Name] {
+				continue
+			}
+			if len(names) == 0 && !annotated(gen.Doc) && !annotated(ts.Doc) {
+				continue
+			}
+			delete(wanted, ts.Name.Name)
+
+			req, err := parseRequest(ts.Name.Name, st)
+			if nil != err {
+				return nil, fmt.Errorf("%s: %w", fset.Position(ts.Pos()), err)
+			}
+			requests = append(requests, req)
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("struct %s not found in %s", name, filename)
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("no struct annotated by %s in %s", annotation, filename)
+	}
+
+	var imports []string
+	for _, req := range requests {
+		for _, f := range req.Fields {
+			if "string" != f.Type && "time.Duration" != f.Type && !contains(imports, "strconv") {
+				imports = append(imports, "strconv")
+			}
+			if "time.Duration" == f.Type && !contains(imports, "time") {
+				imports = append(imports, "time")
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	err = codeTemplate.Execute(&buf, map[string]interface{}{
+		"Package":  file.Name.Name,
+		"Imports":  imports,
+		"Requests": requests,
+	})
+	if nil != err {
+		return nil, err
+	}
+	code, err := format.Source(buf.Bytes())
+	if nil != err {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, buf.String())
+	}
+	return code, nil
+}
+
+func annotated(doc *ast.CommentGroup) bool {
+	if nil == doc {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == annotation {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRequest(name string, st *ast.StructType) (*request, error) {
+	req := &request{Name: name}
+	for _, astField := range st.Fields.List {
+		if len(astField.Names) == 0 {
+			return nil, fmt.Errorf("struct %s: embedded fields are not supported", name)
+		}
+		if nil == astField.Tag {
+			continue
+		}
+		tagValue, err := strconv.Unquote(astField.Tag.Value)
+		if nil != err {
+			return nil, err
+		}
+		tag := reflect.StructTag(tagValue)
+
+		for _, ident := range astField.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			f, err := parseField(ident.Name, astField.Type, tag)
+			if nil != err {
+				return nil, fmt.Errorf("struct %s field %s: %w", name, ident.Name, err)
+			}
+			if nil != f {
+				req.Fields = append(req.Fields, f)
+			}
+		}
+	}
+	return req, nil
+}
+
+// parseField returns the field bound from the params, or nil if the field isn't bound from any.
+func parseField(name string, expr ast.Expr, tag reflect.StructTag) (*field, error) {
+	for _, unsupported := range []string{"tlscert", "ctx", "request", "lang", "encoding", "split", "explode"} {
+		if _, ok := tag.Lookup(unsupported); ok {
+			return nil, fmt.Errorf("%s params are not supported, remove the struct from webgen to bind it by reflection", unsupported)
+		}
+	}
+	f := &field{Name: name}
+	for _, scope := range scopes {
+		paramName, ok := tag.Lookup(scope.tag)
+		if !ok || "-" == paramName {
+			continue
+		}
+		// the aliases are bound from the last, so that the first present name takes precedence.
+		aliases := strings.Split(paramName, ",")
+		for i := len(aliases) - 1; i >= 0; i-- {
+			if alias := strings.TrimSpace(aliases[i]); len(alias) > 0 {
+				f.Params = append(f.Params, param{Source: scope.tag, Getter: scope.getter, Name: alias})
+			}
+		}
+	}
+	defaultValue, hasDefault := tag.Lookup("default")
+	if len(f.Params) == 0 && !hasDefault {
+		return nil, nil
+	}
+	if errmsg, ok := tag.Lookup("errmsg"); ok {
+		f.Errmsg = &errmsg
+	}
+
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		f.Pointer = true
+		expr = t.X
+	case *ast.ArrayType:
+		if nil != t.Len {
+			return nil, fmt.Errorf("unsupported array type")
+		}
+		f.Slice = true
+		expr = t.Elt
+	}
+	f.Type = typeName(expr)
+	if _, ok := basicTypes[f.Type]; !ok {
+		return nil, fmt.Errorf("unsupported type %s, remove the struct from webgen to bind it by reflection", f.Type)
+	}
+	if f.Slice {
+		for _, p := range f.Params {
+			if "query" != p.Source {
+				return nil, fmt.Errorf("slices are bound from the query params only, not %s", p.Source)
+			}
+		}
+	}
+
+	if hasDefault {
+		values := []string{defaultValue}
+		if f.Slice {
+			values = strings.Split(defaultValue, ",")
+		}
+		for _, value := range values {
+			literal, err := defaultLiteral(f.Type, value)
+			if nil != err {
+				return nil, fmt.Errorf("invalid default %q: %w", defaultValue, err)
+			}
+			f.Defaults = append(f.Defaults, literal)
+		}
+	}
+	return f, nil
+}
+
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			return x.Name + "." + t.Sel.Name
+		}
+	}
+	return fmt.Sprintf("%T", expr)
+}
+
+// defaultLiteral parses the default value as binding.Bind does, and returns its Go literal.
+func defaultLiteral(typ, value string) (string, error) {
+	var err error
+	switch typ {
+	case "string":
+		return strconv.Quote(value), nil
+	case "bool":
+		var b bool
+		b, err = strconv.ParseBool(value)
+		value = strconv.FormatBool(b)
+	case "int", "int8", "int16", "int32", "int64":
+		var i int64
+		i, err = strconv.ParseInt(value, 0, bitSize(typ, "int"))
+		value = strconv.FormatInt(i, 10)
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		var u uint64
+		u, err = strconv.ParseUint(value, 0, bitSize(typ, "uint"))
+		value = strconv.FormatUint(u, 10)
+	case "float32", "float64":
+		var f float64
+		f, err = strconv.ParseFloat(value, bitSize(typ, "float"))
+		value = strconv.FormatFloat(f, 'g', -1, 64)
+	case "time.Duration":
+		var d time.Duration
+		d, err = time.ParseDuration(value)
+		value = strconv.FormatInt(int64(d), 10)
+	}
+	return fmt.Sprintf("%s(%s)", typ, value), err
+}
+
+func bitSize(typ, prefix string) int {
+	size, _ := strconv.Atoi(strings.TrimPrefix(typ, prefix))
+	return size
+}
+
+var codeTemplate = template.Must(template.New("webgen").Funcs(template.FuncMap{
+	"parse": func(typ string) string { return basicTypes[typ].parse },
+	"conv":  func(typ, v string) string { return fmt.Sprintf(basicTypes[typ].conv, v) },
+	"quote": strconv.Quote,
+}).Parse(`// Code generated by webgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+
+	"go-spring.dev/web/binding"
+)
+{{range $req := .Requests}}
+var _ binding.ParamsBinder = (*{{$req.Name}})(nil)
+
+// BindParams binds the path, query, header and cookie params into {{$req.Name}} without reflection.
+func (x *{{$req.Name}}) BindParams(r binding.Request) (errs binding.BindingErrors) {
+{{- range $req.Fields}}
+	if err := x.webgenBind{{.Name}}(r); nil != err {
+		errs = append(errs, err)
+	}
+{{- end}}
+	return errs
+}
+{{range $f := $req.Fields}}
+func (x *{{$req.Name}}) webgenBind{{$f.Name}}(r binding.Request) *binding.FieldError {
+{{- if $f.Defaults}}
+{{- if $f.Slice}}
+	x.{{$f.Name}} = []{{$f.Type}}{ {{- range $i, $d := $f.Defaults}}{{if $i}}, {{end}}{{$d}}{{end -}} }
+{{- else if $f.Pointer}}
+	x.{{$f.Name}} = new({{$f.Type}})
+	*x.{{$f.Name}} = {{index $f.Defaults 0}}
+{{- else}}
+	x.{{$f.Name}} = {{index $f.Defaults 0}}
+{{- end}}
+{{- end}}
+{{- range $p := $f.Params}}
+{{- if $f.Slice}}
+	if values, ok := r.QueryParams({{quote $p.Name}}); ok && len(values) > 0 {
+		slice := make([]{{$f.Type}}, len(values))
+{{- if parse $f.Type}}
+		for i, val := range values {
+			{{parse $f.Type}}
+			if nil != err {
+				return &binding.FieldError{Field: {{quote $p.Name}}, Source: {{quote $p.Source}}, Reason: {{if $f.Errmsg}}{{quote $f.Errmsg}}{{else}}err.Error(){{end}}, Err: err}
+			}
+			slice[i] = {{conv $f.Type "v"}}
+		}
+{{- else}}
+		copy(slice, values)
+{{- end}}
+		x.{{$f.Name}} = slice
+	}
+{{- else}}
+	if val, ok := r.{{$p.Getter}}({{quote $p.Name}}); ok {
+{{- $v := "val"}}
+{{- if parse $f.Type}}
+{{- $v = conv $f.Type "v"}}
+		{{parse $f.Type}}
+		if nil != err {
+			return &binding.FieldError{Field: {{quote $p.Name}}, Source: {{quote $p.Source}}, Reason: {{if $f.Errmsg}}{{quote $f.Errmsg}}{{else}}err.Error(){{end}}, Err: err}
+		}
+{{- end}}
+{{- if $f.Pointer}}
+		x.{{$f.Name}} = new({{$f.Type}})
+		*x.{{$f.Name}} = {{$v}}
+{{- else}}
+		x.{{$f.Name}} = {{$v}}
+{{- end}}
+	}
+{{- end}}
+{{- end}}
+	return nil
+}
+{{end}}
+{{- end}}`))
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerate(t *testing.T) {
+	src, err := os.ReadFile("testdata/request.go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	code, err := generate("request.go", src, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	const golden = "testdata/request_webgen.golden"
+	if *update {
+		if err = os.WriteFile(golden, code, 0o644); nil != err {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if string(expected) != string(code) {
+		t.Fatalf("generated code differs from %s, run `go test -update` if intended:\n%s", golden, code)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		src   string
+		names []string
+		err   string
+	}{
+		{
+			src: "package p\n\ntype R struct{ Name string `query:\"name\"` }\n",
+			err: "no struct annotated by //webgen:bind",
+		},
+		{
+			src:   "package p\n\ntype R struct{ Name string `query:\"name\"` }\n",
+			names: []string{"S"},
+			err:   "struct S not found",
+		},
+		{
+			src: "package p\n\n//webgen:bind\ntype R struct{ Tags map[string]string `query:\"tags\"` }\n",
+			err: "struct R field Tags: unsupported type",
+		},
+		{
+			src: "package p\n\ntype E struct{}\n\n//webgen:bind\ntype R struct{ E }\n",
+			err: "embedded fields are not supported",
+		},
+		{
+			src: "package p\n\n//webgen:bind\ntype R struct{ IDs []int `header:\"X-Id\"` }\n",
+			err: "slices are bound from the query params only",
+		},
+		{
+			src: "package p\n\n//webgen:bind\ntype R struct{ CN string `tlscert:\"CommonName\"` }\n",
+			err: "tlscert params are not supported",
+		},
+		{
+			src: "package p\n\n//webgen:bind\ntype R struct{ User string `ctx:\"user\"` }\n",
+			err: "ctx params are not supported",
+		},
+		{
+			src: "package p\n\n//webgen:bind\ntype R struct{ IP string `request:\"clientip\"` }\n",
+			err: "request params are not supported",
+		},
+		{
+			src: "package p\n\n//webgen:bind\ntype R struct{ Page int8 `query:\"page\" default:\"1000\"` }\n",
+			err: "invalid default \"1000\"",
+		},
+	}
+	for _, tt := range tests {
+		_, err := generate("r.go", []byte(tt.src), tt.names)
+		if nil == err || !strings.Contains(err.Error(), tt.err) {
+			t.Fatalf("expected error containing %q, got %v", tt.err, err)
+		}
+	}
+}
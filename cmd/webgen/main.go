@@ -0,0 +1,93 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Webgen generates the binding.ParamsBinder implementations of the request structs, so that
+// binding.Bind binds their path, query, header and cookie params without reflection.
+//
+// The request structs are annotated by a `//webgen:bind` comment, or named by the -type flag,
+// and the code is written into the `<file>_webgen.go` file next to the source file:
+//
+//	//go:generate go run go-spring.dev/web/cmd/webgen
+//
+//	//webgen:bind
+//	type ListTodos struct {
+//		Page   int      `query:"page" default:"1"`
+//		Status []string `query:"status"`
+//		Token  string   `header:"X-Token" errmsg:"token required"`
+//	}
+//
+// The fields of the string, bool, integer, float and time.Duration types, the pointers to them and
+// the slices of them bound from the repeated query params are supported, the structs with fields of
+// other types, e.g. nested structs or maps, are rejected so that they stay bound by reflection.
+// The fields without the path, query, header or cookie tags, e.g. the body fields, are skipped, and
+// the structs with the tlscert, ctx, request, lang, encoding, split or explode tags are rejected.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	var (
+		types  = flag.String("type", "", "comma-separated list of the struct names, the annotated structs if empty")
+		output = flag.String("output", "", "output file name, <file>_webgen.go by default")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: webgen [flags] [file]\n\nThe file defaults to $GOFILE set by go generate.\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	file := flag.Arg(0)
+	if len(file) == 0 {
+		file = os.Getenv("GOFILE")
+	}
+	if len(file) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var names []string
+	if len(*types) > 0 {
+		names = strings.Split(*types, ",")
+	}
+
+	src, err := os.ReadFile(file)
+	if nil != err {
+		fatal(err)
+	}
+	code, err := generate(file, src, names)
+	if nil != err {
+		fatal(err)
+	}
+
+	out := *output
+	if len(out) == 0 {
+		out = strings.TrimSuffix(file, filepath.Ext(file)) + "_webgen.go"
+	}
+	if err = os.WriteFile(out, code, 0o644); nil != err {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "webgen: %v\n", err)
+	os.Exit(1)
+}
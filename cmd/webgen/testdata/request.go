@@ -0,0 +1,24 @@
+package testdata
+
+import "time"
+
+//go:generate go run go-spring.dev/web/cmd/webgen
+
+//webgen:bind
+type ListTodos struct {
+	Owner   string         `path:"owner"`
+	Page    int            `query:"page" default:"1"`
+	Size    uint8          `query:"size" default:"20" errmsg:"size must be a number up to 255"`
+	Ratio   float32        `query:"ratio, r"`
+	Done    *bool          `query:"done"`
+	Status  []string       `query:"status" default:"open,closed"`
+	IDs     []int64        `query:"id"`
+	Timeout time.Duration  `header:"X-Timeout" default:"5s"`
+	Token   string         `header:"X-Token" cookie:"token"`
+	Filter  map[string]any `json:"filter"`
+	ignored string
+}
+
+type NotAnnotated struct {
+	Name string `query:"name"`
+}
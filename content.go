@@ -0,0 +1,67 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// ContentResult serves the content of an io.ReadSeeker, e.g. the videos or the large blobs read from
+// the object stores, with the byte ranges and the conditional requests handled by http.ServeContent.
+// The typed handlers return it instead of a result rendered by the Renderer, see Content.
+type ContentResult struct {
+	content     io.ReadSeeker
+	contentType string
+	modTime     time.Time
+}
+
+var contentResultType = reflect.TypeOf((*ContentResult)(nil))
+
+// Content returns the result serving the content of the type, the type is detected from the first
+// bytes of the content if empty, and the If-Modified-Since requests are checked against the modTime
+// unless it's zero. The content is closed once it's served if it implements io.Closer.
+//
+//	router.Get("/videos/{id}", func(ctx context.Context, req *VideoRequest) (*web.ContentResult, error) {
+//		obj, err := bucket.Open(ctx, req.ID)
+//		if nil != err {
+//			return nil, err
+//		}
+//		return web.Content(obj, "video/mp4", obj.ModTime()), nil
+//	})
+func Content(content io.ReadSeeker, contentType string, modTime time.Time) *ContentResult {
+	return &ContentResult{content: content, contentType: contentType, modTime: modTime}
+}
+
+// ServeHTTP serves the content, so that the plain handlers can serve it as well.
+func (c *ContentResult) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer c.Close()
+	if len(c.contentType) > 0 {
+		w.Header().Set("Content-Type", c.contentType)
+	}
+	http.ServeContent(w, r, "", c.modTime, c.content)
+}
+
+// Close closes the content if it implements io.Closer, e.g. the result isn't served on errors.
+func (c *ContentResult) Close() error {
+	if closer, ok := c.content.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type closingReader struct {
+	*bytes.Reader
+	closed int
+}
+
+func (r *closingReader) Close() error {
+	r.closed++
+	return nil
+}
+
+func TestContentResult(t *testing.T) {
+	modTime := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	var readers []*closingReader
+	open := func() *closingReader {
+		reader := &closingReader{Reader: bytes.NewReader([]byte("0123456789"))}
+		readers = append(readers, reader)
+		return reader
+	}
+
+	r := NewRouter()
+	r.Intercept(ResultCache(time.Minute))
+	r.Get("/video", func(ctx context.Context) (*ContentResult, error) {
+		return Content(open(), "video/mp4", modTime), nil
+	})
+	r.Get("/sniff", func(ctx context.Context) *ContentResult {
+		return Content(bytes.NewReader([]byte("<html><body>hi</body></html>")), "", time.Time{})
+	})
+	r.Get("/fail", func(ctx context.Context) (*ContentResult, error) {
+		return Content(open(), "video/mp4", modTime), Error(http.StatusForbidden, "denied")
+	})
+	r.HandleFunc("/plain", func(w http.ResponseWriter, req *http.Request) {
+		Content(open(), "text/plain", modTime).ServeHTTP(w, req)
+	})
+
+	// the contents are served by each request rather than cached.
+	for i := 0; i < 2; i++ {
+		resp, body := testHandler(t, r, "GET", "/video", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "video/mp4", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+		assert.Equal(t, "Mon, 01 May 2023 00:00:00 GMT", resp.Header.Get("Last-Modified"))
+		assert.Equal(t, "0123456789", body)
+	}
+	assert.Len(t, readers, 2)
+
+	req := httptest.NewRequest("GET", "/video", nil)
+	req.Header.Set("Range", "bytes=3-5")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "bytes 3-5/10", w.Header().Get("Content-Range"))
+	assert.Equal(t, "345", w.Body.String())
+
+	req = httptest.NewRequest("GET", "/video", nil)
+	req.Header.Set("If-Modified-Since", "Mon, 01 May 2023 00:00:00 GMT")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	resp, body := testHandler(t, r, "GET", "/sniff", nil)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "<html><body>hi</body></html>", body)
+
+	// the content isn't served with the error.
+	_, body = testHandler(t, r, "GET", "/fail", nil)
+	assert.Equal(t, "{\"code\":403,\"message\":\"denied\",\"data\":null}\n", body)
+
+	_, body = testHandler(t, r, "GET", "/plain", nil)
+	assert.Equal(t, "0123456789", body)
+
+	for _, reader := range readers {
+		assert.Equal(t, 1, reader.closed)
+	}
+}
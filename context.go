@@ -17,14 +17,21 @@
 package web
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"html/template"
 	"io"
+	"io/fs"
 	"mime/multipart"
 	"net"
 	"net/http"
 	"net/textproto"
 	"net/url"
+	"path"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -45,6 +52,40 @@ func FromContext(ctx context.Context) *Context {
 	return nil
 }
 
+// valueKey is the context key for request-scoped values of type T.
+type valueKey[T any] struct{}
+
+// WithValue returns a copy of parent in which the value of type T is associated with v,
+// the type itself is used as the key so values of different types never collide.
+//
+//	ctx = web.WithValue(ctx, User{ID: 1001})
+//	user, ok := web.Value[User](ctx)
+func WithValue[T any](parent context.Context, v T) context.Context {
+	return context.WithValue(parent, valueKey[T]{}, v)
+}
+
+// Value returns the value of type T associated with ctx by WithValue.
+func Value[T any](ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(valueKey[T]{}).(T)
+	return v, ok
+}
+
+// RegisterValueKey names the values of type T associated by WithValue, so that they're bound into
+// the fields of the request structs tagged by `ctx:"name"`.
+//
+//	web.RegisterValueKey[User]("user")
+//
+//	type Request struct {
+//		User User `ctx:"user"`
+//	}
+func RegisterValueKey[T any](name string) {
+	binding.RegisterContextKey(name, valueKey[T]{})
+}
+
+// Context carries the request and response writer of the current request.
+//
+// A Context and the values it refers to are only valid during the lifetime of the request,
+// use Copy to obtain a detached snapshot that can be passed to background goroutines.
 type Context struct {
 	// A ResponseWriter interface is used by an HTTP handler to
 	// construct an HTTP response.
@@ -59,6 +100,54 @@ type Context struct {
 	sameSite http.SameSite
 }
 
+// ErrDetachedContext is returned when writing the response of a copied Context.
+var ErrDetachedContext = errors.New("web: response writer is not available on a copied context")
+
+// Copy returns a detached snapshot of the context that is safe to be used outside the
+// request's lifetime, e.g. passed to audit logging or async processing goroutines.
+//
+// The snapshot keeps the request headers, path params and context values, but it is
+// not canceled when the request is done, its request body is empty and the response
+// writer refuses to write.
+func (c *Context) Copy() *Context {
+	ctx := context.WithoutCancel(c.Request.Context())
+	if rctx := FromRouteContext(ctx); nil != rctx {
+		ctx = WithRouteContext(ctx, rctx.snapshot())
+	}
+
+	cp := &Context{Writer: detachedWriter{header: c.Writer.Header().Clone()}, sameSite: c.sameSite}
+	cp.Request = c.Request.Clone(WithContext(ctx, cp))
+	cp.Request.Body = http.NoBody
+	cp.Request.MultipartForm = nil
+	return cp
+}
+
+// detachedWriter is the response writer of a copied Context.
+type detachedWriter struct {
+	header http.Header
+}
+
+func (w detachedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w detachedWriter) Write([]byte) (int, error) {
+	return 0, ErrDetachedContext
+}
+
+func (w detachedWriter) WriteHeader(int) {}
+
+// ResponseController returns a controller of the response writer, it reaches the features of
+// the underlying writer through the middleware wrappers that implement Unwrap.
+func (c *Context) ResponseController() *http.ResponseController {
+	return http.NewResponseController(c.Writer)
+}
+
+// Flush sends any buffered data of the response to the client.
+func (c *Context) Flush() error {
+	return c.ResponseController().Flush()
+}
+
 // Context returns the request's context.
 func (c *Context) Context() context.Context {
 	return c.Request.Context()
@@ -78,6 +167,12 @@ func (c *Context) Header(key string) (string, bool) {
 	return "", false
 }
 
+// Headers returns the headers of the request, bound into the map fields by the prefix
+// wildcards, e.g. `header:"X-Meta-*"`.
+func (c *Context) Headers() http.Header {
+	return c.Request.Header
+}
+
 // Cookie returns the named cookie provided in the request.
 func (c *Context) Cookie(name string) (string, bool) {
 	cookie, err := c.Request.Cookie(name)
@@ -108,6 +203,17 @@ func (c *Context) QueryParam(name string) (string, bool) {
 	return "", false
 }
 
+// QueryParams returns all the values of the named query in the request.
+func (c *Context) QueryParams(name string) ([]string, bool) {
+	values, ok := c.Request.URL.Query()[name]
+	return values, ok
+}
+
+// Query returns all the queries in the request, the map and nested fields tagged with `query` are bound from them.
+func (c *Context) Query() url.Values {
+	return c.Request.URL.Query()
+}
+
 // FormParams returns the form in the request.
 func (c *Context) FormParams() (url.Values, error) {
 	if err := c.Request.ParseForm(); nil != err {
@@ -131,11 +237,76 @@ func (c *Context) MultipartParams(maxMemory int64) (*multipart.Form, error) {
 	return c.Request.MultipartForm, nil
 }
 
+// MultipartLimits returns the limits of the multipart forms bound for the request, i.e. the limits
+// set by WithMultipartLimits on the route, then RouterOptions.MaxMultipartMemory of the router serving
+// the request, the zero fields fall back to the package-level limits of the binding package.
+func (c *Context) MultipartLimits() binding.MultipartLimits {
+	var limits binding.MultipartLimits
+	if rctx := FromRouteContext(c.Request.Context()); nil != rctx {
+		limits, _ = rctx.routeMetadata[MultipartLimitsKey].(binding.MultipartLimits)
+	}
+	if opts := routerOptionsOf(c.Request.Context()); nil != opts && limits.MaxMemory <= 0 {
+		limits.MaxMemory = opts.maxMultipartMemory
+	}
+	return limits
+}
+
+// Validator returns the validator of the request structs bound for the request, i.e. the validator
+// set by WithValidator on the route, then by Router.Validator on the router serving the request,
+// nil if neither is set, so that binding.Bind falls back to the global validator.
+func (c *Context) Validator() func(i interface{}) error {
+	return validatorOf(c.Request.Context())
+}
+
 // RequestBody returns the request body.
 func (c *Context) RequestBody() io.Reader {
 	return c.Request.Body
 }
 
+// ReplaceBody replaces the request body with the body reading it, e.g. to enforce the limits of
+// the multipart form while it's parsed, closing the request body closes the original one.
+func (c *Context) ReplaceBody(body io.Reader) {
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, c.Request.Body}
+}
+
+// ReplayBody replaces the request body read already with the body, so that it's read again from the
+// start, e.g. by the binders after the body is captured into the `body:"raw"` fields.
+func (c *Context) ReplayBody(body []byte) {
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+}
+
+// PeerCertificate returns the leaf certificate of the client verified over TLS, nil if none. The
+// certificates presented by the client aren't returned unless the server verified them, e.g. by
+// tls.RequireAndVerifyClientCert or tls.VerifyClientCertIfGiven, so the identity can't be forged.
+func (c *Context) PeerCertificate() *x509.Certificate {
+	if nil == c.Request.TLS || len(c.Request.TLS.VerifiedChains) == 0 || len(c.Request.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return c.Request.TLS.VerifiedChains[0][0]
+}
+
+// Metadata returns the metadata of the request bound into the fields tagged by `request:"name"`,
+// i.e. `clientip` by ClientIP, `method`, `host`, `path` and `requestid` by the `X-Request-Id` header.
+func (c *Context) Metadata(name string) (string, bool) {
+	switch name {
+	case "clientip":
+		return c.ClientIP(), true
+	case "method":
+		return c.Request.Method, true
+	case "host":
+		return c.Request.Host, true
+	case "path":
+		return c.Request.URL.Path, true
+	case "requestid":
+		id := c.Request.Header.Get("X-Request-Id")
+		return id, len(id) > 0
+	}
+	return "", false
+}
+
 // IsWebsocket returns true if the request headers indicate that a websocket
 // handshake is being initiated by the client.
 func (c *Context) IsWebsocket() bool {
@@ -228,10 +399,26 @@ func (c *Context) Data(code int, contentType string, data []byte) error {
 	return c.Render(code, render.BinaryRenderer{DataType: contentType, Data: data})
 }
 
-// JSON serializes the given struct as JSON into the response body.
+// JSON serializes the given struct as JSON into the response body, indented by RouterOptions.IndentJSON if set,
+// the arrays are prefixed by RouterOptions.SecureJSONPrefix if set.
 // It also sets the Content-Type as "application/json".
 func (c *Context) JSON(code int, obj interface{}) error {
-	return c.Render(code, render.JsonRenderer{Data: obj})
+	if opts := routerOptionsOf(c.Request.Context()); nil != opts && len(opts.secureJSONPrefix) > 0 {
+		return c.Render(code, render.SecureJsonRenderer{Prefix: opts.secureJSONPrefix, Indent: opts.indentJSON, Data: obj})
+	}
+	return c.Render(code, render.JsonRenderer{Data: obj, Indent: jsonIndentOf(c.Request.Context())})
+}
+
+// SecureJSON serializes the given struct as JSON into the response body, the arrays are prefixed by
+// RouterOptions.SecureJSONPrefix, or `while(1);` if not set, against the JSON hijacking.
+// It also sets the Content-Type as "application/json".
+func (c *Context) SecureJSON(code int, obj interface{}) error {
+	var secure render.SecureJsonRenderer
+	if opts := routerOptionsOf(c.Request.Context()); nil != opts {
+		secure.Prefix, secure.Indent = opts.secureJSONPrefix, opts.indentJSON
+	}
+	secure.Data = obj
+	return c.Render(code, secure)
 }
 
 // IndentedJSON serializes the given struct as pretty JSON (indented + endlines) into the response body.
@@ -240,6 +427,48 @@ func (c *Context) IndentedJSON(code int, obj interface{}) error {
 	return c.Render(code, render.JsonRenderer{Data: obj, Indent: "  "})
 }
 
+// NDJSON streams the records of a slice or a receive channel as newline delimited JSON into the response body.
+// It also sets the Content-Type as "application/x-ndjson".
+func (c *Context) NDJSON(code int, records interface{}) error {
+	return c.Render(code, render.NdjsonRenderer{Data: records})
+}
+
+// JSONStream streams the records of a slice or a receive channel as a JSON array into the response body,
+// the records are encoded incrementally and flushed in chunks, see render.JsonStreamRenderer.
+// It also sets the Content-Type as "application/json".
+func (c *Context) JSONStream(code int, records interface{}) error {
+	return c.Render(code, render.JsonStreamRenderer{Data: records, Context: c.Request.Context()})
+}
+
+// Multipart writes the parts of the multipart renderer into the response body, see render.NewMultipart.
+// It also sets the Content-Type as "multipart/<subtype>" with the boundary of the parts.
+func (c *Context) Multipart(code int, parts *render.MultipartRenderer) error {
+	return c.Render(code, parts)
+}
+
+// CSV streams the records of a slice or a receive channel of structs as CSV into the response body,
+// with a header row named by the `csv` tags of the fields, see render.CsvRenderer. The response is
+// downloaded as an attachment, set the file name by SetAttachment before.
+// It also sets the Content-Type as "text/csv".
+func (c *Context) CSV(code int, rows interface{}) error {
+	if len(c.Writer.Header().Get("Content-Disposition")) == 0 {
+		c.Writer.Header().Set("Content-Disposition", "attachment")
+	}
+	return c.Render(code, render.CsvRenderer{Data: rows, Context: c.Request.Context()})
+}
+
+// MsgPack serializes the given struct as MessagePack into the response body.
+// It also sets the Content-Type as "application/msgpack".
+func (c *Context) MsgPack(code int, obj interface{}) error {
+	return c.Render(code, render.MsgPackRenderer{Data: obj})
+}
+
+// CBOR serializes the given struct as CBOR into the response body.
+// It also sets the Content-Type as "application/cbor".
+func (c *Context) CBOR(code int, obj interface{}) error {
+	return c.Render(code, render.CBORRenderer{Data: obj})
+}
+
 // XML serializes the given struct as XML into the response body.
 // It also sets the Content-Type as "application/xml".
 func (c *Context) XML(code int, obj interface{}) error {
@@ -252,20 +481,62 @@ func (c *Context) IndentedXML(code int, obj interface{}) error {
 	return c.Render(code, render.XmlRenderer{Data: obj, Indent: "  "})
 }
 
-// File writes the specified file into the body stream in an efficient way.
+// HTML renders the named template into the response body, the localized variant of the template
+// is selected by the locale negotiated by the Locale middleware, see TemplateVariant.
+// It also sets the Content-Type as "text/html".
+func (c *Context) HTML(code int, t *template.Template, name string, data interface{}) error {
+	if locale := LocaleOf(c.Request.Context()); len(locale) > 0 {
+		name = TemplateVariant(t, name, locale)
+	}
+	return c.Render(code, render.HTMLRenderer{Template: t, Name: name, Data: data})
+}
+
+// File writes the specified file into the body stream in an efficient way, the Range and
+// If-Modified-Since requests are served as well, see http.ServeFile.
 func (c *Context) File(filepath string) {
 	http.ServeFile(c.Writer, c.Request, filepath)
 }
 
+// FileFromFS writes the named file of the file system into the body stream like File, e.g. the files
+// of an embed.FS, the name is cleaned so that it can't escape the file system. The directories and
+// the missing files are responded by the not found handler of the router.
+func (c *Context) FileFromFS(fsys fs.FS, name string) {
+	if serveFile(c.Writer, c.Request, http.FS(fsys), path.Clean("/"+name)) {
+		return
+	}
+	if rg := servingRouter(c.Request.Context()); nil != rg {
+		rg.NotFoundHandler().ServeHTTP(c.Writer, c.Request)
+		return
+	}
+	notFound().ServeHTTP(c.Writer, c.Request)
+}
+
+// Attachment writes the specified file into the body stream like File, on the client side, the file
+// will typically be downloaded with the given filename, or the base name of the file if empty.
+func (c *Context) Attachment(filepath, filename string) {
+	if 0 == len(filename) {
+		filename = path.Base(strings.ReplaceAll(filepath, "\\", "/"))
+	}
+	c.SetAttachment(filename)
+	http.ServeFile(c.Writer, c.Request, filepath)
+}
+
 // FileAttachment writes the specified file into the body stream in an efficient way
 // On the client side, the file will typically be downloaded with the given filename
+//
+// Deprecated: use Attachment instead.
 func (c *Context) FileAttachment(filepath, filename string) {
+	c.Attachment(filepath, filename)
+}
+
+// SetAttachment sets the Content-Disposition header so that the response is downloaded by the client
+// as the file named, e.g. the CSV exports.
+func (c *Context) SetAttachment(filename string) {
 	if isASCII(filename) {
 		c.Writer.Header().Set("Content-Disposition", `attachment; filename="`+escapeQuotes(filename)+`"`)
 	} else {
 		c.Writer.Header().Set("Content-Disposition", `attachment; filename*=UTF-8''`+url.QueryEscape(filename))
 	}
-	http.ServeFile(c.Writer, c.Request, filepath)
 }
 
 // RemoteIP parses the IP from Request.RemoteAddr, normalizes and returns the IP (without the port).
@@ -279,26 +550,57 @@ func (c *Context) RemoteIP() string {
 
 // ClientIP implements one best effort algorithm to return the real client IP.
 // It calls c.RemoteIP() under the hood, to check if the remote IP is a trusted proxy or not.
-// If it is it will then try to parse the headers defined in RemoteIPHeaders (defaulting to [X-Forwarded-For, X-Real-Ip]).
-// If the headers are not syntactically valid OR the remote IP does not correspond to a trusted proxy,
-// the remote IP (coming from Request.RemoteAddr) is returned.
+// If it is it will then walk the `X-Forwarded-For` addresses from right to left, skipping the trusted
+// proxies, and return the first untrusted one, since the leftmost addresses are sent by the client.
+// The `X-Real-Ip` header is used if there's no `X-Forwarded-For` header. If the headers are not
+// syntactically valid OR the remote IP does not correspond to a trusted proxy, the remote IP (coming
+// from Request.RemoteAddr) is returned.
 func (c *Context) ClientIP() string {
 	// It also checks if the remoteIP is a trusted proxy or not.
 	// In order to perform this validation, it will see if the IP is contained within at least one of the CIDR blocks
-	// defined by Engine.SetTrustedProxies()
+	// defined by RouterOptions.TrustedProxies.
 	remoteIP := net.ParseIP(c.RemoteIP())
 	if remoteIP == nil {
 		return ""
 	}
 
-	for _, headerName := range []string{"X-Forwarded-For", "X-Real-Ip"} {
-		if ns := strings.Split(c.Request.Header.Get(headerName), ","); len(ns) > 0 && len(ns[0]) > 0 {
-			return ns[0]
+	// the forwarding headers are ignored unless the remote IP is one of RouterOptions.TrustedProxies.
+	opts := routerOptionsOf(c.Request.Context())
+	if !opts.trusts(remoteIP.String()) {
+		return remoteIP.String()
+	}
+
+	if forwarded := c.Request.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		if ip, ok := forwardedClientIP(forwarded, opts); ok {
+			return ip
 		}
+		return remoteIP.String()
+	}
+	if ip := strings.TrimSpace(c.Request.Header.Get("X-Real-Ip")); nil != net.ParseIP(ip) {
+		return ip
 	}
 	return remoteIP.String()
 }
 
+// forwardedClientIP returns the rightmost address of the `X-Forwarded-For` values which isn't a trusted
+// proxy, or the leftmost address if all of them are trusted, reports false if an address is invalid.
+func forwardedClientIP(values []string, opts *routerOptions) (string, bool) {
+	var addrs []string
+	for _, value := range values {
+		addrs = append(addrs, strings.Split(value, ",")...)
+	}
+	for i := len(addrs) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(addrs[i])
+		if nil == net.ParseIP(addr) {
+			return "", false
+		}
+		if 0 == i || !opts.trusts(addr) {
+			return addr, true
+		}
+	}
+	return "", false
+}
+
 type routeContextKey struct{}
 
 func WithRouteContext(parent context.Context, ctx *RouteContext) context.Context {
@@ -312,6 +614,25 @@ func FromRouteContext(ctx context.Context) *RouteContext {
 	return nil
 }
 
+// RoutePattern returns the full routing pattern that matched the request, e.g. `/api/v1/todos/{id}`,
+// so that the metrics and access logs can use low-cardinality labels instead of the raw URLs.
+// The middlewares of the router run before the routing, they read the pattern after calling the
+// next handler, while the inline middlewares of the route can read it before:
+//
+//	router.Use(func(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			start := time.Now()
+//			next.ServeHTTP(w, r)
+//			requestDuration.WithLabelValues(r.Method, web.RoutePattern(r)).Observe(time.Since(start).Seconds())
+//		})
+//	})
+func RoutePattern(r *http.Request) string {
+	if rctx := FromRouteContext(r.Context()); nil != rctx {
+		return rctx.MatchedPattern()
+	}
+	return ""
+}
+
 type RouteContext struct {
 	Routes Routes
 	// URLParams are the stack of routeParams captured during the
@@ -333,18 +654,72 @@ type RouteContext struct {
 	RoutePattern  string
 	routePatterns []string
 
+	// The metadata attached to the matched route.
+	routeMetadata Metadata
+
+	// The router whose routing tree routed the request, set for the unmatched requests as well.
+	router *routerGroup
+
+	// The bytes accounting of the request and response body.
+	body      countingBody
+	writer    countingWriter
+	optionals optionalWriters
+
 	methodsAllowed   []methodTyp
 	methodNotAllowed bool
 }
 
-// AllowedMethods report allowed http methods.
+// AllowedMethods report allowed http methods of the matched route,
+// it is populated when the request method does not match the route.
 func (c *RouteContext) AllowedMethods() (methods []string) {
+	var seen methodTyp
 	for _, m := range c.methodsAllowed {
-		methods = append(methods, methodTypString(m))
+		if seen&m == m {
+			continue
+		}
+		seen |= m
+		if s, ok := reverseMethodMap[m]; ok {
+			methods = append(methods, s)
+		}
 	}
+	sort.Strings(methods)
 	return
 }
 
+// Metadata returns the metadata attached to the matched route.
+func (c *RouteContext) Metadata() Metadata {
+	return c.routeMetadata
+}
+
+// MatchedPattern returns the full routing pattern that matched the request, the patterns of the
+// mounted subrouters are joined with the mount prefixes flattened as Walk does, e.g. `/api/todos/{id}`.
+// It returns an empty string until the request is routed, or if no route matches.
+func (c *RouteContext) MatchedPattern() string {
+	pattern := strings.Join(c.routePatterns, "")
+	for strings.Contains(pattern, "/*/") {
+		pattern = strings.Replace(pattern, "/*/", "/", -1)
+	}
+	// the root route of the subrouter mounted on the `/prefix/` route.
+	return strings.Replace(pattern, "//", "/", -1)
+}
+
+// snapshot returns a copy of the route context that is not recycled after the request.
+func (c *RouteContext) snapshot() *RouteContext {
+	return &RouteContext{
+		Routes: c.Routes,
+		URLParams: RouteParams{
+			Keys:   append([]string(nil), c.URLParams.Keys...),
+			Values: append([]string(nil), c.URLParams.Values...),
+		},
+		RoutePath:     c.RoutePath,
+		RouteMethod:   c.RouteMethod,
+		RoutePattern:  c.RoutePattern,
+		routePatterns: append([]string(nil), c.routePatterns...),
+		routeMetadata: c.routeMetadata,
+		router:        c.router,
+	}
+}
+
 // Reset context to initial state
 func (c *RouteContext) Reset() {
 	c.Routes = nil
@@ -352,12 +727,19 @@ func (c *RouteContext) Reset() {
 	c.RouteMethod = ""
 	c.RoutePattern = ""
 	c.routePatterns = c.routePatterns[:0]
+	c.routeMetadata = nil
+	c.router = nil
 	c.URLParams.Keys = c.URLParams.Keys[:0]
 	c.URLParams.Values = c.URLParams.Values[:0]
 	c.routeParams.Keys = c.routeParams.Keys[:0]
 	c.routeParams.Values = c.routeParams.Values[:0]
 	c.methodNotAllowed = false
 	c.methodsAllowed = c.methodsAllowed[:0]
+	c.body.ReadCloser = nil
+	c.body.n.Store(0)
+	c.writer.ResponseWriter = nil
+	c.writer.n.Store(0)
+	c.optionals = optionalWriters{}
 }
 
 // RouteParams is a structure to track URL routing parameters efficiently.
@@ -415,6 +797,16 @@ func notFound() http.Handler {
 
 func notAllowed() http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		setAllowHeader(writer, request)
 		http.Error(writer, "405 method not allowed", http.StatusMethodNotAllowed)
 	})
 }
+
+// setAllowHeader sets the allowed methods of the matched route to the `Allow` header.
+func setAllowHeader(writer http.ResponseWriter, request *http.Request) {
+	if ctx := FromRouteContext(request.Context()); nil != ctx {
+		if methods := ctx.AllowedMethods(); len(methods) > 0 {
+			writer.Header().Set("Allow", strings.Join(methods, ", "))
+		}
+	}
+}
@@ -2,12 +2,20 @@ package web
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go-spring.dev/web/render"
@@ -270,6 +278,105 @@ func TestContext_XMLRender(t *testing.T) {
 	assert.Equal(t, "<string>go-spring</string>", response.Body.String())
 }
 
+func TestContext_CSVRender(t *testing.T) {
+	type Order struct {
+		ID    int64   `csv:"id"`
+		Total float64 `csv:"total"`
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/orders.csv", nil)
+	response := httptest.NewRecorder()
+	webCtx := &Context{Request: request, Writer: response}
+
+	err := webCtx.CSV(200, []Order{{ID: 1, Total: 9.5}, {ID: 2, Total: 10}})
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", response.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment", response.Header().Get("Content-Disposition"))
+	assert.Equal(t, "id,total\n1,9.5\n2,10\n", response.Body.String())
+
+	response = httptest.NewRecorder()
+	webCtx = &Context{Request: request, Writer: response}
+	webCtx.SetAttachment("订单.csv")
+	assert.NoError(t, webCtx.CSV(200, []Order{}))
+	assert.Equal(t, "attachment; filename*=UTF-8''%E8%AE%A2%E5%8D%95.csv", response.Header().Get("Content-Disposition"))
+	assert.Equal(t, "id,total\n", response.Body.String())
+}
+
+func TestContext_File(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "report.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("0123456789"), 0o644))
+	fsys := fstest.MapFS{
+		"docs/guide.txt": {Data: []byte("guide"), ModTime: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	r := NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) { http.Error(w, "no such file", http.StatusNotFound) })
+	r.Get("/file", func(ctx context.Context) { FromContext(ctx).File(file) })
+	r.Get("/fs/*", func(ctx context.Context) {
+		name, _ := FromContext(ctx).PathParam("*")
+		FromContext(ctx).FileFromFS(fsys, name)
+	})
+	r.Get("/download", func(ctx context.Context) { FromContext(ctx).Attachment(file, "") })
+	r.Get("/download/{name}", func(ctx context.Context) {
+		name, _ := FromContext(ctx).PathParam("name")
+		FromContext(ctx).Attachment(file, name)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/file", nil)
+	req.Header.Set("Range", "bytes=2-4")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "234", w.Body.String())
+
+	resp, body := testHandler(t, r, "GET", "/fs/docs/guide.txt", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "guide", body)
+
+	req = httptest.NewRequest(http.MethodGet, "/fs/docs/guide.txt", nil)
+	req.Header.Set("If-Modified-Since", "Mon, 01 May 2023 00:00:00 GMT")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	for _, name := range []string{"/fs/docs", "/fs/missing.txt"} {
+		resp, body = testHandler(t, r, "GET", name, nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, name)
+		assert.Equal(t, "no such file\n", body, name)
+	}
+
+	resp, body = testHandler(t, r, "GET", "/download", nil)
+	assert.Equal(t, `attachment; filename="report.txt"`, resp.Header.Get("Content-Disposition"))
+	assert.Equal(t, "0123456789", body)
+
+	resp, _ = testHandler(t, r, "GET", "/download/"+url.PathEscape("报告.txt"), nil)
+	assert.Equal(t, "attachment; filename*=UTF-8''%E6%8A%A5%E5%91%8A.txt", resp.Header.Get("Content-Disposition"))
+}
+
+func TestContext_PeerCertificate(t *testing.T) {
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}}
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	webCtx := &Context{Request: request, Writer: httptest.NewRecorder()}
+
+	var params struct {
+		CN string `tlscert:"CommonName"`
+	}
+
+	// the certificates presented by the client aren't trusted unless they're verified.
+	request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}
+	assert.Nil(t, webCtx.PeerCertificate())
+	assert.NoError(t, webCtx.Bind(&params))
+	assert.Equal(t, "", params.CN)
+
+	request.TLS.VerifiedChains = [][]*x509.Certificate{{leaf, {Subject: pkix.Name{CommonName: "ca"}}}}
+	assert.Same(t, leaf, webCtx.PeerCertificate())
+	assert.NoError(t, webCtx.Bind(&params))
+	assert.Equal(t, "alice", params.CN)
+}
+
 func TestContext_RemoteIP(t *testing.T) {
 	request := httptest.NewRequest(http.MethodPost, "/endpoint", nil)
 	request.RemoteAddr = "192.168.1.100:5432"
@@ -286,3 +393,204 @@ func TestContext_ClientIP(t *testing.T) {
 	webCtx := &Context{Request: request, Writer: response}
 	assert.Equal(t, "192.168.1.111", webCtx.ClientIP())
 }
+
+func TestContext_Value(t *testing.T) {
+	type User struct {
+		Name string
+	}
+	type Tenant string
+
+	var router = NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithValue(r.Context(), User{Name: "alice"})
+			ctx = WithValue(ctx, Tenant("acme"))
+			ctx = WithValue(ctx, "plain string")
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	router.Get("/", func(ctx context.Context) string {
+		user, ok := Value[User](ctx)
+		assert.True(t, ok)
+		tenant, _ := Value[Tenant](ctx)
+		str, _ := Value[string](ctx)
+		_, ok = Value[*User](ctx)
+		assert.False(t, ok)
+		return user.Name + "@" + string(tenant) + "/" + str
+	})
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "{\"code\":0,\"data\":\"alice@acme/plain string\"}\n", response.Body.String())
+}
+
+func TestContext_Copy(t *testing.T) {
+	type Tenant string
+
+	done := make(chan string, 1)
+
+	var router = NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithValue(r.Context(), Tenant("acme"))))
+		})
+	})
+	router.Get("/users/{id}", func(ctx context.Context) {
+		webCtx := FromContext(ctx)
+		cp := webCtx.Copy()
+
+		go func() {
+			// wait until the request is done and the route context is recycled.
+			<-webCtx.Context().Done()
+
+			id, _ := cp.PathParam("id")
+			token, _ := cp.Header("X-Token")
+			tenant, _ := Value[Tenant](cp.Context())
+			_, err := cp.Writer.Write([]byte("late"))
+			done <- fmt.Sprintf("%s,%s,%s,%v,%v", id, token, tenant, cp.Context().Err(), err)
+		}()
+
+		assert.Same(t, cp, FromContext(cp.Context()))
+		assert.Nil(t, cp.Context().Err())
+	})
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, ts.URL+"/users/1001", nil)
+	request.Header.Set("X-Token", "secret")
+	response, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, "1001,secret,acme,<nil>,"+ErrDetachedContext.Error(), <-done)
+}
+
+func TestContextResponseController(t *testing.T) {
+	r := NewRouter()
+	r.Use(MaxBytes(1<<20, 1<<20), GetHead())
+	r.Get("/stream", func(ctx context.Context) {
+		webCtx := FromContext(ctx)
+		assert.Nil(t, webCtx.ResponseController().SetWriteDeadline(time.Now().Add(time.Second)))
+		assert.Nil(t, webCtx.ResponseController().EnableFullDuplex())
+		_, _ = webCtx.Writer.Write([]byte("chunk"))
+		assert.Nil(t, webCtx.Flush())
+	})
+	r.Get("/hijack", func(ctx context.Context) {
+		conn, rw, err := FromContext(ctx).ResponseController().Hijack()
+		assert.Nil(t, err)
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n")
+		_ = rw.Flush()
+	})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		req, _ := http.NewRequest(method, ts.URL+"/stream", nil)
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	resp, err := http.Get(ts.URL + "/hijack")
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestRoutePattern(t *testing.T) {
+	var patterns []string
+	r := NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			patterns = append(patterns, "before:"+RoutePattern(r))
+			next.ServeHTTP(w, r)
+			patterns = append(patterns, "after:"+RoutePattern(r))
+		})
+	})
+	r.Group("/api/v1", func(r Router) {
+		r.With(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				patterns = append(patterns, "inline:"+RoutePattern(r))
+				next.ServeHTTP(w, r)
+			})
+		}).Get("/todos/{id}", func(ctx context.Context) {})
+		r.Get("/", func(ctx context.Context) {})
+	})
+
+	testHandler(t, r, "GET", "/api/v1/todos/42", nil)
+	assert.Equal(t, []string{"before:", "inline:/api/v1/todos/{id}", "after:/api/v1/todos/{id}"}, patterns)
+
+	patterns = nil
+	testHandler(t, r, "GET", "/api/v1/", nil)
+	assert.Equal(t, []string{"before:", "after:/api/v1/"}, patterns)
+
+	patterns = nil
+	testHandler(t, r, "GET", "/missing", nil)
+	assert.Equal(t, []string{"before:", "after:"}, patterns)
+
+	assert.Equal(t, "", RoutePattern(httptest.NewRequest("GET", "/", nil)))
+}
+
+func TestContext_BindContextValues(t *testing.T) {
+	type account struct {
+		ID int
+	}
+	RegisterValueKey[account]("account")
+
+	type Param struct {
+		Locale  string  `ctx:"locale"`
+		Account account `ctx:"account"`
+		Tenant  *int64  `ctx:"tenant"`
+		Missing string  `ctx:"missing" default:"none"`
+	}
+
+	r := NewRouter()
+	r.Use(Locale("en", "fr"), func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithValue(r.Context(), account{ID: 7})
+			next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, "tenant", "42")))
+		})
+	})
+	r.Get("/", func(ctx context.Context, p Param) string {
+		return fmt.Sprintf("%s %d %d %s", p.Locale, p.Account.ID, *p.Tenant, p.Missing)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Accept-Language", "fr")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `fr 7 42 none`)
+}
+
+func TestContext_Metadata(t *testing.T) {
+	type Param struct {
+		ClientIP  string `request:"clientip"`
+		Method    string `request:"method"`
+		Host      string `request:"host"`
+		Path      string `request:"path"`
+		RequestID string `request:"requestid"`
+	}
+
+	r := NewRouter()
+	r.Post("/audit/{id}", func(ctx context.Context, p Param) Param { return p })
+
+	request := httptest.NewRequest(http.MethodPost, "http://example.com/audit/1?x=y", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-For", "203.0.113.7")
+	request.Header.Set("X-Request-Id", "req-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"code":0,"data":{"ClientIP":"203.0.113.7","Method":"POST","Host":"example.com","Path":"/audit/1","RequestID":"req-1"}}`, w.Body.String())
+
+	webCtx := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	_, ok := webCtx.Metadata("requestid")
+	assert.False(t, ok)
+	_, ok = webCtx.Metadata("unknown")
+	assert.False(t, ok)
+}
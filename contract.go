@@ -0,0 +1,318 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ContractKey is the route metadata key of the request and response contract of the route.
+const ContractKey = "web.contract"
+
+// Contract is the request and response contract of a route, i.e. the JSON schemas and the example
+// payloads declared by RequestSchema and ResponseSchema, it's verified against the recorded test
+// traffic by ContractRecorder to catch the accidental contract drifts.
+type Contract struct {
+	// Request is the payload of the request body.
+	Request *ContractPayload
+
+	// Responses are the payloads of the response body by status code,
+	// the status code 0 is the payload of the undeclared status codes.
+	Responses map[int]*ContractPayload
+}
+
+// ContractPayload is the JSON schema and the examples of a request or response body.
+type ContractPayload struct {
+	Schema   *JSONSchema
+	Examples []json.RawMessage
+}
+
+// ContractOf returns the contract in the route metadata, or nil if the route declares none.
+func ContractOf(meta Metadata) *Contract {
+	contract, _ := meta[ContractKey].(*Contract)
+	return contract
+}
+
+// RequestSchema declares the JSON schema of the request body of the route, the examples are
+// encoded as JSON, a json.RawMessage example is kept as it is.
+//
+//	router.Post("/users", CreateUser).Apply(
+//		web.RequestSchema(`{"type":"object","required":["name"]}`, User{Name: "foo"}),
+//		web.ResponseSchema(http.StatusCreated, `{"type":"object","required":["id"]}`),
+//	)
+func RequestSchema(schema string, examples ...interface{}) RouteOption {
+	payload := newContractPayload(schema, examples)
+	return func(e Endpoint) {
+		contract := ContractOf(e.Metadata()).clone()
+		contract.Request = payload
+		e.Meta(ContractKey, contract)
+	}
+}
+
+// ResponseSchema declares the JSON schema of the response body of the route with the status code,
+// the status code 0 declares the schema of the undeclared status codes, see RequestSchema.
+func ResponseSchema(status int, schema string, examples ...interface{}) RouteOption {
+	payload := newContractPayload(schema, examples)
+	return func(e Endpoint) {
+		contract := ContractOf(e.Metadata()).clone()
+		contract.Responses[status] = payload
+		e.Meta(ContractKey, contract)
+	}
+}
+
+func newContractPayload(schema string, examples []interface{}) *ContractPayload {
+	s, err := ParseJSONSchema(schema)
+	if nil != err {
+		panic(err.Error())
+	}
+	payload := &ContractPayload{Schema: s}
+	for _, example := range examples {
+		data, err := json.Marshal(example)
+		if nil != err {
+			panic(fmt.Sprintf("invalid contract example: %v", err))
+		}
+		payload.Examples = append(payload.Examples, data)
+	}
+	return payload
+}
+
+// clone returns a copy of the contract, the metadata may be read by the requests in the dynamic mode.
+func (c *Contract) clone() *Contract {
+	contract := &Contract{Responses: map[int]*ContractPayload{}}
+	if nil != c {
+		contract.Request = c.Request
+		for status, payload := range c.Responses {
+			contract.Responses[status] = payload
+		}
+	}
+	return contract
+}
+
+// ValidateRequest validates the request body against the request schema.
+func (c *Contract) ValidateRequest(body []byte) error {
+	if nil == c.Request {
+		return nil
+	}
+	return c.Request.Schema.Validate(body)
+}
+
+// ValidateResponse validates the response body against the schema of the status code, the
+// undeclared status codes are violations if the contract declares any response schema.
+func (c *Contract) ValidateResponse(status int, body []byte) error {
+	if 0 == len(c.Responses) {
+		return nil
+	}
+	payload, ok := c.Responses[status]
+	if !ok {
+		if payload, ok = c.Responses[0]; !ok {
+			return fmt.Errorf("undeclared status %d", status)
+		}
+	}
+	if !bodyAllowedForStatus(status) {
+		return nil
+	}
+	return payload.Schema.Validate(body)
+}
+
+// VerifyExamples validates the examples against the schemas they're declared with.
+func (c *Contract) VerifyExamples() error {
+	var errs []error
+	if nil != c.Request {
+		for i, example := range c.Request.Examples {
+			if err := c.Request.Schema.Validate(example); nil != err {
+				errs = append(errs, fmt.Errorf("request example %d: %w", i, err))
+			}
+		}
+	}
+	statuses := make([]int, 0, len(c.Responses))
+	for status := range c.Responses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		payload := c.Responses[status]
+		for i, example := range payload.Examples {
+			if err := payload.Schema.Validate(example); nil != err {
+				errs = append(errs, fmt.Errorf("response %d example %d: %w", status, i, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// VerifyContractExamples validates the examples of the contracts of all the routes, including
+// the routes of the mounted subrouters, against the schemas they're declared with.
+func VerifyContractExamples(r Routes) error {
+	var errs []error
+	verifyContractExamples(r, "", &errs)
+	return errors.Join(errs...)
+}
+
+func verifyContractExamples(r Routes, parentRoute string, errs *[]error) {
+	for _, route := range r.Routes() {
+		pattern := strings.Replace(parentRoute+route.Pattern, "/*/", "/", -1)
+		if nil != route.SubRoutes {
+			verifyContractExamples(route.SubRoutes, pattern, errs)
+			continue
+		}
+		methods := make([]string, 0, len(route.Metadata))
+		for method := range route.Metadata {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			if contract := ContractOf(route.Metadata[method]); nil != contract {
+				if err := contract.VerifyExamples(); nil != err {
+					*errs = append(*errs, fmt.Errorf("%s %s: %w", method, pattern, err))
+				}
+			}
+		}
+	}
+}
+
+// ContractViolation is a recorded request or response that violates the contract of its route.
+type ContractViolation struct {
+	Method  string
+	Pattern string
+
+	// Status is the status code of the violating response, or 0 for a violating request.
+	Status int
+	Err    error
+}
+
+func (v ContractViolation) Error() string {
+	if 0 == v.Status {
+		return fmt.Sprintf("%s %s request: %v", v.Method, v.Pattern, v.Err)
+	}
+	return fmt.Sprintf("%s %s response %d: %v", v.Method, v.Pattern, v.Status, v.Err)
+}
+
+// ContractRecorder validates the test traffic of the routes against their contracts, the routes
+// without a contract are ignored. It buffers the whole request and response bodies, so it's meant
+// for the tests rather than the production servers.
+//
+//	recorder := &web.ContractRecorder{}
+//	router.Use(recorder.Middleware())
+//	// ... sends the test requests
+//	recorder.Verify(t)
+type ContractRecorder struct {
+	mu         sync.Mutex
+	violations []ContractViolation
+}
+
+// Middleware returns the middleware recording the traffic, it must be used by the router
+// or the groups of the routes so that the matched routes are known after the handlers return.
+func (c *ContractRecorder) Middleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body []byte
+			if nil != r.Body && http.NoBody != r.Body {
+				body, _ = io.ReadAll(r.Body)
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{bytes.NewReader(body), r.Body}
+			}
+
+			writer := &contractWriter{ResponseWriter: w}
+			next.ServeHTTP(writer, r)
+
+			ctx := FromRouteContext(r.Context())
+			if nil == ctx {
+				return
+			}
+			contract := ContractOf(ctx.routeMetadata)
+			if nil == contract {
+				return
+			}
+
+			method, pattern := r.Method, ctx.MatchedPattern()
+			if err := contract.ValidateRequest(body); nil != err {
+				c.record(ContractViolation{Method: method, Pattern: pattern, Err: err})
+			}
+			status := writer.status
+			if 0 == status {
+				status = http.StatusOK
+			}
+			if err := contract.ValidateResponse(status, writer.body.Bytes()); nil != err {
+				c.record(ContractViolation{Method: method, Pattern: pattern, Status: status, Err: err})
+			}
+		})
+	}
+}
+
+func (c *ContractRecorder) record(violation ContractViolation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.violations = append(c.violations, violation)
+}
+
+// Violations returns the recorded violations in the order they're recorded.
+func (c *ContractRecorder) Violations() []ContractViolation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ContractViolation(nil), c.violations...)
+}
+
+// Verify reports the recorded violations as the errors of the test, t is usually a *testing.T.
+func (c *ContractRecorder) Verify(t interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}) {
+	t.Helper()
+	for _, violation := range c.Violations() {
+		t.Errorf("contract violation: %v", violation)
+	}
+}
+
+// contractWriter copies the response body written through it.
+type contractWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *contractWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *contractWriter) WriteHeader(code int) {
+	if 0 == w.status && (code < 100 || code > 199) {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *contractWriter) Write(p []byte) (int, error) {
+	if 0 == w.status {
+		w.status = http.StatusOK
+	}
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *contractWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
@@ -0,0 +1,87 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type contractT struct {
+	errors []string
+}
+
+func (t *contractT) Helper() {}
+
+func (t *contractT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestContractRecorder(t *testing.T) {
+	recorder := &ContractRecorder{}
+	router := NewRouter()
+	router.Use(recorder.Middleware())
+
+	router.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		var user map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&user)
+		if "drift" == user["name"] {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"1"}`))
+			return
+		}
+		if nil == user["name"] {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"name required"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}).Apply(
+		RequestSchema(`{"type":"object","required":["name"]}`, map[string]string{"name": "foo"}),
+		ResponseSchema(http.StatusCreated, `{"type":"object","properties":{"id":{"type":"integer"}}}`, json.RawMessage(`{"id":1}`)),
+	)
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	sub := NewRouter()
+	sub.Delete("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Apply(ResponseSchema(http.StatusNoContent, `false`))
+	router.Mount("/api", sub)
+
+	send := func(method, path, body string) {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	send(http.MethodPost, "/users", `{"name":"foo"}`)
+	send(http.MethodGet, "/health", ``)
+	send(http.MethodDelete, "/api/items/1", ``)
+	assert.Empty(t, recorder.Violations())
+
+	send(http.MethodPost, "/users", `{"name":"drift"}`)
+	send(http.MethodPost, "/users", `{}`)
+	violations := recorder.Violations()
+	if assert.Len(t, violations, 3) {
+		assert.Equal(t, `POST /users response 201: #/id: expected integer, got string`, violations[0].Error())
+		assert.Equal(t, `POST /users request: #: missing required property "name"`, violations[1].Error())
+		assert.Equal(t, `POST /users response 400: undeclared status 400`, violations[2].Error())
+	}
+
+	ct := &contractT{}
+	recorder.Verify(ct)
+	assert.Len(t, ct.errors, 3)
+	assert.Equal(t, `contract violation: POST /users response 201: #/id: expected integer, got string`, ct.errors[0])
+
+	assert.Nil(t, VerifyContractExamples(router))
+	router.Get("/bad", func(w http.ResponseWriter, r *http.Request) {}).
+		Apply(ResponseSchema(0, `{"type":"array"}`, "oops"))
+	assert.EqualError(t, VerifyContractExamples(router), "GET /bad: response 0 example 0: #: expected array, got string")
+
+	assert.Panics(t, func() { RequestSchema(`{`) })
+	assert.Panics(t, func() { RequestSchema(`{}`, func() {}) })
+}
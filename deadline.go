@@ -0,0 +1,184 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDeadlineHeader is the default header carrying the request timeout budget.
+const DefaultDeadlineHeader = "X-Request-Timeout"
+
+// DeadlineOptions configures the Deadline middleware.
+type DeadlineOptions struct {
+	// Header is the name of header carrying the timeout budget of the caller,
+	// defaults to `X-Request-Timeout`.
+	//
+	// The value is a Go duration string (e.g. `1.5s`, `300ms`) or an integer number of
+	// milliseconds, when the header is `grpc-timeout` the gRPC format is used instead
+	// (e.g. `100m` for 100 milliseconds, `2S` for 2 seconds).
+	Header string
+
+	// Max caps the budget accepted from callers, zero means no cap.
+	Max time.Duration
+
+	// Trusted reports whether the budget of the caller is trusted, e.g. of the gateways or the
+	// internal services in front of the service. If nil, only the budgets sent from the remote IPs
+	// of RouterOptions.TrustedProxies are trusted, and none if the router has no trusted proxies,
+	// since the header is set by the client otherwise.
+	Trusted func(r *http.Request) bool
+}
+
+// Deadline returns a middleware that applies the timeout budget propagated by the callers
+// to the request context, so that downstream calls respect the budget of the whole chain.
+// The budget only ever shortens the request deadline, missing or invalid values are ignored.
+func Deadline(options DeadlineOptions) MiddlewareFunc {
+	header := options.Header
+	if 0 == len(header) {
+		header = DefaultDeadlineHeader
+	}
+
+	trusted := options.Trusted
+	if nil == trusted {
+		trusted = trustedProxyRequest
+	}
+
+	parse := parseTimeout
+	if strings.EqualFold(header, "grpc-timeout") {
+		parse = parseGrpcTimeout
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			value := request.Header.Get(header)
+			if 0 == len(value) || !trusted(request) {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			budget, err := parse(value)
+			if nil != err {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			if options.Max > 0 && budget > options.Max {
+				budget = options.Max
+			}
+
+			ctx, cancel := context.WithTimeout(request.Context(), budget)
+			defer cancel()
+
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
+
+// trustedProxyRequest reports whether the request is sent from one of RouterOptions.TrustedProxies
+// of the router serving it, false if the router has no trusted proxies.
+func trustedProxyRequest(request *http.Request) bool {
+	opts := routerOptionsOf(request.Context())
+	if nil == opts || nil == opts.trustedProxies {
+		return false
+	}
+	host, _, err := net.SplitHostPort(strings.TrimSpace(request.RemoteAddr))
+	if nil != err {
+		return false
+	}
+	return opts.trusts(host)
+}
+
+// PropagateDeadline sets the remaining budget of the request context deadline
+// to the `X-Request-Timeout` header of the outgoing request.
+func PropagateDeadline(outgoing *http.Request) {
+	if deadline, ok := outgoing.Context().Deadline(); ok {
+		budget := time.Until(deadline)
+		if budget < 0 {
+			budget = 0
+		}
+		outgoing.Header.Set(DefaultDeadlineHeader, strconv.FormatInt(budget.Milliseconds(), 10))
+	}
+}
+
+// maxTimeout is the largest timeout, the larger budgets are clamped to it instead of overflowing.
+const maxTimeout = time.Duration(math.MaxInt64)
+
+// scaleTimeout returns n units, clamped to maxTimeout.
+func scaleTimeout(n uint64, unit time.Duration) time.Duration {
+	if n > uint64(maxTimeout/unit) {
+		return maxTimeout
+	}
+	return time.Duration(n) * unit
+}
+
+// parseTimeout parses a Go duration string or an integer number of milliseconds.
+func parseTimeout(value string) (time.Duration, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); nil == err {
+		if ms < 0 {
+			return 0, fmt.Errorf("invalid timeout: %s", value)
+		}
+		return scaleTimeout(uint64(ms), time.Millisecond), nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if nil != err {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid timeout: %s", value)
+	}
+	return d, nil
+}
+
+// parseGrpcTimeout parses the gRPC timeout format: an integer followed by a unit,
+// H(hours), M(minutes), S(seconds), m(milliseconds), u(microseconds), n(nanoseconds).
+func parseGrpcTimeout(value string) (time.Duration, error) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, fmt.Errorf("invalid grpc timeout: %s", value)
+	}
+
+	var unit time.Duration
+	switch value[len(value)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, fmt.Errorf("invalid grpc timeout unit: %s", value)
+	}
+
+	n, err := strconv.ParseUint(value[:len(value)-1], 10, 64)
+	if nil != err {
+		return 0, fmt.Errorf("invalid grpc timeout: %s", value)
+	}
+	return scaleTimeout(n, unit), nil
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadline(t *testing.T) {
+	var remaining time.Duration
+	var hasDeadline bool
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var deadline time.Time
+		deadline, hasDeadline = r.Context().Deadline()
+		remaining = time.Until(deadline)
+	})
+
+	serve := func(mw MiddlewareFunc, header, value string) {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(value) > 0 {
+			request.Header.Set(header, value)
+		}
+		mw(handler).ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	trusted := func(r *http.Request) bool { return true }
+	mw := Deadline(DeadlineOptions{Max: 10 * time.Second, Trusted: trusted})
+
+	serve(mw, DefaultDeadlineHeader, "")
+	assert.False(t, hasDeadline)
+
+	serve(mw, DefaultDeadlineHeader, "invalid")
+	assert.False(t, hasDeadline)
+
+	serve(mw, DefaultDeadlineHeader, "1500")
+	assert.True(t, hasDeadline)
+	assert.InDelta(t, 1500*time.Millisecond, remaining, float64(100*time.Millisecond))
+
+	serve(mw, DefaultDeadlineHeader, "2s")
+	assert.InDelta(t, 2*time.Second, remaining, float64(100*time.Millisecond))
+
+	serve(mw, DefaultDeadlineHeader, "1h")
+	assert.InDelta(t, 10*time.Second, remaining, float64(100*time.Millisecond))
+
+	grpc := Deadline(DeadlineOptions{Header: "grpc-timeout", Trusted: trusted})
+	serve(grpc, "Grpc-Timeout", "300m")
+	assert.InDelta(t, 300*time.Millisecond, remaining, float64(100*time.Millisecond))
+
+	serve(Deadline(DeadlineOptions{Trusted: trusted}), DefaultDeadlineHeader, "9223372036854775807")
+	assert.True(t, hasDeadline)
+	assert.Greater(t, remaining, time.Hour)
+
+	untrusted := Deadline(DeadlineOptions{Trusted: func(r *http.Request) bool { return false }})
+	serve(untrusted, DefaultDeadlineHeader, "1s")
+	assert.False(t, hasDeadline)
+
+	// nobody is trusted by default, except the trusted proxies of the router.
+	serve(Deadline(DeadlineOptions{}), DefaultDeadlineHeader, "1s")
+	assert.False(t, hasDeadline)
+
+	for remoteAddr, expected := range map[string]bool{"10.0.0.1:1234": true, "192.0.2.1:1234": false} {
+		r := NewRouterWith(RouterOptions{TrustedProxies: []string{"10.0.0.0/8"}})
+		r.Use(Deadline(DeadlineOptions{}))
+		r.Get("/", handler)
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.RemoteAddr = remoteAddr
+		request.Header.Set(DefaultDeadlineHeader, "1s")
+		r.ServeHTTP(httptest.NewRecorder(), request)
+		assert.Equal(t, expected, hasDeadline, remoteAddr)
+	}
+}
+
+func TestPropagateDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	outgoing, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1/", nil)
+	PropagateDeadline(outgoing)
+
+	budget, err := parseTimeout(outgoing.Header.Get(DefaultDeadlineHeader))
+	assert.Nil(t, err)
+	assert.InDelta(t, 2*time.Second, budget, float64(100*time.Millisecond))
+}
+
+func TestParseGrpcTimeout(t *testing.T) {
+	var cases = []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"1H", time.Hour},
+		{"2M", 2 * time.Minute},
+		{"3S", 3 * time.Second},
+		{"4m", 4 * time.Millisecond},
+		{"5u", 5 * time.Microsecond},
+		{"6n", 6 * time.Nanosecond},
+		// the budgets overflowing time.Duration are clamped instead of expiring at once.
+		{"99999999H", maxTimeout},
+	}
+	for _, c := range cases {
+		d, err := parseGrpcTimeout(c.value)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, d)
+	}
+
+	for _, value := range []string{"", "1", "1x", "-1S", "1234567890S"} {
+		_, err := parseGrpcTimeout(value)
+		assert.Error(t, err, value)
+	}
+}
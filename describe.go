@@ -0,0 +1,204 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// describedRoute is a route or a mount point printed by Describe.
+type describedRoute struct {
+	method      string
+	pattern     string
+	middlewares Middlewares
+	renderer    Renderer
+	handler     string
+	source      string
+}
+
+// Describe prints the routes of the router for debugging, each route with its methods and the full
+// middleware chain applied to its handler in order, the renderer of the typed handlers and the source
+// location where it's registered. The mount points are printed with the mounted handler, and the
+// routes of the mounted routers are printed with the mount prefix.
+//
+//	GET /users/{id}
+//		middlewares: web.RecoveryWith -> main.Auth
+//		renderer:    web.jsonRender
+//		source:      /app/main.go:42
+func (rg *routerGroup) Describe(w io.Writer) {
+	// the inline routers share the routing tree with the router they are derived from.
+	for rg.inline {
+		rg = rg.parent
+	}
+
+	routes := rg.describe("", nil)
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].pattern != routes[j].pattern {
+			return routes[i].pattern < routes[j].pattern
+		}
+		return routes[i].method < routes[j].method
+	})
+
+	for _, route := range routes {
+		if len(route.handler) > 0 {
+			fmt.Fprintf(w, "%s %s -> %s\n", route.method, route.pattern, route.handler)
+		} else {
+			fmt.Fprintf(w, "%s %s\n", route.method, route.pattern)
+		}
+
+		names := make([]string, 0, len(route.middlewares))
+		for _, mw := range route.middlewares {
+			names = append(names, funcName(mw))
+		}
+		if 0 == len(names) {
+			names = append(names, "(none)")
+		}
+		fmt.Fprintf(w, "\tmiddlewares: %s\n", strings.Join(names, " -> "))
+		if nil != route.renderer {
+			fmt.Fprintf(w, "\trenderer:    %s\n", rendererName(route.renderer))
+		}
+		fmt.Fprintf(w, "\tsource:      %s\n", route.source)
+	}
+}
+
+// describe collects the routes of the router and its mounted routers under the prefix.
+func (rg *routerGroup) describe(prefix string, parentMws Middlewares) []describedRoute {
+	mws := append(parentMws[:len(parentMws):len(parentMws)], rg.middlewares...)
+
+	type mount struct {
+		pattern     string
+		router      *routerGroup
+		middlewares Middlewares
+	}
+
+	var routes []describedRoute
+	var mounts []mount
+
+	if nil != rg.mu {
+		rg.mu.RLock()
+	}
+	rg.tree.walk(func(eps endpoints, subroutes Routes) bool {
+		for mt, ep := range eps {
+			if nil == ep.handler || 0 == len(ep.pattern) {
+				continue
+			}
+
+			pattern := strings.Replace(prefix+ep.pattern, "/*/", "/", -1)
+			handler, chain := unwrapChain(ep.handler)
+
+			if m, ok := handler.(*mountHandler); ok {
+				// a mount registers the routes `/prefix`, `/prefix/` and `/prefix/*`, only the latter is printed.
+				if mt != mALL || !strings.HasSuffix(ep.pattern, "*") {
+					continue
+				}
+				handler = m.handler
+				if m, ok := handler.(*mountedServeMux); ok {
+					handler = m.mux
+				}
+				routeMws := append(mws[:len(mws):len(mws)], chain...)
+				routes = append(routes, describedRoute{
+					method:      "MOUNT",
+					pattern:     pattern,
+					middlewares: routeMws,
+					handler:     fmt.Sprintf("%T", handler),
+					source:      ep.source,
+				})
+				if sub, ok := handler.(*routerGroup); ok {
+					mounts = append(mounts, mount{pattern: prefix + ep.pattern, router: sub, middlewares: routeMws})
+				}
+				continue
+			}
+
+			method := methodTypString(mt)
+			if mt == mALL {
+				method = "ANY"
+			} else if 0 == len(method) {
+				continue
+			} else if all := eps[mALL]; nil != all && nil != all.handler && all.source == ep.source {
+				// the method is registered by Any.
+				continue
+			}
+
+			renderer, _ := ep.meta[RendererKey].(Renderer)
+			for owner := ep.inline; nil == renderer && nil != owner && owner.inline; owner = owner.parent {
+				renderer = owner.renderer
+			}
+			if nil == renderer {
+				renderer = rg.currentRenderer()
+			}
+
+			routes = append(routes, describedRoute{
+				method:      method,
+				pattern:     pattern,
+				middlewares: append(mws[:len(mws):len(mws)], chain...),
+				renderer:    renderer,
+				source:      ep.source,
+			})
+		}
+		return false
+	})
+	if nil != rg.mu {
+		rg.mu.RUnlock()
+	}
+
+	for _, m := range mounts {
+		routes = append(routes, m.router.describe(m.pattern, m.middlewares)...)
+	}
+	return routes
+}
+
+// unwrapChain returns the handler wrapped by the inline middlewares and the middlewares.
+func unwrapChain(h http.Handler) (http.Handler, Middlewares) {
+	if chain, ok := h.(*ChainHandler); ok {
+		return chain.Endpoint, chain.Middlewares
+	}
+	return h, nil
+}
+
+// closureSuffix matches the suffix of the closure names, e.g. `.func1`, `.func2.1` and `-fm`.
+var closureSuffix = regexp.MustCompile(`(\.func\d+|\.\d+)*(-fm)?$`)
+
+// funcName returns the name of the function without the package path, the closures returned by
+// the middleware constructors are named after the constructors, e.g. `web.RecoveryWith`.
+func funcName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Sprintf("%T", fn)
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if nil == f {
+		return fmt.Sprintf("%T", fn)
+	}
+	name := f.Name()
+	name = name[strings.LastIndexByte(name, '/')+1:]
+	return closureSuffix.ReplaceAllString(name, "")
+}
+
+// rendererName returns the function name of the RendererFunc, or the type name of the renderer.
+func rendererName(renderer Renderer) string {
+	if fn, ok := renderer.(RendererFunc); ok {
+		return funcName(fn)
+	}
+	return fmt.Sprintf("%T", renderer)
+}
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func describeAuth(next http.Handler) http.Handler {
+	return next
+}
+
+func TestRouterDescribe(t *testing.T) {
+	csv := RendererFunc(func(ctx *Context, err error, result interface{}) {})
+
+	r := NewRouter()
+	r.Use(Recovery())
+	r.Get("/users/{id}", func(ctx context.Context) string { return "" })
+	r.With(describeAuth).Renderer(csv).Post("/users", func(ctx context.Context) string { return "" })
+	r.Any("/ping", func(w http.ResponseWriter, r *http.Request) {})
+	r.Group("/admin", func(r Router) {
+		r.Use(describeAuth)
+		r.Delete("/users/{id}", func(ctx context.Context) {})
+	})
+	r.Mount("/debug", http.NewServeMux())
+
+	var buf bytes.Buffer
+	r.With(describeAuth).Describe(&buf)
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !strings.Contains(line, "source:") {
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, []string{
+		"MOUNT /admin/* -> *web.routerGroup",
+		"\tmiddlewares: web.RecoveryWith",
+		"DELETE /admin/users/{id}",
+		"\tmiddlewares: web.RecoveryWith -> web.describeAuth",
+		"\trenderer:    web.jsonRender",
+		"MOUNT /debug/* -> *http.ServeMux",
+		"\tmiddlewares: web.RecoveryWith",
+		"ANY /ping",
+		"\tmiddlewares: web.RecoveryWith",
+		"\trenderer:    web.jsonRender",
+		"POST /users",
+		"\tmiddlewares: web.RecoveryWith -> web.describeAuth",
+		"\trenderer:    web.TestRouterDescribe",
+		"GET /users/{id}",
+		"\tmiddlewares: web.RecoveryWith",
+		"\trenderer:    web.jsonRender",
+	}, lines)
+	assert.Contains(t, buf.String(), "\tsource:      "+packageDir+"/describe_test.go:")
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Dynamic enables the thread-safe registration and removal of routes while the router is
+// serving requests, e.g. for plugin systems or tenant-specific webhooks. The routing tree
+// is guarded by a read-write lock, so the route lookup of each request takes a read lock.
+//
+// It must be enabled before any route is registered, the groups created afterward are dynamic
+// as well. The middlewares and metadata of the routes can't be changed after the routes are
+// registered, the new routes are registered with the middlewares of the router.
+//
+//	router := web.NewRouter().Dynamic()
+//	go http.ListenAndServe(":8080", router)
+//
+//	router.Post("/webhooks/"+tenant, Webhook)
+//	router.Remove(http.MethodPost, "/webhooks/"+tenant)
+func (rg *routerGroup) Dynamic() Router {
+	if rg.inline || nil != rg.handler {
+		panic("dynamic mode must be enabled on the router before routes registers")
+	}
+	if nil == rg.mu {
+		rg.mu = &sync.RWMutex{}
+	}
+	return rg
+}
+
+// Remove removes the route of the method registered with the pattern, an empty
+// method removes the routes of all methods, reports whether any route is removed.
+func (rg *routerGroup) Remove(method, pattern string) bool {
+	m := mALL
+	if len(method) > 0 {
+		var ok bool
+		if m, ok = methodMap[method]; !ok {
+			panic(fmt.Errorf("%q http method is not supported", method))
+		}
+	}
+
+	if nil != rg.mu {
+		rg.mu.Lock()
+		defer rg.mu.Unlock()
+	}
+
+	// the pattern with the optional params removes all the patterns it's expanded into.
+	routes, err := expandOptional(pattern)
+	if nil != err {
+		panic(err.Error())
+	}
+
+	var removed bool
+	for _, route := range routes {
+		if rg.tree.removeRoute(m, route.pattern) {
+			removed = true
+		}
+		if leaf, ok := rg.tree.static[route.pattern]; ok && nil == leaf.endpoints {
+			delete(rg.tree.static, route.pattern)
+		}
+	}
+	if removed && rg.tree.prioritized {
+		rg.tree.updatePriority()
+	}
+	return removed
+}
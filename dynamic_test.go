@@ -0,0 +1,93 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterDynamic(t *testing.T) {
+	r := NewRouter().Dynamic()
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					assert.Equal(t, "pong", serve(http.MethodGet, "/ping").Body.String())
+					serve(http.MethodPost, "/webhooks/0")
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		tenant := fmt.Sprintf("/webhooks/%d", i%10)
+		r.Post(tenant, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("webhook"))
+		}).Apply(MaxInflight(10), Consumes("application/json"))
+		r.Remove(http.MethodPost, tenant)
+	}
+	close(stop)
+	wg.Wait()
+
+	r.Post("/webhooks/{tenant}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("webhook"))
+	})
+	r.Put("/webhooks/{tenant}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("put"))
+	})
+	assert.Equal(t, "webhook", serve(http.MethodPost, "/webhooks/acme").Body.String())
+
+	assert.True(t, r.Remove(http.MethodPost, "/webhooks/{tenant}"))
+	assert.False(t, r.Remove(http.MethodPost, "/webhooks/{tenant}"))
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/webhooks/acme").Code)
+	assert.Equal(t, "put", serve(http.MethodPut, "/webhooks/acme").Body.String())
+
+	assert.True(t, r.Remove("", "/webhooks/{tenant}"))
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPut, "/webhooks/acme").Code)
+	assert.Equal(t, "pong", serve(http.MethodGet, "/ping").Body.String())
+	assert.Len(t, r.Routes(), 1)
+
+	assert.Panics(t, func() { r.Dynamic() })
+	assert.Panics(t, func() { r.Remove("FETCH", "/ping") })
+
+	// the removed routes leave no empty nodes behind.
+	tree := r.(*routerGroup).tree
+	size := countNodes(tree)
+	for i := 0; i < 100; i++ {
+		pattern := fmt.Sprintf("/tenants/%d/hooks/{id}", i)
+		r.Post(pattern, func(w http.ResponseWriter, r *http.Request) {})
+		r.Remove("", pattern)
+	}
+	assert.Equal(t, size, countNodes(tree))
+}
+
+func countNodes(n *node) int {
+	count := 1
+	for _, nds := range n.children {
+		for _, child := range nds {
+			count += countNodes(child)
+		}
+	}
+	return count
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import "sync"
+
+// Metadata is the arbitrary key/value pairs attached to a route.
+type Metadata map[string]interface{}
+
+// Get returns the metadata value associated with key.
+func (m Metadata) Get(key string) (interface{}, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+// Endpoint is a route registered on the Router, it allows further configuration
+// of the route after registration.
+//
+//	router.Get("/users", ListUsers).Meta("tag", "admin")
+//
+// The metadata is exposed by Routes() and the RouteContext of the matched requests,
+// so it should be attached before the router starts serving requests.
+type Endpoint interface {
+	// Meta attaches a metadata value to the route.
+	Meta(key string, value interface{}) Endpoint
+
+	// Metadata returns the metadata attached to the route.
+	Metadata() Metadata
+
+	// Priority sets the matching priority of the route among overlapping routes,
+	// higher priority routes are matched first, the default priority is 0.
+	//
+	//	router.Get("/files/latest", Latest)
+	//	router.Get("/files/{name}", Download).Priority(1) // matches `/files/latest` as well
+	Priority(priority int) Endpoint
+
+	// Apply configures the route with the given options.
+	//
+	//	router.Get("/reports", Generate).Apply(web.MaxInflight(10))
+	Apply(options ...RouteOption) Endpoint
+}
+
+// RouteOption configures a registered route.
+type RouteOption func(e Endpoint)
+
+type routeEndpoint struct {
+	meta   Metadata
+	tree   *node
+	leaves []*node // the optional params register a leaf for each pattern, see expandOptional
+	method methodTyp
+	mu     *sync.RWMutex
+}
+
+// Meta attaches a metadata value to the route.
+func (e *routeEndpoint) Meta(key string, value interface{}) Endpoint {
+	if nil == e.mu {
+		e.meta[key] = value
+		return e
+	}
+
+	// copy on write, the metadata may be read by the requests in the dynamic mode.
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	meta := make(Metadata, len(e.meta)+1)
+	for k, v := range e.meta {
+		meta[k] = v
+	}
+	meta[key] = value
+
+	for _, leaf := range e.leaves {
+		leaf.setMetadata(e.method, meta)
+	}
+	e.meta = meta
+	return e
+}
+
+// Metadata returns the metadata attached to the route.
+func (e *routeEndpoint) Metadata() Metadata {
+	return e.meta
+}
+
+// Priority sets the matching priority of the route among overlapping routes.
+func (e *routeEndpoint) Priority(priority int) Endpoint {
+	if nil != e.mu {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+	}
+	for _, leaf := range e.leaves {
+		leaf.setPriority(e.method, priority)
+	}
+	e.tree.prioritized = true
+	e.tree.updatePriority()
+	return e
+}
+
+// Apply configures the route with the given options.
+func (e *routeEndpoint) Apply(options ...RouteOption) Endpoint {
+	for _, option := range options {
+		option(e)
+	}
+	return e
+}
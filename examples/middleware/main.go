@@ -14,6 +14,9 @@ func main() {
 
 	router.Use(web.Recovery())
 
+	// server side sessions of the login state
+	router.Use(web.Sessions{}.Middleware())
+
 	// access log
 	router.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
@@ -46,10 +49,14 @@ func main() {
 		r.Post("/login", func(ctx context.Context, req struct {
 			Username string `form:"username"`
 			Password string `form:"password"`
+			Remember bool   `form:"remember"`
 		}) error {
 			if "admin" == req.Username && "admin123" == req.Password {
-				web.FromContext(ctx).SetCookie("token", req.Username, 600, "/", "", false, false)
-				return nil
+				var opts []web.LoginOption
+				if req.Remember {
+					opts = append(opts, web.RememberMe())
+				}
+				return web.LoginSession(ctx, req.Username, opts...)
 			}
 			return web.Error(400, "login failed")
 		})
@@ -64,9 +71,9 @@ func main() {
 		// user login check
 		r.Use(func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-				// check login state in cookies
+				// check login state in session
 				//
-				if _, err := request.Cookie("token"); nil != err {
+				if session, ok := web.SessionOf(request.Context()); !ok || nil == session.Principal {
 					writer.WriteHeader(http.StatusForbidden)
 					return
 				}
@@ -79,16 +86,16 @@ func main() {
 		r.Get("/userInfo", func(ctx context.Context) interface{} {
 			// TODO: load user from database
 			//
+			session, _ := web.SessionOf(ctx)
 			return map[string]interface{}{
-				"username": "admin",
+				"username": session.Principal,
 				"time":     time.Now().String(),
 			}
 		})
 
-		r.Get("/logout", func(ctx context.Context) string {
-			// delete cookie
-			web.FromContext(ctx).SetCookie("token", "", -1, "/", "", false, false)
-			return "success"
+		r.Get("/logout", func(ctx context.Context) error {
+			// delete session
+			return web.Logout(ctx)
 		})
 
 	})
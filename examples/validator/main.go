@@ -21,13 +21,13 @@ func init() {
 }
 
 type UserRegisterModel struct {
-	Username  string                `form:"username" validate:"min=6,max=20"`  // username
-	Password  string                `form:"password" validate:"min=10,max=20"` // password
-	Avatar    *multipart.FileHeader `form:"avatar" validate:"nonzero"`         // avatar
-	Captcha   string                `form:"captcha" validate:"min=4,max=4"`    // captcha
-	UserAgent string                `header:"User-Agent"`                      // user agent
-	Ad        string                `query:"ad"`                               // advertising ID
-	Token     string                `cookie:"token"`                           // token
+	Username  string                `form:"username" validate:"min=6,max=20"`                                             // username
+	Password  string                `form:"password" validate:"min=10,max=20" errmsg:"password must be 10-20 characters"` // password
+	Avatar    *multipart.FileHeader `form:"avatar" validate:"nonzero"`                                                    // avatar
+	Captcha   string                `form:"captcha" validate:"min=4,max=4"`                                               // captcha
+	UserAgent string                `header:"User-Agent"`                                                                 // user agent
+	Ad        string                `query:"ad"`                                                                          // advertising ID
+	Token     string                `cookie:"token"`                                                                      // token
 }
 
 func main() {
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FairQueue shares the serving capacity between the clients, so that a single client, e.g. of
+// an API key or an IP, can't consume all of it. Each client gets a share of the capacity in
+// proportion to its weight, its excess requests wait in its own queue or are rejected.
+//
+//	router.Use(web.FairQueue{
+//		Capacity:     64,
+//		MaxPerClient: 16,
+//		MaxQueue:     32,
+//		Timeout:      5 * time.Second,
+//		Key:          func(r *http.Request) string { return r.Header.Get("X-API-Key") },
+//	}.Middleware())
+type FairQueue struct {
+	// Capacity is the number of requests served concurrently for all the clients.
+	Capacity int
+
+	// MaxPerClient is the number of requests served concurrently for each client, zero means Capacity.
+	MaxPerClient int
+
+	// MaxQueue is the number of requests of each client waiting for a free slot, the requests
+	// beyond it are rejected with 429 Too Many Requests, zero disables the waiting.
+	MaxQueue int
+
+	// Timeout is the maximum waiting time of the requests, the requests waiting longer are
+	// rejected with 503 Service Unavailable, zero waits until the request is canceled.
+	Timeout time.Duration
+
+	// Key returns the client of the request, defaults to the IP of the remote address,
+	// set it to use the forwarded client IP behind the trusted proxies.
+	Key func(r *http.Request) string
+
+	// Weight returns the weight of the client, defaults to 1 for all the clients.
+	Weight func(key string) int
+}
+
+// Middleware returns a middleware serving the requests in the fair queue.
+func (q FairQueue) Middleware() MiddlewareFunc {
+	if q.Capacity <= 0 || q.MaxPerClient < 0 || q.MaxQueue < 0 || q.Timeout < 0 {
+		panic(fmt.Sprintf("invalid fair queue: capacity=%d, max per client=%d, max queue=%d, timeout=%s",
+			q.Capacity, q.MaxPerClient, q.MaxQueue, q.Timeout))
+	}
+	if 0 == q.MaxPerClient || q.MaxPerClient > q.Capacity {
+		q.MaxPerClient = q.Capacity
+	}
+	if nil == q.Key {
+		q.Key = remoteHost
+	}
+
+	s := &fairScheduler{queue: q, clients: map[string]*fairClient{}}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			key := q.Key(request)
+			switch s.acquire(request.Context(), key) {
+			case fairRejected:
+				http.Error(writer, "429 too many requests", http.StatusTooManyRequests)
+				return
+			case fairExpired:
+				http.Error(writer, "503 service unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			defer s.release(key)
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// remoteHost returns the IP of the remote address of the request.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if nil != err {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+type fairResult int
+
+const (
+	fairGranted fairResult = iota
+	fairRejected
+	fairExpired
+)
+
+type fairClient struct {
+	weight  int
+	active  int
+	waiters []*fairWaiter
+}
+
+type fairWaiter struct {
+	seq     uint64
+	ready   chan struct{}
+	granted bool
+}
+
+// fairScheduler grants the free slots to the waiting client with the least active requests
+// in proportion to its weight, the clients with the same share are served in arrival order.
+type fairScheduler struct {
+	queue   FairQueue
+	mu      sync.Mutex
+	active  int
+	seq     uint64
+	clients map[string]*fairClient
+}
+
+func (s *fairScheduler) acquire(ctx context.Context, key string) fairResult {
+	s.mu.Lock()
+	c, ok := s.clients[key]
+	if !ok {
+		c = &fairClient{weight: 1}
+		if nil != s.queue.Weight {
+			c.weight = max(s.queue.Weight(key), 1)
+		}
+		s.clients[key] = c
+	}
+
+	if s.active < s.queue.Capacity && c.active < s.queue.MaxPerClient && 0 == len(c.waiters) {
+		s.active++
+		c.active++
+		s.mu.Unlock()
+		return fairGranted
+	}
+
+	if len(c.waiters) >= s.queue.MaxQueue {
+		s.cleanup(key, c)
+		s.mu.Unlock()
+		return fairRejected
+	}
+
+	s.seq++
+	w := &fairWaiter{seq: s.seq, ready: make(chan struct{})}
+	c.waiters = append(c.waiters, w)
+	s.mu.Unlock()
+
+	var expired <-chan time.Time
+	if s.queue.Timeout > 0 {
+		timer := time.NewTimer(s.queue.Timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	result := fairExpired
+	select {
+	case <-w.ready:
+		return fairGranted
+	case <-expired:
+	case <-ctx.Done():
+		result = fairRejected
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w.granted {
+		// granted while giving up, the slot is passed on.
+		s.releaseLocked(key, c)
+		return result
+	}
+	for i, waiter := range c.waiters {
+		if waiter == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			break
+		}
+	}
+	s.cleanup(key, c)
+	return result
+}
+
+func (s *fairScheduler) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked(key, s.clients[key])
+}
+
+func (s *fairScheduler) releaseLocked(key string, c *fairClient) {
+	s.active--
+	c.active--
+	s.dispatch()
+	s.cleanup(key, c)
+}
+
+// dispatch grants the free slots to the waiting clients.
+func (s *fairScheduler) dispatch() {
+	for s.active < s.queue.Capacity {
+		var next *fairClient
+		for _, c := range s.clients {
+			if 0 == len(c.waiters) || c.active >= s.queue.MaxPerClient {
+				continue
+			}
+			if nil == next {
+				next = c
+				continue
+			}
+			// compare the shares active/weight of the clients.
+			share, nextShare := c.active*next.weight, next.active*c.weight
+			if share < nextShare || (share == nextShare && c.waiters[0].seq < next.waiters[0].seq) {
+				next = c
+			}
+		}
+		if nil == next {
+			return
+		}
+
+		w := next.waiters[0]
+		next.waiters = next.waiters[1:]
+		w.granted = true
+		close(w.ready)
+		s.active++
+		next.active++
+	}
+}
+
+// cleanup forgets the client without requests.
+func (s *fairScheduler) cleanup(key string, c *fairClient) {
+	if 0 == c.active && 0 == len(c.waiters) {
+		delete(s.clients, key)
+	}
+}
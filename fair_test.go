@@ -0,0 +1,116 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFairQueue(t *testing.T) {
+	started := make(chan string, 4)
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- r.RemoteAddr
+		<-release
+	})
+
+	serve := func(h http.Handler, remoteAddr string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	h := FairQueue{Capacity: 1}.Middleware()(handler)
+	done := make(chan int)
+	go func() { done <- serve(h, "10.0.0.1:1000") }()
+	<-started
+	assert.Equal(t, http.StatusTooManyRequests, serve(h, "10.0.0.1:1001"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(h, "10.0.0.2:1000"))
+	release <- struct{}{}
+	assert.Equal(t, http.StatusOK, <-done)
+
+	h = FairQueue{Capacity: 1, MaxQueue: 1, Timeout: 10 * time.Millisecond}.Middleware()(handler)
+	go func() { done <- serve(h, "10.0.0.1:1000") }()
+	<-started
+	assert.Equal(t, http.StatusServiceUnavailable, serve(h, "10.0.0.2:1000"))
+	release <- struct{}{}
+	assert.Equal(t, http.StatusOK, <-done)
+
+	assert.Panics(t, func() { FairQueue{}.Middleware() })
+}
+
+func TestFairScheduler(t *testing.T) {
+	s := &fairScheduler{
+		queue:   FairQueue{Capacity: 2, MaxPerClient: 2, MaxQueue: 10, Weight: func(key string) int { return len(key) }},
+		clients: map[string]*fairClient{},
+	}
+
+	granted := make(chan string, 10)
+	wait := func(key string, n int) {
+		go func() {
+			if fairGranted == s.acquire(context.Background(), key) {
+				granted <- key
+			}
+		}()
+		for i := 0; i < 1000; i++ {
+			s.mu.Lock()
+			c := s.clients[key]
+			queued := nil != c && len(c.waiters) == n
+			s.mu.Unlock()
+			if queued {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("%s: expected %d waiting requests", key, n)
+	}
+
+	// the client a takes the whole capacity, then waits behind the client b.
+	assert.Equal(t, fairGranted, s.acquire(context.Background(), "a"))
+	assert.Equal(t, fairGranted, s.acquire(context.Background(), "a"))
+	wait("a", 1)
+	wait("a", 2)
+	wait("b", 1)
+
+	s.release("a")
+	assert.Equal(t, "b", <-granted)
+	s.release("a")
+	assert.Equal(t, "a", <-granted)
+
+	// the client cc of weight 2 gets twice the share of the client d, ahead of its arrival order.
+	s.release("a")
+	s.release("b")
+	assert.Equal(t, "a", <-granted)
+	s.mu.Lock()
+	s.queue.Capacity = 3
+	s.mu.Unlock()
+	assert.Equal(t, fairGranted, s.acquire(context.Background(), "cc"))
+	assert.Equal(t, fairGranted, s.acquire(context.Background(), "d"))
+	wait("d", 1)
+	wait("cc", 1)
+	s.release("a")
+	assert.Equal(t, "cc", <-granted)
+	s.release("cc")
+	assert.Equal(t, "d", <-granted)
+	s.release("d")
+	s.mu.Lock()
+	s.queue.Capacity = 2
+	s.mu.Unlock()
+
+	// the canceled requests leave the queue.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, fairRejected, s.acquire(ctx, "e"))
+	s.release("cc")
+	s.release("d")
+	s.mu.Lock()
+	assert.Equal(t, 0, s.active)
+	assert.Empty(t, s.clients)
+	s.mu.Unlock()
+}
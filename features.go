@@ -0,0 +1,90 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Features are the feature flags toggling the routes at runtime, the routes of the disabled
+// features respond as if they were not registered.
+//
+//	features := web.NewFeatures(map[string]bool{"new-checkout": false})
+//	router.With(features.Require("new-checkout")).Post("/checkout/v2", CheckoutV2)
+//	features.Set("new-checkout", true)
+type Features struct {
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+// NewFeatures returns the feature flags with their initial states.
+func NewFeatures(flags map[string]bool) *Features {
+	f := &Features{flags: make(map[string]bool, len(flags))}
+	for name, enabled := range flags {
+		f.flags[name] = enabled
+	}
+	return f
+}
+
+// Enabled reports whether the feature is enabled, the undefined features are disabled.
+func (f *Features) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.flags[name]
+}
+
+// Set enables or disables the feature, reports whether the feature is defined by NewFeatures,
+// the undefined features are not changed.
+func (f *Features) Set(name string, enabled bool) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.flags[name]; !ok {
+		return false
+	}
+	f.flags[name] = enabled
+	return true
+}
+
+// All returns a copy of the feature flags.
+func (f *Features) All() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	flags := make(map[string]bool, len(f.flags))
+	for name, enabled := range f.flags {
+		flags[name] = enabled
+	}
+	return flags
+}
+
+// Require returns a middleware serving the requests only if the feature is enabled, the requests
+// of a disabled feature are served by the NotFound handler of the router.
+func (f *Features) Require(name string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if f.Enabled(name) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if rg := servingRouter(r.Context()); nil != rg {
+				rg.NotFoundHandler().ServeHTTP(w, r)
+				return
+			}
+			http.NotFound(w, r)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxInflightKey is the route metadata key of the concurrency limiter of the route.
+const MaxInflightKey = "web.maxInflight"
+
+// MaxInflight limits the number of requests served concurrently by the route,
+// the requests beyond the limit are rejected with 503 Service Unavailable, rendered
+// by the renderer of the route, unless a waiting queue is configured by MaxQueue.
+// The limit may be changed at runtime by SetMaxInflight.
+//
+//	router.Get("/reports", Generate).Apply(web.MaxInflight(10), web.MaxQueue(20, 5*time.Second))
+func MaxInflight(limit int) RouteOption {
+	if limit <= 0 {
+		panic(fmt.Sprintf("invalid max inflight limit: %d", limit))
+	}
+	return func(e Endpoint) {
+		l := inflightOf(e)
+		l.limited, l.limit = true, limit
+		e.Meta(MaxInflightKey, l)
+	}
+}
+
+// MaxQueue allows up to size requests waiting at most timeout for a free slot of
+// the MaxInflight limit of the route, before being rejected. The zero timeout waits
+// until a slot is free or the request is canceled, e.g. by the client or Deadline.
+func MaxQueue(size int, timeout time.Duration) RouteOption {
+	if size < 0 || timeout < 0 {
+		panic(fmt.Sprintf("invalid max queue: size=%d, timeout=%s", size, timeout))
+	}
+	return func(e Endpoint) {
+		l := inflightOf(e)
+		l.queue, l.timeout = size, timeout
+		e.Meta(MaxInflightKey, l)
+	}
+}
+
+// SetMaxInflight changes the MaxInflight limit of the route registered with the method and pattern
+// on the router while serving requests, the requests beyond a lowered limit complete normally, and
+// the waiting requests are admitted as soon as the limit is raised. It reports whether the route
+// exists with a MaxInflight limit, the routes of the mounted routers are found by their full patterns.
+func SetMaxInflight(r Routes, method, pattern string, limit int) bool {
+	if limit <= 0 {
+		panic(fmt.Sprintf("invalid max inflight limit: %d", limit))
+	}
+	l := lookupInflight(r, method, pattern, "")
+	if nil == l {
+		return false
+	}
+	l.setLimit(limit)
+	return true
+}
+
+// lookupInflight returns the limiter of the route registered with the method and pattern.
+func lookupInflight(r Routes, method, pattern, prefix string) *inflightLimiter {
+	for _, route := range r.Routes() {
+		full := strings.Replace(prefix+route.Pattern, "/*/", "/", -1)
+		if nil != route.SubRoutes {
+			if l := lookupInflight(route.SubRoutes, method, pattern, prefix+route.Pattern); nil != l {
+				return l
+			}
+			continue
+		}
+		if full != pattern {
+			continue
+		}
+		if l, ok := route.Metadata[method][MaxInflightKey].(*inflightLimiter); ok && l.limited {
+			return l
+		}
+	}
+	return nil
+}
+
+// inflightLimiter is the concurrency limiter attached to the route metadata.
+type inflightLimiter struct {
+	limited bool // MaxQueue may be applied without MaxInflight
+	mu      sync.Mutex
+	limit   int
+	active  int
+	queue   int
+	timeout time.Duration
+	waiters []chan struct{}
+}
+
+// inflightOf returns a copy of the limiter attached to the route, the limiter is replaced
+// instead of being updated in place as it may be in use by the requests in the dynamic mode.
+func inflightOf(e Endpoint) *inflightLimiter {
+	if l, ok := e.Metadata()[MaxInflightKey].(*inflightLimiter); ok {
+		return &inflightLimiter{limited: l.limited, limit: l.limit, queue: l.queue, timeout: l.timeout}
+	}
+	return &inflightLimiter{}
+}
+
+// acquire takes a slot of the limiter, waiting in the queue if it's allowed.
+func (l *inflightLimiter) acquire(ctx context.Context) bool {
+	l.mu.Lock()
+	if l.active < l.limit && 0 == len(l.waiters) {
+		l.active++
+		l.mu.Unlock()
+		return true
+	}
+	if len(l.waiters) >= l.queue {
+		l.mu.Unlock()
+		return false
+	}
+	ready := make(chan struct{})
+	l.waiters = append(l.waiters, ready)
+	l.mu.Unlock()
+
+	var expired <-chan time.Time
+	if l.timeout > 0 {
+		timer := time.NewTimer(l.timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case <-ready:
+		return true
+	case <-expired:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, w := range l.waiters {
+		if w == ready {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			return false
+		}
+	}
+
+	// the slot is granted meanwhile, pass it on.
+	l.active--
+	l.dispatch()
+	return false
+}
+
+func (l *inflightLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	l.dispatch()
+}
+
+func (l *inflightLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.dispatch()
+}
+
+// waiting returns the number of the requests waiting in the queue.
+func (l *inflightLimiter) waiting() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.waiters)
+}
+
+// dispatch grants the free slots to the waiting requests in order.
+func (l *inflightLimiter) dispatch() {
+	for l.active < l.limit && len(l.waiters) > 0 {
+		close(l.waiters[0])
+		l.waiters = l.waiters[1:]
+		l.active++
+	}
+}
+
+// limitInflight serves the request within the concurrency limit of the matched route.
+func limitInflight(ctx *RouteContext, h http.Handler, w http.ResponseWriter, r *http.Request) {
+	l, ok := ctx.routeMetadata[MaxInflightKey].(*inflightLimiter)
+	if !ok || !l.limited {
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	if !l.acquire(r.Context()) {
+		renderRouteError(w, r, Error(http.StatusServiceUnavailable, http.StatusText(http.StatusServiceUnavailable)))
+		return
+	}
+	defer l.release()
+
+	h.ServeHTTP(w, r)
+}
@@ -0,0 +1,91 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxInflight(t *testing.T) {
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+
+	r := NewRouter()
+	r.Get("/report", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+		w.Write([]byte("report"))
+	}).Apply(MaxInflight(1))
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve("/report") }()
+	<-entered
+
+	assert.Equal(t, `{"code":503,"message":"Service Unavailable","data":null}`+"\n", serve("/report").Body.String())
+	assert.Equal(t, "pong", serve("/ping").Body.String())
+
+	close(unblock)
+	assert.Equal(t, "report", (<-done).Body.String())
+
+	go func() { done <- serve("/report") }()
+	<-entered
+	assert.Equal(t, "report", (<-done).Body.String())
+}
+
+func TestMaxQueue(t *testing.T) {
+	entered := make(chan struct{}, 2)
+	unblock := make(chan struct{})
+
+	r := NewRouter()
+	r.Get("/report", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+		w.Write([]byte("report"))
+	}).Apply(MaxInflight(1), MaxQueue(1, time.Second))
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report", nil))
+		return w
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- serve() }()
+	<-entered
+
+	queued := make(chan *httptest.ResponseRecorder)
+	go func() { queued <- serve() }()
+
+	// wait for the second request to join the queue, the third one overflows it.
+	l := r.Routes()[0].Metadata[http.MethodGet][MaxInflightKey].(*inflightLimiter)
+	for l.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Contains(t, serve().Body.String(), `"code":503`)
+
+	close(unblock)
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	assert.Equal(t, "report", (<-queued).Body.String())
+}
+
+func TestMaxQueueTimeout(t *testing.T) {
+	l := &inflightLimiter{limited: true, limit: 1, queue: 1, timeout: 10 * time.Millisecond}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	assert.True(t, l.acquire(r.Context()))
+	assert.False(t, l.acquire(r.Context()))
+	l.release()
+	assert.True(t, l.acquire(r.Context()))
+}
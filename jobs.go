@@ -0,0 +1,372 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrJobsBusy is returned by Jobs.Submit when the queue of the jobs is full or the jobs are shut down.
+var ErrJobsBusy = errors.New("web: too many pending jobs")
+
+// JobStatus is the status of an asynchronous job.
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is the state of an asynchronous job reported by its status route.
+type Job struct {
+	ID     string    `json:"id"`
+	Status JobStatus `json:"status"`
+
+	// Progress is the completed fraction of the job, from 0 to 1.
+	Progress float64 `json:"progress"`
+
+	// Result is the result of the succeeded job, Error is the error message of the failed job,
+	// or `Internal Server Error` if the job panicked, the panic is logged by the router logger.
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Done reports whether the job has succeeded or failed.
+func (j *Job) Done() bool {
+	return JobSucceeded == j.Status || JobFailed == j.Status
+}
+
+// JobStore persists the states of the jobs.
+type JobStore interface {
+	// Load returns the job of the ID, or nil if it doesn't exist or has expired.
+	Load(ctx context.Context, id string) (*Job, error)
+
+	// Save creates or replaces the job.
+	Save(ctx context.Context, job *Job) error
+}
+
+// NewMemoryJobStore returns a job store keeping the jobs in memory, the jobs done for longer than
+// the ttl are removed when they are loaded, and swept once per ttl when the jobs are saved, so that
+// the jobs never polled again don't pile up, measured by the clock of the router. The zero ttl keeps
+// the jobs forever.
+func NewMemoryJobStore(ttl time.Duration) JobStore {
+	return &memoryJobStore{ttl: ttl, jobs: map[string]Job{}}
+}
+
+type memoryJobStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	jobs      map[string]Job
+	nextSweep time.Time
+}
+
+func (m *memoryJobStore) Load(ctx context.Context, id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	if m.expired(&job, ClockOf(ctx).Now()) {
+		delete(m.jobs, id)
+		return nil, nil
+	}
+	return &job, nil
+}
+
+func (m *memoryJobStore) Save(ctx context.Context, job *Job) error {
+	now := ClockOf(ctx).Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ttl > 0 && now.After(m.nextSweep) {
+		for id, j := range m.jobs {
+			if m.expired(&j, now) {
+				delete(m.jobs, id)
+			}
+		}
+		m.nextSweep = now.Add(m.ttl)
+	}
+	m.jobs[job.ID] = *job
+	return nil
+}
+
+// expired reports whether the job has been done for longer than the ttl.
+func (m *memoryJobStore) expired(job *Job, now time.Time) bool {
+	return m.ttl > 0 && job.Done() && !now.Before(job.UpdatedAt.Add(m.ttl))
+}
+
+// JobFunc is the work of an asynchronous job, it reports the completed fraction of the work by
+// progress, and returns the result reported by the status route of the job. The ctx carries the
+// values of the submitting request, and is canceled when the job times out or the jobs shut down.
+type JobFunc func(ctx context.Context, progress func(fraction float64)) (interface{}, error)
+
+// Jobs runs the long-running operations in background and reports their progress by the generated
+// status routes, the submitting request is answered with 202 Accepted and the Location of the
+// status route of the job, which is polled by the clients until the job is done.
+//
+//	jobs := &web.Jobs{Workers: 4}
+//	jobs.Mount(router, "/jobs")
+//
+//	router.Post("/reports", func(w http.ResponseWriter, r *http.Request) {
+//		jobs.Submit(w, r, func(ctx context.Context, progress func(float64)) (interface{}, error) {
+//			return generateReport(ctx, progress)
+//		})
+//	})
+//
+// The jobs are run by a pool of the worker goroutines, the submissions beyond the capacity of
+// the queue are rejected with 503 Service Unavailable, call Shutdown to stop the workers.
+type Jobs struct {
+	// Store persists the states of the jobs, NewMemoryJobStore with the ttl of an hour by default.
+	Store JobStore
+
+	// Workers is the number of the jobs run concurrently, 4 by default.
+	Workers int
+
+	// MaxQueue is the number of the jobs waiting for a worker, 64 by default.
+	MaxQueue int
+
+	// Timeout is the time limit of each job, no limit if it's zero.
+	Timeout time.Duration
+
+	once    sync.Once
+	mu      sync.Mutex
+	prefix  string
+	queue   chan jobTask
+	pending int
+	closed  bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type jobTask struct {
+	ctx context.Context
+	job *Job
+	fn  JobFunc
+}
+
+func (j *Jobs) init() {
+	j.once.Do(func() {
+		if nil == j.Store {
+			j.Store = NewMemoryJobStore(time.Hour)
+		}
+		if j.Workers <= 0 {
+			j.Workers = 4
+		}
+		if j.MaxQueue <= 0 {
+			j.MaxQueue = 64
+		}
+		j.ctx, j.cancel = context.WithCancel(context.Background())
+		j.queue = make(chan jobTask, j.MaxQueue)
+		j.wg.Add(j.Workers)
+		for i := 0; i < j.Workers; i++ {
+			go j.work()
+		}
+	})
+}
+
+// Mount registers the status route of the jobs on the router, `GET pattern/{id}`, the pattern must be
+// the full path of the status routes since it's the prefix of the Location of the submitted jobs.
+// The status route responds the job as JSON, with `Retry-After` until the job is done, the unknown
+// jobs and the failures of the store are rendered by the renderer of the router.
+func (j *Jobs) Mount(r Router, pattern string) {
+	j.init()
+	j.prefix = strings.TrimSuffix(pattern, "/")
+	r.Get(j.prefix+"/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := &Context{Writer: w, Request: r}
+		id, _ := ctx.PathParam("id")
+		job, err := j.Store.Load(r.Context(), id)
+		if nil != err {
+			LoggerOf(r.Context()).ErrorContext(r.Context(), "loading job failed", slog.String("id", id), slog.Any("error", err))
+			renderRouteError(w, r, Error(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)))
+			return
+		}
+		if nil == job {
+			renderRouteError(w, r, Error(http.StatusNotFound, fmt.Sprintf("job %q is not found", id)))
+			return
+		}
+		if !job.Done() {
+			w.Header().Set("Retry-After", "1")
+		}
+		_ = ctx.JSON(http.StatusOK, job)
+	})
+}
+
+// Submit enqueues the work as a job and writes the response of the request, 202 Accepted with the
+// Location of the status route and the pending job as JSON, or 503 Service Unavailable if the queue
+// is full. The work runs with the values of the request beyond its lifetime, see Context.Copy.
+func (j *Jobs) Submit(w http.ResponseWriter, r *http.Request, fn JobFunc) (*Job, error) {
+	j.init()
+	if 0 == len(j.prefix) {
+		panic("the status route of the jobs is not mounted")
+	}
+
+	j.mu.Lock()
+	if j.closed || j.pending >= j.MaxQueue {
+		j.mu.Unlock()
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, ErrJobsBusy.Error(), http.StatusServiceUnavailable)
+		return nil, ErrJobsBusy
+	}
+	j.pending++
+	j.mu.Unlock()
+
+	ctx := &Context{Writer: w, Request: r}
+	job, err := j.enqueue(ctx, fn)
+	if nil != err {
+		j.mu.Lock()
+		j.pending--
+		j.mu.Unlock()
+		if errors.Is(err, ErrJobsBusy) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return nil, err
+	}
+
+	w.Header().Set("Location", j.prefix+"/"+job.ID)
+	return job, ctx.JSON(http.StatusAccepted, job)
+}
+
+func (j *Jobs) enqueue(ctx *Context, fn JobFunc) (*Job, error) {
+	id, err := newJobID()
+	if nil != err {
+		return nil, err
+	}
+
+	// the job keeps the values of the request beyond its lifetime.
+	taskCtx := ctx.Copy().Request.Context()
+	now := ClockOf(taskCtx).Now()
+	job := &Job{ID: id, Status: JobPending, CreatedAt: now, UpdatedAt: now}
+	if err = j.Store.Save(taskCtx, job); nil != err {
+		return nil, err
+	}
+
+	snapshot := *job
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		job.Status, job.Error = JobFailed, ErrJobsBusy.Error()
+		_ = j.Store.Save(taskCtx, job)
+		return nil, ErrJobsBusy
+	}
+	// the queue has room for the job reserved by Submit.
+	j.queue <- jobTask{ctx: taskCtx, job: job, fn: fn}
+	return &snapshot, nil
+}
+
+func (j *Jobs) work() {
+	defer j.wg.Done()
+	for task := range j.queue {
+		j.mu.Lock()
+		j.pending--
+		j.mu.Unlock()
+		j.run(task)
+	}
+}
+
+func (j *Jobs) run(task jobTask) {
+	ctx, cancel := context.WithCancel(task.ctx)
+	defer cancel()
+	stop := context.AfterFunc(j.ctx, cancel)
+	defer stop()
+	if j.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+
+	job := task.job
+	save := func(update func(job *Job)) {
+		update(job)
+		job.UpdatedAt = ClockOf(ctx).Now()
+		_ = j.Store.Save(context.WithoutCancel(ctx), job)
+	}
+	save(func(job *Job) { job.Status = JobRunning })
+
+	result, err := func() (result interface{}, err error) {
+		defer func() {
+			if r := recover(); nil != r {
+				// the panic details stay in the logs, the status route is open to any client holding the job ID.
+				LoggerOf(ctx).ErrorContext(ctx, "job panicked", slog.String("id", job.ID), slog.Any("panic", r), slog.String("stack", string(debug.Stack())))
+				err = errors.New(http.StatusText(http.StatusInternalServerError))
+			}
+		}()
+		return task.fn(ctx, func(fraction float64) {
+			save(func(job *Job) { job.Progress = min(max(fraction, 0), 1) })
+		})
+	}()
+
+	save(func(job *Job) {
+		if nil != err {
+			job.Status, job.Error = JobFailed, err.Error()
+			return
+		}
+		job.Status, job.Progress, job.Result = JobSucceeded, 1, result
+	})
+}
+
+// Shutdown stops accepting the jobs and waits for the pending and running jobs to be done,
+// the running jobs are canceled if the ctx is done first.
+func (j *Jobs) Shutdown(ctx context.Context) error {
+	j.init()
+	j.mu.Lock()
+	if !j.closed {
+		j.closed = true
+		close(j.queue)
+	}
+	j.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		j.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		j.cancel()
+		return ctx.Err()
+	}
+}
+
+// newJobID returns a random job ID of 128 bits.
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); nil != err {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
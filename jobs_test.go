@@ -0,0 +1,163 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobs(t *testing.T) {
+	var logs bytes.Buffer
+	jobs := &Jobs{Workers: 1, MaxQueue: 1}
+	router := NewRouterWith(RouterOptions{Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	jobs.Mount(router, "/jobs/")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router.Post("/reports/{kind}", func(w http.ResponseWriter, r *http.Request) {
+		kind := URLParam(r, "kind")
+		_, _ = jobs.Submit(w, r, func(ctx context.Context, progress func(float64)) (interface{}, error) {
+			switch kind {
+			case "block":
+				progress(0.5)
+				started <- struct{}{}
+				<-release
+			case "fail":
+				return nil, errors.New("out of paper")
+			case "panic":
+				panic("boom")
+			}
+			return map[string]string{"kind": kind}, nil
+		})
+	})
+
+	submit := func(kind string) (*httptest.ResponseRecorder, Job) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reports/"+kind, nil))
+		var job Job
+		_ = json.Unmarshal(w.Body.Bytes(), &job)
+		return w, job
+	}
+	status := func(location string) (*httptest.ResponseRecorder, Job) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, location, nil))
+		var job Job
+		_ = json.Unmarshal(w.Body.Bytes(), &job)
+		return w, job
+	}
+	await := func(location string) Job {
+		for i := 0; i < 1000; i++ {
+			if _, job := status(location); job.Done() {
+				return job
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("job %s is not done", location)
+		return Job{}
+	}
+
+	w, job := submit("block")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, JobPending, job.Status)
+	location := w.Header().Get("Location")
+	assert.Equal(t, "/jobs/"+job.ID, location)
+	<-started
+
+	w, job = status(location)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, JobRunning, job.Status)
+	assert.Equal(t, 0.5, job.Progress)
+
+	// the single worker is busy, the queue holds one more job only.
+	queued, _ := submit("fail")
+	assert.Equal(t, http.StatusAccepted, queued.Code)
+	w, _ = submit("ok")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	close(release)
+	job = await(location)
+	assert.Equal(t, JobSucceeded, job.Status)
+	assert.Equal(t, 1.0, job.Progress)
+	assert.Equal(t, map[string]interface{}{"kind": "block"}, job.Result)
+
+	job = await(queued.Header().Get("Location"))
+	assert.Equal(t, JobFailed, job.Status)
+	assert.Equal(t, "out of paper", job.Error)
+
+	w, _ = submit("panic")
+	job = await(w.Header().Get("Location"))
+	assert.Equal(t, "Internal Server Error", job.Error)
+	assert.Contains(t, logs.String(), "boom")
+
+	w, _ = status("/jobs/unknown")
+	assert.Equal(t, `{"code":404,"message":"job \"unknown\" is not found","data":null}`+"\n", w.Body.String())
+
+	assert.Nil(t, jobs.Shutdown(context.Background()))
+	w, _ = submit("ok")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestJobsShutdownTimeout(t *testing.T) {
+	jobs := &Jobs{Workers: 1}
+	router := NewRouter()
+	jobs.Mount(router, "/jobs")
+
+	started := make(chan struct{})
+	router.Post("/work", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = jobs.Submit(w, r, func(ctx context.Context, progress func(float64)) (interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/work", nil))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, jobs.Shutdown(ctx))
+	assert.Nil(t, jobs.Shutdown(context.Background()))
+
+	job, err := jobs.Store.Load(context.Background(), w.Header().Get("Location")[len("/jobs/"):])
+	assert.Nil(t, err)
+	assert.Equal(t, JobFailed, job.Status)
+	assert.Equal(t, context.Canceled.Error(), job.Error)
+}
+
+func TestMemoryJobStore(t *testing.T) {
+	now := time.Now()
+	router := NewRouter().Clock(ClockFunc(func() time.Time { return now }))
+	ctx := WithRouteContext(context.Background(), &RouteContext{router: router.(*routerGroup)})
+
+	store := NewMemoryJobStore(time.Minute)
+	assert.Nil(t, store.Save(ctx, &Job{ID: "running", Status: JobRunning, UpdatedAt: now}))
+	assert.Nil(t, store.Save(ctx, &Job{ID: "done", Status: JobSucceeded, UpdatedAt: now}))
+
+	job, err := store.Load(ctx, "done")
+	assert.Nil(t, err)
+	assert.Equal(t, "done", job.ID)
+
+	now = now.Add(time.Minute)
+	job, _ = store.Load(ctx, "done")
+	assert.Nil(t, job)
+	job, _ = store.Load(ctx, "running")
+	assert.Equal(t, "running", job.ID)
+
+	// the jobs done and never loaded again are swept.
+	assert.Nil(t, store.Save(ctx, &Job{ID: "forgotten", Status: JobFailed, UpdatedAt: now}))
+	now = now.Add(2 * time.Minute)
+	assert.Nil(t, store.Save(ctx, &Job{ID: "next", Status: JobRunning, UpdatedAt: now}))
+	assert.Len(t, store.(*memoryJobStore).jobs, 2)
+	_, ok := store.(*memoryJobStore).jobs["forgotten"]
+	assert.False(t, ok)
+}
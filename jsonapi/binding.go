@@ -0,0 +1,44 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonapi
+
+import (
+	"io"
+
+	"go-spring.dev/web/binding"
+)
+
+func init() {
+	binding.RegisterBodyBinder(MediaType, Bind)
+}
+
+// Bind binds the resource of the JSON:API request body into the struct pointed by i, see Unmarshal.
+// It's registered as the body binder of the JSON:API media type when the package is imported.
+func Bind(i interface{}, r binding.Request) error {
+	body := r.RequestBody()
+	if nil == body {
+		return nil
+	}
+	data, err := io.ReadAll(body)
+	if nil != err {
+		return err
+	}
+	if 0 == len(data) {
+		return nil
+	}
+	return Unmarshal(data, i)
+}
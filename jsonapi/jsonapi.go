@@ -0,0 +1,440 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package jsonapi renders and binds the JSON:API (https://jsonapi.org) documents.
+//
+// The resources are mapped from the structs by the `jsonapi` field tags:
+//
+//	type Article struct {
+//		ID     int     `jsonapi:"primary,articles"`
+//		Title  string  `jsonapi:"attr,title"`
+//		Body   string  `jsonapi:"attr,body,omitempty"`
+//		Author *Person `jsonapi:"relation,author"`
+//	}
+//
+// Importing the package registers the body binder of the JSON:API media type, and the routers
+// render the JSON:API documents with the Renderer:
+//
+//	router.Renderer(jsonapi.Renderer())
+//	router.Get("/articles/{id}", func(ctx context.Context, req struct {
+//		ID int `path:"id"`
+//	}) (*Article, error) {
+//		...
+//	})
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MediaType is the media type of the JSON:API documents.
+const MediaType = "application/vnd.api+json"
+
+// Document is the top level JSON:API document.
+type Document struct {
+	Data     interface{}            `json:"data,omitempty"`
+	Errors   []*ErrorObject         `json:"errors,omitempty"`
+	Included []*Resource            `json:"included,omitempty"`
+	Links    *Links                 `json:"links,omitempty"`
+	Meta     map[string]interface{} `json:"meta,omitempty"`
+}
+
+// MarshalJSON marshals the document, the data member is present unless the document has errors,
+// since a null data means the empty to-one primary data.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	type document Document
+	if len(d.Errors) > 0 {
+		return json.Marshal((*document)(d))
+	}
+	return json.Marshal(struct {
+		Data interface{} `json:"data"`
+		*document
+	}{Data: d.Data, document: (*document)(d)})
+}
+
+// Resource is a JSON:API resource object.
+type Resource struct {
+	Type          string                   `json:"type"`
+	ID            string                   `json:"id,omitempty"`
+	Attributes    map[string]interface{}   `json:"attributes,omitempty"`
+	Relationships map[string]*Relationship `json:"relationships,omitempty"`
+	Links         *Links                   `json:"links,omitempty"`
+}
+
+// Relationship is a JSON:API relationship object, the Data is a *ResourceIdentifier,
+// a []*ResourceIdentifier, or nil for the empty to-one relationships.
+type Relationship struct {
+	Data  interface{} `json:"data"`
+	Links *Links      `json:"links,omitempty"`
+}
+
+// ResourceIdentifier identifies a resource by the type and id.
+type ResourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Links are the links of a document, resource or relationship, including the pagination links.
+type Links struct {
+	Self    string `json:"self,omitempty"`
+	Related string `json:"related,omitempty"`
+	First   string `json:"first,omitempty"`
+	Prev    string `json:"prev,omitempty"`
+	Next    string `json:"next,omitempty"`
+	Last    string `json:"last,omitempty"`
+}
+
+// ErrorSource points to the source of the error in the request.
+type ErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
+}
+
+// ErrorObject is a JSON:API error object, it's an error itself so that handlers may return it.
+type ErrorObject struct {
+	ID     string                 `json:"id,omitempty"`
+	Status string                 `json:"status,omitempty"`
+	Code   string                 `json:"code,omitempty"`
+	Title  string                 `json:"title,omitempty"`
+	Detail string                 `json:"detail,omitempty"`
+	Source *ErrorSource           `json:"source,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+func (e *ErrorObject) Error() string {
+	if len(e.Detail) > 0 {
+		return fmt.Sprintf("%s: %s", e.Title, e.Detail)
+	}
+	return e.Title
+}
+
+// Errors are multiple JSON:API error objects returned as a single error.
+type Errors []*ErrorObject
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Marshal returns the document of the primary data v, a struct (or a pointer to it) is marshaled
+// as a single resource, and a slice as a collection of resources.
+func Marshal(v interface{}) (*Document, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr && !value.IsNil() && value.Elem().Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	switch {
+	case !value.IsValid(), value.Kind() == reflect.Ptr && value.IsNil():
+		return &Document{Data: nil}, nil
+	case value.Kind() == reflect.Slice || value.Kind() == reflect.Array:
+		resources := make([]*Resource, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			resource, err := MarshalResource(value.Index(i).Interface())
+			if nil != err {
+				return nil, err
+			}
+			resources = append(resources, resource)
+		}
+		return &Document{Data: resources}, nil
+	default:
+		resource, err := MarshalResource(v)
+		if nil != err {
+			return nil, err
+		}
+		return &Document{Data: resource}, nil
+	}
+}
+
+// MarshalResource returns the resource object of the struct v.
+func MarshalResource(v interface{}) (*Resource, error) {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("jsonapi: %T is not a struct", v)
+	}
+
+	resource := &Resource{}
+	primary := false
+	for _, f := range fieldsOf(value.Type()) {
+		field := value.FieldByIndex(f.index)
+		switch f.kind {
+		case "primary":
+			primary = true
+			resource.Type = f.name
+			if !field.IsZero() {
+				resource.ID = formatID(field)
+			}
+		case "attr":
+			if f.omitempty && field.IsZero() {
+				continue
+			}
+			if nil == resource.Attributes {
+				resource.Attributes = map[string]interface{}{}
+			}
+			resource.Attributes[f.name] = field.Interface()
+		case "relation":
+			relationship, err := marshalRelationship(field)
+			if nil != err {
+				return nil, fmt.Errorf("jsonapi: relation '%s': %w", f.name, err)
+			}
+			if f.omitempty && nil == relationship.Data {
+				continue
+			}
+			if nil == resource.Relationships {
+				resource.Relationships = map[string]*Relationship{}
+			}
+			resource.Relationships[f.name] = relationship
+		}
+	}
+	if !primary {
+		return nil, fmt.Errorf("jsonapi: %s has no primary field", value.Type())
+	}
+	return resource, nil
+}
+
+func marshalRelationship(field reflect.Value) (*Relationship, error) {
+	identify := func(v reflect.Value) (*ResourceIdentifier, error) {
+		resource, err := MarshalResource(v.Interface())
+		if nil != err {
+			return nil, err
+		}
+		return &ResourceIdentifier{Type: resource.Type, ID: resource.ID}, nil
+	}
+
+	switch field.Kind() {
+	case reflect.Slice, reflect.Array:
+		identifiers := make([]*ResourceIdentifier, 0, field.Len())
+		for i := 0; i < field.Len(); i++ {
+			identifier, err := identify(field.Index(i))
+			if nil != err {
+				return nil, err
+			}
+			identifiers = append(identifiers, identifier)
+		}
+		return &Relationship{Data: identifiers}, nil
+	case reflect.Ptr:
+		if field.IsNil() {
+			return &Relationship{Data: nil}, nil
+		}
+		fallthrough
+	default:
+		identifier, err := identify(field)
+		if nil != err {
+			return nil, err
+		}
+		return &Relationship{Data: identifier}, nil
+	}
+}
+
+// Unmarshal binds the resource of the document data into the struct pointed by v.
+func Unmarshal(data []byte, v interface{}) error {
+	var document struct {
+		Data *struct {
+			Type          string                     `json:"type"`
+			ID            string                     `json:"id"`
+			Attributes    map[string]json.RawMessage `json:"attributes"`
+			Relationships map[string]struct {
+				Data json.RawMessage `json:"data"`
+			} `json:"relationships"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &document); nil != err {
+		return err
+	}
+	if nil == document.Data {
+		return fmt.Errorf("jsonapi: document has no primary data")
+	}
+
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("jsonapi: %T is not a struct pointer", v)
+	}
+	value = value.Elem()
+
+	resource := document.Data
+	for _, f := range fieldsOf(value.Type()) {
+		field := value.FieldByIndex(f.index)
+		switch f.kind {
+		case "primary":
+			if resource.Type != f.name {
+				return fmt.Errorf("jsonapi: resource type '%s' mismatches '%s'", resource.Type, f.name)
+			}
+			if len(resource.ID) > 0 {
+				if err := parseID(field, resource.ID); nil != err {
+					return fmt.Errorf("jsonapi: id: %w", err)
+				}
+			}
+		case "attr":
+			if raw, ok := resource.Attributes[f.name]; ok {
+				if err := json.Unmarshal(raw, field.Addr().Interface()); nil != err {
+					return fmt.Errorf("jsonapi: attribute '%s': %w", f.name, err)
+				}
+			}
+		case "relation":
+			if relationship, ok := resource.Relationships[f.name]; ok {
+				if err := unmarshalRelationship(field, relationship.Data); nil != err {
+					return fmt.Errorf("jsonapi: relation '%s': %w", f.name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func unmarshalRelationship(field reflect.Value, data json.RawMessage) error {
+	// assign sets the primary id of the related resource.
+	assign := func(target reflect.Value, identifier ResourceIdentifier) error {
+		if target.Kind() == reflect.Ptr {
+			target.Set(reflect.New(target.Type().Elem()))
+			target = target.Elem()
+		}
+		for _, f := range fieldsOf(target.Type()) {
+			if "primary" == f.kind {
+				if identifier.Type != f.name {
+					return fmt.Errorf("resource type '%s' mismatches '%s'", identifier.Type, f.name)
+				}
+				return parseID(target.FieldByIndex(f.index), identifier.ID)
+			}
+		}
+		return fmt.Errorf("%s has no primary field", target.Type())
+	}
+
+	if field.Kind() == reflect.Slice {
+		var identifiers []ResourceIdentifier
+		if err := json.Unmarshal(data, &identifiers); nil != err {
+			return err
+		}
+		slice := reflect.MakeSlice(field.Type(), len(identifiers), len(identifiers))
+		for i, identifier := range identifiers {
+			if err := assign(slice.Index(i), identifier); nil != err {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	var identifier *ResourceIdentifier
+	if err := json.Unmarshal(data, &identifier); nil != err {
+		return err
+	}
+	if nil == identifier {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	return assign(field, *identifier)
+}
+
+// PaginationLinks returns the pagination links of the page number of a collection with the page size
+// and the total count of resources, the page is selected by the `page[number]` and `page[size]` query
+// params, and the other query params of the url are kept.
+func PaginationLinks(u *url.URL, number, size, total int) *Links {
+	if size <= 0 {
+		size = 1
+	}
+	last := (total + size - 1) / size
+	if last < 1 {
+		last = 1
+	}
+
+	link := func(n int) string {
+		query := u.Query()
+		query.Set("page[number]", strconv.Itoa(n))
+		query.Set("page[size]", strconv.Itoa(size))
+		cp := *u
+		cp.RawQuery = query.Encode()
+		return cp.String()
+	}
+
+	links := &Links{Self: link(number), First: link(1), Last: link(last)}
+	if number > 1 {
+		links.Prev = link(number - 1)
+	}
+	if number < last {
+		links.Next = link(number + 1)
+	}
+	return links
+}
+
+type fieldInfo struct {
+	index     []int
+	kind      string
+	name      string
+	omitempty bool
+}
+
+// fieldsOf returns the fields of the struct type tagged by `jsonapi`.
+func fieldsOf(t reflect.Type) []fieldInfo {
+	var fields []fieldInfo
+	for _, field := range reflect.VisibleFields(t) {
+		tag, ok := field.Tag.Lookup("jsonapi")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		f := fieldInfo{index: field.Index, kind: parts[0]}
+		if len(parts) > 1 {
+			f.name = parts[1]
+		}
+		for _, option := range parts[2:] {
+			f.omitempty = f.omitempty || "omitempty" == option
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func formatID(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func parseID(v reflect.Value, id string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(id)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(id, 10, v.Type().Bits())
+		if nil != err {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(id, 10, v.Type().Bits())
+		if nil != err {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported id type %s", v.Type())
+	}
+	return nil
+}
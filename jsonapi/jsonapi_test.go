@@ -0,0 +1,155 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-spring.dev/web"
+	"go-spring.dev/web/jsonapi"
+)
+
+type Person struct {
+	ID   string `jsonapi:"primary,people"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type Tag struct {
+	ID int `jsonapi:"primary,tags"`
+}
+
+type Article struct {
+	ID     int     `jsonapi:"primary,articles"`
+	Title  string  `jsonapi:"attr,title"`
+	Body   string  `jsonapi:"attr,body,omitempty"`
+	Author *Person `jsonapi:"relation,author"`
+	Tags   []Tag   `jsonapi:"relation,tags"`
+}
+
+func TestMarshal(t *testing.T) {
+	article := &Article{ID: 1, Title: "JSON:API", Author: &Person{ID: "9"}, Tags: []Tag{{ID: 2}, {ID: 3}}}
+
+	document, err := jsonapi.Marshal(article)
+	assert.Nil(t, err)
+	data, err := document.MarshalJSON()
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"data":{"type":"articles","id":"1","attributes":{"title":"JSON:API"},"relationships":{
+		"author":{"data":{"type":"people","id":"9"}},
+		"tags":{"data":[{"type":"tags","id":"2"},{"type":"tags","id":"3"}]}}}}`, string(data))
+
+	document, err = jsonapi.Marshal([]Article{})
+	assert.Nil(t, err)
+	data, _ = document.MarshalJSON()
+	assert.JSONEq(t, `{"data":[]}`, string(data))
+
+	document, err = jsonapi.Marshal((*Article)(nil))
+	assert.Nil(t, err)
+	data, _ = document.MarshalJSON()
+	assert.JSONEq(t, `{"data":null}`, string(data))
+
+	_, err = jsonapi.Marshal(struct{ Name string }{})
+	assert.NotNil(t, err)
+}
+
+func TestUnmarshal(t *testing.T) {
+	var article Article
+	err := jsonapi.Unmarshal([]byte(`{"data":{"type":"articles","id":"7","attributes":{"title":"hello","body":"world"},
+		"relationships":{"author":{"data":{"type":"people","id":"9"}},"tags":{"data":[{"type":"tags","id":"2"}]}}}}`), &article)
+	assert.Nil(t, err)
+	assert.Equal(t, Article{ID: 7, Title: "hello", Body: "world", Author: &Person{ID: "9"}, Tags: []Tag{{ID: 2}}}, article)
+
+	err = jsonapi.Unmarshal([]byte(`{"data":{"type":"people","id":"7"}}`), &article)
+	assert.EqualError(t, err, "jsonapi: resource type 'people' mismatches 'articles'")
+
+	err = jsonapi.Unmarshal([]byte(`{"data":{"type":"articles","relationships":{"tags":{"data":[{"type":"tags","id":"x"}]}}}}`), &article)
+	assert.NotNil(t, err)
+}
+
+func TestPaginationLinks(t *testing.T) {
+	u, _ := url.Parse("/articles?sort=title")
+	links := jsonapi.PaginationLinks(u, 2, 10, 35)
+	assert.Equal(t, "/articles?page%5Bnumber%5D=2&page%5Bsize%5D=10&sort=title", links.Self)
+	assert.Equal(t, "/articles?page%5Bnumber%5D=1&page%5Bsize%5D=10&sort=title", links.Prev)
+	assert.Equal(t, "/articles?page%5Bnumber%5D=3&page%5Bsize%5D=10&sort=title", links.Next)
+	assert.Equal(t, "/articles?page%5Bnumber%5D=4&page%5Bsize%5D=10&sort=title", links.Last)
+
+	links = jsonapi.PaginationLinks(u, 1, 10, 0)
+	assert.Empty(t, links.Prev)
+	assert.Empty(t, links.Next)
+	assert.Equal(t, links.First, links.Last)
+}
+
+func TestRenderer(t *testing.T) {
+	r := web.NewRouter()
+	r.Renderer(jsonapi.Renderer())
+	r.Post("/articles/{id}", func(ctx context.Context, req struct {
+		PathID int `path:"id"`
+		Article
+	}) (*Article, error) {
+		if req.PathID != req.ID {
+			return nil, &jsonapi.ErrorObject{Status: "409", Title: "Conflict", Detail: "id mismatches"}
+		}
+		return &req.Article, nil
+	})
+	r.Get("/articles", func(ctx context.Context) (*jsonapi.Document, error) {
+		document, err := jsonapi.Marshal([]Article{{ID: 1, Title: "first"}})
+		if nil == err {
+			document.Links = &jsonapi.Links{Self: "/articles"}
+		}
+		return document, err
+	})
+	r.Get("/missing", func(ctx context.Context) (*Article, error) {
+		return nil, web.Error(http.StatusNotFound, "article not found")
+	})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", jsonapi.MediaType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/articles/5", `{"data":{"type":"articles","id":"5","attributes":{"title":"hi"}}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, jsonapi.MediaType, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"data":{"type":"articles","id":"5","attributes":{"title":"hi"},"relationships":{"author":{"data":null},"tags":{"data":[]}}}}`, w.Body.String())
+
+	w = serve(http.MethodPost, "/articles/6", `{"data":{"type":"articles","id":"5"}}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.JSONEq(t, `{"errors":[{"status":"409","title":"Conflict","detail":"id mismatches"}]}`, w.Body.String())
+
+	w = serve(http.MethodPost, "/articles/5", `{"data":{"type":"people","id":"5"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(http.MethodPost, "/articles/five", `{"data":{"type":"articles","id":"5"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"errors":[{"status":"400","title":"Bad Request","detail":"id: strconv.ParseInt: parsing \"five\": invalid syntax","meta":{"field":"id","source":"path"}}]}`, w.Body.String())
+
+	w = serve(http.MethodGet, "/articles", "")
+	assert.JSONEq(t, `{"data":[{"type":"articles","id":"1","attributes":{"title":"first"},"relationships":{"author":{"data":null},"tags":{"data":[]}}}],"links":{"self":"/articles"}}`, w.Body.String())
+
+	w = serve(http.MethodGet, "/missing", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"errors":[{"status":"404","title":"Not Found","detail":"article not found"}]}`, w.Body.String())
+}
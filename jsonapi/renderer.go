@@ -0,0 +1,123 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-spring.dev/web"
+	"go-spring.dev/web/binding"
+)
+
+// Renderer returns a renderer writing the results of the handlers as JSON:API documents, the
+// results of type *Document are written as is, and the other results are marshaled by Marshal.
+// The errors are written as the error objects with the status of the error: the Errors and
+// *ErrorObject with their own status, web.HttpError with its code, 400 for the binding and
+// validation errors, 413 for the too large request bodies, and 500 for the others.
+func Renderer() web.Renderer {
+	return web.RendererFunc(render)
+}
+
+func render(ctx *web.Context, err error, result interface{}) {
+	if nil != err {
+		status, errs := errorObjects(err)
+		write(ctx, status, &Document{Errors: errs})
+		return
+	}
+
+	document, ok := result.(*Document)
+	if !ok {
+		var marshalErr error
+		if document, marshalErr = Marshal(result); nil != marshalErr {
+			status, errs := errorObjects(marshalErr)
+			write(ctx, status, &Document{Errors: errs})
+			return
+		}
+	}
+	write(ctx, http.StatusOK, document)
+}
+
+func write(ctx *web.Context, status int, document *Document) {
+	data, err := json.Marshal(document)
+	if nil != err {
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(&Document{Errors: []*ErrorObject{newErrorObject(status, err.Error())}})
+	}
+	_ = ctx.Data(status, MediaType, data)
+}
+
+// errorObjects returns the HTTP status and error objects of the error.
+func errorObjects(err error) (int, []*ErrorObject) {
+	var errs Errors
+	var errObject *ErrorObject
+	var httpErr web.HttpError
+	var maxBytesErr *http.MaxBytesError
+	var multipartLimitErr *binding.MultipartLimitError
+	var bindingErrs binding.BindingErrors
+
+	switch {
+	case errors.As(err, &errs) && len(errs) > 0:
+		return statusOf(errs[0]), errs
+	case errors.As(err, &errObject):
+		return statusOf(errObject), []*ErrorObject{errObject}
+	case errors.As(err, &httpErr):
+		return httpErr.Code, []*ErrorObject{newErrorObject(httpErr.Code, httpErr.Message)}
+	case errors.As(err, &maxBytesErr) || errors.As(err, &multipartLimitErr):
+		return http.StatusRequestEntityTooLarge, []*ErrorObject{newErrorObject(http.StatusRequestEntityTooLarge, err.Error())}
+	case errors.As(err, &bindingErrs) && len(bindingErrs) > 0:
+		objects := make([]*ErrorObject, len(bindingErrs))
+		for i, fieldErr := range bindingErrs {
+			objects[i] = fieldErrorObject(fieldErr)
+		}
+		return http.StatusBadRequest, objects
+	case errors.Is(err, binding.ErrBinding) || errors.Is(err, binding.ErrValidate):
+		return http.StatusBadRequest, []*ErrorObject{newErrorObject(http.StatusBadRequest, err.Error())}
+	default:
+		return http.StatusInternalServerError, []*ErrorObject{newErrorObject(http.StatusInternalServerError, err.Error())}
+	}
+}
+
+// fieldErrorObject returns the error object of the field failing to bind or validate,
+// the source refers to the query parameter or the header the field is bound from.
+func fieldErrorObject(e *binding.FieldError) *ErrorObject {
+	errObject := newErrorObject(http.StatusBadRequest, e.Error())
+	switch e.Source {
+	case "query":
+		errObject.Source = &ErrorSource{Parameter: e.Field}
+	case "header":
+		errObject.Source = &ErrorSource{Header: e.Field}
+	}
+	if len(e.Field) > 0 {
+		errObject.Meta = map[string]interface{}{"field": e.Field, "source": e.Source}
+	}
+	return errObject
+}
+
+func newErrorObject(status int, detail string) *ErrorObject {
+	return &ErrorObject{Status: strconv.Itoa(status), Title: http.StatusText(status), Detail: detail}
+}
+
+// statusOf returns the HTTP status of the error object, 500 if it has no valid status.
+func statusOf(e *ErrorObject) int {
+	if status, err := strconv.Atoi(e.Status); nil == err && status >= 400 && status <= 599 {
+		return status
+	}
+	return http.StatusInternalServerError
+}
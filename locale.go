@@ -0,0 +1,96 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"path"
+	"strings"
+
+	"go-spring.dev/web/binding"
+)
+
+type localeKey struct{}
+
+func init() {
+	// the negotiated locale is bound into the fields tagged by `ctx:"locale"`.
+	binding.RegisterContextKey("locale", localeKey{})
+}
+
+// Locale returns a middleware that negotiates the locale of the request among the supported
+// language tags by the `Accept-Language` header, the first supported tag is the default.
+// The response carries the negotiated locale by the `Content-Language` header and varies
+// on `Accept-Language`, so that the caches keep the localized responses apart.
+//
+//	router.Use(web.Locale("en", "fr", "zh-CN"))
+func Locale(supported ...string) MiddlewareFunc {
+	if 0 == len(supported) {
+		panic("locale middleware requires at least one supported language tag")
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			locale := binding.NegotiateLanguage(request.Header.Get("Accept-Language"), supported)
+
+			header := writer.Header()
+			header.Set("Content-Language", locale)
+			if !headerContains(header, "Vary", "Accept-Language") {
+				header.Add("Vary", "Accept-Language")
+			}
+
+			next.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), localeKey{}, locale)))
+		})
+	}
+}
+
+// LocaleOf returns the locale negotiated by the Locale middleware, or an empty string.
+func LocaleOf(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// TemplateVariant returns the name of the localized variant of the named template, the locale is
+// inserted before the extension of the name, e.g. `index.fr-CA.html`, then `index.fr.html` for the
+// `fr-CA` locale, falls back to the name itself if no variant is defined.
+func TemplateVariant(t *template.Template, name, locale string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for len(locale) > 0 {
+		if variant := base + "." + locale + ext; nil != t.Lookup(variant) {
+			return variant
+		}
+		i := strings.LastIndexByte(locale, '-')
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return name
+}
+
+// headerContains reports whether the comma separated values of the header contain the token.
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
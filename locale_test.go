@@ -0,0 +1,45 @@
+package web
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocale(t *testing.T) {
+	tmpl := template.Must(template.New("index.html").Parse(`hello {{.}}`))
+	template.Must(tmpl.New("index.fr.html").Parse(`bonjour {{.}}`))
+
+	r := NewRouter()
+	r.Use(Locale("en", "fr", "fr-CA"))
+	r.Get("/", func(ctx context.Context) {
+		_ = FromContext(ctx).HTML(http.StatusOK, tmpl, "index.html", LocaleOf(ctx))
+	})
+
+	tests := []struct {
+		acceptLanguage string
+		locale         string
+		body           string
+	}{
+		{"", "en", "hello en"},
+		{"fr-CA", "fr-CA", "bonjour fr-CA"},
+		{"fr-BE, en;q=0.5", "fr", "bonjour fr"},
+	}
+	for _, tt := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Accept-Language", tt.acceptLanguage)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, request)
+		assert.Equal(t, tt.locale, w.Header().Get("Content-Language"))
+		assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, tt.body, w.Body.String())
+	}
+
+	assert.Equal(t, "index.html", TemplateVariant(tmpl, "index.html", "de"))
+	assert.Panics(t, func() { Locale() })
+}
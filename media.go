@@ -0,0 +1,179 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ConsumesKey is the route metadata key of the request content types accepted by the route.
+const ConsumesKey = "web.consumes"
+
+// ProducesKey is the route metadata key of the response content types produced by the route.
+const ProducesKey = "web.produces"
+
+// Consumes declares the request content types accepted by the route, the requests with a body
+// of other content types are rejected with 415 Unsupported Media Type before binding.
+// The media ranges like `application/*` are allowed.
+//
+//	router.Post("/users", CreateUser).Apply(web.Consumes("application/json"))
+func Consumes(contentTypes ...string) RouteOption {
+	var mediaTypes []string
+	for _, contentType := range contentTypes {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if nil != err {
+			panic(fmt.Sprintf("invalid consumes content type '%s': %v", contentType, err))
+		}
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	return func(e Endpoint) {
+		e.Meta(ConsumesKey, mediaTypes)
+	}
+}
+
+// Produces declares the response content types produced by the route, in the order of preference.
+// The requests accepting none of them are rejected with 406 Not Acceptable, and the handlers and
+// renderers pick the negotiated content type by NegotiatedType. The results of the typed handlers
+// are rendered in the envelope of JsonRender encoded as JSON, XML, MessagePack or CBOR if one of them
+// is negotiated, the other content types are left to the Renderer of the route.
+//
+//	router.Get("/reports", Export).Apply(web.Produces("application/json", "text/csv"))
+func Produces(contentTypes ...string) RouteOption {
+	for _, contentType := range contentTypes {
+		if _, _, err := mime.ParseMediaType(contentType); nil != err {
+			panic(fmt.Sprintf("invalid produces content type '%s': %v", contentType, err))
+		}
+	}
+	return func(e Endpoint) {
+		e.Meta(ProducesKey, contentTypes)
+	}
+}
+
+// NegotiatedType returns the content type produced by the matched route that's preferred by the
+// `Accept` header of the request, or an empty string if the route doesn't declare Produces.
+func NegotiatedType(r *http.Request) string {
+	ctx := FromRouteContext(r.Context())
+	if nil == ctx {
+		return ""
+	}
+	offers, _ := ctx.routeMetadata[ProducesKey].([]string)
+	return Negotiate(r.Header.Get("Accept"), offers...)
+}
+
+// Negotiate returns the offered content type preferred by the `Accept` header value, the offers
+// are in the order of preference, returns the first offer if the header is empty, or an empty
+// string if none of them is acceptable.
+func Negotiate(accept string, offers ...string) string {
+	if 0 == len(offers) {
+		return ""
+	}
+	if 0 == len(strings.TrimSpace(accept)) {
+		return offers[0]
+	}
+
+	type mediaRange struct {
+		mediaType   string
+		quality     float64
+		specificity int
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if nil != err {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(q, 64); nil == err {
+				quality = v
+			}
+		}
+		specificity := 3
+		if "*/*" == mediaType {
+			specificity = 1
+		} else if strings.HasSuffix(mediaType, "/*") {
+			specificity = 2
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality, specificity: specificity})
+	}
+
+	best, bestQuality := "", 0.0
+	for _, offer := range offers {
+		offerType, _, _ := mime.ParseMediaType(offer)
+
+		// the quality of the offer is given by the most specific matching range.
+		quality, specificity := 0.0, 0
+		for _, r := range ranges {
+			if r.specificity > specificity && matchMediaType(r.mediaType, offerType) {
+				quality, specificity = r.quality, r.specificity
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best
+}
+
+// acceptable reports whether the request accepts any content type produced by the matched route.
+func acceptable(ctx *RouteContext, r *http.Request) bool {
+	offers, ok := ctx.routeMetadata[ProducesKey].([]string)
+	if !ok || len(offers) == 0 {
+		return true
+	}
+	return len(Negotiate(r.Header.Get("Accept"), offers...)) > 0
+}
+
+// consumable reports whether the request body can be consumed by the matched route.
+func consumable(ctx *RouteContext, r *http.Request) bool {
+	mediaTypes, ok := ctx.routeMetadata[ConsumesKey].([]string)
+	if !ok || len(mediaTypes) == 0 {
+		return true
+	}
+
+	// the requests without body are not checked.
+	if 0 == r.ContentLength && 0 == len(r.TransferEncoding) && 0 == len(r.Header.Get("Content-Type")) {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if nil != err {
+		return false
+	}
+	for _, accepted := range mediaTypes {
+		if matchMediaType(accepted, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMediaType reports whether the media type matches the media range.
+func matchMediaType(mediaRange, mediaType string) bool {
+	if "*/*" == mediaRange || mediaRange == mediaType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(mediaRange, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumes(t *testing.T) {
+	r := NewRouter()
+	r.Post("/users", func(ctx context.Context, req struct {
+		Name string `json:"name"`
+	}) string {
+		return req.Name
+	}).Apply(Consumes("application/json"))
+	r.Post("/images", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("image"))
+	}).Apply(Consumes("image/*"))
+
+	serve := func(path, contentType, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if len(contentType) > 0 {
+			request.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	w := serve("/users", "application/json; charset=utf-8", `{"name":"bob"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"data":"bob"`)
+
+	w = serve("/users", "application/x-www-form-urlencoded", "name=bob")
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = serve("/users", "", "name=bob")
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = serve("/users", "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, "image", serve("/images", "image/png", "png").Body.String())
+	assert.Equal(t, http.StatusUnsupportedMediaType, serve("/images", "text/plain", "png").Code)
+
+	assert.Panics(t, func() { Consumes("invalid/;") })
+}
+
+func TestMatchMediaType(t *testing.T) {
+	assert.True(t, matchMediaType("*/*", "text/plain"))
+	assert.True(t, matchMediaType("text/*", "text/plain"))
+	assert.True(t, matchMediaType("text/plain", "text/plain"))
+	assert.False(t, matchMediaType("text/*", "textual/plain"))
+	assert.False(t, matchMediaType("application/json", "application/xml"))
+}
+
+func TestProduces(t *testing.T) {
+	r := NewRouter()
+	r.Get("/reports", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(NegotiatedType(r)))
+	}).Apply(Produces("application/json", "text/csv"))
+	r.Get("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("plain:" + NegotiatedType(r)))
+	})
+
+	serve := func(path, accept string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		if len(accept) > 0 {
+			request.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	assert.Equal(t, "application/json", serve("/reports", "").Body.String())
+	assert.Equal(t, "text/csv", serve("/reports", "text/csv").Body.String())
+	assert.Equal(t, "text/csv", serve("/reports", "application/json;q=0.5, text/*").Body.String())
+	assert.Equal(t, "application/json", serve("/reports", "*/*").Body.String())
+	assert.Equal(t, http.StatusNotAcceptable, serve("/reports", "application/xml").Code)
+	assert.Equal(t, "plain:", serve("/plain", "application/xml").Body.String())
+}
+
+func TestProducesRenderer(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	r := NewRouter()
+	r.Get("/users/{name}", func(ctx context.Context, req *struct {
+		Name string `path:"name"`
+	}) user {
+		return user{Name: req.Name}
+	}).Apply(Produces("application/json", "application/xml"))
+	r.Group("/internal", func(r Router) {
+		r.Renderer(MsgPackRender())
+		r.Get("/users/{name}", func(ctx context.Context) user { return user{Name: "bob"} }).Apply(Produces("application/msgpack", "application/json"))
+	})
+
+	serve := func(path, accept string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	w := serve("/users/bob", "application/json")
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"code":0,"data":{"name":"bob"}}`, w.Body.String())
+
+	w = serve("/users/bob", "application/xml")
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<response><code>0</code><data><name>bob</name></data></response>", w.Body.String())
+
+	w = serve("/internal/users/bob", "application/json")
+	assert.JSONEq(t, `{"code":0,"data":{"name":"bob"}}`, w.Body.String())
+	w = serve("/internal/users/bob", "application/msgpack")
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+
+	// the custom renderers render the negotiated content type themselves.
+	r.Group("/custom", func(r Router) {
+		r.Renderer(RendererFunc(func(ctx *Context, err error, result interface{}) {
+			_ = ctx.String(http.StatusOK, "%s %v", NegotiatedType(ctx.Request), result)
+		}))
+		r.Get("/users/{name}", func(ctx context.Context) user { return user{Name: "bob"} }).Apply(Produces("application/xml"))
+	})
+	w = serve("/custom/users/bob", "application/xml")
+	assert.Equal(t, "application/xml {bob}", w.Body.String())
+
+	// the observers of JsonRender are kept when the envelope is encoded in the negotiated type.
+	var observed error
+	r.Group("/observed", func(r Router) {
+		r.Renderer(JsonRender(func(ctx *Context, err error) { observed = err }))
+		r.Get("/users", func(ctx context.Context) interface{} { return map[string]int{"a": 1} }).Apply(Produces("application/xml"))
+	})
+	w = serve("/observed/users", "application/xml")
+	assert.Contains(t, w.Body.String(), "<code>500</code>")
+	assert.NotNil(t, observed)
+}
+
+func TestNegotiate(t *testing.T) {
+	assert.Equal(t, "text/html", Negotiate("text/html,application/xhtml+xml,*/*;q=0.8", "application/json", "text/html"))
+	assert.Equal(t, "application/json", Negotiate("text/*;q=0.1, */*;q=0.5", "text/plain", "application/json"))
+	assert.Equal(t, "", Negotiate("text/plain;q=0", "text/plain"))
+	assert.Equal(t, "", Negotiate("text/plain"))
+}
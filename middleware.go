@@ -17,11 +17,18 @@
 package web
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"runtime"
 	"runtime/debug"
+	"strconv"
+	"strings"
 )
 
 // MiddlewareFunc is a function which receives an http.Handler and returns another http.Handler.
@@ -77,25 +84,222 @@ func Recovery() MiddlewareFunc {
 }
 
 // RecoveryWith returns a middleware for a given writer that recovers from any panics and writes a 500 if there was one.
+//
+// The handlers may panic with an error to abort the request, e.g. `panic(web.Error(400, "invalid name"))`
+// in deeply nested validation code, such errors are rendered by the Renderer of the typed handler like the
+// returned errors, or written with the status and message of the HttpError otherwise. The other panics are
+// written to panicOut only, the client gets a generic 500 response. Nothing is written if the response has
+// started already, and http.ErrAbortHandler is panicked again to abort the response as net/http does.
 func RecoveryWith(panicOut io.Writer) MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 
 		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 
+			slot := &recoverySlot{}
+			rw := &recoveryWriter{ResponseWriter: writer}
+
 			defer func() {
 				if rv := recover(); nil != rv {
 
-					if nil != panicOut {
+					var httpErr HttpError
+					err, ok := rv.(error)
+					if _, runtimeErr := rv.(runtime.Error); runtimeErr {
+						ok = false
+					}
+					if ok && errors.Is(err, http.ErrAbortHandler) {
+						panic(rv)
+					}
+
+					expected := ok && errors.As(err, &httpErr)
+					if nil != panicOut && !expected {
 						fmt.Fprintf(panicOut, "[recovered]: %v\n%s", rv, debug.Stack())
 					}
 
-					if request.Header.Get("Connection") != "Upgrade" {
-						writer.WriteHeader(http.StatusInternalServerError)
+					if rw.started {
+						return
+					}
+					if !expected {
+						httpErr = Error(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+						err = httpErr
+					}
+
+					switch {
+					case nil != slot.render:
+						slot.render.Render(slot.ctx, err, nil)
+					case expected || request.Header.Get("Connection") != "Upgrade":
+						http.Error(writer, httpErr.Message, httpErr.Code)
 					}
 				}
 			}()
 
+			next.ServeHTTP(rw, request.WithContext(context.WithValue(request.Context(), recoveryKey{}, slot)))
+		})
+	}
+}
+
+// recoveryWriter records whether the response has started, so that Recovery doesn't write
+// the error response over the header or the body written already.
+type recoveryWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *recoveryWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *recoveryWriter) WriteHeader(code int) {
+	if code < 100 || code > 199 || code == http.StatusSwitchingProtocols {
+		w.started = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recoveryWriter) Write(p []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *recoveryWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.started = true
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+}
+
+func (w *recoveryWriter) Flush() {
+	w.started = true
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *recoveryWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.started = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+type recoveryKey struct{}
+
+// recoverySlot records the context and renderer of the typed handler serving the request,
+// so that Recovery renders the error panics the same way as the returned errors.
+type recoverySlot struct {
+	ctx    *Context
+	render Renderer
+}
+
+// MethodOverride returns a middleware that overrides the method of the POST requests with the
+// `X-HTTP-Method-Override` header or the `_method` query param, so that the clients which can only
+// send POST requests (e.g. html forms posted to `/users/1?_method=DELETE`) can trigger the PUT, PATCH
+// and DELETE routes. The request body is never read, since it runs before the body limits of the
+// route apply.
+// It should be used on the router before the route lookup, which then selects the overridden method.
+func MethodOverride() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if http.MethodPost == request.Method {
+				method := request.Header.Get("X-HTTP-Method-Override")
+				if 0 == len(method) {
+					method = request.URL.Query().Get("_method")
+				}
+
+				switch method = strings.ToUpper(method); method {
+				case http.MethodPut, http.MethodPatch, http.MethodDelete:
+					request.Method = method
+					if ctx := FromRouteContext(request.Context()); nil != ctx && len(ctx.RouteMethod) > 0 {
+						ctx.RouteMethod = method
+					}
+				}
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// GetHead returns a middleware that routes the HEAD requests to the GET handlers when no explicit
+// HEAD route exists, the response body is discarded and its length is reported by the Content-Length
+// header. It should be used on the router before the route lookup.
+func GetHead() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if http.MethodHead == request.Method {
+				if ctx := FromRouteContext(request.Context()); nil != ctx && nil != ctx.Routes {
+					routePath := ctx.RoutePath
+					if 0 == len(routePath) {
+						if len(request.URL.RawPath) > 0 {
+							routePath = request.URL.RawPath
+						} else {
+							routePath = request.URL.Path
+						}
+					}
+
+					if !ctx.Routes.Match(&RouteContext{}, http.MethodHead, routePath) &&
+						ctx.Routes.Match(&RouteContext{}, http.MethodGet, routePath) {
+						ctx.RouteMethod = http.MethodGet
+
+						hw := &headWriter{ResponseWriter: writer}
+						next.ServeHTTP(hw, request)
+						hw.flushHeader()
+						return
+					}
+				}
+			}
+
 			next.ServeHTTP(writer, request)
 		})
 	}
 }
+
+// headWriter discards the response body of the GET handlers serving HEAD requests,
+// the header is deferred until the handler returns to report the Content-Length.
+type headWriter struct {
+	http.ResponseWriter
+	status      int
+	written     int64
+	wroteHeader bool
+}
+
+func (w *headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *headWriter) WriteHeader(code int) {
+	if code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if 0 == w.status {
+		w.status = code
+	}
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if 0 == w.status {
+		w.status = http.StatusOK
+	}
+	w.written += int64(len(p))
+	return len(p), nil
+}
+
+func (w *headWriter) Flush() {
+	w.flushHeader()
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *headWriter) flushHeader() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if 0 == w.status {
+		w.status = http.StatusOK
+	}
+
+	header := w.Header()
+	if w.written > 0 && bodyAllowedForStatus(w.status) &&
+		0 == len(header.Get("Content-Length")) && 0 == len(header.Get("Transfer-Encoding")) {
+		header.Set("Content-Length", strconv.FormatInt(w.written, 10))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
@@ -0,0 +1,187 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodOverride(t *testing.T) {
+	r := NewRouter()
+	r.Use(MethodOverride())
+	r.Post("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("post"))
+	})
+	r.Put("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("put"))
+	})
+	r.Delete("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("delete " + r.FormValue("name")))
+	})
+
+	serve := func(request *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, request)
+		return w
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	assert.Equal(t, "post", serve(request).Body.String())
+
+	request = httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	request.Header.Set("X-HTTP-Method-Override", "put")
+	assert.Equal(t, "put", serve(request).Body.String())
+
+	request = httptest.NewRequest(http.MethodPost, "/users/1?_method=DELETE&name=bob", nil)
+	assert.Equal(t, "delete bob", serve(request).Body.String())
+
+	// the body isn't read looking for the method, e.g. before the body limits of the route apply.
+	form := url.Values{"_method": {"DELETE"}}
+	request = httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, "post", serve(request).Body.String())
+
+	// only POST requests are overridden, and only to the PUT, PATCH and DELETE methods.
+	request = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	request.Header.Set("X-HTTP-Method-Override", "DELETE")
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(request).Code)
+
+	request = httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	request.Header.Set("X-HTTP-Method-Override", "CONNECT")
+	assert.Equal(t, "post", serve(request).Body.String())
+
+	// overridden by the middleware of the subrouter after the mount lookup.
+	r = NewRouter()
+	r.Group("/admin", func(r Router) {
+		r.Use(MethodOverride())
+		r.Patch("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("patch"))
+		})
+	})
+
+	request = httptest.NewRequest(http.MethodPost, "/admin/users/1", nil)
+	request.Header.Set("X-HTTP-Method-Override", "PATCH")
+	assert.Equal(t, "patch", serve(request).Body.String())
+
+	// json body is not consumed looking for the form field.
+	request = httptest.NewRequest(http.MethodPost, "/admin/users/1", strings.NewReader(`{"_method":"PATCH"}`))
+	request.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(request).Code)
+}
+
+func TestRecoveryErrorPanics(t *testing.T) {
+	var out bytes.Buffer
+
+	r := NewRouter()
+	r.Use(RecoveryWith(&out))
+	r.Get("/typed", func(ctx context.Context) string {
+		panic(Error(http.StatusBadRequest, "invalid name"))
+	})
+	r.Get("/error", func(ctx context.Context) string {
+		panic(errors.New("failed"))
+	})
+	r.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		panic(Error(http.StatusForbidden, "forbidden"))
+	})
+	r.HandleFunc("/crash", func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]int
+		m["crash"] = 1
+	})
+	r.HandleFunc("/partial", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic(errors.New("failed"))
+	})
+	r.HandleFunc("/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := serve("/typed")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"code":400,"message":"invalid name","data":null}`, w.Body.String())
+	assert.Equal(t, 0, out.Len())
+
+	// the unexpected errors are logged only, the client gets a generic message.
+	w = serve("/error")
+	assert.JSONEq(t, `{"code":500,"message":"Internal Server Error","data":null}`, w.Body.String())
+	assert.Contains(t, out.String(), "[recovered]: failed")
+
+	out.Reset()
+	w = serve("/plain")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "forbidden\n", w.Body.String())
+	assert.Equal(t, 0, out.Len())
+
+	w = serve("/crash")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "Internal Server Error\n", w.Body.String())
+	assert.Contains(t, out.String(), "assignment to entry in nil map")
+
+	// the responses started already are left as they are.
+	w = serve("/partial")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "partial", w.Body.String())
+
+	// http.ErrAbortHandler aborts the response.
+	assert.PanicsWithError(t, http.ErrAbortHandler.Error(), func() { serve("/abort") })
+}
+
+func TestGetHead(t *testing.T) {
+	r := NewRouter()
+	r.Use(GetHead())
+	r.Get("/hi", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "yes")
+		w.Write([]byte("hello "))
+		w.Write([]byte("world"))
+	})
+	r.Get("/explicit", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("get"))
+	})
+	r.Head("/explicit", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Head", "explicit")
+	})
+	r.Post("/posts", func(w http.ResponseWriter, r *http.Request) {})
+	r.Group("/api", func(r Router) {
+		r.Get("/todos", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("todos"))
+		})
+	})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := serve(http.MethodHead, "/hi")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "yes", w.Header().Get("X-Test"))
+	assert.Equal(t, "11", w.Header().Get("Content-Length"))
+	assert.Equal(t, 0, w.Body.Len())
+
+	w = serve(http.MethodHead, "/explicit")
+	assert.Equal(t, "explicit", w.Header().Get("X-Head"))
+
+	w = serve(http.MethodHead, "/api/todos")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Content-Length"))
+	assert.Equal(t, 0, w.Body.Len())
+
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodHead, "/posts").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodHead, "/missing").Code)
+	assert.Equal(t, "hello world", serve(http.MethodGet, "/hi").Body.String())
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"go-spring.dev/web/render"
+)
+
+// MsgPackRender renders the results of the typed handlers as MessagePack in the same envelope of
+// JsonRender, i.e. the `code`, `message` and `data` keys, so that the internal services can swap the
+// wire format without changing the handlers. The results that can't be encoded are rendered as an
+// internal server error, and the observers are notified of the encoding error.
+//
+//	router.Renderer(web.MsgPackRender())
+func MsgPackRender(observers ...func(ctx *Context, err error)) Renderer {
+	return envelopeRender{
+		contentType: render.MsgPackRenderer{}.ContentType(),
+		marshal:     marshalMsgPack,
+		observers:   observers,
+	}
+}
+
+// marshalMsgPack encodes the value as render.MsgPackRenderer does.
+func marshalMsgPack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(v); nil != err {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
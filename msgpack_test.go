@@ -0,0 +1,59 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgPackRender(t *testing.T) {
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	var observed []error
+	r := NewRouter().Renderer(MsgPackRender(func(ctx *Context, err error) { observed = append(observed, err) }))
+	r.Post("/users", func(ctx context.Context, req *user) (*user, error) {
+		if len(req.Name) == 0 {
+			return nil, Error(http.StatusBadRequest, "name required")
+		}
+		req.ID = 1
+		return req, nil
+	})
+	r.Get("/broken", func(ctx context.Context) interface{} { return map[string]interface{}{"fn": func() {}} })
+
+	type envelope struct {
+		Code    int                    `msgpack:"code"`
+		Message string                 `msgpack:"message"`
+		Data    map[string]interface{} `msgpack:"data"`
+	}
+	serve := func(method, path string, body interface{}) envelope {
+		data, err := msgpack.Marshal(body)
+		assert.Nil(t, err)
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/msgpack")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+
+		var resp envelope
+		assert.Nil(t, msgpack.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	resp := serve("POST", "/users", map[string]string{"name": "Tom"})
+	assert.Equal(t, envelope{Data: map[string]interface{}{"id": int8(1), "name": "Tom"}}, resp)
+
+	resp = serve("POST", "/users", map[string]string{})
+	assert.Equal(t, envelope{Code: http.StatusBadRequest, Message: "name required"}, resp)
+
+	resp = serve("GET", "/broken", nil)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Len(t, observed, 1)
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"go-spring.dev/web/binding"
+)
+
+// MultipartLimitsKey is the route metadata key of the multipart limits of the route.
+const MultipartLimitsKey = "web.multipartLimits"
+
+// WithMultipartLimits overrides the limits of the multipart forms bound for the route, the zero fields
+// fall back to the limits of the router and the package, see binding.SetMultipartLimits. The forms
+// exceeding the limits are rendered as binding.MultipartLimitError with 413 Request Entity Too Large.
+//
+//	router.Post("/avatars", Upload).Apply(web.WithMultipartLimits(binding.MultipartLimits{MaxFileSize: 1 << 20, MaxFiles: 1}))
+func WithMultipartLimits(limits binding.MultipartLimits) RouteOption {
+	return func(e Endpoint) {
+		e.Meta(MultipartLimitsKey, limits)
+	}
+}
+
+// MultipartStream is the field type of the request structs reading the multipart/form-data
+// body part by part, e.g. the multi-gigabyte uploads, see binding.MultipartStream.
+//
+//	router.Post("/buckets/{bucket}", func(ctx context.Context, req *struct {
+//		Bucket string `path:"bucket"`
+//		Files  web.MultipartStream
+//	}) error {
+//		for {
+//			part, err := req.Files.NextPart()
+//			if err == io.EOF {
+//				return nil
+//			}
+//			...
+//		}
+//	})
+type MultipartStream = binding.MultipartStream
+
+// SavedFile is the field type of the request structs saving the uploaded files to the directory
+// named by the `save` tag while they're bound, see binding.SavedFile.
+//
+//	type Profile struct {
+//		Avatar *web.SavedFile `form:"avatar" save:"/var/uploads"`
+//	}
+type SavedFile = binding.SavedFile
@@ -0,0 +1,189 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-spring.dev/web/binding"
+)
+
+func TestWithMultipartLimits(t *testing.T) {
+	type upload struct {
+		Files []*multipart.FileHeader `form:"file"`
+	}
+
+	r := NewRouterWith(RouterOptions{MaxMultipartMemory: 1 << 10})
+	handler := func(ctx context.Context, req *upload) int { return len(req.Files) }
+	r.Post("/avatars", handler).Apply(WithMultipartLimits(binding.MultipartLimits{MaxFileSize: 4, MaxFiles: 1}))
+	r.Post("/files", handler)
+
+	post := func(path string) string {
+		buf := new(bytes.Buffer)
+		mw := multipart.NewWriter(buf)
+		for _, name := range []string{"a.png", "b.png"} {
+			w, _ := mw.CreateFormFile("file", name)
+			_, _ = w.Write([]byte("12345"))
+		}
+		mw.Close()
+
+		req := httptest.NewRequest("POST", path, buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	body := post("/files")
+	assert.Equal(t, "{\"code\":0,\"data\":2}\n", body)
+
+	body = post("/avatars")
+	assert.Contains(t, body, "{\"code\":413,\"message\":\"binding failed: multipart file")
+	assert.Contains(t, body, "exceeds the limit of 4 bytes")
+}
+
+func TestMultipartStream(t *testing.T) {
+	type upload struct {
+		Bucket string `path:"bucket"`
+		Files  MultipartStream
+	}
+
+	r := NewRouter()
+	handler := func(ctx context.Context, req upload) ([]string, error) {
+		parts := []string{req.Bucket}
+		for {
+			part, err := req.Files.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if nil != err {
+				return nil, err
+			}
+			data, _ := io.ReadAll(part)
+			parts = append(parts, fmt.Sprintf("%s:%s:%s", part.FormName(), part.FileName(), data))
+		}
+		// the body isn't parsed into the multipart form.
+		if nil != FromContext(ctx).Request.MultipartForm {
+			return nil, fmt.Errorf("multipart form parsed")
+		}
+		return parts, nil
+	}
+	r.Post("/buckets/{bucket}", handler)
+	r.Post("/limited/{bucket}", handler).Apply(WithMultipartLimits(binding.MultipartLimits{MaxFiles: 1}))
+
+	post := func(path string) string {
+		buf := new(bytes.Buffer)
+		mw := multipart.NewWriter(buf)
+		_ = mw.WriteField("note", "hi")
+		for _, name := range []string{"a.bin", "b.bin"} {
+			w, _ := mw.CreateFormFile("file", name)
+			_, _ = w.Write([]byte(name))
+		}
+		mw.Close()
+
+		req := httptest.NewRequest("POST", path, buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	assert.JSONEq(t, `{"code":0,"data":["b1","note::hi","file:a.bin:a.bin","file:b.bin:b.bin"]}`, post("/buckets/b1"))
+	assert.Contains(t, post("/limited/b1"), `{"code":413,"message":"too many multipart files: 2 files exceeds the limit of 1 files"`)
+}
+
+func TestSavedFile(t *testing.T) {
+	type profile struct {
+		Name   string      `form:"name"`
+		Avatar *SavedFile  `form:"avatar" save:"uploads"`
+		Photos []SavedFile `form:"photos" save:"uploads/photos"`
+		Resume SavedFile   `form:"resume" save:"uploads"`
+		Age    int         `form:"age"`
+	}
+
+	// the relative save directories are under the working directory.
+	wd, _ := os.Getwd()
+	_ = os.Chdir(t.TempDir())
+	defer func() { _ = os.Chdir(wd) }()
+
+	var saved profile
+	r := NewRouter()
+	r.Post("/profiles", func(ctx context.Context, req profile) string {
+		saved = req
+		return req.Name
+	})
+
+	post := func(age string) string {
+		buf := new(bytes.Buffer)
+		mw := multipart.NewWriter(buf)
+		_ = mw.WriteField("name", "bob")
+		_ = mw.WriteField("age", age)
+		w, _ := mw.CreateFormFile("avatar", "../../me.png")
+		_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n0000"))
+		for _, name := range []string{"a.txt", "b.txt"} {
+			w, _ = mw.CreateFormFile("photos", name)
+			_, _ = w.Write([]byte("hello " + name))
+		}
+		mw.Close()
+
+		req := httptest.NewRequest("POST", "/profiles", buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	assert.JSONEq(t, `{"code":0,"data":"bob"}`, post("1"))
+	if assert.NotNil(t, saved.Avatar) {
+		assert.Equal(t, "uploads", filepath.Dir(saved.Avatar.Path))
+		assert.Equal(t, ".png", filepath.Ext(saved.Avatar.Path))
+		assert.Equal(t, "me.png", saved.Avatar.Filename)
+		assert.Equal(t, int64(12), saved.Avatar.Size)
+		assert.Equal(t, "image/png", saved.Avatar.ContentType)
+	}
+	if assert.Len(t, saved.Photos, 2) {
+		data, _ := os.ReadFile(saved.Photos[1].Path)
+		assert.Equal(t, "hello b.txt", string(data))
+		assert.Equal(t, "text/plain; charset=utf-8", saved.Photos[1].ContentType)
+	}
+	assert.Equal(t, SavedFile{}, saved.Resume)
+
+	// the files saved for the forms failing to bind are removed.
+	assert.Contains(t, post("x"), `"code":400`)
+	avatars, _ := filepath.Glob("uploads/*.png")
+	photos, _ := filepath.Glob("uploads/photos/*")
+	assert.Len(t, avatars, 1)
+	assert.Len(t, photos, 2)
+
+	// the files saved for the forms failing to validate after they're bound are removed as well.
+	type signed struct {
+		Avatar *SavedFile `form:"avatar" save:"signed"`
+		Note   string     `form:"note" validate:"required"`
+	}
+	required := func(i interface{}) error {
+		if len(i.(*signed).Note) == 0 {
+			return binding.BindingErrors{{Field: "Note", Source: "validate", Reason: "is required"}}
+		}
+		return nil
+	}
+	r.Post("/signed", func(ctx context.Context, req signed) string { return req.Note }).Apply(WithValidator(required))
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	w, _ := mw.CreateFormFile("avatar", "me.png")
+	_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n0000"))
+	mw.Close()
+	req := httptest.NewRequest("POST", "/signed", buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Contains(t, rec.Body.String(), `"code":400`)
+	files, _ := filepath.Glob("signed/*")
+	assert.Empty(t, files)
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// optionalRoute is a pattern expanded from the trailing optional params, see expandOptional.
+type optionalRoute struct {
+	pattern string
+
+	// defaults are the default values of the optional params missing in the pattern.
+	defaults [][2]string
+}
+
+// expandOptional expands the trailing optional params of the pattern into the patterns with and
+// without them, the longest first, e.g. `/reports/{year}/{month?}` matches both `/reports/2024/05`
+// and `/reports/2024`. The missing params bind the default value given by `{month?=01}`, or the
+// zero value otherwise, the regexp follows the default, e.g. `{month?=01:[0-9]{2}}`.
+func expandOptional(pattern string) ([]optionalRoute, error) {
+	segments := splitSegments(pattern)
+
+	type optional struct {
+		segment      string
+		name         string
+		defaultValue string
+		hasDefault   bool
+	}
+
+	var params []optional
+	first := len(segments)
+	for i, segment := range segments {
+		var key, expr string
+		var hasExpr, isOptional bool
+		if len(segment) > 1 && '{' == segment[0] && '}' == segment[len(segment)-1] {
+			key, expr, hasExpr = strings.Cut(segment[1:len(segment)-1], ":")
+			isOptional = strings.Contains(key, "?")
+		}
+		if !isOptional {
+			if len(params) > 0 {
+				return nil, fmt.Errorf("routing pattern '%s': optional param '%s' must be followed by optional params only", pattern, params[len(params)-1].name)
+			}
+			continue
+		}
+
+		name, def, _ := strings.Cut(key, "?")
+		if len(def) > 0 && '=' != def[0] {
+			return nil, fmt.Errorf("routing pattern '%s': invalid optional param '%s'", pattern, segment)
+		}
+		if strings.HasSuffix(name, "...") {
+			return nil, fmt.Errorf("routing pattern '%s': wildcard param '%s' can't be optional", pattern, name)
+		}
+
+		segment = "{" + name + "}"
+		if hasExpr {
+			segment = "{" + name + ":" + expr + "}"
+		}
+		if len(params) == 0 {
+			first = i
+		}
+		params = append(params, optional{segment: segment, name: name, defaultValue: strings.TrimPrefix(def, "="), hasDefault: len(def) > 0})
+	}
+
+	if 0 == len(params) {
+		return []optionalRoute{{pattern: pattern}}, nil
+	}
+
+	routes := make([]optionalRoute, 0, len(params)+1)
+	for n := len(params); n >= 0; n-- {
+		parts := append([]string{}, segments[:first]...)
+		var defaults [][2]string
+		for i, param := range params {
+			if i < n {
+				parts = append(parts, param.segment)
+			} else if param.hasDefault {
+				defaults = append(defaults, [2]string{param.name, param.defaultValue})
+			}
+		}
+		route := optionalRoute{pattern: strings.Join(parts, "/"), defaults: defaults}
+		if 0 == len(route.pattern) {
+			route.pattern = "/"
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// splitSegments splits the pattern by the slashes outside the params, the regexps may contain slashes.
+func splitSegments(pattern string) []string {
+	var segments []string
+	start, depth := 0, 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '/':
+			if 0 == depth {
+				segments = append(segments, pattern[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(segments, pattern[start:])
+}
+
+// paramDefaults adds the default values of the optional params missing in the matched route.
+func paramDefaults(defaults [][2]string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ctx := FromRouteContext(r.Context()); nil != ctx {
+			for _, param := range defaults {
+				ctx.URLParams.Add(param[0], param[1])
+			}
+			setPathValue(ctx, r)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
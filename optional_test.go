@@ -0,0 +1,77 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandOptional(t *testing.T) {
+	routes, err := expandOptional("/reports/{year:[0-9]{4}}/{month?=01:[0-9]{2}}/{day?}")
+	assert.NoError(t, err)
+	assert.Equal(t, []optionalRoute{
+		{pattern: "/reports/{year:[0-9]{4}}/{month:[0-9]{2}}/{day}"},
+		{pattern: "/reports/{year:[0-9]{4}}/{month:[0-9]{2}}"},
+		{pattern: "/reports/{year:[0-9]{4}}", defaults: [][2]string{{"month", "01"}}},
+	}, routes)
+
+	routes, err = expandOptional("/{lang?=en}")
+	assert.NoError(t, err)
+	assert.Equal(t, []optionalRoute{{pattern: "/{lang}"}, {pattern: "/", defaults: [][2]string{{"lang", "en"}}}}, routes)
+
+	routes, err = expandOptional("/users/{id}")
+	assert.NoError(t, err)
+	assert.Equal(t, []optionalRoute{{pattern: "/users/{id}"}}, routes)
+
+	for _, pattern := range []string{"/{a?}/b", "/{a?}/{b}", "/{a?x}", "/{rest...?}", "/{a?}/"} {
+		_, err = expandOptional(pattern)
+		assert.Error(t, err, pattern)
+	}
+}
+
+func TestOptionalParams(t *testing.T) {
+	type request struct {
+		Year  int `path:"year"`
+		Month int `path:"month"`
+		Day   int `path:"day"`
+	}
+
+	r := NewRouter()
+	r.Renderer(RendererFunc(func(ctx *Context, err error, result interface{}) {
+		_ = ctx.String(http.StatusOK, "%v", result)
+	}))
+	r.Get("/reports/{year}/{month?=1}/{day?}", func(ctx context.Context, req request) string {
+		return fmt.Sprintf("%d-%d-%d|%s", req.Year, req.Month, req.Day, URLParam(FromContext(ctx).Request, "month"))
+	}).Meta("tag", "reports")
+
+	tests := map[string]string{
+		"/reports/2024/5/20": "2024-5-20|5",
+		"/reports/2024/5":    "2024-5-0|5",
+		"/reports/2024":      "2024-1-0|1",
+	}
+	for path, expected := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, expected, w.Body.String(), path)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// the metadata is attached to all the expanded routes.
+	routes := r.Routes()
+	assert.Len(t, routes, 3)
+	for _, route := range routes {
+		assert.Equal(t, "reports", route.Metadata[http.MethodGet]["tag"], route.Pattern)
+	}
+
+	assert.Panics(t, func() { r.Get("/reports/{year}", func(ctx context.Context) {}) })
+
+	assert.True(t, r.Remove(http.MethodGet, "/reports/{year}/{month?=1}/{day?}"))
+	assert.Empty(t, r.Routes())
+}
@@ -77,6 +77,11 @@ type Options struct {
 	// If zero, DefaultMaxHeaderBytes is used.
 	MaxHeaderBytes int `json:"max-header-bytes" value:"${max-header-bytes:=0}"`
 
+	// WarmupRetry is the delay of retrying the failed warm-up functions of the router, doubled after
+	// each failure up to a minute, the failures are logged by the logger of the router.
+	// If zero, 1s is used.
+	WarmupRetry time.Duration `json:"warmup-retry" value:"${warmup-retry:=1s}"`
+
 	// Router optionally specifies an external router.
 	Router Router `json:"-"`
 }
@@ -0,0 +1,47 @@
+//go:build go1.22
+
+// the module targets Go 1.21, enable the Go 1.22 patterns of the http.ServeMux.
+//go:debug httpmuxgo121=0
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountServeMux(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := FromRouteContext(r.Context())
+		id, _ := ctx.URLParams.Get("id")
+		fmt.Fprintf(w, "%s %s %s %s", r.PathValue("tenant"), r.PathValue("id"), id, r.URL.Path)
+	})
+	mux.HandleFunc("/files/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := FromRouteContext(r.Context())
+		path, _ := ctx.URLParams.Get("path")
+		fmt.Fprintf(w, "%s %s", r.PathValue("path"), path)
+	})
+
+	r := NewRouter()
+	r.Mount("/tenants/{tenant}", mux)
+	r.Get("/todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "root %s", r.PathValue("id"))
+	})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	assert.Equal(t, "acme 42 42 /todos/42", serve(http.MethodGet, "/tenants/acme/todos/42").Body.String())
+	assert.Equal(t, "a/b c.txt a/b c.txt", serve(http.MethodGet, "/tenants/acme/files/a/b%20c.txt").Body.String())
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/tenants/acme/todos/42").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/tenants/acme/users").Code)
+	assert.Equal(t, "root 7", serve(http.MethodGet, "/todos/7").Body.String())
+}
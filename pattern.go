@@ -0,0 +1,120 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"regexp/syntax"
+	"strings"
+)
+
+// TranslatePattern translates a chi or gorilla/mux routing pattern into the syntax of this router,
+// returns the name of the variable translated into the catch-all `*` if there is any.
+//
+// The chi patterns are compatible as they are. The gorilla/mux variables with the default
+// `[^/]+` pattern become plain params, and the trailing variables whose pattern matches
+// slashes (e.g. `/static/{path:.*}`) become the catch-all `*`.
+func TranslatePattern(pattern string) (translated string, wildcard string, err error) {
+	var sb strings.Builder
+	for rest := pattern; len(rest) > 0; {
+		ps := strings.IndexByte(rest, '{')
+		if ps < 0 {
+			sb.WriteString(rest)
+			break
+		}
+		sb.WriteString(rest[:ps])
+
+		// read to the closing brace, the regexp may contain braces as well.
+		pe, depth := -1, 0
+		for i := ps; i < len(rest) && pe < 0; i++ {
+			switch rest[i] {
+			case '{':
+				depth++
+			case '}':
+				if depth--; 0 == depth {
+					pe = i
+				}
+			}
+		}
+		if pe < 0 {
+			return "", "", fmt.Errorf("routing pattern '%s': closing delimiter '}' is missing", pattern)
+		}
+
+		name, expr, hasExpr := strings.Cut(rest[ps+1:pe], ":")
+		rest = rest[pe+1:]
+
+		switch {
+		case !hasExpr || "[^/]+" == expr:
+			sb.WriteString("{" + name + "}")
+		case matchesSlash(expr):
+			if len(rest) > 0 {
+				return "", "", fmt.Errorf("routing pattern '%s': variable '%s' matching slashes must be the last", pattern, name)
+			}
+			sb.WriteString("*")
+			wildcard = name
+		default:
+			sb.WriteString("{" + name + ":" + expr + "}")
+		}
+	}
+	return sb.String(), wildcard, nil
+}
+
+// HandleCompat registers a new route with a chi or gorilla/mux routing pattern, see TranslatePattern.
+// The value of the variable translated into the catch-all `*` is available by its name as well.
+func HandleCompat(r Router, pattern string, handler http.Handler) Endpoint {
+	translated, wildcard, err := TranslatePattern(pattern)
+	if nil != err {
+		panic(err)
+	}
+	if len(wildcard) > 0 {
+		handler = namedWildcard(wildcard, handler)
+	}
+	return r.Handle(translated, handler)
+}
+
+// matchesSlash reports whether the regular expression may match a slash.
+func matchesSlash(expr string) bool {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if nil != err {
+		// leave the invalid expression to be reported by the router.
+		return false
+	}
+	return reMatchesSlash(re)
+}
+
+func reMatchesSlash(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	case syntax.OpLiteral:
+		return strings.ContainsRune(string(re.Rune), '/')
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if re.Rune[i] <= '/' && '/' <= re.Rune[i+1] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, sub := range re.Sub {
+		if reMatchesSlash(sub) {
+			return true
+		}
+	}
+	return false
+}
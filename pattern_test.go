@@ -0,0 +1,60 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslatePattern(t *testing.T) {
+	var cases = []struct {
+		pattern    string
+		translated string
+		wildcard   string
+	}{
+		{"/users/{id}", "/users/{id}", ""},
+		{"/users/{id:[0-9]+}", "/users/{id:[0-9]+}", ""},
+		{"/articles/{category}/{id:[0-9]{3}}", "/articles/{category}/{id:[0-9]{3}}", ""},
+		{"/articles/{id:[^/]+}", "/articles/{id}", ""},
+		{"/static/{path:.*}", "/static/*", "path"},
+		{"/files/{path:[a-z/]+}", "/files/*", "path"},
+		{"/files/{name:[^?]+}", "/files/*", "name"},
+		{"/{category}-{id:[0-9]+}", "/{category}-{id:[0-9]+}", ""},
+		{"/assets/*", "/assets/*", ""},
+	}
+	for _, c := range cases {
+		translated, wildcard, err := TranslatePattern(c.pattern)
+		assert.Nil(t, err, c.pattern)
+		assert.Equal(t, c.translated, translated, c.pattern)
+		assert.Equal(t, c.wildcard, wildcard, c.pattern)
+	}
+
+	for _, pattern := range []string{"/{path:.*}/edit", "/users/{id"} {
+		_, _, err := TranslatePattern(pattern)
+		assert.Error(t, err, pattern)
+	}
+}
+
+func TestHandleCompat(t *testing.T) {
+	reply := func(w http.ResponseWriter, r *http.Request) {
+		ctx := FromRouteContext(r.Context())
+		w.Write([]byte(fmt.Sprintf("%v=%v", ctx.URLParams.Keys, ctx.URLParams.Values)))
+	}
+
+	r := NewRouter()
+	HandleCompat(r, "/articles/{id:[0-9]+}", http.HandlerFunc(reply))
+	HandleCompat(r, "/static/{path:.*}", http.HandlerFunc(reply))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, "[id]=[42]", serve("/articles/42").Body.String())
+	assert.Equal(t, http.StatusNotFound, serve("/articles/abc").Code)
+	assert.Equal(t, "[* path]=[css/main.css css/main.css]", serve("/static/css/main.css").Body.String())
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package playground validates the request structs by github.com/go-playground/validator, i.e. by
+// the `validate` tags and the struct-level validations, and renders the failures per field with the
+// reasons translated into the language of the request.
+//
+//	v := playground.New()
+//	binding.RegisterValidator(v.Struct)
+//	router.Renderer(v.Renderer(web.JsonRender()))
+package playground
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/ja"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	estranslations "github.com/go-playground/validator/v10/translations/es"
+	frtranslations "github.com/go-playground/validator/v10/translations/fr"
+	jatranslations "github.com/go-playground/validator/v10/translations/ja"
+	zhtranslations "github.com/go-playground/validator/v10/translations/zh"
+	"go-spring.dev/web"
+	"go-spring.dev/web/binding"
+)
+
+// Translations registers the translations of the validation failures for a locale.
+type Translations func(v *validator.Validate, trans ut.Translator) error
+
+// the languages translated by default, English is the fallback.
+var builtinLanguages = []struct {
+	locale       locales.Translator
+	translations Translations
+}{
+	{en.New(), entranslations.RegisterDefaultTranslations},
+	{es.New(), estranslations.RegisterDefaultTranslations},
+	{fr.New(), frtranslations.RegisterDefaultTranslations},
+	{ja.New(), jatranslations.RegisterDefaultTranslations},
+	{zh.New(), zhtranslations.RegisterDefaultTranslations},
+}
+
+// Validator validates the request structs by go-playground/validator.
+type Validator struct {
+	validate *validator.Validate
+	uni      *ut.UniversalTranslator
+}
+
+// New returns a Validator translating the failures into English, Spanish, French, Japanese and Chinese,
+// the fields are named in the reasons by their json, form, query, path, header or cookie tags.
+func New() *Validator {
+	v := &Validator{
+		validate: validator.New(validator.WithRequiredStructEnabled()),
+		uni:      ut.New(en.New()),
+	}
+	v.validate.RegisterTagNameFunc(fieldName)
+	for _, lang := range builtinLanguages {
+		if err := v.RegisterTranslations(lang.locale, lang.translations); nil != err {
+			panic(err)
+		}
+	}
+	return v
+}
+
+// Engine returns the underlying validator, e.g. to register the custom validations and the struct-level
+// validations by RegisterValidation and RegisterStructValidation.
+func (v *Validator) Engine() *validator.Validate {
+	return v.validate
+}
+
+// RegisterTranslations registers the translations of the locale, overriding the ones registered before.
+func (v *Validator) RegisterTranslations(locale locales.Translator, translations Translations) error {
+	if err := v.uni.AddTranslator(locale, true); nil != err {
+		return err
+	}
+	trans, _ := v.uni.GetTranslator(locale.Locale())
+	return translations(v.validate, trans)
+}
+
+// Struct validates the struct, the failures are returned as binding.BindingErrors with the reasons in
+// English, each one named by the path of the struct field and wrapping the validator.FieldError, the
+// other errors, e.g. validator.InvalidValidationError, are returned as is.
+func (v *Validator) Struct(i interface{}) error {
+	err := v.validate.Struct(i)
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	fallback := v.uni.GetFallback()
+	errs := make(binding.BindingErrors, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		// the struct namespace is prefixed by the name of the struct type, e.g. `User.Address.City`.
+		_, field, _ := strings.Cut(fieldErr.StructNamespace(), ".")
+		errs = append(errs, &binding.FieldError{Field: field, Source: "validate", Reason: fieldErr.Translate(fallback), Err: fieldErr})
+	}
+	return errs
+}
+
+// Translate translates the reasons of the validation failures in err into the first supported language
+// of the Accept-Language header, the reasons replaced by the `errmsg` tags are kept as is.
+func (v *Validator) Translate(acceptLanguage string, err error) {
+	var errs binding.BindingErrors
+	if !errors.As(err, &errs) {
+		return
+	}
+
+	trans, fallback := v.translator(acceptLanguage), v.uni.GetFallback()
+	if trans == fallback {
+		return
+	}
+	for _, e := range errs {
+		var fieldErr validator.FieldError
+		if errors.As(e.Err, &fieldErr) && e.Reason == fieldErr.Translate(fallback) {
+			e.Reason = fieldErr.Translate(trans)
+		}
+	}
+}
+
+// Renderer returns the renderer translating the validation failures before rendering them by next,
+// the language is the locale negotiated by the web.Locale middleware, or by the Accept-Language header.
+func (v *Validator) Renderer(next web.Renderer) web.Renderer {
+	return &translatingRenderer{v: v, next: next}
+}
+
+type translatingRenderer struct {
+	v    *Validator
+	next web.Renderer
+}
+
+func (r *translatingRenderer) Render(ctx *web.Context, err error, result interface{}) {
+	if nil != err {
+		language := web.LocaleOf(ctx.Request.Context())
+		if len(language) == 0 {
+			language = ctx.Request.Header.Get("Accept-Language")
+		}
+		r.v.Translate(language, err)
+	}
+	r.next.Render(ctx, err, result)
+}
+
+func (r *translatingRenderer) CheckResult(t reflect.Type) error {
+	if checker, ok := r.next.(web.ResultChecker); ok {
+		return checker.CheckResult(t)
+	}
+	return nil
+}
+
+// translator returns the translator of the first supported language of the Accept-Language header,
+// the regions are dropped if not supported, e.g. `fr-CA` falls back to `fr`.
+func (v *Validator) translator(acceptLanguage string) ut.Translator {
+	for _, tag := range binding.AcceptedLanguages(acceptLanguage) {
+		tag = strings.ReplaceAll(tag, "-", "_")
+		for len(tag) > 0 {
+			if trans, ok := v.uni.GetTranslator(tag); ok {
+				return trans
+			}
+			i := strings.LastIndexByte(tag, '_')
+			if i < 0 {
+				break
+			}
+			tag = tag[:i]
+		}
+	}
+	return v.uni.GetFallback()
+}
+
+// fieldName returns the name of the field in the request, i.e. the name of its first binding tag.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query", "path", "header", "cookie"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if "-" == name {
+			return ""
+		}
+		if len(name) > 0 {
+			return name
+		}
+	}
+	return field.Name
+}
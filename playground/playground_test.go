@@ -0,0 +1,108 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package playground_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"go-spring.dev/web"
+	"go-spring.dev/web/binding"
+	"go-spring.dev/web/playground"
+)
+
+type Address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type SignUp struct {
+	Username string  `json:"username" validate:"required,min=3"`
+	Password string  `json:"password" validate:"required" errmsg:"password required"`
+	Confirm  string  `json:"confirm"`
+	Address  Address `json:"address"`
+}
+
+func newRouter(v *playground.Validator) web.Router {
+	router := web.NewRouter()
+	router.Validator(v.Struct)
+	router.Renderer(v.Renderer(web.JsonRender()))
+	router.Post("/signup", func(ctx context.Context, req SignUp) string { return req.Username })
+	return router
+}
+
+func signUp(router web.Router, body, acceptLanguage string) []*binding.FieldError {
+	request := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept-Language", acceptLanguage)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+
+	var resp struct {
+		Errors []*binding.FieldError `json:"errors"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Errors
+}
+
+func TestValidator(t *testing.T) {
+	v := playground.New()
+	v.Engine().RegisterStructValidation(func(sl validator.StructLevel) {
+		if s := sl.Current().Interface().(SignUp); s.Password != s.Confirm {
+			sl.ReportError(s.Confirm, "confirm", "Confirm", "eqfield", "password")
+		}
+	}, SignUp{})
+	router := newRouter(v)
+
+	errs := signUp(router, `{"username":"al","password":"","confirm":"x"}`, "")
+	assert.Equal(t, []*binding.FieldError{
+		{Field: "Username", Source: "validate", Reason: "username must be at least 3 characters in length"},
+		{Field: "Password", Source: "validate", Reason: "password required"},
+		{Field: "Address.City", Source: "validate", Reason: "city is a required field"},
+		{Field: "Confirm", Source: "validate", Reason: "confirm must be equal to password"},
+	}, errs)
+
+	// the reasons are translated into the language of the request, except the errmsg tags.
+	errs = signUp(router, `{"username":"al","password":"","confirm":""}`, "de, fr-CA;q=0.8, en;q=0.5")
+	if assert.Len(t, errs, 3) {
+		assert.Equal(t, "username doit faire une taille minimum de 3 caractères", errs[0].Reason)
+		assert.Equal(t, "password required", errs[1].Reason)
+		assert.Equal(t, "city est un champ obligatoire", errs[2].Reason)
+	}
+
+	assert.Empty(t, signUp(router, `{"username":"alice","password":"x","confirm":"x","address":{"city":"Paris"}}`, "fr"))
+}
+
+func TestValidatorLocale(t *testing.T) {
+	v := playground.New()
+	router := web.NewRouter()
+	router.Use(web.Locale("en", "zh-CN"))
+	router.Validator(v.Struct)
+	router.Renderer(v.Renderer(web.JsonRender()))
+	router.Post("/signup", func(ctx context.Context, req SignUp) string { return req.Username })
+
+	// the locale negotiated by the Locale middleware takes precedence over the Accept-Language header.
+	errs := signUp(router, `{"username":"alice","password":"x","confirm":"x"}`, "zh-CN,zh;q=0.9")
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "city为必填字段", errs[0].Reason)
+	}
+}
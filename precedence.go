@@ -0,0 +1,52 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"go-spring.dev/web/binding"
+)
+
+// ScopePolicyKey is the route metadata key of the scope policy of the route.
+const ScopePolicyKey = "web.scopePolicy"
+
+// WithScopePolicy overrides RouterOptions.ScopePolicy for the request structs bound for the route,
+// e.g. to prefer the header over the query, or to reject the conflicting values with 400 Bad Request.
+//
+//	router.Get("/reports", ListReports).Apply(web.WithScopePolicy(binding.ScopePolicy{
+//		Precedence: []binding.BindScope{binding.BindScopeHeader, binding.BindScopeQuery},
+//		Strict:     true,
+//	}))
+func WithScopePolicy(policy binding.ScopePolicy) RouteOption {
+	return func(e Endpoint) {
+		e.Meta(ScopePolicyKey, policy)
+	}
+}
+
+// ScopePolicy returns the scope policy of the request structs bound for the request, i.e. the policy
+// set by WithScopePolicy on the route, then RouterOptions.ScopePolicy of the router serving the request,
+// nil if neither is set, so that binding.Bind falls back to the package-level policy.
+func (c *Context) ScopePolicy() *binding.ScopePolicy {
+	if rctx := FromRouteContext(c.Request.Context()); nil != rctx {
+		if policy, ok := rctx.routeMetadata[ScopePolicyKey].(binding.ScopePolicy); ok {
+			return &policy
+		}
+	}
+	if opts := routerOptionsOf(c.Request.Context()); nil != opts {
+		return opts.scopePolicy
+	}
+	return nil
+}
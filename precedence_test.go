@@ -0,0 +1,43 @@
+package web
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-spring.dev/web/binding"
+)
+
+func TestScopePolicy(t *testing.T) {
+	type Param struct {
+		Tenant string `query:"tenant" header:"X-Tenant"`
+	}
+	handler := func(ctx context.Context, p Param) string { return p.Tenant }
+
+	r := NewRouterWith(RouterOptions{ScopePolicy: &binding.ScopePolicy{Precedence: []binding.BindScope{binding.BindScopeQuery}}})
+	r.Get("/router", handler)
+	r.Get("/strict", handler).Apply(WithScopePolicy(binding.ScopePolicy{Strict: true}))
+
+	get := func(path, tenant string) string {
+		req := httptest.NewRequest("GET", path+"?tenant=query", nil)
+		req.Header.Set("X-Tenant", tenant)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	assert.JSONEq(t, `{"code":0,"data":"query"}`, get("/router", "header"))
+	assert.JSONEq(t, `{"code":0,"data":"query"}`, get("/strict", "query"))
+	assert.Contains(t, get("/strict", "header"), `"code":400`)
+	assert.Contains(t, get("/strict", "header"), `"reason":"conflicting values in header \"X-Tenant\" and query \"tenant\""`)
+
+	// the default policy lets the header override the query.
+	plain := NewRouter()
+	plain.Get("/", handler)
+	req := httptest.NewRequest("GET", "/?tenant=query", nil)
+	req.Header.Set("X-Tenant", "header")
+	w := httptest.NewRecorder()
+	plain.ServeHTTP(w, req)
+	assert.JSONEq(t, `{"code":0,"data":"header"}`, w.Body.String())
+}
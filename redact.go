@@ -0,0 +1,224 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultRedactionMask replaces the values of the redacted attributes.
+const DefaultRedactionMask = "[REDACTED]"
+
+// Redaction configures the request attributes masked in the observability output, such as the access
+// logs, request dumps and audit records, so that the secrets are masked consistently everywhere.
+// The names are matched case-insensitively.
+type Redaction struct {
+	// Headers are the names of the redacted headers.
+	Headers []string
+
+	// QueryParams are the names of the redacted query params.
+	QueryParams []string
+
+	// BodyFields are the names of the redacted fields of the JSON bodies, at any depth.
+	BodyFields []string
+
+	// Mask replaces the redacted values, DefaultRedactionMask if empty.
+	Mask string
+}
+
+// DefaultRedaction is the redaction used by the observability middlewares unless configured otherwise.
+var DefaultRedaction = &Redaction{
+	Headers:     []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token"},
+	QueryParams: []string{"access_token", "api_key", "password", "secret", "token"},
+	BodyFields:  []string{"password", "secret", "token", "access_token", "refresh_token", "client_secret"},
+}
+
+// Header returns a copy of the header with the values of the redacted headers masked.
+func (r *Redaction) Header(header http.Header) http.Header {
+	redacted := header.Clone()
+	for name, values := range redacted {
+		if matchName(r.Headers, name) {
+			masked := make([]string, len(values))
+			for i := range masked {
+				masked[i] = r.mask()
+			}
+			redacted[name] = masked
+		}
+	}
+	return redacted
+}
+
+// URL returns the request URI of the url with the values of the redacted query params masked,
+// the order of the query params is kept.
+func (r *Redaction) URL(u *url.URL) string {
+	if 0 == len(u.RawQuery) {
+		return u.RequestURI()
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); nil == err && matchName(r.QueryParams, name) {
+			params[i] = key + "=" + url.QueryEscape(r.mask())
+		}
+	}
+
+	cp := *u
+	cp.RawQuery = strings.Join(params, "&")
+	return cp.RequestURI()
+}
+
+// Body returns the body with the values of the redacted fields masked if it's JSON,
+// the other bodies are returned as is.
+func (r *Redaction) Body(contentType string, body []byte) []byte {
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return body
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); nil != err {
+		return body
+	}
+	if !r.redactValue(value) {
+		return body
+	}
+
+	redacted, err := json.Marshal(value)
+	if nil != err {
+		return body
+	}
+	return redacted
+}
+
+// redactValue masks the redacted fields of the decoded JSON value, reports whether any field is masked.
+func (r *Redaction) redactValue(value interface{}) bool {
+	redacted := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if matchName(r.BodyFields, key) {
+				v[key] = r.mask()
+				redacted = true
+			} else if r.redactValue(field) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if r.redactValue(item) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+func (r *Redaction) mask() string {
+	if len(r.Mask) > 0 {
+		return r.Mask
+	}
+	return DefaultRedactionMask
+}
+
+func matchName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessLog returns a middleware logging the requests by the logger of the router once served, the
+// URL and the headers of the requests are masked by the redaction, DefaultRedaction if nil.
+//
+//	router.Use(web.AccessLog(nil))
+func AccessLog(redaction *Redaction) MiddlewareFunc {
+	if nil == redaction {
+		redaction = DefaultRedaction
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			clock := ClockOf(request.Context())
+			start := clock.Now()
+
+			lw := &accessLogWriter{ResponseWriter: writer}
+			next.ServeHTTP(new(optionalWriters).expose(lw), request)
+
+			if 0 == lw.status {
+				lw.status = http.StatusOK
+			}
+			LoggerOf(request.Context()).LogAttrs(request.Context(), slog.LevelInfo, "access",
+				slog.String("method", request.Method),
+				slog.String("uri", redaction.URL(request.URL)),
+				slog.Int("status", lw.status),
+				slog.Int64("bytes", lw.written),
+				slog.Duration("duration", clock.Now().Sub(start)),
+				slog.Any("header", redaction.Header(request.Header)),
+			)
+		})
+	}
+}
+
+// accessLogWriter records the status and the bytes of the response.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *accessLogWriter) WriteHeader(code int) {
+	if 0 == w.status && (code < 100 || code > 199 || code == http.StatusSwitchingProtocols) {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessLogWriter) Write(p []byte) (int, error) {
+	if 0 == w.status {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *accessLogWriter) ReadFrom(r io.Reader) (int64, error) {
+	if 0 == w.status {
+		w.status = http.StatusOK
+	}
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err := rf.ReadFrom(r)
+		w.written += n
+		return n, err
+	}
+	return io.Copy(struct{ io.Writer }{w}, r)
+}
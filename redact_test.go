@@ -0,0 +1,61 @@
+package web
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedaction(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	header.Add("Cookie", "a=1")
+	header.Add("Cookie", "b=2")
+	header.Set("Accept", "application/json")
+
+	redacted := DefaultRedaction.Header(header)
+	assert.Equal(t, []string{"[REDACTED]"}, redacted.Values("Authorization"))
+	assert.Equal(t, []string{"[REDACTED]", "[REDACTED]"}, redacted.Values("Cookie"))
+	assert.Equal(t, "application/json", redacted.Get("Accept"))
+	assert.Equal(t, "Bearer secret", header.Get("Authorization"))
+
+	u, _ := url.Parse("/login?user=foo&Token=abc&page=1&api%5Fkey=xyz")
+	assert.Equal(t, "/login?user=foo&Token=%5BREDACTED%5D&page=1&api%5Fkey=%5BREDACTED%5D", DefaultRedaction.URL(u))
+	u, _ = url.Parse("/login")
+	assert.Equal(t, "/login", DefaultRedaction.URL(u))
+
+	body := []byte(`{"user":"foo","password":"bar","nested":[{"Token":"x","id":12345678901234567890}]}`)
+	assert.Equal(t, `{"nested":[{"Token":"***","id":12345678901234567890}],"password":"***","user":"foo"}`,
+		string((&Redaction{BodyFields: []string{"password", "token"}, Mask: "***"}).Body("application/json; charset=utf-8", body)))
+	assert.Equal(t, `{"user":"foo"}`, string(DefaultRedaction.Body("application/json", []byte(`{"user":"foo"}`))))
+	assert.Equal(t, "password=bar", string(DefaultRedaction.Body("application/x-www-form-urlencoded", []byte("password=bar"))))
+	assert.Equal(t, "{invalid", string(DefaultRedaction.Body("application/json", []byte("{invalid"))))
+}
+
+func TestAccessLog(t *testing.T) {
+	var logs bytes.Buffer
+	r := NewRouterWith(RouterOptions{Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	r.Use(AccessLog(nil))
+	r.Get("/login", func(w http.ResponseWriter, r *http.Request) {
+		_, hijacker := w.(http.Hijacker)
+		assert.False(t, hijacker)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("welcome"))
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/login?user=foo&token=abc", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, request)
+	assert.Equal(t, "welcome", w.Body.String())
+
+	assert.Contains(t, logs.String(), `msg=access method=GET uri="/login?user=foo&token=%5BREDACTED%5D" status=201 bytes=7`)
+	assert.Contains(t, logs.String(), "Authorization:[[REDACTED]]")
+	assert.NotContains(t, logs.String(), "secret")
+	assert.NotContains(t, logs.String(), "abc")
+}
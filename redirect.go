@@ -0,0 +1,89 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// redirectParams matches the path param placeholders of the redirect location.
+var redirectParams = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// Redirect registers a route of all methods redirecting the requests to the location with the code,
+// the `{name}` placeholders of the location are replaced by the path params of the pattern, and
+// `{*}` by the wildcard remainder. The query string is preserved if the location has none.
+//
+//	router.Redirect("/docs", "/docs/", http.StatusMovedPermanently)
+//	router.Redirect("/api/v1/users/{id}", "/api/v2/users/{id}", http.StatusPermanentRedirect)
+//	router.Redirect("/old/*", "/new/{*}", http.StatusFound)
+func (rg *routerGroup) Redirect(pattern, location string, code int) {
+	if code < http.StatusMultipleChoices || code > http.StatusPermanentRedirect {
+		panic(fmt.Sprintf("routing pattern '%s': cannot redirect with status code %d", pattern, code))
+	}
+
+	keys := patParamKeys(pattern)
+	for _, match := range redirectParams.FindAllStringSubmatch(location, -1) {
+		if !slices.Contains(keys, match[1]) {
+			panic(fmt.Sprintf("routing pattern '%s': redirect location '%s' refers to unknown param '%s'", pattern, location, match[1]))
+		}
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := location
+		if ctx := FromRouteContext(r.Context()); nil != ctx {
+			target = redirectParams.ReplaceAllStringFunc(location, func(placeholder string) string {
+				name := placeholder[1 : len(placeholder)-1]
+				value, _ := ctx.URLParams.Get(name)
+				return escapeRedirectParam(r, name, value)
+			})
+		}
+		// the params mustn't turn the location into another host, e.g. `/{p}` into `//evil.com`.
+		if strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		if len(r.URL.RawQuery) > 0 && !strings.Contains(target, "?") {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, code)
+	})
+	rg.register(mALL, pattern, handler)
+}
+
+// escapeRedirectParam path-escapes the value of the param substituted into the redirect location, the
+// slashes of the wildcard remainder are kept as the separators of the segments.
+func escapeRedirectParam(r *http.Request, name, value string) string {
+	// the params are matched against the escaped path if the request path has escaped characters.
+	if len(r.URL.RawPath) > 0 {
+		if unescaped, err := url.PathUnescape(value); nil == err {
+			value = unescaped
+		}
+	}
+	if "*" != name {
+		return url.PathEscape(value)
+	}
+	segments := strings.Split(value, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,45 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterRedirect(t *testing.T) {
+	r := NewRouter()
+	r.Redirect("/docs", "/docs/", http.StatusMovedPermanently)
+	r.Redirect("/api/v1/users/{id}", "/api/v2/users/{id}", http.StatusPermanentRedirect)
+	r.Redirect("/old/*", "/new/{*}?from=old", http.StatusFound)
+	r.Redirect("/go/{p}", "/{p}", http.StatusFound)
+	r.Redirect("/legacy/*", "/{*}", http.StatusFound)
+
+	tests := []struct {
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "/docs", http.StatusMovedPermanently, "/docs/"},
+		{http.MethodPost, "/api/v1/users/42?fields=name", http.StatusPermanentRedirect, "/api/v2/users/42?fields=name"},
+		{http.MethodGet, "/old/a/b.html?x=1", http.StatusFound, "/new/a/b.html?from=old"},
+		{http.MethodGet, "/old/a%20b/c%3Fd", http.StatusFound, "/new/a%20b/c%3Fd?from=old"},
+		// the params are escaped, and can't redirect to another host.
+		{http.MethodGet, "/go/%2Fevil.com", http.StatusFound, "/%2Fevil.com"},
+		{http.MethodGet, "/go/%5Cevil.com", http.StatusFound, "/%5Cevil.com"},
+		{http.MethodGet, "/go/docs", http.StatusFound, "/docs"},
+		{http.MethodGet, "/legacy//evil.com", http.StatusBadRequest, ""},
+		{http.MethodGet, "/legacy/a/b", http.StatusFound, "/a/b"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		assert.Equal(t, tt.code, w.Code, tt.path)
+		assert.Equal(t, tt.location, w.Header().Get("Location"), tt.path)
+	}
+
+	assert.Panics(t, func() { r.Redirect("/a", "/b", http.StatusOK) })
+	assert.Panics(t, func() { r.Redirect("/a/{id}", "/b/{name}", http.StatusFound) })
+}
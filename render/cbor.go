@@ -0,0 +1,36 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"net/http"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CBORRenderer encodes the data as CBOR, the fields without a `cbor` tag fall back to the `json` tag.
+type CBORRenderer struct {
+	Data interface{}
+}
+
+func (c CBORRenderer) ContentType() string {
+	return "application/cbor"
+}
+
+func (c CBORRenderer) Render(writer http.ResponseWriter) error {
+	return cbor.NewEncoder(writer).Encode(c.Data)
+}
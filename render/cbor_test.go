@@ -0,0 +1,43 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCBORRenderer(t *testing.T) {
+	w := httptest.NewRecorder()
+	data := struct {
+		Foo string `json:"foo"`
+		Bar int    `cbor:"bar" json:"baz"`
+	}{Foo: "foo", Bar: 1}
+
+	render := CBORRenderer{Data: data}
+	err := render.Render(w)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "application/cbor", render.ContentType())
+
+	var decoded map[string]interface{}
+	assert.Nil(t, cbor.Unmarshal(w.Body.Bytes(), &decoded))
+	assert.Equal(t, map[string]interface{}{"foo": "foo", "bar": uint64(1)}, decoded)
+}
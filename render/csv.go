@@ -0,0 +1,275 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"context"
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CsvRenderer streams the records of a slice, an array or a receive channel as CSV, the records
+// are structs or pointers to structs, or the rows of []string written as is. The header row names
+// the columns of the exported fields by their `csv` tags, or by the field names if untagged, the
+// fields tagged by `csv:"-"` are skipped and the fields of the embedded structs are promoted:
+//
+//	type Order struct {
+//		ID      int64     `csv:"id"`
+//		Total   float64   `csv:"total"`
+//		Created time.Time `csv:"created_at"`
+//		Secret  string    `csv:"-"`
+//	}
+//
+// The values implementing encoding.TextMarshaler or fmt.Stringer are formatted by them, e.g. the
+// time.Time values in RFC 3339, and the nil pointers are empty. The cells starting with `=`, `+`, `-`,
+// `@`, a tab or a carriage return, other than the numbers, are prefixed with `'`, so that the
+// spreadsheets opening the file don't evaluate them as formulas, unless AllowFormulas is set.
+// The records are flushed in chunks like JsonStreamRenderer, a failure in the middle leaves the
+// records written before it.
+type CsvRenderer struct {
+	Data interface{}
+
+	// Comma is the field delimiter, ',' if zero.
+	Comma rune
+
+	// WriteTimeout is the deadline of writing each chunk, see NewStreamWriter.
+	WriteTimeout time.Duration
+
+	// AllowFormulas writes the cells that look like formulas as they are, e.g. for the trusted data.
+	AllowFormulas bool
+
+	// Context stops receiving the records from the channel once it's done, see JsonStreamRenderer.
+	Context context.Context
+}
+
+func (c CsvRenderer) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (c CsvRenderer) Render(writer http.ResponseWriter) error {
+	value := reflect.ValueOf(c.Data)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Chan:
+	default:
+		return fmt.Errorf("csv: records must be a slice, an array or a channel, not %T", c.Data)
+	}
+	if err := checkRecvChan(value); nil != err {
+		return err
+	}
+
+	columns, err := csvColumnsOf(value.Type().Elem())
+	if nil != err {
+		return err
+	}
+
+	w := csv.NewWriter(NewStreamWriter(writer, c.WriteTimeout))
+	if 0 != c.Comma {
+		w.Comma = c.Comma
+	}
+	if nil != columns {
+		header := make([]string, len(columns))
+		for i, column := range columns {
+			header[i] = column.name
+		}
+		if err = w.Write(header); nil != err {
+			return err
+		}
+	}
+
+	write := func(record reflect.Value) error {
+		row := csvRow(record, columns)
+		if !c.AllowFormulas {
+			// the []string rows are the records themselves, which are left untouched.
+			escaped := make([]string, len(row))
+			for i, cell := range row {
+				escaped[i] = escapeFormula(cell)
+			}
+			row = escaped
+		}
+		return w.Write(row)
+	}
+	if err = c.encode(w, value, write); nil != err {
+		w.Flush()
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// encode writes the records of the value, the records received from a channel are flushed as soon as
+// the channel has no more records ready.
+func (c CsvRenderer) encode(w *csv.Writer, value reflect.Value, write func(record reflect.Value) error) error {
+	if value.Kind() != reflect.Chan {
+		for i := 0; i < value.Len(); i++ {
+			if err := write(value.Index(i)); nil != err {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for {
+		record, ok := value.TryRecv()
+		if !ok && !record.IsValid() {
+			w.Flush()
+			if err := w.Error(); nil != err {
+				return err
+			}
+			var err error
+			if record, ok, err = recvRecord(c.Context, value); nil != err {
+				return err
+			}
+		}
+		if !ok {
+			return nil // closed
+		}
+		if err := write(record); nil != err {
+			return err
+		}
+	}
+}
+
+// escapeFormula prefixes the cell that a spreadsheet would evaluate as a formula with `'`.
+func escapeFormula(cell string) string {
+	if 0 == len(cell) || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return cell
+	}
+	if _, err := strconv.ParseFloat(cell, 64); nil == err {
+		return cell
+	}
+	return "'" + cell
+}
+
+// csvColumn is a column of the CSV records, i.e. a field of the record struct.
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// csvColumnsOf returns the columns of the records of the type, nil for the []string rows.
+func csvColumnsOf(t reflect.Type) ([]csvColumn, error) {
+	if reflect.Slice == t.Kind() && reflect.String == t.Elem().Kind() {
+		return nil, nil
+	}
+	if reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	if reflect.Struct != t.Kind() {
+		return nil, fmt.Errorf("csv: unsupported record type %s, must be a struct or []string", t)
+	}
+	return appendCsvColumns(nil, t, nil), nil
+}
+
+func appendCsvColumns(columns []csvColumn, t reflect.Type, index []int) []csvColumn {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("csv")
+		if "-" == tag {
+			continue
+		}
+		fi := append(index[:len(index):len(index)], i)
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && 0 == len(name) {
+			ft := f.Type
+			if reflect.Ptr == ft.Kind() {
+				ft = ft.Elem()
+			}
+			if reflect.Struct == ft.Kind() {
+				columns = appendCsvColumns(columns, ft, fi)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if 0 == len(name) {
+			name = f.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: fi})
+	}
+	return columns
+}
+
+// csvRow returns the values of the columns of the record.
+func csvRow(record reflect.Value, columns []csvColumn) []string {
+	for reflect.Interface == record.Kind() || reflect.Ptr == record.Kind() {
+		if record.IsNil() {
+			return make([]string, len(columns))
+		}
+		record = record.Elem()
+	}
+	if nil == columns {
+		return record.Convert(reflect.TypeOf([]string(nil))).Interface().([]string)
+	}
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		// the fields of the nil embedded pointers are empty.
+		if v, err := record.FieldByIndexErr(column.index); nil == err {
+			row[i] = csvValue(v)
+		}
+	}
+	return row
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// csvValue formats the value of a column.
+func csvValue(v reflect.Value) string {
+	for reflect.Interface == v.Kind() || reflect.Ptr == v.Kind() {
+		if v.IsNil() {
+			return ""
+		}
+		if v.Type().Implements(textMarshalerType) || v.Type().Implements(stringerType) {
+			break
+		}
+		v = v.Elem()
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		if text, err := v.Interface().(encoding.TextMarshaler).MarshalText(); nil == err {
+			return string(text)
+		}
+	}
+	if v.Type().Implements(stringerType) {
+		return v.Interface().(fmt.Stringer).String()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	case reflect.Slice:
+		if reflect.Uint8 == v.Type().Elem().Kind() {
+			return string(v.Bytes())
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type csvBase struct {
+	ID int64 `csv:"id"`
+}
+
+type csvRegion struct {
+	Region string `csv:"region"`
+}
+
+type csvOrder struct {
+	csvBase
+	*csvRegion
+	Customer string        `csv:"customer"`
+	Total    float64       `csv:"total"`
+	Paid     bool          `csv:"paid"`
+	Created  time.Time     `csv:"created_at"`
+	Timeout  time.Duration `csv:"timeout"`
+	Note     *string       `csv:"note"`
+	Tags     []string      `csv:"tags"`
+	Secret   string        `csv:"-"`
+	Count    uint8
+	internal string
+}
+
+func TestCsvRenderer(t *testing.T) {
+	created := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	note := `said "hi", left`
+	orders := []*csvOrder{
+		{csvBase: csvBase{ID: 1}, Customer: "alice", Total: 9.5, Paid: true, Created: created, Timeout: time.Minute, Note: &note, Tags: []string{"a", "b"}, Secret: "x", Count: 2},
+		nil,
+		{csvBase: csvBase{ID: 2}, csvRegion: &csvRegion{Region: "eu"}, Customer: "bob", Total: 10},
+	}
+
+	w := httptest.NewRecorder()
+	render := CsvRenderer{Data: orders}
+	assert.Nil(t, render.Render(w))
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "text/csv; charset=utf-8", render.ContentType())
+	assert.Equal(t, "id,region,customer,total,paid,created_at,timeout,note,tags,Count\n"+
+		"1,,alice,9.5,true,2023-05-01T10:00:00Z,1m0s,\"said \"\"hi\"\", left\",[a b],2\n"+
+		",,,,,,,,,\n"+
+		"2,eu,bob,10,false,0001-01-01T00:00:00Z,0s,,[],0\n", w.Body.String())
+
+	// the channel records, the []string rows without the header, and the delimiter.
+	rows := make(chan []string, 2)
+	rows <- []string{"a", "b"}
+	rows <- []string{"c", "d"}
+	close(rows)
+	w = httptest.NewRecorder()
+	assert.Nil(t, CsvRenderer{Data: (<-chan []string)(rows), Comma: ';'}.Render(w))
+	assert.Equal(t, "a;b\nc;d\n", w.Body.String())
+
+	// the cells looking like formulas are escaped unless allowed, the numbers are kept.
+	formulas := [][]string{{"=1+1", "+SUM(A1)", "-2+3", "@cmd", "\tx", "-1.5", "+3", "a=b"}}
+	w = httptest.NewRecorder()
+	assert.Nil(t, CsvRenderer{Data: formulas}.Render(w))
+	assert.Equal(t, "'=1+1,'+SUM(A1),'-2+3,'@cmd,'\tx,-1.5,+3,a=b\n", w.Body.String())
+	w = httptest.NewRecorder()
+	assert.Nil(t, CsvRenderer{Data: formulas, AllowFormulas: true}.Render(w))
+	assert.Equal(t, "=1+1,+SUM(A1),-2+3,@cmd,\"\tx\",-1.5,+3,a=b\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.EqualError(t, CsvRenderer{Data: (chan<- []string)(rows)}.Render(w), "render: can't receive the records from the send-only channel chan<- []string")
+	assert.EqualError(t, CsvRenderer{Data: []int{1}}.Render(w), "csv: unsupported record type int, must be a struct or []string")
+	assert.EqualError(t, CsvRenderer{Data: csvOrder{}}.Render(w), "csv: records must be a slice, an array or a channel, not render.csvOrder")
+}
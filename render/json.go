@@ -17,7 +17,9 @@
 package render
 
 import (
+	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 )
 
@@ -32,9 +34,44 @@ func (j JsonRenderer) ContentType() string {
 }
 
 func (j JsonRenderer) Render(writer http.ResponseWriter) error {
+	return j.encode(writer)
+}
+
+func (j JsonRenderer) encode(writer io.Writer) error {
 	encoder := json.NewEncoder(writer)
 	if len(j.Prefix) > 0 || len(j.Indent) > 0 {
 		encoder.SetIndent(j.Prefix, j.Indent)
 	}
 	return encoder.Encode(j.Data)
 }
+
+// SecureJsonRenderer is JsonRenderer prefixing the array responses by the Prefix, `while(1);` if empty,
+// so that the array-rooted responses can't be hijacked by the cross-origin pages including them as
+// scripts. The first-party clients strip the prefix before parsing, the objects aren't prefixed.
+type SecureJsonRenderer struct {
+	Prefix string
+	Indent string
+	Data   interface{}
+}
+
+func (s SecureJsonRenderer) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (s SecureJsonRenderer) Render(writer http.ResponseWriter) error {
+	var buf bytes.Buffer
+	if err := (JsonRenderer{Indent: s.Indent, Data: s.Data}).encode(&buf); nil != err {
+		return err
+	}
+	if bytes.HasPrefix(buf.Bytes(), []byte("[")) {
+		prefix := s.Prefix
+		if len(prefix) == 0 {
+			prefix = "while(1);"
+		}
+		if _, err := writer.Write([]byte(prefix)); nil != err {
+			return err
+		}
+	}
+	_, err := writer.Write(buf.Bytes())
+	return err
+}
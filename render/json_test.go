@@ -38,3 +38,24 @@ func TestJSONRenderer(t *testing.T) {
 	assert.Equal(t, "application/json; charset=utf-8", render.ContentType())
 	assert.Equal(t, "{\"foo\":\"bar\",\"html\":\"\\u003cb\\u003e\"}\n", w.Body.String())
 }
+
+func TestSecureJSONRenderer(t *testing.T) {
+	w := httptest.NewRecorder()
+	render := SecureJsonRenderer{Data: []string{"a", "b"}}
+	assert.Nil(t, render.Render(w))
+	assert.Equal(t, "application/json; charset=utf-8", render.ContentType())
+	assert.Equal(t, "while(1);[\"a\",\"b\"]\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, SecureJsonRenderer{Prefix: ")]}',\n", Data: []int{1}}.Render(w))
+	assert.Equal(t, ")]}',\n[1]\n", w.Body.String())
+
+	// the objects aren't prefixed.
+	w = httptest.NewRecorder()
+	assert.Nil(t, SecureJsonRenderer{Data: map[string]int{"a": 1}}.Render(w))
+	assert.Equal(t, "{\"a\":1}\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.NotNil(t, SecureJsonRenderer{Data: []func(){nil}}.Render(w))
+	assert.Equal(t, "", w.Body.String())
+}
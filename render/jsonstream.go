@@ -0,0 +1,177 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// DefaultStreamBufferSize is the default size of the chunks flushed by the JsonStreamRenderer.
+var DefaultStreamBufferSize = 32 * 1024
+
+// JsonStreamRenderer encodes the records of a slice, an array, a receive channel or an iterator, see
+// IsSeq, as a JSON array incrementally, the encoded records are flushed to the client in chunks of BufferSize instead of
+// building the entire payload in memory. The records received from a channel are also flushed as
+// soon as the channel has no more records ready, so a slow producer doesn't delay the client.
+//
+// The array is left unterminated if a record fails to encode or write, so that the client
+// detects the truncated response instead of taking it as complete.
+type JsonStreamRenderer struct {
+	Data interface{}
+
+	// BufferSize is the size of the chunks flushed to the client, zero means DefaultStreamBufferSize.
+	BufferSize int
+
+	// WriteTimeout is the deadline of writing each chunk, see NewStreamWriter.
+	WriteTimeout time.Duration
+
+	// Context stops receiving the records from the channel once it's done, e.g. the request context,
+	// so that the renderer doesn't wait for a producer that's gone with the request.
+	Context context.Context
+}
+
+func (j JsonStreamRenderer) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (j JsonStreamRenderer) Render(writer http.ResponseWriter) error {
+	value := reflect.ValueOf(j.Data)
+	if reflect.Func == value.Kind() && !IsSeq(value.Type()) {
+		return JsonRenderer{Data: j.Data}.Render(writer)
+	}
+	if err := checkRecvChan(value); nil != err {
+		return err
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Chan, reflect.Func:
+		if value.Kind() != reflect.Array && value.IsNil() {
+			_, err := writer.Write([]byte("null\n"))
+			return err
+		}
+	default:
+		return JsonRenderer{Data: j.Data}.Render(writer)
+	}
+
+	size := j.BufferSize
+	if size <= 0 {
+		size = DefaultStreamBufferSize
+	}
+	buf := bufio.NewWriterSize(NewStreamWriter(writer, j.WriteTimeout), size)
+
+	count, err := j.encode(buf, value)
+	if nil != err {
+		_ = buf.Flush() // the encoded records, the array is left unterminated.
+		return err
+	}
+
+	end := "]\n"
+	if 0 == count {
+		end = "[]\n"
+	}
+	if _, err = buf.WriteString(end); nil != err {
+		return err
+	}
+	return buf.Flush()
+}
+
+// encode writes the records of the value as the elements of a JSON array, reports the number of records.
+func (j JsonStreamRenderer) encode(buf *bufio.Writer, value reflect.Value) (int, error) {
+	count := 0
+	write := func(record reflect.Value) error {
+		data, err := json.Marshal(record.Interface())
+		if nil != err {
+			return err
+		}
+		sep := byte('[')
+		if count > 0 {
+			sep = ','
+		}
+		if err = buf.WriteByte(sep); nil != err {
+			return err
+		}
+		count++
+		_, err = buf.Write(data)
+		return err
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := write(value.Index(i)); nil != err {
+				return count, err
+			}
+		}
+		return count, nil
+	case reflect.Func:
+		var err error
+		RangeSeq(value, func(record reflect.Value) bool {
+			err = write(record)
+			return nil == err
+		})
+		return count, err
+	}
+
+	for {
+		record, ok := value.TryRecv()
+		if !ok && !record.IsValid() {
+			// flush the pending records while waiting for the producer.
+			if err := buf.Flush(); nil != err {
+				return count, err
+			}
+			var err error
+			if record, ok, err = recvRecord(j.Context, value); nil != err {
+				return count, err
+			}
+		}
+		if !ok {
+			return count, nil // closed
+		}
+		if err := write(record); nil != err {
+			return count, err
+		}
+	}
+}
+
+// checkRecvChan returns an error if the value is a channel the records can't be received from.
+func checkRecvChan(value reflect.Value) error {
+	if reflect.Chan == value.Kind() && 0 == value.Type().ChanDir()&reflect.RecvDir {
+		return fmt.Errorf("render: can't receive the records from the send-only channel %s", value.Type())
+	}
+	return nil
+}
+
+// recvRecord receives a record from the channel, until the ctx is done if it's not nil.
+func recvRecord(ctx context.Context, value reflect.Value) (reflect.Value, bool, error) {
+	if nil == ctx {
+		record, ok := value.Recv()
+		return record, ok, nil
+	}
+	chosen, record, ok := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: value},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+	})
+	if 1 == chosen {
+		return reflect.Value{}, false, ctx.Err()
+	}
+	return record, ok, nil
+}
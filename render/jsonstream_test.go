@@ -0,0 +1,134 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *flushCounter) Flush() {
+	f.flushes++
+	f.ResponseRecorder.Flush()
+}
+
+func TestJsonStreamRenderer(t *testing.T) {
+	type record struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	records := make([]record, 1000)
+	for i := range records {
+		records[i] = record{ID: i, Name: strings.Repeat("x", i%10)}
+	}
+	expected, _ := json.Marshal(records)
+
+	w := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	render := JsonStreamRenderer{Data: records, BufferSize: 1024}
+	assert.Nil(t, render.Render(w))
+	assert.Equal(t, "application/json; charset=utf-8", render.ContentType())
+	assert.Equal(t, string(expected)+"\n", w.Body.String())
+	assert.True(t, w.flushes > len(expected)/1024)
+
+	ch := make(chan record, 2)
+	ch <- record{1, "foo"}
+	ch <- record{2, "bar"}
+	close(ch)
+	w = &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	assert.Nil(t, JsonStreamRenderer{Data: (<-chan record)(ch)}.Render(w))
+	assert.Equal(t, "[{\"id\":1,\"name\":\"foo\"},{\"id\":2,\"name\":\"bar\"}]\n", w.Body.String())
+
+	// the records received from a slow producer are flushed while waiting for the next one.
+	ch = make(chan record)
+	flushed := make(chan string)
+	go func() {
+		ch <- record{3, "baz"}
+		ch <- record{4, "qux"}
+		close(ch)
+	}()
+	w = &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	go func() {
+		_ = JsonStreamRenderer{Data: ch}.Render(w)
+		flushed <- w.Body.String()
+	}()
+	assert.Equal(t, "[{\"id\":3,\"name\":\"baz\"},{\"id\":4,\"name\":\"qux\"}]\n", <-flushed)
+
+	// the send-only channels are rejected, and the receiving stops once the context is done.
+	assert.EqualError(t, JsonStreamRenderer{Data: (chan<- record)(ch)}.Render(httptest.NewRecorder()), "render: can't receive the records from the send-only channel chan<- render.record")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pending := make(chan record, 1)
+	pending <- record{5, "gone"}
+	w = &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	assert.ErrorIs(t, JsonStreamRenderer{Data: pending, Context: ctx}.Render(w), context.Canceled)
+	assert.Equal(t, "[{\"id\":5,\"name\":\"gone\"}", w.Body.String())
+
+	// the records yielded by an iterator, the iteration stops on errors.
+	seq := func(yield func(record) bool) {
+		for i := 6; i <= 8; i++ {
+			if !yield(record{i, "seq"}) {
+				return
+			}
+		}
+	}
+	w = &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	assert.Nil(t, JsonStreamRenderer{Data: seq}.Render(w))
+	assert.Equal(t, "[{\"id\":6,\"name\":\"seq\"},{\"id\":7,\"name\":\"seq\"},{\"id\":8,\"name\":\"seq\"}]\n", w.Body.String())
+
+	yielded := 0
+	w = &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	err := JsonStreamRenderer{Data: func(yield func(interface{}) bool) {
+		for yielded < 3 && yield(func() {}) {
+			yielded++
+		}
+	}}.Render(w)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, yielded)
+
+	for _, tt := range []struct {
+		data interface{}
+		body string
+	}{
+		{(func(yield func(int) bool))(nil), "null\n"},
+		{[]record{}, "[]\n"},
+		{[]record(nil), "null\n"},
+		{[0]int{}, "[]\n"},
+		{[2]int{1, 2}, "[1,2]\n"},
+		{record{5, "one"}, "{\"id\":5,\"name\":\"one\"}\n"},
+	} {
+		w := httptest.NewRecorder()
+		assert.Nil(t, JsonStreamRenderer{Data: tt.data}.Render(w))
+		assert.Equal(t, tt.body, w.Body.String())
+	}
+
+	// the array is left unterminated on errors.
+	w = &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	err = JsonStreamRenderer{Data: []interface{}{1, func() {}}}.Render(w)
+	assert.NotNil(t, err)
+	assert.Equal(t, "[1", w.Body.String())
+}
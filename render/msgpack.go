@@ -0,0 +1,38 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgPackRenderer encodes the data as MessagePack, the fields without a `msgpack` tag fall back to the `json` tag.
+type MsgPackRenderer struct {
+	Data interface{}
+}
+
+func (m MsgPackRenderer) ContentType() string {
+	return "application/msgpack"
+}
+
+func (m MsgPackRenderer) Render(writer http.ResponseWriter) error {
+	encoder := msgpack.NewEncoder(writer)
+	encoder.SetCustomStructTag("json")
+	return encoder.Encode(m.Data)
+}
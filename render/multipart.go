@@ -0,0 +1,156 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+)
+
+// MultipartRenderer builds a multipart response, e.g. multipart/mixed to return several
+// files or a JSON document together with its binary attachments in one response, or
+// multipart/byteranges to return several ranges of a resource.
+//
+//	m := render.NewMultipart("mixed")
+//	m.JSON(meta).File("avatar.png", "image/png", avatar)
+//	ctx.Render(http.StatusOK, m)
+//
+// The parts are written in the order they're added, the readers of the parts are read
+// only when the response is rendered and are not closed by the renderer.
+type MultipartRenderer struct {
+	subtype  string
+	boundary string
+	parts    []multipartPart
+}
+
+type multipartPart struct {
+	header textproto.MIMEHeader
+	write  func(w io.Writer) error
+}
+
+// NewMultipart returns a multipart renderer of the subtype, e.g. "mixed", "related" or
+// "byteranges", with a random boundary.
+func NewMultipart(subtype string) *MultipartRenderer {
+	if len(subtype) <= 0 {
+		subtype = "mixed"
+	}
+	return &MultipartRenderer{subtype: subtype, boundary: multipart.NewWriter(io.Discard).Boundary()}
+}
+
+// SetBoundary overrides the random boundary of the parts, see multipart.Writer.SetBoundary.
+func (m *MultipartRenderer) SetBoundary(boundary string) error {
+	if err := multipart.NewWriter(io.Discard).SetBoundary(boundary); nil != err {
+		return err
+	}
+	m.boundary = boundary
+	return nil
+}
+
+// Boundary returns the boundary of the parts.
+func (m *MultipartRenderer) Boundary() string {
+	return m.boundary
+}
+
+// Len returns the number of the parts.
+func (m *MultipartRenderer) Len() int {
+	return len(m.parts)
+}
+
+// Part adds a part with the header, the body is copied from the reader.
+func (m *MultipartRenderer) Part(header textproto.MIMEHeader, body io.Reader) *MultipartRenderer {
+	return m.add(header, func(w io.Writer) error {
+		if nil == body {
+			return nil
+		}
+		_, err := io.Copy(w, body)
+		return err
+	})
+}
+
+// Data adds a part of the content type with the bytes.
+func (m *MultipartRenderer) Data(contentType string, data []byte) *MultipartRenderer {
+	return m.add(partHeader(contentType), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// JSON adds a part of the value serialized as JSON, the value is encoded when the response is rendered.
+func (m *MultipartRenderer) JSON(obj interface{}) *MultipartRenderer {
+	return m.add(partHeader("application/json; charset=utf-8"), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(obj)
+	})
+}
+
+// File adds a part of the content type as an attachment with the filename,
+// the non-ASCII filename is encoded as described in RFC 2231.
+func (m *MultipartRenderer) File(filename, contentType string, body io.Reader) *MultipartRenderer {
+	header := partHeader(contentType)
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	return m.Part(header, body)
+}
+
+// Range adds a part of the byte range [start, end] of a resource with the size in bytes,
+// the size is unknown if it's negative, see https://www.rfc-editor.org/rfc/rfc9110#name-content-range.
+func (m *MultipartRenderer) Range(contentType string, start, end, size int64, body io.Reader) *MultipartRenderer {
+	complete := "*"
+	if size >= 0 {
+		complete = fmt.Sprint(size)
+	}
+	header := partHeader(contentType)
+	header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", start, end, complete))
+	return m.Part(header, body)
+}
+
+func (m *MultipartRenderer) add(header textproto.MIMEHeader, write func(w io.Writer) error) *MultipartRenderer {
+	m.parts = append(m.parts, multipartPart{header: header, write: write})
+	return m
+}
+
+func partHeader(contentType string) textproto.MIMEHeader {
+	header := textproto.MIMEHeader{}
+	if len(contentType) > 0 {
+		header.Set("Content-Type", contentType)
+	}
+	return header
+}
+
+func (m *MultipartRenderer) ContentType() string {
+	return mime.FormatMediaType("multipart/"+m.subtype, map[string]string{"boundary": m.boundary})
+}
+
+func (m *MultipartRenderer) Render(writer http.ResponseWriter) error {
+	mw := multipart.NewWriter(writer)
+	if err := mw.SetBoundary(m.boundary); nil != err {
+		return err
+	}
+	for _, part := range m.parts {
+		w, err := mw.CreatePart(part.header)
+		if nil != err {
+			return err
+		}
+		if err = part.write(w); nil != err {
+			return err
+		}
+	}
+	return mw.Close()
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultipartRenderer(t *testing.T) {
+	render := NewMultipart("mixed")
+	assert.Nil(t, render.SetBoundary("frontier"))
+	assert.NotNil(t, render.SetBoundary(""))
+	assert.Equal(t, "frontier", render.Boundary())
+
+	render.JSON(map[string]string{"name": "foo"}).
+		Data("text/plain", []byte("hello")).
+		File("résumé.txt", "text/plain", strings.NewReader("content")).
+		Part(textproto.MIMEHeader{"X-Id": {"1"}}, nil)
+	assert.Equal(t, 4, render.Len())
+	assert.Equal(t, "multipart/mixed; boundary=frontier", render.ContentType())
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, render.Render(w))
+
+	r := multipart.NewReader(w.Body, "frontier")
+	expects := []struct {
+		header textproto.MIMEHeader
+		body   string
+	}{
+		{textproto.MIMEHeader{"Content-Type": {"application/json; charset=utf-8"}}, "{\"name\":\"foo\"}\n"},
+		{textproto.MIMEHeader{"Content-Type": {"text/plain"}}, "hello"},
+		{textproto.MIMEHeader{"Content-Type": {"text/plain"}, "Content-Disposition": {"attachment; filename*=utf-8''r%C3%A9sum%C3%A9.txt"}}, "content"},
+		{textproto.MIMEHeader{"X-Id": {"1"}}, ""},
+	}
+	for _, expect := range expects {
+		part, err := r.NextPart()
+		assert.Nil(t, err)
+		assert.Equal(t, expect.header, part.Header)
+		body, err := io.ReadAll(part)
+		assert.Nil(t, err)
+		assert.Equal(t, expect.body, string(body))
+	}
+	_, err := r.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestMultipartRendererByteranges(t *testing.T) {
+	render := NewMultipart("byteranges")
+	render.Range("text/plain", 0, 4, 20, strings.NewReader("hello")).
+		Range("text/plain", 10, 14, -1, strings.NewReader("world"))
+
+	mediaType, params, err := mime.ParseMediaType(render.ContentType())
+	assert.Nil(t, err)
+	assert.Equal(t, "multipart/byteranges", mediaType)
+	assert.Equal(t, render.Boundary(), params["boundary"])
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, render.Render(w))
+
+	r := multipart.NewReader(w.Body, render.Boundary())
+	part, err := r.NextPart()
+	assert.Nil(t, err)
+	assert.Equal(t, "bytes 0-4/20", part.Header.Get("Content-Range"))
+	part, err = r.NextPart()
+	assert.Nil(t, err)
+	assert.Equal(t, "bytes 10-14/*", part.Header.Get("Content-Range"))
+	body, _ := io.ReadAll(part)
+	assert.Equal(t, "world", string(body))
+}
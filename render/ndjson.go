@@ -0,0 +1,85 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// NdjsonRenderer streams the records of a slice, an array, a receive channel or an iterator, see IsSeq,
+// as newline delimited JSON, each record is flushed to the client as soon as it's written.
+// The channel is consumed until it's closed, so its producer should stop on the request
+// context cancellation and close the channel.
+type NdjsonRenderer struct {
+	Data interface{}
+
+	// WriteTimeout is the deadline of writing each record, see NewStreamWriter.
+	WriteTimeout time.Duration
+}
+
+func (n NdjsonRenderer) ContentType() string {
+	return "application/x-ndjson; charset=utf-8"
+}
+
+func (n NdjsonRenderer) Render(writer http.ResponseWriter) error {
+	if nil == n.Data {
+		return nil
+	}
+
+	// the encoder writes each record with a single write call.
+	encoder := json.NewEncoder(NewStreamWriter(writer, n.WriteTimeout))
+	write := encoder.Encode
+
+	value := reflect.ValueOf(n.Data)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := write(value.Index(i).Interface()); nil != err {
+				return err
+			}
+		}
+	case reflect.Chan:
+		for {
+			record, ok := value.Recv()
+			if !ok {
+				break
+			}
+			if err := write(record.Interface()); nil != err {
+				return err
+			}
+		}
+	case reflect.Func:
+		if !IsSeq(value.Type()) {
+			return write(n.Data)
+		}
+		if value.IsNil() {
+			return nil
+		}
+		var err error
+		RangeSeq(value, func(record reflect.Value) bool {
+			err = write(record.Interface())
+			return nil == err
+		})
+		return err
+	default:
+		return write(n.Data)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNdjsonRenderer(t *testing.T) {
+	type record struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	w := httptest.NewRecorder()
+	render := NdjsonRenderer{Data: []record{{1, "foo"}, {2, "bar"}}}
+	err := render.Render(w)
+	assert.Nil(t, err)
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "application/x-ndjson; charset=utf-8", render.ContentType())
+	assert.Equal(t, "{\"id\":1,\"name\":\"foo\"}\n{\"id\":2,\"name\":\"bar\"}\n", w.Body.String())
+
+	records := make(chan record, 2)
+	records <- record{3, "baz"}
+	records <- record{4, "qux"}
+	close(records)
+
+	w = httptest.NewRecorder()
+	err = NdjsonRenderer{Data: (<-chan record)(records)}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"id\":3,\"name\":\"baz\"}\n{\"id\":4,\"name\":\"qux\"}\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	err = NdjsonRenderer{Data: func(yield func(record) bool) {
+		_ = yield(record{6, "seq"}) && yield(record{7, "seq"})
+	}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"id\":6,\"name\":\"seq\"}\n{\"id\":7,\"name\":\"seq\"}\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	err = NdjsonRenderer{Data: record{5, "one"}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"id\":5,\"name\":\"one\"}\n", w.Body.String())
+}
+
+func TestNdjsonRendererWriteTimeout(t *testing.T) {
+	w := httptest.NewRecorder()
+	render := NdjsonRenderer{Data: []int{1, 2}, WriteTimeout: time.Second}
+	assert.Nil(t, render.Render(w))
+	assert.Equal(t, "1\n2\n", w.Body.String())
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// DefaultWriteTimeout is the default deadline of each write of the streaming renderers.
+var DefaultWriteTimeout = 30 * time.Second
+
+// StreamWriter writes the chunks of a streaming response, each chunk is flushed to the client
+// as soon as it's written, and bounded by a write deadline so that a stalled client, or a
+// buffering proxy in front of it, can't block the writing goroutine forever.
+type StreamWriter struct {
+	writer     http.ResponseWriter
+	controller *http.ResponseController
+	timeout    time.Duration
+}
+
+// NewStreamWriter returns a StreamWriter of the response, a zero timeout means DefaultWriteTimeout
+// and a negative timeout disables the write deadline.
+func NewStreamWriter(writer http.ResponseWriter, timeout time.Duration) *StreamWriter {
+	if 0 == timeout {
+		timeout = DefaultWriteTimeout
+	}
+	return &StreamWriter{writer: writer, controller: http.NewResponseController(writer), timeout: timeout}
+}
+
+// Write writes the chunk within the write deadline and flushes it to the client, the deadline is
+// cleared once the chunk is written, so that it doesn't apply to the later writes on the connection,
+// e.g. of the next requests on a kept-alive connection.
+func (s *StreamWriter) Write(p []byte) (int, error) {
+	if s.timeout > 0 {
+		if err := s.controller.SetWriteDeadline(time.Now().Add(s.timeout)); nil != err && !errors.Is(err, http.ErrNotSupported) {
+			return 0, err
+		}
+		defer func() { _ = s.controller.SetWriteDeadline(time.Time{}) }()
+	}
+	n, err := s.writer.Write(p)
+	if nil != err {
+		return n, err
+	}
+	if err = s.controller.Flush(); nil != err && !errors.Is(err, http.ErrNotSupported) {
+		return n, err
+	}
+	return n, nil
+}
+
+var boolType = reflect.TypeOf(true)
+
+// IsSeq reports whether the type is an iterator of records, i.e. iter.Seq[T], or a func type of the
+// same signature `func(yield func(T) bool)`, which the streaming renderers accept like the channels.
+func IsSeq(t reflect.Type) bool {
+	if reflect.Func != t.Kind() || 1 != t.NumIn() || 0 != t.NumOut() {
+		return false
+	}
+	yield := t.In(0)
+	return reflect.Func == yield.Kind() && 1 == yield.NumIn() && 1 == yield.NumOut() && boolType == yield.Out(0)
+}
+
+// RangeSeq calls fn with the records yielded by the iterator, see IsSeq, until fn returns false.
+func RangeSeq(seq reflect.Value, fn func(record reflect.Value) bool) {
+	yield := reflect.MakeFunc(seq.Type().In(0), func(args []reflect.Value) []reflect.Value {
+		return []reflect.Value{reflect.ValueOf(fn(args[0]))}
+	})
+	seq.Call([]reflect.Value{yield})
+}
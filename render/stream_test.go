@@ -0,0 +1,53 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+	deadlines []time.Time
+}
+
+func (d *deadlineRecorder) SetWriteDeadline(deadline time.Time) error {
+	d.deadlines = append(d.deadlines, deadline)
+	return nil
+}
+
+func TestStreamWriterDeadline(t *testing.T) {
+	w := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+	_, err := NewStreamWriter(w, time.Second).Write([]byte("chunk"))
+	assert.Nil(t, err)
+	assert.Equal(t, "chunk", w.Body.String())
+
+	// the deadline of the chunk is cleared once it's written.
+	if assert.Len(t, w.deadlines, 2) {
+		assert.False(t, w.deadlines[0].IsZero())
+		assert.True(t, w.deadlines[1].IsZero())
+	}
+
+	w = &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+	_, err = NewStreamWriter(w, -1).Write([]byte("chunk"))
+	assert.Nil(t, err)
+	assert.Empty(t, w.deadlines)
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"go-spring.dev/web/render"
+)
+
+// RendererKey is the route metadata key of the renderer overriding the renderer of the router.
+const RendererKey = "web.renderer"
+
+// defaultRenderer renders the responses of the routers without a renderer.
+var defaultRenderer Renderer = jsonRender{}
+
+// WithRenderer overrides the renderer of the router for the route.
+//
+//	router.Get("/export", Export).Apply(web.WithRenderer(csvRenderer))
+func WithRenderer(renderer Renderer) RouteOption {
+	return func(e Endpoint) {
+		e.Meta(RendererKey, renderer)
+	}
+}
+
+// currentRenderer returns the renderer of the router, the routers without a renderer set
+// explicitly inherit the renderer of their parent router.
+func (rg *routerGroup) currentRenderer() Renderer {
+	for g := rg; nil != g; g = g.parent {
+		if nil != g.renderer {
+			return g.renderer
+		}
+	}
+	return defaultRenderer
+}
+
+// routeRenderer renders the response of the typed handlers registered on the router, the renderer
+// is resolved at request time, so that the changes of the router renderer take effect immediately.
+type routeRenderer struct {
+	rg *routerGroup
+}
+
+func (r routeRenderer) Render(ctx *Context, err error, result interface{}) {
+	negotiatedRenderer(ctx.Request, r.resolve(FromRouteContext(ctx.Request.Context()))).Render(ctx, err, result)
+}
+
+// renderRouteError renders the err by the renderer of the router serving the request, or as plain
+// text for the requests not served by a router, e.g. of the middlewares used with http.ServeMux.
+func renderRouteError(w http.ResponseWriter, r *http.Request, err error) {
+	if rg := servingRouter(r.Context()); nil != rg {
+		routeRenderer{rg}.Render(&Context{Writer: w, Request: r}, err, nil)
+		return
+	}
+	code, message := responseStatus(err)
+	http.Error(w, message, code)
+}
+
+// envelopeRenders are the renderers of the envelope of JsonRender by the media types other than JSON.
+var envelopeRenders = map[string]envelopeRender{
+	"application/xml":     {contentType: render.XmlRenderer{}.ContentType(), marshal: marshalXmlEnvelope},
+	"text/xml":            {contentType: "text/xml; charset=utf-8", marshal: marshalXmlEnvelope},
+	"application/msgpack": {contentType: render.MsgPackRenderer{}.ContentType(), marshal: marshalMsgPack},
+	"application/cbor":    {contentType: render.CBORRenderer{}.ContentType(), marshal: cbor.Marshal},
+}
+
+// negotiatedRenderer returns the renderer encoding the envelope in the content type negotiated by
+// Produces of the route, i.e. JSON, XML, MessagePack or CBOR, if the renderer of the route is one of
+// the envelope renderers, e.g. JsonRender or MsgPackRender, keeping its observers. The other renderers
+// render the routes as is, they may render the negotiated content type by NegotiatedType.
+func negotiatedRenderer(r *http.Request, renderer Renderer) Renderer {
+	var observers []func(ctx *Context, err error)
+	if j, ok := jsonRenderOf(renderer); ok {
+		observers = j.observers
+	} else if e, ok := renderer.(envelopeRender); ok {
+		observers = e.observers
+	} else {
+		return renderer
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(NegotiatedType(r))
+	switch {
+	case 0 == len(mediaType):
+		return renderer
+	case "application/json" == mediaType || strings.HasSuffix(mediaType, "+json"):
+		if _, ok := renderer.(envelopeRender); ok {
+			return jsonRender{observers: observers}
+		}
+		return renderer
+	}
+
+	negotiated, ok := envelopeRenders[mediaType]
+	if !ok {
+		return renderer
+	}
+	negotiated.observers = observers
+	return negotiated
+}
+
+// xmlResponse is the envelope of JsonRender encoded as XML.
+type xmlResponse struct {
+	XMLName xml.Name          `xml:"response"`
+	Code    int               `xml:"code"`
+	Message string            `xml:"message,omitempty"`
+	Data    interface{}       `xml:"data"`
+	Errors  *xmlBindingErrors `xml:"errors,omitempty"`
+}
+
+type xmlBindingErrors struct {
+	Errors []xmlBindingError `xml:"error"`
+}
+
+type xmlBindingError struct {
+	Field  string `xml:"field,attr,omitempty"`
+	Source string `xml:"source,attr"`
+	Reason string `xml:",chardata"`
+}
+
+// marshalXmlEnvelope encodes the envelope of JsonRender as XML.
+func marshalXmlEnvelope(v interface{}) ([]byte, error) {
+	resp := v.(jsonResponse)
+	x := xmlResponse{Code: resp.Code, Message: resp.Message, Data: resp.Data}
+	if len(resp.Errors) > 0 {
+		x.Errors = &xmlBindingErrors{}
+		for _, e := range resp.Errors {
+			x.Errors.Errors = append(x.Errors.Errors, xmlBindingError{Field: e.Field, Source: e.Source, Reason: e.Reason})
+		}
+	}
+	return xml.Marshal(x)
+}
+
+// resolve returns the renderer of the route, overridden by the route metadata, or set explicitly
+// on the inline routers the route is registered with, or of the router serving the route, which
+// is a clone of the router the route is registered with if the router is cloned.
+func (r routeRenderer) resolve(rctx *RouteContext) Renderer {
+	if nil != rctx {
+		if renderer, ok := rctx.routeMetadata[RendererKey].(Renderer); ok {
+			return renderer
+		}
+	}
+
+	owner := r.rg
+	for ; owner.inline; owner = owner.parent {
+		if nil != owner.renderer {
+			return owner.renderer
+		}
+	}
+	if nil != rctx && nil != rctx.router {
+		owner = rctx.router
+	}
+	return owner.currentRenderer()
+}
+
+func (r routeRenderer) CheckResult(t reflect.Type) error {
+	if checker, ok := resultCheckerOf(r.rg.currentRenderer()); ok {
+		return checker.CheckResult(t)
+	}
+	return nil
+}
+
+// envelopeRender renders the results in the envelope of JsonRender encoded by the marshal function,
+// the results that can't be encoded are rendered as an internal server error.
+type envelopeRender struct {
+	contentType string
+	marshal     func(v interface{}) ([]byte, error)
+	observers   []func(ctx *Context, err error)
+}
+
+func (e envelopeRender) Render(ctx *Context, err error, result interface{}) {
+	code, message := responseStatus(err)
+
+	// encode the response before writing the status, so that the encoding error can still be rendered.
+	data, encodeErr := e.marshal(jsonResponse{Code: code, Message: message, Data: result, Errors: bindingErrorsOf(err)})
+	if nil != encodeErr {
+		for _, observe := range e.observers {
+			observe(ctx, encodeErr)
+		}
+		data, _ = e.marshal(jsonResponse{Code: http.StatusInternalServerError, Message: encodeErr.Error()})
+	}
+
+	_ = ctx.Data(http.StatusOK, e.contentType, data)
+}
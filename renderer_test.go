@@ -0,0 +1,97 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRendererInheritance(t *testing.T) {
+	named := func(name string) Renderer {
+		return RendererFunc(func(ctx *Context, err error, result interface{}) {
+			_ = ctx.String(http.StatusOK, "%s:%v", name, result)
+		})
+	}
+	hello := func(ctx context.Context) string { return "hello" }
+
+	r := NewRouter()
+	r.Get("/", hello)
+	r.Get("/override", hello).Apply(WithRenderer(named("route")))
+	r.Group("/inherit", func(r Router) {
+		r.Get("/", hello)
+	})
+	r.Group("/own", func(r Router) {
+		r.Renderer(named("own"))
+		r.Get("/", hello)
+		r.Group("/nested", func(r Router) {
+			r.Get("/", hello)
+		})
+	})
+	reset := r.Group("/reset", func(r Router) {
+		r.Renderer(named("reset"))
+		r.Get("/", hello)
+	})
+	r.With().Get("/inline", hello)
+
+	serve := func(path string) string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Body.String()
+	}
+
+	assert.Equal(t, `{"code":0,"data":"hello"}`+"\n", serve("/"))
+	assert.Equal(t, "route:hello", serve("/override"))
+	assert.Equal(t, "own:hello", serve("/own/nested/"))
+
+	// the renderer set on the parent afterwards propagates to the inheriting routers.
+	r.Renderer(named("root"))
+	assert.Equal(t, "root:hello", serve("/"))
+	assert.Equal(t, "root:hello", serve("/inherit/"))
+	assert.Equal(t, "root:hello", serve("/inline"))
+	assert.Equal(t, "own:hello", serve("/own/"))
+	assert.Equal(t, "reset:hello", serve("/reset/"))
+	assert.Equal(t, "route:hello", serve("/override"))
+
+	reset.InheritRenderer()
+	assert.Equal(t, "root:hello", serve("/reset/"))
+
+	r.InheritRenderer()
+	assert.Equal(t, `{"code":0,"data":"hello"}`+"\n", serve("/reset/"))
+}
+
+func TestRenderUnmatchedErrors(t *testing.T) {
+	r := NewRouter()
+	r.NotFoundError(nil)
+	r.MethodNotAllowedError(Error(http.StatusMethodNotAllowed, "use %s", "GET"))
+	r.Get("/todos", func(ctx context.Context) string { return "todos" })
+	r.Group("/admin", func(r Router) {
+		r.Renderer(RendererFunc(func(ctx *Context, err error, result interface{}) {
+			_ = ctx.String(http.StatusTeapot, "admin: %v", err)
+		}))
+		r.Get("/users", func(ctx context.Context) string { return "users" })
+	})
+
+	_, body := testHandler(t, r, "GET", "/missing", nil)
+	assert.Equal(t, "{\"code\":404,\"message\":\"Not Found\",\"data\":null}\n", body)
+
+	resp, body := testHandler(t, r, "POST", "/todos", nil)
+	assert.Equal(t, "GET", resp.Header.Get("Allow"))
+	assert.Equal(t, "{\"code\":405,\"message\":\"use GET\",\"data\":null}\n", body)
+
+	// the unmatched requests of the group are rendered by its renderer.
+	resp, body = testHandler(t, r, "GET", "/admin/missing", nil)
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	assert.Equal(t, "admin: 404: Not Found", body)
+
+	// the clone renders by its own renderer.
+	cp := r.Clone().Renderer(RendererFunc(func(ctx *Context, err error, result interface{}) {
+		_ = ctx.String(http.StatusNotFound, "clone: %v", err)
+	}))
+	_, body = testHandler(t, cp, "GET", "/missing", nil)
+	assert.Equal(t, "clone: 404: Not Found", body)
+	_, body = testHandler(t, r, "GET", "/missing", nil)
+	assert.Equal(t, "{\"code\":404,\"message\":\"Not Found\",\"data\":null}\n", body)
+}
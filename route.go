@@ -0,0 +1,145 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+// RouteBuilder registers the handlers of multiple methods on one routing pattern,
+// the middlewares and renderer of the builder are shared by its handlers only.
+//
+//	router.Route("/todos/{id}").
+//		Use(Auth).
+//		Get(GetTodo).
+//		Put(UpdateTodo).
+//		Delete(DeleteTodo)
+type RouteBuilder interface {
+	// Use appends a MiddlewareFunc to the chain of the route handlers.
+	Use(mwf ...MiddlewareFunc) RouteBuilder
+
+	// Renderer to be used by the route handlers.
+	Renderer(renderer Renderer) RouteBuilder
+
+	// Any registers the handler that matches all the HTTP methods.
+	Any(handler interface{}) RouteBuilder
+
+	// Get registers the handler of the GET method.
+	Get(handler interface{}) RouteBuilder
+
+	// Head registers the handler of the HEAD method.
+	Head(handler interface{}) RouteBuilder
+
+	// Post registers the handler of the POST method.
+	Post(handler interface{}) RouteBuilder
+
+	// Put registers the handler of the PUT method.
+	Put(handler interface{}) RouteBuilder
+
+	// Patch registers the handler of the PATCH method.
+	Patch(handler interface{}) RouteBuilder
+
+	// Delete registers the handler of the DELETE method.
+	Delete(handler interface{}) RouteBuilder
+
+	// Connect registers the handler of the CONNECT method.
+	Connect(handler interface{}) RouteBuilder
+
+	// Options registers the handler of the OPTIONS method.
+	Options(handler interface{}) RouteBuilder
+
+	// Trace registers the handler of the TRACE method.
+	Trace(handler interface{}) RouteBuilder
+}
+
+type routeBuilder struct {
+	router  Router
+	pattern string
+}
+
+// Route returns a builder registering the handlers of multiple methods on the routing pattern.
+func (rg *routerGroup) Route(pattern string) RouteBuilder {
+	return &routeBuilder{router: rg.With(), pattern: pattern}
+}
+
+// Use appends a MiddlewareFunc to the chain of the route handlers.
+func (b *routeBuilder) Use(mwf ...MiddlewareFunc) RouteBuilder {
+	b.router.Use(mwf...)
+	return b
+}
+
+// Renderer to be used by the route handlers.
+func (b *routeBuilder) Renderer(renderer Renderer) RouteBuilder {
+	b.router.Renderer(renderer)
+	return b
+}
+
+// Any registers the handler that matches all the HTTP methods.
+func (b *routeBuilder) Any(handler interface{}) RouteBuilder {
+	b.router.Any(b.pattern, handler)
+	return b
+}
+
+// Get registers the handler of the GET method.
+func (b *routeBuilder) Get(handler interface{}) RouteBuilder {
+	b.router.Get(b.pattern, handler)
+	return b
+}
+
+// Head registers the handler of the HEAD method.
+func (b *routeBuilder) Head(handler interface{}) RouteBuilder {
+	b.router.Head(b.pattern, handler)
+	return b
+}
+
+// Post registers the handler of the POST method.
+func (b *routeBuilder) Post(handler interface{}) RouteBuilder {
+	b.router.Post(b.pattern, handler)
+	return b
+}
+
+// Put registers the handler of the PUT method.
+func (b *routeBuilder) Put(handler interface{}) RouteBuilder {
+	b.router.Put(b.pattern, handler)
+	return b
+}
+
+// Patch registers the handler of the PATCH method.
+func (b *routeBuilder) Patch(handler interface{}) RouteBuilder {
+	b.router.Patch(b.pattern, handler)
+	return b
+}
+
+// Delete registers the handler of the DELETE method.
+func (b *routeBuilder) Delete(handler interface{}) RouteBuilder {
+	b.router.Delete(b.pattern, handler)
+	return b
+}
+
+// Connect registers the handler of the CONNECT method.
+func (b *routeBuilder) Connect(handler interface{}) RouteBuilder {
+	b.router.Connect(b.pattern, handler)
+	return b
+}
+
+// Options registers the handler of the OPTIONS method.
+func (b *routeBuilder) Options(handler interface{}) RouteBuilder {
+	b.router.Options(b.pattern, handler)
+	return b
+}
+
+// Trace registers the handler of the TRACE method.
+func (b *routeBuilder) Trace(handler interface{}) RouteBuilder {
+	b.router.Trace(b.pattern, handler)
+	return b
+}
@@ -0,0 +1,193 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RouteConfig is a route definition of the configuration, the requests are either forwarded to
+// the upstream server of Proxy, or served by the handler registered by the application as Handler.
+type RouteConfig struct {
+	// Pattern is the routing pattern of the route.
+	Pattern string `json:"pattern" yaml:"pattern"`
+
+	// Methods are the HTTP methods of the route, the route matches all the methods if empty.
+	Methods []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+
+	// Proxy is the URL of the upstream server, the request path is appended to the path of the URL.
+	Proxy string `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+
+	// StripPrefix is removed from the request path before it's forwarded to the upstream server.
+	StripPrefix string `json:"strip-prefix,omitempty" yaml:"strip-prefix,omitempty"`
+
+	// Handler is the name of the handler registered by the application.
+	Handler string `json:"handler,omitempty" yaml:"handler,omitempty"`
+
+	// Meta is the metadata attached to the route.
+	Meta map[string]interface{} `json:"meta,omitempty" yaml:"meta,omitempty"`
+}
+
+// RoutesConfig is the configuration of the routes, see LoadRoutes.
+type RoutesConfig struct {
+	Routes []RouteConfig `json:"routes" yaml:"routes"`
+}
+
+// LoadRoutesFile reads the route definitions from the YAML or JSON file, see LoadRoutes.
+func LoadRoutesFile(r Router, filename string, handlers map[string]interface{}) error {
+	data, err := os.ReadFile(filename)
+	if nil != err {
+		return err
+	}
+	if err = LoadRoutes(r, data, handlers); nil != err {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	return nil
+}
+
+// LoadRoutes reads the route definitions from the YAML or JSON data and registers them on the router,
+// the handlers are the named handlers of the application, of the signatures accepted by Router.Get.
+// The definitions are validated before any route is registered, and the routes already registered
+// are removed if a route fails to register, e.g. because it conflicts with an existing route.
+//
+//	routes:
+//	  - pattern: /api/users/*
+//	    proxy: http://users.internal:8080
+//	    strip-prefix: /api
+//	  - pattern: /health
+//	    methods: [GET]
+//	    handler: health
+func LoadRoutes(r Router, data []byte, handlers map[string]interface{}) error {
+	var config RoutesConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); nil != err && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	// the reverse proxies dial by the DialPolicy of the router.
+	var transport http.RoundTripper
+	if rg, ok := r.(*routerGroup); ok && nil != rg.opts && nil != rg.opts.dialPolicy {
+		transport = rg.opts.dialPolicy.Transport()
+	}
+
+	routeHandlers := make([]interface{}, len(config.Routes))
+	for i, route := range config.Routes {
+		handler, err := route.handler(handlers, transport)
+		if nil != err {
+			return fmt.Errorf("route %d '%s': %w", i, route.Pattern, err)
+		}
+		routeHandlers[i] = handler
+	}
+
+	var registered []RouteConfig
+	for i, route := range config.Routes {
+		if err := route.register(r, routeHandlers[i]); nil != err {
+			for _, route := range registered {
+				route.remove(r)
+			}
+			return fmt.Errorf("route %d '%s': %w", i, route.Pattern, err)
+		}
+		registered = append(registered, route)
+	}
+	return nil
+}
+
+// handler returns the handler of the route, the reverse proxy of the upstream dialing by the transport,
+// the default transport if nil, or the named handler.
+func (c RouteConfig) handler(handlers map[string]interface{}, transport http.RoundTripper) (interface{}, error) {
+	if 0 == len(c.Pattern) || '/' != c.Pattern[0] {
+		return nil, fmt.Errorf("routing pattern must begin with '/'")
+	}
+	for _, method := range c.Methods {
+		if _, ok := methodMap[method]; !ok {
+			return nil, fmt.Errorf("%q http method is not supported", method)
+		}
+	}
+
+	switch {
+	case len(c.Proxy) > 0 && len(c.Handler) > 0:
+		return nil, fmt.Errorf("either proxy or handler is allowed")
+	case len(c.Handler) > 0:
+		handler, ok := handlers[c.Handler]
+		if !ok || nil == handler {
+			return nil, fmt.Errorf("handler '%s' is not found", c.Handler)
+		}
+		if len(c.StripPrefix) > 0 {
+			return nil, fmt.Errorf("strip-prefix is only allowed with proxy")
+		}
+		return handler, nil
+	case len(c.Proxy) > 0:
+		target, err := url.Parse(c.Proxy)
+		if nil != err {
+			return nil, err
+		}
+		if 0 == len(target.Scheme) || 0 == len(target.Host) {
+			return nil, fmt.Errorf("proxy '%s' must be an absolute URL", c.Proxy)
+		}
+		var handler http.Handler = &httputil.ReverseProxy{Transport: transport, Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+		}}
+		if len(c.StripPrefix) > 0 {
+			handler = http.StripPrefix(c.StripPrefix, handler)
+		}
+		return handler, nil
+	default:
+		return nil, fmt.Errorf("either proxy or handler is required")
+	}
+}
+
+// register registers the route on the router, the panics of the registration are returned as errors.
+func (c RouteConfig) register(r Router, handler interface{}) (err error) {
+	defer func() {
+		if v := recover(); nil != v {
+			err = fmt.Errorf("%v", v)
+		}
+	}()
+
+	var e Endpoint
+	if 0 == len(c.Methods) {
+		e = r.Any(c.Pattern, handler)
+	} else {
+		e = r.Methods(c.Methods...)(c.Pattern, handler)
+	}
+	for key, value := range c.Meta {
+		e.Meta(key, value)
+	}
+	return nil
+}
+
+// remove removes the registered route from the router.
+func (c RouteConfig) remove(r Router) {
+	if 0 == len(c.Methods) {
+		r.Remove("", c.Pattern)
+		return
+	}
+	for _, method := range c.Methods {
+		r.Remove(method, c.Pattern)
+	}
+}
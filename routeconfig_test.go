@@ -0,0 +1,105 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRoutes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Forwarded-Host"))
+	}))
+	defer upstream.Close()
+
+	config := `
+routes:
+  - pattern: /api/users/*
+    proxy: ` + upstream.URL + `/v1
+    strip-prefix: /api
+  - pattern: /health
+    methods: [GET, HEAD]
+    handler: health
+    meta:
+      tag: ops
+`
+	r := NewRouter()
+	r.Renderer(RendererFunc(func(ctx *Context, err error, result interface{}) {
+		_ = ctx.String(http.StatusOK, "%v", result)
+	}))
+	err := LoadRoutes(r, []byte(config), map[string]interface{}{
+		"health": func(ctx context.Context) string { return "ok" },
+	})
+	assert.NoError(t, err)
+
+	serve := func(method, path string) (int, string) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w.Code, w.Body.String()
+	}
+
+	code, body := serve(http.MethodDelete, "/api/users/42")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "DELETE /v1/users/42 example.com", body)
+
+	code, body = serve(http.MethodGet, "/health")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body)
+	code, _ = serve(http.MethodPost, "/health")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	for _, route := range r.Routes() {
+		if "/health" == route.Pattern {
+			assert.Equal(t, "ops", route.Metadata[http.MethodGet]["tag"])
+		}
+	}
+
+	// the reverse proxies dial by the DialPolicy of the router.
+	r = NewRouterWith(RouterOptions{DialPolicy: &DialPolicy{}})
+	assert.NoError(t, LoadRoutes(r, []byte(config), map[string]interface{}{
+		"health": func(ctx context.Context) string { return "ok" },
+	}))
+	code, _ = serve(http.MethodGet, "/api/users/42")
+	assert.Equal(t, http.StatusBadGateway, code)
+
+	// the JSON config.
+	file := filepath.Join(t.TempDir(), "routes.json")
+	assert.NoError(t, os.WriteFile(file, []byte(`{"routes": [{"pattern": "/ping", "handler": "health"}]}`), 0644))
+	r = NewRouter()
+	assert.NoError(t, LoadRoutesFile(r, file, map[string]interface{}{"health": func(ctx context.Context) string { return "ok" }}))
+	assert.Len(t, r.Routes(), 1)
+}
+
+func TestLoadRoutesError(t *testing.T) {
+	handlers := map[string]interface{}{"health": func(ctx context.Context) {}}
+
+	tests := map[string]string{
+		"unknown handler": `{"routes": [{"pattern": "/a", "handler": "missing"}]}`,
+		"no target":       `{"routes": [{"pattern": "/a"}]}`,
+		"both targets":    `{"routes": [{"pattern": "/a", "handler": "health", "proxy": "http://localhost"}]}`,
+		"relative proxy":  `{"routes": [{"pattern": "/a", "proxy": "/upstream"}]}`,
+		"invalid method":  `{"routes": [{"pattern": "/a", "methods": ["FETCH"], "handler": "health"}]}`,
+		"invalid pattern": `{"routes": [{"pattern": "a", "handler": "health"}]}`,
+		"unknown field":   `{"routes": [{"pattern": "/a", "handlr": "health"}]}`,
+	}
+	for name, config := range tests {
+		r := NewRouter()
+		assert.Error(t, LoadRoutes(r, []byte(config), handlers), name)
+		assert.Empty(t, r.Routes(), name)
+	}
+
+	// the routes registered before the conflicting route are removed.
+	r := NewRouter()
+	r.Get("/b", func(ctx context.Context) {})
+	err := LoadRoutes(r, []byte(`{"routes": [{"pattern": "/a", "handler": "health"}, {"pattern": "/b", "handler": "health"}]}`), handlers)
+	assert.ErrorContains(t, err, "route 1 '/b'")
+	assert.Len(t, r.Routes(), 1)
+
+	assert.NoError(t, LoadRoutes(NewRouter(), nil, handlers))
+}
@@ -17,7 +17,10 @@
 package web
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/http"
 	"strings"
 	"sync"
@@ -64,51 +67,117 @@ type Router interface {
 	// Renderer to be used Response renderer in default.
 	Renderer(renderer Renderer) Router
 
+	// InheritRenderer makes the router inherit the renderer of its parent router again.
+	InheritRenderer() Router
+
+	// Clock sets the clock of the time dependent features, e.g. the cache expiries.
+	Clock(clock Clock) Router
+
+	// Rand sets the random source of the router features.
+	Rand(rand Rand) Router
+
+	// Validator sets the validator of the request structs bound for the routes of the router.
+	Validator(validator func(i interface{}) error) Router
+
+	// Intercept appends a HandlerInterceptor to the typed handlers chain.
+	Intercept(interceptors ...HandlerInterceptor) Router
+
+	// With adds inline middlewares for an endpoint handler.
+	With(mwf ...MiddlewareFunc) Router
+
 	// Group creates a new router group.
 	Group(pattern string, fn ...func(r Router)) Router
 
+	// GroupWith creates an inline group with additional middlewares sharing the routing patterns of the router.
+	GroupWith(fn func(r Router), mws ...MiddlewareFunc) Router
+
+	// Warmup registers a warm-up function, the routes respond 503 until it completes.
+	Warmup(fn func(ctx context.Context) error) Router
+
+	// Route returns a builder registering the handlers of multiple methods on the routing pattern.
+	Route(pattern string) RouteBuilder
+
+	// Mount attaches another http.Handler or Router as a subrouter along a routing path.
+	Mount(pattern string, handler http.Handler)
+
 	// Handle registers a new route with a matcher for the URL pattern.
-	Handle(pattern string, handler http.Handler)
+	Handle(pattern string, handler http.Handler) Endpoint
 
 	// HandleFunc registers a new route with a matcher for the URL pattern.
-	HandleFunc(pattern string, handler http.HandlerFunc)
+	HandleFunc(pattern string, handler http.HandlerFunc) Endpoint
 
 	// Any registers a route that matches all the HTTP methods.
 	// GET, POST, PUT, PATCH, HEAD, OPTIONS, DELETE, CONNECT, TRACE.
-	Any(pattern string, handler interface{})
+	Any(pattern string, handler interface{}) Endpoint
+
+	// Methods returns a function registering the route that matches the given HTTP methods only.
+	Methods(methods ...string) func(pattern string, handler interface{}) Endpoint
 
 	// Get registers a new GET route with a matcher for the URL path of the get method.
-	Get(pattern string, handler interface{})
+	Get(pattern string, handler interface{}) Endpoint
 
 	// Head registers a new HEAD route with a matcher for the URL path of the head method.
-	Head(pattern string, handler interface{})
+	Head(pattern string, handler interface{}) Endpoint
 
 	// Post registers a new POST route with a matcher for the URL path of the post method.
-	Post(pattern string, handler interface{})
+	Post(pattern string, handler interface{}) Endpoint
 
 	// Put registers a new PUT route with a matcher for the URL path of the put method.
-	Put(pattern string, handler interface{})
+	Put(pattern string, handler interface{}) Endpoint
 
 	// Patch registers a new PATCH route with a matcher for the URL path of the patch method.
-	Patch(pattern string, handler interface{})
+	Patch(pattern string, handler interface{}) Endpoint
 
 	// Delete registers a new DELETE route with a matcher for the URL path of the delete method.
-	Delete(pattern string, handler interface{})
+	Delete(pattern string, handler interface{}) Endpoint
 
 	// Connect registers a new CONNECT route with a matcher for the URL path of the connect method.
-	Connect(pattern string, handler interface{})
+	Connect(pattern string, handler interface{}) Endpoint
 
 	// Options registers a new OPTIONS route with a matcher for the URL path of the options method.
-	Options(pattern string, handler interface{})
+	Options(pattern string, handler interface{}) Endpoint
 
 	// Trace registers a new TRACE route with a matcher for the URL path of the trace method.
-	Trace(pattern string, handler interface{})
+	Trace(pattern string, handler interface{}) Endpoint
+
+	// Static serves the files of the directory under the routing pattern.
+	Static(pattern, dir string, opts ...StaticOption)
+
+	// StaticFS serves the files of the file system under the routing pattern.
+	StaticFS(pattern string, fsys fs.FS, opts ...StaticOption)
+
+	// StaticFile serves a single file on the routing pattern.
+	StaticFile(pattern, file string)
+
+	// Redirect registers a route redirecting the requests to the location with the code,
+	// the `{name}` placeholders of the location are replaced by the path params.
+	Redirect(pattern, location string, code int)
+
+	// Clone returns a deep copy of the router, its routing tree, middlewares and renderer, so that
+	// the copy can be changed without affecting the original router.
+	Clone() Router
+
+	// Dynamic enables the thread-safe registration and removal of routes while serving requests.
+	Dynamic() Router
+
+	// Remove removes the route of the method registered with the pattern, an empty
+	// method removes the routes of all methods, reports whether any route is removed.
+	Remove(method, pattern string) bool
 
 	// NotFound to be used when no route matches.
 	NotFound(handler http.HandlerFunc)
 
 	// MethodNotAllowed to be used when the request method does not match the route.
 	MethodNotAllowed(handler http.HandlerFunc)
+
+	// NotFoundError renders the err by the renderer of the router when no route matches.
+	NotFoundError(err error)
+
+	// MethodNotAllowedError renders the err by the renderer of the router when the request method does not match the route.
+	MethodNotAllowedError(err error)
+
+	// Describe prints the routes of the router with the middlewares, renderer and mount points for debugging.
+	Describe(w io.Writer)
 }
 
 type Routes interface {
@@ -118,6 +187,10 @@ type Routes interface {
 	// Middlewares returns the list of middlewares in use by the router.
 	Middlewares() Middlewares
 
+	// Walk visits every route recursively descending into mounted subrouters,
+	// with the mount prefixes flattened into the route pattern.
+	Walk(fn func(method, pattern string, handler http.Handler, mws Middlewares) error) error
+
 	// Match searches the routing tree for a handler that matches
 	// the method/path - similar to routing a http request, but without
 	// executing the handler thereafter.
@@ -127,9 +200,8 @@ type Routes interface {
 // NewRouter returns a new router instance.
 func NewRouter() Router {
 	return &routerGroup{
-		tree:     &node{},
-		renderer: JsonRender(),
-		pool:     &sync.Pool{New: func() interface{} { return &RouteContext{} }},
+		tree: &node{},
+		pool: &sync.Pool{New: func() interface{} { return &RouteContext{} }},
 	}
 }
 
@@ -139,10 +211,20 @@ type routerGroup struct {
 	tree              *node
 	parent            *routerGroup
 	middlewares       Middlewares
-	renderer          Renderer
+	renderer          Renderer // nil inherits the renderer of the parent, see currentRenderer
+	interceptors      []HandlerInterceptor
+	clock             Clock                     // nil inherits the clock of the parent, see ClockOf
+	rand              Rand                      // nil inherits the random source of the parent, see RandOf
+	validator         func(i interface{}) error // nil inherits the validator of the parent, see validatorOf
 	notFoundHandler   http.HandlerFunc
 	notAllowedHandler http.HandlerFunc
 	pool              *sync.Pool
+	warmup            *warmup
+	warmups           []*warmup
+	opts              *routerOptions // shared by the groups of the router, see NewRouterWith
+
+	// mu guards the tree in the dynamic mode, see Dynamic.
+	mu *sync.RWMutex
 }
 
 // Use appends a MiddlewareFunc to the chain.
@@ -156,14 +238,64 @@ func (rg *routerGroup) Use(mwf ...MiddlewareFunc) Router {
 }
 
 // Renderer to be used Response renderer in default.
+// The renderer is resolved when the response is rendered, so it applies to the routes registered
+// already as well, and to the groups and inline routers inheriting it.
 func (rg *routerGroup) Renderer(renderer Renderer) Router {
+	rg.renderer = renderer
+	return rg
+}
+
+// InheritRenderer makes the router inherit the renderer of its parent router again,
+// the root router falls back to the default JsonRender.
+func (rg *routerGroup) InheritRenderer() Router {
+	rg.renderer = nil
+	return rg
+}
+
+// Intercept appends a HandlerInterceptor to the typed handlers chain.
+// Interceptors run after the request has been bound and before the result is rendered,
+// and are executed in the order that they are applied to the Router.
+func (rg *routerGroup) Intercept(interceptors ...HandlerInterceptor) Router {
 	if rg.handler != nil {
-		panic("renderer must be defined before routes registers")
+		panic("interceptors must be defined before routes registers")
 	}
-	rg.renderer = renderer
+	rg.interceptors = append(rg.interceptors[:len(rg.interceptors):len(rg.interceptors)], interceptors...)
 	return rg
 }
 
+// With adds inline middlewares for an endpoint handler, the returned router shares the
+// routing tree with rg, and the middlewares only apply to the routes registered on it.
+//
+//	router.With(auth).Get("/admin", AdminIndex)
+func (rg *routerGroup) With(mwf ...MiddlewareFunc) Router {
+	// Similarly as in handle(), we must build the router handler once additional
+	// middleware registration isn't allowed for this stack, like now.
+	if !rg.inline && rg.handler == nil {
+		rg.updateRouteHandler()
+	}
+
+	// Copy middlewares from parent inline routers
+	var mws Middlewares
+	if rg.inline {
+		mws = make(Middlewares, len(rg.middlewares))
+		copy(mws, rg.middlewares)
+	}
+	mws = append(mws, mwf...)
+
+	return &routerGroup{
+		inline:            true,
+		parent:            rg,
+		tree:              rg.tree,
+		middlewares:       mws,
+		interceptors:      rg.interceptors,
+		notFoundHandler:   rg.notFoundHandler,
+		notAllowedHandler: rg.notAllowedHandler,
+		pool:              rg.pool,
+		opts:              rg.opts,
+		mu:                rg.mu,
+	}
+}
+
 func (rg *routerGroup) NotFoundHandler() http.Handler {
 	if rg.notFoundHandler != nil {
 		return rg.notFoundHandler
@@ -197,6 +329,7 @@ func (rg *routerGroup) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// with context
 	r = r.WithContext(WithRouteContext(r.Context(), ctx))
+	w = ctx.accounting(w, r)
 	rg.handler.ServeHTTP(w, r)
 
 	// put context to pool
@@ -207,7 +340,7 @@ func (rg *routerGroup) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // Recursively update data on child routers.
 func (rg *routerGroup) updateSubRoutes(fn func(subMux *routerGroup)) {
-	for _, r := range rg.tree.routes() {
+	for _, r := range rg.Routes() {
 		subMux, ok := r.SubRoutes.(*routerGroup)
 		if !ok {
 			continue
@@ -250,29 +383,64 @@ func (rg *routerGroup) routeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	method, ok := methodMap[ctx.RouteMethod]
 	if !ok {
+		ctx.router = rg
 		rg.NotAllowedHandler().ServeHTTP(w, r)
 		return
 	}
 
 	// Find the route
-	if _, _, h := rg.tree.FindRoute(ctx, method, routePath); h != nil {
-		// sets the path values in the Request value based on the provided request context.
-		setPathValue(ctx, r)
+	if nil != rg.mu {
+		rg.mu.RLock()
+	}
+	_, _, h := rg.tree.FindRoute(ctx, method, routePath)
+	if nil != rg.mu {
+		rg.mu.RUnlock()
+	}
 
-		// serve http request.
-		h.ServeHTTP(w, r)
+	if h != nil {
+		rg.serveRoute(ctx, h, w, r)
 		return
 	}
+	ctx.router = rg
 	if ctx.methodNotAllowed {
 		rg.NotAllowedHandler().ServeHTTP(w, r)
-	} else {
+	} else if !rg.fallbackRoute(ctx, method, routePath, w, r) {
 		rg.NotFoundHandler().ServeHTTP(w, r)
 	}
 }
 
+// serveRoute serves the request by the handler of the matched route.
+func (rg *routerGroup) serveRoute(ctx *RouteContext, h http.Handler, w http.ResponseWriter, r *http.Request) {
+	ctx.router = rg
+
+	// sets the path values in the Request value based on the provided request context.
+	setPathValue(ctx, r)
+
+	// reject the request body the route can't consume.
+	if !consumable(ctx, r) {
+		http.Error(w, "415 unsupported media type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// reject the request accepting none of the content types the route produces.
+	if !acceptable(ctx, r) {
+		http.Error(w, "406 not acceptable", http.StatusNotAcceptable)
+		return
+	}
+
+	// limit the request body read by the handlers, see MaxBodyBytes.
+	limitBody(rg, ctx, w, r)
+
+	// serve http request within the concurrency limit of the route.
+	limitInflight(ctx, h, w, r)
+}
+
 // Group creates a new router group.
 func (rg *routerGroup) Group(pattern string, fn ...func(r Router)) Router {
-	subRouter := &routerGroup{tree: &node{}, renderer: rg.renderer, pool: rg.pool}
+	subRouter := &routerGroup{tree: &node{}, parent: rg, interceptors: rg.interceptors, pool: rg.pool, opts: rg.opts}
+	if nil != rg.mu {
+		subRouter.mu = &sync.RWMutex{}
+	}
 	for _, f := range fn {
 		f(subRouter)
 	}
@@ -280,9 +448,42 @@ func (rg *routerGroup) Group(pattern string, fn ...func(r Router)) Router {
 	return subRouter
 }
 
+// GroupWith creates an inline group with the additional middlewares, the routes of the group share
+// the routing patterns of the router, so the routes under the same prefix can be split by middlewares
+// without inventing sub-paths.
+//
+//	router.Group("/api/v1", func(r web.Router) {
+//		r.Get("/status", Status)
+//		r.GroupWith(func(r web.Router) {
+//			r.Get("/todos", ListTodos)
+//			r.Post("/todos", CreateTodo)
+//		}, Auth)
+//	})
+func (rg *routerGroup) GroupWith(fn func(r Router), mws ...MiddlewareFunc) Router {
+	im := rg.With(mws...)
+	if nil != fn {
+		fn(im)
+	}
+	return im
+}
+
+// mountedPattern reports whether a handler is mounted on the pattern already, the tree is read
+// under the lock of the dynamic routers, see Dynamic.
+func (rg *routerGroup) mountedPattern(pattern string) bool {
+	if nil != rg.mu {
+		rg.mu.RLock()
+		defer rg.mu.RUnlock()
+	}
+	return rg.tree.findPattern(pattern+"*") || rg.tree.findPattern(pattern+"/*")
+}
+
 // Mount attaches another http.Handler or RouterGroup as a subrouter along a routing
 // path. It's very useful to split up a large API as many independent routers and
 // compose them as a single service using Mount.
+//
+// The mounted http.ServeMux matches the path relative to the mount, the values of
+// its Go 1.22 patterns are added to the route params, and the path params of the
+// router are available by Request.PathValue in its handlers.
 func (rg *routerGroup) Mount(pattern string, handler http.Handler) {
 	if handler == nil {
 		panic(fmt.Sprintf("attempting to Mount() a nil handler on '%s'", pattern))
@@ -290,7 +491,7 @@ func (rg *routerGroup) Mount(pattern string, handler http.Handler) {
 
 	// Provide runtime safety for ensuring a pattern isn't mounted on an existing
 	// routing pattern.
-	if rg.tree.findPattern(pattern+"*") || rg.tree.findPattern(pattern+"/*") {
+	if rg.mountedPattern(pattern) {
 		panic(fmt.Sprintf("attempting to Mount() a handler on an existing path, '%s'", pattern))
 	}
 
@@ -303,24 +504,16 @@ func (rg *routerGroup) Mount(pattern string, handler http.Handler) {
 		subr.MethodNotAllowed(rg.notAllowedHandler)
 	}
 
-	mountHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := FromRouteContext(r.Context())
-
-		// shift the url path past the previous subrouter
-		ctx.RoutePath = rg.nextRoutePath(ctx)
-
-		// reset the wildcard URLParam which connects the subrouter
-		n := len(ctx.URLParams.Keys) - 1
-		if n >= 0 && ctx.URLParams.Keys[n] == "*" && len(ctx.URLParams.Values) > n {
-			ctx.URLParams.Values[n] = ""
-		}
+	// the http.ServeMux matches the path relative to the mount.
+	if mux, ok := handler.(*http.ServeMux); ok {
+		handler = &mountedServeMux{mux: mux}
+	}
 
-		handler.ServeHTTP(w, r)
-	})
+	mountHandler := &mountHandler{rg: rg, handler: handler}
 
 	if pattern == "" || pattern[len(pattern)-1] != '/' {
-		rg.handle(mALL|mSTUB, pattern, mountHandler)
-		rg.handle(mALL|mSTUB, pattern+"/", mountHandler)
+		rg.handle(mALL|mSTUB, pattern, mountHandler, nil)
+		rg.handle(mALL|mSTUB, pattern+"/", mountHandler, nil)
 		pattern += "/"
 	}
 
@@ -329,25 +522,76 @@ func (rg *routerGroup) Mount(pattern string, handler http.Handler) {
 	if subroutes != nil {
 		method |= mSTUB
 	}
-	n := rg.handle(method, pattern+"*", mountHandler)
+	n := rg.handle(method, pattern+"*", mountHandler, nil)
 
 	if subroutes != nil {
 		n.subroutes = subroutes
 	}
 }
 
+// mountHandler serves the requests of the handler mounted on the router.
+type mountHandler struct {
+	rg      *routerGroup
+	handler http.Handler
+}
+
+func (m *mountHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := FromRouteContext(r.Context())
+
+	// shift the url path past the previous subrouter
+	ctx.RoutePath = m.rg.nextRoutePath(ctx)
+
+	// reset the wildcard URLParam which connects the subrouter
+	n := len(ctx.URLParams.Keys) - 1
+	if n >= 0 && ctx.URLParams.Keys[n] == "*" && len(ctx.URLParams.Values) > n {
+		ctx.URLParams.Values[n] = ""
+	}
+
+	m.handler.ServeHTTP(w, r)
+}
+
 // bind a new route with a matcher for the URL pattern.
 // Automatic binding request to handler input params and validate params.
-func (rg *routerGroup) bind(method methodTyp, pattern string, handler interface{}) *node {
-	return rg.handle(method, pattern, Bind(handler, rg.renderer))
+func (rg *routerGroup) bind(method methodTyp, pattern string, handler interface{}) Endpoint {
+	if err := validBindMethod(method, handler); nil != err {
+		panic(fmt.Sprintf("routing pattern '%s': %v", pattern, err))
+	}
+	return rg.register(method, pattern, Bind(handler, routeRenderer{rg}, rg.interceptors...))
 }
 
-func (rg *routerGroup) handle(method methodTyp, pattern string, handler http.Handler) *node {
+// register a new route endpoint with a matcher for the URL pattern.
+func (rg *routerGroup) register(method methodTyp, pattern string, handler http.Handler) Endpoint {
+	// the trailing optional params register the patterns with and without them.
+	routes, err := expandOptional(pattern)
+	if nil != err {
+		panic(err.Error())
+	}
+
+	meta := Metadata{}
+	e := &routeEndpoint{meta: meta, tree: rg.tree, method: method, mu: rg.mu}
+	for _, route := range routes {
+		pattern, handler := route.pattern, handler
+		if len(route.defaults) > 0 {
+			handler = paramDefaults(route.defaults, handler)
+		}
+
+		// the trailing `{name...}` wildcard matches the remainder as `*`, exposed by the name as well.
+		if i := strings.LastIndexByte(pattern, '/'); i >= 0 && strings.HasPrefix(pattern[i+1:], "{") && strings.HasSuffix(pattern, "...}") {
+			handler = namedWildcard(pattern[i+2:len(pattern)-4], handler)
+			pattern = pattern[:i+1] + "*"
+		}
+
+		e.leaves = append(e.leaves, rg.handle(method, pattern, handler, meta))
+	}
+	return e
+}
+
+func (rg *routerGroup) handle(method methodTyp, pattern string, handler http.Handler, meta Metadata) *node {
 	if len(pattern) == 0 || pattern[0] != '/' {
 		panic(fmt.Sprintf("routing pattern must begin with '/' in '%s'", pattern))
 	}
 	if !rg.inline && rg.handler == nil {
-		rg.handler = rg.middlewares.HandlerFunc(rg.routeHTTP)
+		rg.updateRouteHandler()
 	}
 
 	if rg.inline {
@@ -355,85 +599,129 @@ func (rg *routerGroup) handle(method methodTyp, pattern string, handler http.Han
 		handler = rg.middlewares.Handler(handler)
 	}
 
-	// Add the endpoint to the tree
-	return rg.tree.InsertRoute(method, pattern, handler)
+	if nil != rg.mu {
+		rg.mu.Lock()
+		defer rg.mu.Unlock()
+	}
+
+	// Add the endpoint to the tree, rejecting the duplicated or shadowed routes.
+	n := rg.tree.insertNode(pattern)
+	n.checkConflict(method, pattern)
+	n.setEndpoint(method, handler, pattern)
+	if rg.inline {
+		n.setInline(method, rg)
+	}
+	rg.tree.indexStatic(pattern, n)
+	if nil != meta {
+		n.setMetadata(method, meta)
+	}
+	if rg.tree.prioritized {
+		rg.tree.updatePriority()
+	}
+	return n
+}
+
+// updateRouteHandler builds the single router handler that is a chain of the middleware
+// stack, as defined by calls to Use(), and the tree router itself.
+func (rg *routerGroup) updateRouteHandler() {
+	rg.handler = rg.middlewares.HandlerFunc(rg.routeHTTP)
 }
 
-func (rg *routerGroup) method(method, pattern string, handler http.Handler) {
+func (rg *routerGroup) method(method, pattern string, handler http.Handler) Endpoint {
 	if m, ok := methodMap[method]; ok {
-		rg.handle(m, pattern, handler)
-		return
+		return rg.register(m, pattern, handler)
 	}
 	panic(fmt.Errorf("%q http method is not supported", method))
 }
 
 // Handle registers a new route with a matcher for the URL pattern.
-func (rg *routerGroup) Handle(pattern string, handler http.Handler) {
+func (rg *routerGroup) Handle(pattern string, handler http.Handler) Endpoint {
 	if parts := strings.SplitN(pattern, " ", 2); 2 == len(parts) {
-		rg.method(parts[0], parts[1], handler)
-		return
+		return rg.method(parts[0], parts[1], handler)
 	}
-	rg.handle(mALL, pattern, handler)
+	return rg.register(mALL, pattern, handler)
 }
 
 // HandleFunc registers a new route with a matcher for the URL pattern.
-func (rg *routerGroup) HandleFunc(pattern string, handler http.HandlerFunc) {
+func (rg *routerGroup) HandleFunc(pattern string, handler http.HandlerFunc) Endpoint {
 	if parts := strings.SplitN(pattern, " ", 2); 2 == len(parts) {
-		rg.method(parts[0], parts[1], handler)
-		return
+		return rg.method(parts[0], parts[1], handler)
 	}
-	rg.handle(mALL, pattern, handler)
+	return rg.register(mALL, pattern, handler)
 }
 
 // Any registers a route that matches all the HTTP methods.
 // GET, POST, PUT, PATCH, HEAD, OPTIONS, DELETE, CONNECT, TRACE.
-func (rg *routerGroup) Any(pattern string, handler interface{}) {
-	rg.bind(mALL, pattern, handler)
+func (rg *routerGroup) Any(pattern string, handler interface{}) Endpoint {
+	return rg.bind(mALL, pattern, handler)
+}
+
+// Methods returns a function registering the route that matches the given HTTP methods only,
+// so that one handler serves a subset of the methods without registering it repeatedly.
+//
+//	router.Methods(http.MethodGet, http.MethodPost)("/hook", Hook)
+func (rg *routerGroup) Methods(methods ...string) func(pattern string, handler interface{}) Endpoint {
+	if 0 == len(methods) {
+		panic("at least one http method is required")
+	}
+
+	var mt methodTyp
+	for _, method := range methods {
+		m, ok := methodMap[method]
+		if !ok {
+			panic(fmt.Errorf("%q http method is not supported", method))
+		}
+		mt |= m
+	}
+
+	return func(pattern string, handler interface{}) Endpoint {
+		return rg.bind(mt, pattern, handler)
+	}
 }
 
 // Get registers a new GET route with a matcher for the URL pattern of the get method.
-func (rg *routerGroup) Get(pattern string, handler interface{}) {
-	rg.bind(mGET, pattern, handler)
+func (rg *routerGroup) Get(pattern string, handler interface{}) Endpoint {
+	return rg.bind(mGET, pattern, handler)
 }
 
 // Head registers a new HEAD route with a matcher for the URL pattern of the get method.
-func (rg *routerGroup) Head(pattern string, handler interface{}) {
-	rg.bind(mHEAD, pattern, handler)
+func (rg *routerGroup) Head(pattern string, handler interface{}) Endpoint {
+	return rg.bind(mHEAD, pattern, handler)
 }
 
 // Post registers a new POST route with a matcher for the URL pattern of the get method.
-func (rg *routerGroup) Post(pattern string, handler interface{}) {
-	rg.bind(mPOST, pattern, handler)
+func (rg *routerGroup) Post(pattern string, handler interface{}) Endpoint {
+	return rg.bind(mPOST, pattern, handler)
 }
 
 // Put registers a new PUT route with a matcher for the URL pattern of the get method.
-func (rg *routerGroup) Put(pattern string, handler interface{}) {
-	rg.bind(mPUT, pattern, handler)
+func (rg *routerGroup) Put(pattern string, handler interface{}) Endpoint {
+	return rg.bind(mPUT, pattern, handler)
 }
 
 // Patch registers a new PATCH route with a matcher for the URL pattern of the get method.
-func (rg *routerGroup) Patch(pattern string, handler interface{}) {
-	rg.bind(mPATCH, pattern, handler)
+func (rg *routerGroup) Patch(pattern string, handler interface{}) Endpoint {
+	return rg.bind(mPATCH, pattern, handler)
 }
 
 // Delete registers a new DELETE route with a matcher for the URL pattern of the get method.
-func (rg *routerGroup) Delete(pattern string, handler interface{}) {
-	rg.bind(mDELETE, pattern, handler)
+func (rg *routerGroup) Delete(pattern string, handler interface{}) Endpoint {
+	return rg.bind(mDELETE, pattern, handler)
 }
 
 // Connect registers a new CONNECT route with a matcher for the URL pattern of the get method.
-func (rg *routerGroup) Connect(pattern string, handler interface{}) {
-	rg.bind(mCONNECT, pattern, handler)
+func (rg *routerGroup) Connect(pattern string, handler interface{}) Endpoint {
+	return rg.bind(mCONNECT, pattern, handler)
 }
 
 // Options registers a new OPTIONS route with a matcher for the URL pattern of the get method.
-func (rg *routerGroup) Options(pattern string, handler interface{}) {
-	rg.bind(mOPTIONS, pattern, handler)
+func (rg *routerGroup) Options(pattern string, handler interface{}) Endpoint {
+	return rg.bind(mOPTIONS, pattern, handler)
 }
 
 // Trace registers a new TRACE route with a matcher for the URL pattern of the get method.
-func (rg *routerGroup) Trace(pattern string, handler interface{}) {
-	rg.bind(mTRACE, pattern, handler)
+func (rg *routerGroup) Trace(pattern string, handler interface{}) Endpoint {
+	return rg.bind(mTRACE, pattern, handler)
 }
 
 // NotFound to be used when no route matches.
@@ -476,12 +764,56 @@ func (rg *routerGroup) MethodNotAllowed(handler http.HandlerFunc) {
 	})
 }
 
+// NotFoundError renders the err by the renderer of the router when no route matches, so that the
+// unmatched requests get the same response as the errors of the typed handlers, e.g. the JSON envelope
+// of the JsonRender. A nil err renders the 404 Not Found HttpError.
+func (rg *routerGroup) NotFoundError(err error) {
+	if nil == err {
+		err = Error(http.StatusNotFound, "")
+	}
+	rg.NotFound(rg.renderError(err))
+}
+
+// MethodNotAllowedError renders the err by the renderer of the router when the request method does not
+// match the route, the allowed methods are set to the `Allow` header. A nil err renders the 405 Method
+// Not Allowed HttpError.
+func (rg *routerGroup) MethodNotAllowedError(err error) {
+	if nil == err {
+		err = Error(http.StatusMethodNotAllowed, "")
+	}
+	render := rg.renderError(err)
+	rg.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		setAllowHeader(w, r)
+		render(w, r)
+	})
+}
+
+// renderError returns a handler rendering the err by the renderer of the router serving the request.
+func (rg *routerGroup) renderError(err error) http.HandlerFunc {
+	renderer := routeRenderer{rg}
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderer.Render(&Context{Writer: w, Request: r}, err, nil)
+	}
+}
+
 // Routes returns a slice of routing information from the tree,
 // useful for traversing available Routes of a router.
 func (rg *routerGroup) Routes() []Route {
+	if nil != rg.mu {
+		rg.mu.RLock()
+		defer rg.mu.RUnlock()
+	}
 	return rg.tree.routes()
 }
 
+// Walk visits every route recursively descending into mounted subrouters,
+// the middlewares passed to fn are the full chain applied to the route handler.
+func (rg *routerGroup) Walk(fn func(method, pattern string, handler http.Handler, mws Middlewares) error) error {
+	return walk(rg, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		return fn(method, route, handler, middlewares)
+	}, "")
+}
+
 // Middlewares returns a slice of middleware handler functions.
 func (rg *routerGroup) Middlewares() Middlewares {
 	return rg.middlewares
@@ -496,7 +828,13 @@ func (rg *routerGroup) Match(ctx *RouteContext, method, path string) bool {
 		return false
 	}
 
+	if nil != rg.mu {
+		rg.mu.RLock()
+	}
 	node, _, h := rg.tree.FindRoute(ctx, m, path)
+	if nil != rg.mu {
+		rg.mu.RUnlock()
+	}
 
 	if node != nil && node.subroutes != nil {
 		ctx.RoutePath = rg.nextRoutePath(ctx)
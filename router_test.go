@@ -3,12 +3,15 @@ package web
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -105,8 +108,7 @@ func TestMuxBasic(t *testing.T) {
 
 	m.Head("/ping", headPing)
 	m.Post("/ping", createPing)
-	m.Get("/ping/{id}", pingWoop)
-	m.Get("/ping/{id}", pingOne) // expected to overwrite to pingOne handler
+	m.Get("/ping/{id}", pingOne)
 	m.Get("/ping/{iidd}/woop", pingWoop)
 	m.HandleFunc("/admin/*", catchAll)
 	// m.Post("/admin/*", catchAll)
@@ -323,6 +325,28 @@ func TestMethodNotAllowed(t *testing.T) {
 		if resp.StatusCode != 405 {
 			t.Fatal(resp.Status)
 		}
+		if allow := resp.Header.Get("Allow"); allow != "GET, HEAD" {
+			t.Fatalf("unexpected allow header: %q", allow)
+		}
+	})
+
+	t.Run("Custom Handler", func(t *testing.T) {
+		r := NewRouter()
+		r.Put("/item/{id}", func(w http.ResponseWriter, r *http.Request) {})
+		r.Delete("/item/{id}", func(w http.ResponseWriter, r *http.Request) {})
+		r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+			methods := FromRouteContext(r.Context()).AllowedMethods()
+			w.WriteHeader(405)
+			w.Write([]byte(strings.Join(methods, ",")))
+		})
+
+		resp, body := testHandler(t, r, "GET", "/item/1", nil)
+		if resp.StatusCode != 405 {
+			t.Fatal(resp.Status)
+		}
+		if body != "DELETE,PUT" {
+			t.Fatalf("unexpected allowed methods: %q", body)
+		}
 	})
 }
 
@@ -1313,3 +1337,432 @@ func BenchmarkMux(b *testing.B) {
 		})
 	}
 }
+
+func TestRouterIntercept(t *testing.T) {
+	var trace []string
+	interceptor := func(name string) HandlerInterceptor {
+		return func(ctx context.Context, req interface{}, next Invoke) (interface{}, error) {
+			trace = append(trace, name)
+			return next(ctx, req)
+		}
+	}
+
+	r := NewRouter()
+	r.Intercept(interceptor("root"))
+	r.Get("/a", func(ctx context.Context) string { return "a" })
+	r.Group("/sub", func(r Router) {
+		r.Intercept(interceptor("sub"))
+		r.Get("/b", func(ctx context.Context) string { return "b" })
+	})
+
+	testHandler(t, r, "GET", "/a", nil)
+	testHandler(t, r, "GET", "/sub/b", nil)
+	if fmt.Sprint(trace) != "[root root sub]" {
+		t.Fatalf("unexpected interceptors trace: %v", trace)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic()")
+		}
+	}()
+	r.Intercept(interceptor("late"))
+}
+
+func TestMuxWith(t *testing.T) {
+	var trace []string
+	mw := func(name string) MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				trace = append(trace, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	r := NewRouter()
+	r.Use(mw("root"))
+	r.Get("/public", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("public"))
+	})
+	r.With(mw("auth")).Get("/admin", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("admin"))
+	})
+	r.With(mw("auth")).With(mw("audit")).Post("/admin", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("admin post"))
+	})
+
+	if _, body := testHandler(t, r, "GET", "/public", nil); body != "public" {
+		t.Fatalf(body)
+	}
+	if fmt.Sprint(trace) != "[root]" {
+		t.Fatalf("unexpected middlewares trace: %v", trace)
+	}
+
+	trace = nil
+	if _, body := testHandler(t, r, "GET", "/admin", nil); body != "admin" {
+		t.Fatalf(body)
+	}
+	if fmt.Sprint(trace) != "[root auth]" {
+		t.Fatalf("unexpected middlewares trace: %v", trace)
+	}
+
+	trace = nil
+	if _, body := testHandler(t, r, "POST", "/admin", nil); body != "admin post" {
+		t.Fatalf(body)
+	}
+	if fmt.Sprint(trace) != "[root auth audit]" {
+		t.Fatalf("unexpected middlewares trace: %v", trace)
+	}
+}
+
+func TestRouteMetadata(t *testing.T) {
+	r := NewRouter()
+	r.Get("/users", func(ctx context.Context) string {
+		tag, _ := FromRouteContext(ctx).Metadata().Get("tag")
+		return tag.(string)
+	}).Meta("tag", "admin").Meta("summary", "list users")
+	r.Post("/users", func(ctx context.Context) {})
+	r.Handle("/any", http.NotFoundHandler()).Meta("tag", "any")
+
+	for _, route := range r.Routes() {
+		switch route.Pattern {
+		case "/users":
+			if route.Metadata["GET"]["tag"] != "admin" || route.Metadata["GET"]["summary"] != "list users" {
+				t.Fatalf("unexpected metadata: %v", route.Metadata)
+			}
+			if len(route.Metadata["POST"]) != 0 {
+				t.Fatalf("unexpected metadata: %v", route.Metadata)
+			}
+		case "/any":
+			if route.Metadata["*"]["tag"] != "any" || route.Metadata["PUT"]["tag"] != "any" {
+				t.Fatalf("unexpected metadata: %v", route.Metadata)
+			}
+		default:
+			t.Fatalf("unexpected route: %s", route.Pattern)
+		}
+	}
+
+	if _, body := testHandler(t, r, "GET", "/users", nil); body != "{\"code\":0,\"data\":\"admin\"}\n" {
+		t.Fatalf(body)
+	}
+}
+
+func TestMuxRoutePriority(t *testing.T) {
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}
+	}
+
+	r := NewRouter()
+	r.Get("/files/latest", handler("static"))
+	name := r.Get("/files/{name}", handler("param"))
+	wildcard := r.Get("/files/*", handler("wildcard"))
+	r.Get("/items/{id:[0-9]+}", handler("id"))
+	slug := r.Get("/items/{slug:[a-z0-9]+}", handler("slug"))
+
+	var cases = []struct {
+		path, expected string
+	}{
+		{"/files/latest", "static"},
+		{"/files/a.txt", "param"},
+		{"/files/a/b.txt", "wildcard"},
+		{"/items/123", "id"},
+		{"/items/abc", "slug"},
+	}
+	for _, c := range cases {
+		if _, body := testHandler(t, r, "GET", c.path, nil); body != c.expected {
+			t.Fatalf("%s: expected %s, got %s", c.path, c.expected, body)
+		}
+	}
+
+	name.Priority(1)
+	slug.Priority(1)
+	if _, body := testHandler(t, r, "GET", "/files/latest", nil); body != "param" {
+		t.Fatalf(body)
+	}
+	if _, body := testHandler(t, r, "GET", "/items/123", nil); body != "slug" {
+		t.Fatalf(body)
+	}
+
+	wildcard.Priority(2)
+	if _, body := testHandler(t, r, "GET", "/files/a.txt", nil); body != "wildcard" {
+		t.Fatalf(body)
+	}
+
+	// restore the default order
+	name.Priority(0)
+	slug.Priority(0)
+	wildcard.Priority(0)
+	for _, c := range cases {
+		if _, body := testHandler(t, r, "GET", c.path, nil); body != c.expected {
+			t.Fatalf("%s: expected %s, got %s", c.path, c.expected, body)
+		}
+	}
+
+	// routes registered later keep the explicit priorities.
+	wildcard.Priority(-1)
+	name.Priority(1)
+	r.Get("/files/latest/info", handler("info"))
+	if _, body := testHandler(t, r, "GET", "/files/latest", nil); body != "param" {
+		t.Fatalf(body)
+	}
+
+	for _, route := range r.Routes() {
+		switch route.Pattern {
+		case "/files/{name}":
+			if route.Priority != 1 {
+				t.Fatalf("%s: unexpected priority %d", route.Pattern, route.Priority)
+			}
+		case "/files/*":
+			if route.Priority != -1 {
+				t.Fatalf("%s: unexpected priority %d", route.Pattern, route.Priority)
+			}
+		}
+	}
+}
+
+func TestRouterWalk(t *testing.T) {
+	mw := func(next http.Handler) http.Handler { return next }
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := NewRouter().(*routerGroup)
+	r.Use(mw)
+	r.Get("/", handler)
+	r.Group("/api", func(r Router) {
+		r.Use(mw)
+		r.Get("/users", handler)
+		r.With(mw).Post("/users/{id}", handler)
+	})
+
+	admin := NewRouter()
+	admin.Get("/stats", handler)
+	r.Mount("/admin", admin)
+
+	var visited []string
+	err := r.Walk(func(method, pattern string, h http.Handler, mws Middlewares) error {
+		visited = append(visited, fmt.Sprintf("%s %s %d", method, pattern, len(mws)))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(visited)
+	expected := []string{
+		"GET / 1",
+		"GET /admin/stats 1",
+		"GET /api/users 2",
+		"POST /api/users/{id} 3",
+	}
+	if fmt.Sprint(visited) != fmt.Sprint(expected) {
+		t.Fatalf("unexpected routes: %v", visited)
+	}
+
+	stop := errors.New("stop")
+	if err = r.Walk(func(method, pattern string, h http.Handler, mws Middlewares) error { return stop }); err != stop {
+		t.Fatalf("expected walk error, got %v", err)
+	}
+}
+
+func TestRouterRoute(t *testing.T) {
+	var trace []string
+	mw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace = append(trace, r.Method)
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	r := NewRouter()
+	r.Route("/todos/{id}").
+		Use(mw).
+		Renderer(RendererFunc(func(ctx *Context, err error, result interface{}) {
+			ctx.String(http.StatusOK, "%v", result)
+		})).
+		Get(func(ctx context.Context) string { return "get" }).
+		Put(func(ctx context.Context) string { return "put" }).
+		Delete(func(ctx context.Context) string { return "delete" })
+	r.Get("/todos", func(ctx context.Context) string { return "list" })
+
+	if _, body := testHandler(t, r, "GET", "/todos/1", nil); body != "get" {
+		t.Fatalf(body)
+	}
+	if _, body := testHandler(t, r, "PUT", "/todos/1", nil); body != "put" {
+		t.Fatalf(body)
+	}
+	if _, body := testHandler(t, r, "DELETE", "/todos/1", nil); body != "delete" {
+		t.Fatalf(body)
+	}
+	if resp, _ := testHandler(t, r, "POST", "/todos/1", nil); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", resp.StatusCode)
+	}
+	if _, body := testHandler(t, r, "GET", "/todos", nil); strings.TrimSpace(body) != `{"code":0,"data":"list"}` {
+		t.Fatalf(body)
+	}
+	if fmt.Sprint(trace) != "[GET PUT DELETE]" {
+		t.Fatalf("unexpected middlewares trace: %v", trace)
+	}
+}
+
+func TestRouterMethods(t *testing.T) {
+	r := NewRouter()
+	r.Methods(http.MethodGet, http.MethodPost)("/hook", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method))
+	}).Meta("name", "hook")
+
+	if _, body := testHandler(t, r, "GET", "/hook", nil); body != "GET" {
+		t.Fatalf(body)
+	}
+	if _, body := testHandler(t, r, "POST", "/hook", nil); body != "POST" {
+		t.Fatalf(body)
+	}
+	if resp, _ := testHandler(t, r, "PUT", "/hook", nil); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", resp.StatusCode)
+	}
+
+	var routes []string
+	for _, route := range r.Routes() {
+		for method, h := range route.Handlers {
+			if nil != h {
+				routes = append(routes, method+" "+route.Pattern)
+			}
+		}
+	}
+	sort.Strings(routes)
+	if fmt.Sprint(routes) != "[GET /hook POST /hook]" {
+		t.Fatalf("unexpected routes: %v", routes)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		rctx := &RouteContext{}
+		if !r.Match(rctx, method, "/hook") || rctx.Metadata()["name"] != "hook" {
+			t.Fatalf("%s: missing route metadata", method)
+		}
+	}
+
+	if !r.Remove(http.MethodPost, "/hook") {
+		t.Fatalf("expected POST /hook to be removed")
+	}
+	if _, body := testHandler(t, r, "GET", "/hook", nil); body != "GET" {
+		t.Fatalf(body)
+	}
+
+	for _, methods := range [][]string{nil, {"GET", "FETCH"}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected panic on methods %v", methods)
+				}
+			}()
+			r.Methods(methods...)
+		}()
+	}
+}
+
+func TestRouterGroupWith(t *testing.T) {
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	tag := func(name string) MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Tag", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	r := NewRouter()
+	r.Group("/api/v1", func(r Router) {
+		r.Get("/status", func(ctx context.Context) string { return "ok" })
+		r.GroupWith(func(r Router) {
+			r.Get("/todos", func(ctx context.Context) string { return "todos" })
+			r.GroupWith(func(r Router) {
+				r.Delete("/todos/{id}", func(ctx context.Context) string { return "deleted" })
+			}, tag("admin"))
+		}, auth, tag("auth"))
+	})
+
+	if resp, _ := testHandler(t, r, "GET", "/api/v1/status", nil); resp.StatusCode != http.StatusOK || resp.Header.Get("X-Tag") != "" {
+		t.Fatalf("unexpected public response: %d %v", resp.StatusCode, resp.Header)
+	}
+	if resp, _ := testHandler(t, r, "GET", "/api/v1/todos", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest("DELETE", "/api/v1/todos/1", nil)
+	req.Header.Set("Authorization", "token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || fmt.Sprint(w.Header()["X-Tag"]) != "[auth admin]" {
+		t.Fatalf("unexpected authenticated response: %d %v", w.Code, w.Header())
+	}
+}
+
+func TestRouterConflict(t *testing.T) {
+	r := NewRouter()
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Delete("/users/{name}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/users/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {})
+
+	conflict := func(fn func()) (msg string) {
+		defer func() { msg = fmt.Sprint(recover()) }()
+		fn()
+		return
+	}
+
+	msg := conflict(func() { r.Get("/users/{name}", func(w http.ResponseWriter, r *http.Request) {}) })
+	if !strings.Contains(msg, "'GET /users/{name}' registered at ") ||
+		!strings.Contains(msg, "conflicts with 'GET /users/{id}' registered at ") ||
+		strings.Count(msg, "router_test.go:") != 2 {
+		t.Fatalf("unexpected conflict message: %s", msg)
+	}
+
+	msg = conflict(func() { r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {}) })
+	if !strings.Contains(msg, "'GET /users/{id}' registered at ") || !strings.Contains(msg, "is already registered at ") {
+		t.Fatalf("unexpected duplicate message: %s", msg)
+	}
+
+	msg = conflict(func() { r.Any("/users/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {}) })
+	if !strings.Contains(msg, "'GET /users/{id:[0-9]+}' registered at ") || !strings.Contains(msg, "is already registered at ") {
+		t.Fatalf("unexpected conflict message: %s", msg)
+	}
+
+	// explicit routes are allowed to override the routes of the mounted router.
+	sr := NewRouter()
+	sr.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	r.(*routerGroup).Mount("/accounts", sr)
+	if msg = conflict(func() { r.Get("/accounts/", func(w http.ResponseWriter, r *http.Request) {}) }); msg != "<nil>" {
+		t.Fatalf("unexpected conflict on mounted route: %s", msg)
+	}
+}
+
+func BenchmarkMuxStatic(b *testing.B) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	mx := NewRouter()
+	mx.Get("/api/v1/users", h)
+	mx.Get("/api/v1/users/{id}", h)
+	mx.Get("/api/v1/users/{id}/profile", h)
+	mx.Get("/api/v1/users/me/profile", h)
+	mx.Get("/api/v1/orders/{id:[0-9]+}", h)
+	mx.Get("/api/v1/*", h)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/api/v1/users/me/profile", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		mx.ServeHTTP(w, r)
+	}
+}
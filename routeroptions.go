@@ -0,0 +1,244 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"go-spring.dev/web/binding"
+)
+
+// RouterOptions configures the behaviors of the router created by NewRouterWith, the zero
+// values keep the defaults of NewRouter, so only the options to change need to be set.
+//
+//	router := web.NewRouterWith(web.RouterOptions{
+//		MaxMultipartMemory:    8 << 20,
+//		RedirectTrailingSlash: true,
+//		TrustedProxies:        []string{"10.0.0.0/8"},
+//	})
+type RouterOptions struct {
+	// Renderer renders the responses of the typed handlers, JsonRender if nil.
+	Renderer Renderer
+
+	// Clock provides the current time to the time dependent features, SystemClock if nil.
+	Clock Clock
+
+	// Rand provides the random numbers to the router features, SystemRand if nil.
+	Rand Rand
+
+	// Logger is the logger of the router returned by LoggerOf, slog.Default() if nil.
+	Logger *slog.Logger
+
+	// MaxMultipartMemory is the maximum bytes of the multipart file parts stored in
+	// memory when the requests are bound, the remainder is stored on disk in temporary
+	// files. If zero, 32 MB is used.
+	MaxMultipartMemory int64
+
+	// MaxBodyBytes is the maximum bytes of the request bodies read by the handlers, e.g. bound by
+	// the typed handlers or Context.Bind, the routes may override it by the MaxBodyBytes option.
+	// If zero, there is no limit.
+	MaxBodyBytes int64
+
+	// RedirectTrailingSlash redirects the unmatched requests to the path with or without
+	// the trailing slash if a route matches it, with 301 for GET and HEAD requests and 308
+	// for the others, instead of responding 404.
+	RedirectTrailingSlash bool
+
+	// CaseInsensitive matches the unmatched requests against the lower-cased path again,
+	// so `/Users/{id}` is routed to the `/users/{id}` route, the path params are lower-cased
+	// as well, the patterns with upper-case letters still match the exact case only.
+	CaseInsensitive bool
+
+	// TrustedProxies are the IPs or CIDRs of the proxies whose forwarding headers are used
+	// by Context.ClientIP, the headers are trusted from any remote address if nil.
+	TrustedProxies []string
+
+	// ScopePolicy decides how the request fields tagged with multiple scopes are bound, e.g.
+	// the precedence of the header over the query, the routes may override it by WithScopePolicy.
+	// If nil, the package-level policy of the binding package applies.
+	ScopePolicy *binding.ScopePolicy
+
+	// IndentJSON indents the JSON responses of JsonRender and Context.JSON by the indent, e.g. set
+	// it to "  " in the development environments only, the responses are compact if empty.
+	IndentJSON string
+
+	// SecureJSONPrefix prefixes the JSON array responses of Context.JSON by it, e.g. `while(1);`,
+	// against the JSON hijacking, see Context.SecureJSON. The responses aren't prefixed if empty.
+	SecureJSONPrefix string
+
+	// DialPolicy guards the upstream connections of the reverse proxies of the routes loaded by
+	// LoadRoutes against SSRF, e.g. if the route definitions are managed by the tenants.
+	// If nil, the proxies dial any address.
+	DialPolicy *DialPolicy
+}
+
+// routerOptions holds the behaviors set by NewRouterWith, shared by the groups of the router.
+type routerOptions struct {
+	logger                *slog.Logger
+	maxMultipartMemory    int64
+	maxBodyBytes          int64
+	redirectTrailingSlash bool
+	caseInsensitive       bool
+	trustedProxies        []netip.Prefix
+	scopePolicy           *binding.ScopePolicy
+	indentJSON            string
+	secureJSONPrefix      string
+	dialPolicy            *DialPolicy
+}
+
+// NewRouterWith returns a new router instance configured by the options,
+// it panics if a trusted proxy is neither an IP nor a CIDR.
+func NewRouterWith(options RouterOptions) Router {
+	rg := NewRouter().(*routerGroup)
+	rg.renderer = options.Renderer
+	rg.clock = options.Clock
+	rg.rand = options.Rand
+	rg.opts = &routerOptions{
+		logger:                options.Logger,
+		maxMultipartMemory:    options.MaxMultipartMemory,
+		maxBodyBytes:          options.MaxBodyBytes,
+		redirectTrailingSlash: options.RedirectTrailingSlash,
+		caseInsensitive:       options.CaseInsensitive,
+		scopePolicy:           options.ScopePolicy,
+		indentJSON:            options.IndentJSON,
+		secureJSONPrefix:      options.SecureJSONPrefix,
+		dialPolicy:            options.DialPolicy,
+	}
+	if nil != options.TrustedProxies {
+		rg.opts.trustedProxies = make([]netip.Prefix, 0, len(options.TrustedProxies))
+	}
+	for _, proxy := range options.TrustedProxies {
+		prefix, err := parseTrustedProxy(proxy)
+		if nil != err {
+			panic(fmt.Errorf("invalid trusted proxy %q: %w", proxy, err))
+		}
+		rg.opts.trustedProxies = append(rg.opts.trustedProxies, prefix)
+	}
+	return rg
+}
+
+func parseTrustedProxy(proxy string) (netip.Prefix, error) {
+	if strings.Contains(proxy, "/") {
+		return netip.ParsePrefix(proxy)
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if nil != err {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// routerOptionsOf returns the options of the router serving the request, or nil.
+func routerOptionsOf(ctx context.Context) *routerOptions {
+	if rg := servingRouter(ctx); nil != rg {
+		return rg.opts
+	}
+	return nil
+}
+
+// LoggerOf returns the logger of the router serving the request, or slog.Default().
+func LoggerOf(ctx context.Context) *slog.Logger {
+	if opts := routerOptionsOf(ctx); nil != opts && nil != opts.logger {
+		return opts.logger
+	}
+	return slog.Default()
+}
+
+// routerLogger returns the logger of the router, or slog.Default().
+func routerLogger(r Routes) *slog.Logger {
+	if rg, ok := r.(*routerGroup); ok && nil != rg.opts && nil != rg.opts.logger {
+		return rg.opts.logger
+	}
+	return slog.Default()
+}
+
+// jsonIndentOf returns the indent of the JSON responses of the router serving the request.
+func jsonIndentOf(ctx context.Context) string {
+	if opts := routerOptionsOf(ctx); nil != opts {
+		return opts.indentJSON
+	}
+	return ""
+}
+
+// trusts reports whether the forwarding headers sent from the remote IP are trusted.
+func (opts *routerOptions) trusts(remoteIP string) bool {
+	if nil == opts || nil == opts.trustedProxies {
+		return true
+	}
+	addr, err := netip.ParseAddr(remoteIP)
+	if nil != err {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range opts.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackRoute serves the unmatched request by the options of the router, i.e. redirects it to the
+// path with or without the trailing slash, or routes it by the lower-cased path, reports whether it's served.
+func (rg *routerGroup) fallbackRoute(ctx *RouteContext, method methodTyp, routePath string, w http.ResponseWriter, r *http.Request) bool {
+	if nil == rg.opts {
+		return false
+	}
+
+	if rg.opts.caseInsensitive {
+		if lower := strings.ToLower(routePath); lower != routePath {
+			if nil != rg.mu {
+				rg.mu.RLock()
+			}
+			_, _, h := rg.tree.FindRoute(ctx, method, lower)
+			if nil != rg.mu {
+				rg.mu.RUnlock()
+			}
+			if nil != h {
+				rg.serveRoute(ctx, h, w, r)
+				return true
+			}
+		}
+	}
+
+	if rg.opts.redirectTrailingSlash && "/" != routePath {
+		escaped := r.URL.EscapedPath()
+		alt, target := routePath+"/", escaped+"/"
+		if strings.HasSuffix(routePath, "/") {
+			alt, target = strings.TrimSuffix(routePath, "/"), strings.TrimSuffix(escaped, "/")
+		}
+		// collapse the leading slashes, so that the target isn't taken as another host, e.g. `//evil.com`.
+		target = "/" + strings.TrimLeft(target, `/\`)
+		if rg.Match(&RouteContext{}, ctx.RouteMethod, alt) {
+			code := http.StatusPermanentRedirect
+			if http.MethodGet == r.Method || http.MethodHead == r.Method {
+				code = http.StatusMovedPermanently
+			}
+			if len(r.URL.RawQuery) > 0 {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, code)
+			return true
+		}
+	}
+	return false
+}
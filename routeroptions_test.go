@@ -0,0 +1,167 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRouterWith(t *testing.T) {
+	var logs bytes.Buffer
+	r := NewRouterWith(RouterOptions{
+		Renderer:              RendererFunc(func(ctx *Context, err error, result interface{}) { _ = ctx.String(http.StatusOK, "%v", result) }),
+		Logger:                slog.New(slog.NewTextHandler(&logs, nil)),
+		RedirectTrailingSlash: true,
+		CaseInsensitive:       true,
+		TrustedProxies:        []string{"10.0.0.0/8", "::1"},
+	})
+	r.Get("/users/{id}", func(ctx context.Context) string {
+		LoggerOf(ctx).Info("get user")
+		id, _ := FromContext(ctx).PathParam("id")
+		return "user " + id
+	})
+	r.Group("/api", func(r Router) {
+		r.Post("/todos/", func(ctx context.Context) string { return "todos" })
+		r.Get("/ip", func(ctx context.Context) string { return FromContext(ctx).ClientIP() })
+	})
+
+	_, body := testHandler(t, r, "GET", "/users/1", nil)
+	assert.Equal(t, "user 1", body)
+	assert.Contains(t, logs.String(), "get user")
+
+	_, body = testHandler(t, r, "GET", "/USERS/Ab", nil)
+	assert.Equal(t, "user ab", body)
+
+	resp, _ := testHandler(t, r, "GET", "/users/1/?q=1", nil)
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "/users/1?q=1", resp.Header.Get("Location"))
+
+	resp, _ = testHandler(t, r, "POST", "/api/todos", nil)
+	assert.Equal(t, http.StatusPermanentRedirect, resp.StatusCode)
+	assert.Equal(t, "/api/todos/", resp.Header.Get("Location"))
+
+	resp, _ = testHandler(t, r, "GET", "/api/missing/", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	for remote, expect := range map[string]string{
+		"10.1.2.3:1234":  "203.0.113.7",
+		"[::1]:1234":     "203.0.113.7",
+		"192.0.2.1:1234": "192.0.2.1",
+	} {
+		req := httptest.NewRequest("GET", "/api/ip", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, expect, w.Body.String(), remote)
+	}
+
+	// the routers of NewRouter keep the strict matching.
+	r = NewRouter()
+	r.Get("/users", func(ctx context.Context) string { return "users" })
+	resp, _ = testHandler(t, r, "GET", "/users/", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = testHandler(t, r, "GET", "/Users", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	assert.Panics(t, func() { NewRouterWith(RouterOptions{TrustedProxies: []string{"proxy"}}) })
+}
+
+func TestRouterOptionsIndentJSON(t *testing.T) {
+	r := NewRouterWith(RouterOptions{IndentJSON: "  "})
+	r.Get("/user", func(ctx context.Context) map[string]string { return map[string]string{"name": "alice"} })
+	r.Get("/raw", func(ctx context.Context) {
+		_ = FromContext(ctx).JSON(http.StatusOK, map[string]int{"id": 1})
+	})
+
+	_, body := testHandler(t, r, "GET", "/user", nil)
+	assert.Equal(t, "{\n  \"code\": 0,\n  \"data\": {\n    \"name\": \"alice\"\n  }\n}\n", body)
+	_, body = testHandler(t, r, "GET", "/raw", nil)
+	assert.Equal(t, "{\n  \"id\": 1\n}\n", body)
+
+	// the routers without the indent stay compact unless the renderer indents.
+	r = NewRouter()
+	r.Get("/user", func(ctx context.Context) map[string]string { return map[string]string{"name": "alice"} })
+	_, body = testHandler(t, r, "GET", "/user", nil)
+	assert.Equal(t, "{\"code\":0,\"data\":{\"name\":\"alice\"}}\n", body)
+
+	r.Renderer(IndentedJsonRender("\t"))
+	_, body = testHandler(t, r, "GET", "/user", nil)
+	assert.Equal(t, "{\n\t\"code\": 0,\n\t\"data\": {\n\t\t\"name\": \"alice\"\n\t}\n}\n", body)
+}
+
+func TestRouterOptionsSecureJSONPrefix(t *testing.T) {
+	r := NewRouterWith(RouterOptions{SecureJSONPrefix: ")]}',\n"})
+	r.Get("/list", func(ctx context.Context) { _ = FromContext(ctx).JSON(http.StatusOK, []int{1, 2}) })
+	r.Get("/object", func(ctx context.Context) { _ = FromContext(ctx).JSON(http.StatusOK, map[string]int{"id": 1}) })
+
+	_, body := testHandler(t, r, "GET", "/list", nil)
+	assert.Equal(t, ")]}',\n[1,2]\n", body)
+	_, body = testHandler(t, r, "GET", "/object", nil)
+	assert.Equal(t, "{\"id\":1}\n", body)
+
+	// the default prefix of SecureJSON, Context.JSON isn't prefixed without the option.
+	r = NewRouter()
+	r.Get("/secure", func(ctx context.Context) { _ = FromContext(ctx).SecureJSON(http.StatusOK, []int{1, 2}) })
+	r.Get("/list", func(ctx context.Context) { _ = FromContext(ctx).JSON(http.StatusOK, []int{1, 2}) })
+	resp, body := testHandler(t, r, "GET", "/secure", nil)
+	assert.Equal(t, "while(1);[1,2]\n", body)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+	_, body = testHandler(t, r, "GET", "/list", nil)
+	assert.Equal(t, "[1,2]\n", body)
+}
+
+func TestRedirectTrailingSlashOpenRedirect(t *testing.T) {
+	r := NewRouterWith(RouterOptions{RedirectTrailingSlash: true})
+	r.Get("/{a}", func(ctx context.Context) string { return "a" })
+	r.Get("/{a}/{b}", func(ctx context.Context) string { return "b" })
+
+	for path, location := range map[string]string{
+		"/%2Fevil.com/": "/%2Fevil.com",
+		"//evil.com/":   "/evil.com",
+		"/%5Cevil.com/": "/%5Cevil.com",
+	} {
+		req := httptest.NewRequest("GET", "http://example.com"+path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusMovedPermanently, w.Code, path)
+		assert.Equal(t, location, w.Header().Get("Location"), path)
+	}
+}
+
+func TestClientIPSkipsTrustedProxies(t *testing.T) {
+	r := NewRouterWith(RouterOptions{TrustedProxies: []string{"10.0.0.0/8"}})
+	r.Get("/ip", func(ctx context.Context) {
+		_ = FromContext(ctx).String(http.StatusOK, "%s", FromContext(ctx).ClientIP())
+	})
+
+	for _, tt := range []struct {
+		forwarded []string
+		realIP    string
+		expect    string
+	}{
+		// the leftmost address is spoofed by the client.
+		{forwarded: []string{"1.2.3.4, 203.0.113.7, 10.0.0.5"}, expect: "203.0.113.7"},
+		{forwarded: []string{"1.2.3.4", "203.0.113.7, 10.0.0.5"}, expect: "203.0.113.7"},
+		{forwarded: []string{"10.0.0.9, 10.0.0.5"}, expect: "10.0.0.9"},
+		{forwarded: []string{"1.2.3.4, bogus, 10.0.0.5"}, expect: "10.1.2.3"},
+		{realIP: "203.0.113.8", expect: "203.0.113.8"},
+	} {
+		req := httptest.NewRequest("GET", "/ip", nil)
+		req.RemoteAddr = "10.1.2.3:1234"
+		for _, value := range tt.forwarded {
+			req.Header.Add("X-Forwarded-For", value)
+		}
+		if len(tt.realIP) > 0 {
+			req.Header.Set("X-Real-Ip", tt.realIP)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tt.expect, w.Body.String(), tt.forwarded)
+	}
+}
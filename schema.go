@@ -0,0 +1,242 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONSchema is a JSON schema of the request and response payloads, it supports the subset of
+// the JSON Schema keywords describing the shape of the payloads: `type`, `enum`, `properties`,
+// `required`, `additionalProperties`, `items`, `minItems`, `maxItems`, `minLength`, `maxLength`,
+// `pattern`, `minimum` and `maximum`, the other keywords are ignored.
+type JSONSchema struct {
+	Type                 []string               `json:"type,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+
+	never   bool // the `false` schema that no value is valid against
+	pattern *regexp.Regexp
+}
+
+// ParseJSONSchema parses the JSON schema.
+func ParseJSONSchema(schema string) (*JSONSchema, error) {
+	s := &JSONSchema{}
+	if err := json.Unmarshal([]byte(schema), s); nil != err {
+		return nil, fmt.Errorf("invalid json schema: %w", err)
+	}
+	return s, nil
+}
+
+func (s *JSONSchema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = JSONSchema{}
+		return nil
+	case "false":
+		*s = JSONSchema{never: true}
+		return nil
+	}
+
+	type schema JSONSchema
+	var raw struct {
+		*schema
+		Type json.RawMessage `json:"type,omitempty"`
+	}
+	raw.schema = (*schema)(s)
+	if err := json.Unmarshal(data, &raw); nil != err {
+		return err
+	}
+
+	// the type is either a type name or an array of the type names.
+	if len(raw.Type) > 0 {
+		var typ string
+		if err := json.Unmarshal(raw.Type, &typ); nil == err {
+			s.Type = []string{typ}
+		} else if err = json.Unmarshal(raw.Type, &s.Type); nil != err {
+			return fmt.Errorf("invalid type %s", raw.Type)
+		}
+	}
+	if len(s.Pattern) > 0 {
+		pattern, err := regexp.Compile(s.Pattern)
+		if nil != err {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+	// the enum values are compared with the values decoded with json.Number.
+	for i, v := range s.Enum {
+		if f, ok := v.(float64); ok {
+			s.Enum[i] = json.Number(fmt.Sprint(f))
+		}
+	}
+	return nil
+}
+
+// Validate validates the JSON document against the schema, the violations are joined in the error,
+// each one is prefixed by the JSON pointer of the invalid value, e.g. `#/items/0/sku: expected string`.
+func (s *JSONSchema) Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); nil != err {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+
+	var errs []error
+	s.validate(v, "#", &errs)
+	return errors.Join(errs...)
+}
+
+func (s *JSONSchema) validate(v interface{}, path string, errs *[]error) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	if s.never {
+		fail("no value is allowed")
+		return
+	}
+	if len(s.Type) > 0 && !s.matchType(v) {
+		fail("expected %s, got %s", strings.Join(s.Type, " or "), jsonType(v))
+		return
+	}
+	if len(s.Enum) > 0 && !s.matchEnum(v) {
+		fail("value is not one of the enum values")
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := s.Properties[name]; ok {
+				property.validate(value[name], path+"/"+escapePointer(name), errs)
+			} else if nil != s.AdditionalProperties {
+				s.AdditionalProperties.validate(value[name], path+"/"+escapePointer(name), errs)
+			}
+		}
+	case []interface{}:
+		if nil != s.MinItems && len(value) < *s.MinItems {
+			fail("expected at least %d items, got %d", *s.MinItems, len(value))
+		}
+		if nil != s.MaxItems && len(value) > *s.MaxItems {
+			fail("expected at most %d items, got %d", *s.MaxItems, len(value))
+		}
+		if nil != s.Items {
+			for i, item := range value {
+				s.Items.validate(item, fmt.Sprintf("%s/%d", path, i), errs)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(value)
+		if nil != s.MinLength && length < *s.MinLength {
+			fail("expected at least %d characters, got %d", *s.MinLength, length)
+		}
+		if nil != s.MaxLength && length > *s.MaxLength {
+			fail("expected at most %d characters, got %d", *s.MaxLength, length)
+		}
+		if nil != s.pattern && !s.pattern.MatchString(value) {
+			fail("value doesn't match the pattern %q", s.Pattern)
+		}
+	case json.Number:
+		f, _ := value.Float64()
+		if nil != s.Minimum && f < *s.Minimum {
+			fail("expected a minimum of %v, got %s", *s.Minimum, value)
+		}
+		if nil != s.Maximum && f > *s.Maximum {
+			fail("expected a maximum of %v, got %s", *s.Maximum, value)
+		}
+	}
+}
+
+func (s *JSONSchema) matchType(v interface{}) bool {
+	actual := jsonType(v)
+	for _, typ := range s.Type {
+		if typ == actual || ("number" == typ && "integer" == actual) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *JSONSchema) matchEnum(v interface{}) bool {
+	if n, ok := v.(json.Number); ok {
+		f, _ := n.Float64()
+		v = json.Number(fmt.Sprint(f))
+	}
+	for _, e := range s.Enum {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON schema type of the value decoded with json.Number.
+func jsonType(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if f, err := value.Float64(); nil == err && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// escapePointer escapes the property name as a JSON pointer token, see RFC 6901.
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,49 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema, err := ParseJSONSchema(`{
+		"type": "object",
+		"required": ["id", "name"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"name": {"type": "string", "minLength": 1, "maxLength": 5, "pattern": "^[a-z]+$"},
+			"score": {"type": ["number", "null"], "maximum": 10},
+			"role": {"enum": ["admin", "user", 1]},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}},
+			"a/b": true
+		}
+	}`)
+	assert.Nil(t, err)
+
+	assert.Nil(t, schema.Validate([]byte(`{"id":1,"name":"foo","score":9.5,"role":"admin","tags":["x"],"a/b":{}}`)))
+	assert.Nil(t, schema.Validate([]byte(`{"id":2.0,"name":"bar","score":null,"role":1.0}`)))
+
+	err = schema.Validate([]byte(`{"id":1.5,"name":"Foobar","score":11,"role":"guest","tags":["x",2,"z"],"extra":1}`))
+	assert.EqualError(t, err, `#/extra: no value is allowed
+#/id: expected integer, got number
+#/name: expected at most 5 characters, got 6
+#/name: value doesn't match the pattern "^[a-z]+$"
+#/role: value is not one of the enum values
+#/score: expected a maximum of 10, got 11
+#/tags: expected at most 2 items, got 3
+#/tags/1: expected string, got integer`)
+
+	err = schema.Validate([]byte(`{"id":0}`))
+	assert.EqualError(t, err, `#: missing required property "name"
+#/id: expected a minimum of 1, got 0`)
+
+	assert.EqualError(t, schema.Validate([]byte(`[]`)), "#: expected object, got array")
+	assert.ErrorContains(t, schema.Validate([]byte(`{`)), "invalid json")
+
+	_, err = ParseJSONSchema(`{"type": 1}`)
+	assert.ErrorContains(t, err, "invalid type 1")
+	_, err = ParseJSONSchema(`{"pattern": "("}`)
+	assert.ErrorContains(t, err, "invalid pattern")
+}
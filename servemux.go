@@ -0,0 +1,245 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ServeMux is a compatibility router accepting the patterns of the http.ServeMux
+// introduced in Go 1.22, so that the code written for the standard library routing
+// can migrate to this router without rewriting the patterns.
+//
+//	mux := web.NewServeMux()
+//	mux.HandleFunc("GET /users/{id}", GetUser)      // GET and HEAD requests
+//	mux.HandleFunc("/files/{path...}", ServeFiles)  // the remaining path
+//	mux.HandleFunc("/static/", ServeStatic)         // any path under /static/
+//	mux.HandleFunc("/{$}", Home)                    // the root path only
+//
+// Host patterns are not supported, and the requests for a subtree root without the
+// trailing slash are not redirected. The patterns with a method take precedence over
+// the same pattern without, regardless of the order they're registered.
+type ServeMux struct {
+	Router
+	mu       sync.RWMutex
+	handlers map[string]http.Handler // keyed by "METHOD path" of the routes registered.
+	explicit map[string]bool         // the "METHOD path" of the patterns with a method.
+	generic  map[string]bool         // the path of the patterns without method.
+}
+
+// NewServeMux returns a new compatibility router.
+func NewServeMux() *ServeMux {
+	return &ServeMux{Router: NewRouter(), handlers: map[string]http.Handler{}, explicit: map[string]bool{}, generic: map[string]bool{}}
+}
+
+// Handle registers the handler for the given http.ServeMux pattern.
+func (mux *ServeMux) Handle(pattern string, handler http.Handler) {
+	if nil == handler {
+		panic(fmt.Sprintf("nil handler for pattern '%s'", pattern))
+	}
+
+	method, path, wildcard, err := parseServeMuxPattern(pattern)
+	if nil != err {
+		panic(err)
+	}
+
+	if len(wildcard) > 0 {
+		handler = namedWildcard(wildcard, handler)
+	}
+
+	if len(method) > 0 {
+		if mux.explicit[method+" "+path] {
+			panic(fmt.Sprintf("pattern '%s' conflicts with a registered pattern", pattern))
+		}
+		mux.explicit[method+" "+path] = true
+		mux.register(method, path, handler)
+
+		// the GET patterns match the HEAD requests as well.
+		if http.MethodGet == method && !mux.explicit[http.MethodHead+" "+path] {
+			mux.register(http.MethodHead, path, handler)
+		}
+		return
+	}
+
+	if mux.generic[path] {
+		panic(fmt.Sprintf("pattern '%s' conflicts with a registered pattern", pattern))
+	}
+	mux.generic[path] = true
+
+	// the patterns without method match the methods not registered explicitly.
+	for m := range methodMap {
+		if mux.explicit[m+" "+path] || (http.MethodHead == m && mux.explicit[http.MethodGet+" "+path]) {
+			continue
+		}
+		mux.register(m, path, handler)
+	}
+}
+
+// register routes the method and path to the handler, the route is registered once, the handler of
+// a pattern without method is replaced by the one of the same pattern with the method afterward.
+func (mux *ServeMux) register(method, path string, handler http.Handler) {
+	key := method + " " + path
+	mux.mu.Lock()
+	_, routed := mux.handlers[key]
+	mux.handlers[key] = handler
+	mux.mu.Unlock()
+	if routed {
+		return
+	}
+
+	mux.Router.Handle(key, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.mu.RLock()
+		h := mux.handlers[key]
+		mux.mu.RUnlock()
+		h.ServeHTTP(w, r)
+	}))
+}
+
+// HandleFunc registers the handler function for the given http.ServeMux pattern.
+func (mux *ServeMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	if nil == handler {
+		panic(fmt.Sprintf("nil handler for pattern '%s'", pattern))
+	}
+	mux.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// parseServeMuxPattern translates the http.ServeMux pattern `[METHOD ]/path` into the routing pattern,
+// returns the name of the trailing `{name...}` wildcard that's translated into the catch-all `*`.
+func parseServeMuxPattern(pattern string) (method, path, wildcard string, err error) {
+	path = pattern
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		method, path = pattern[:i], strings.TrimLeft(pattern[i+1:], " \t")
+		if _, ok := methodMap[method]; !ok {
+			return "", "", "", fmt.Errorf("pattern '%s': %q http method is not supported", pattern, method)
+		}
+	}
+
+	if 0 == len(path) || '/' != path[0] {
+		return "", "", "", fmt.Errorf("pattern '%s': host patterns are not supported", pattern)
+	}
+
+	segments := strings.Split(path[1:], "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch {
+		case "{$}" == segment:
+			if !last {
+				return "", "", "", fmt.Errorf("pattern '%s': {$} not at the end", pattern)
+			}
+			segments[i] = ""
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "...}"):
+			if !last {
+				return "", "", "", fmt.Errorf("pattern '%s': wildcard %s not at the end", pattern, segment)
+			}
+			wildcard = segment[1 : len(segment)-4]
+			segments[i] = "*"
+		case "" == segment && last:
+			// the trailing slash matches all the paths of the subtree.
+			segments[i] = "*"
+		}
+	}
+
+	return method, "/" + strings.Join(segments, "/"), wildcard, nil
+}
+
+// namedWildcard exposes the catch-all value by the name of the `{name...}` wildcard.
+func namedWildcard(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ctx := FromRouteContext(r.Context()); nil != ctx {
+			if value, ok := ctx.URLParams.Get("*"); ok {
+				ctx.URLParams.Add(name, value)
+				setPathValue(ctx, r)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// mountedServeMux serves the requests of the http.ServeMux mounted on the router, the ServeMux
+// matches the path relative to the mount, and the values of its Go 1.22 patterns are added to
+// the route params, while the path params of the router are kept as the request path values.
+//
+// The redirects of the ServeMux, e.g. to the subtree root with the trailing slash, are relative
+// to the mount as well.
+type mountedServeMux struct {
+	mux *http.ServeMux
+}
+
+func (m *mountedServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := FromRouteContext(r.Context())
+	if nil == ctx {
+		m.mux.ServeHTTP(w, r)
+		return
+	}
+
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	if len(r.URL.RawPath) > 0 {
+		r2.URL.RawPath = ctx.RoutePath
+		r2.URL.Path, _ = url.PathUnescape(ctx.RoutePath)
+	} else {
+		r2.URL.Path = ctx.RoutePath
+	}
+
+	if _, pattern := m.mux.Handler(r2); len(pattern) > 0 {
+		for _, param := range serveMuxParams(pattern, r2.URL.EscapedPath()) {
+			ctx.URLParams.Add(param[0], param[1])
+		}
+	}
+
+	m.mux.ServeHTTP(w, r2)
+}
+
+// serveMuxParams returns the name and value pairs of the wildcards of the http.ServeMux pattern matching the path.
+func serveMuxParams(pattern, path string) (params [][2]string) {
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		pattern = strings.TrimLeft(pattern[i+1:], " \t")
+	}
+	i := strings.IndexByte(pattern, '/')
+	if i < 0 {
+		return nil
+	}
+	pattern = pattern[i:] // strip the host
+
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, segment := range strings.Split(pattern[1:], "/") {
+		if "{$}" == segment || !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+
+		name := segment[1 : len(segment)-1]
+		if strings.HasSuffix(name, "...") {
+			var value string
+			if i < len(segments) {
+				value, _ = url.PathUnescape(strings.Join(segments[i:], "/"))
+			}
+			return append(params, [2]string{strings.TrimSuffix(name, "..."), value})
+		}
+		if i >= len(segments) {
+			break
+		}
+		value, _ := url.PathUnescape(segments[i])
+		params = append(params, [2]string{name, value})
+	}
+	return params
+}
@@ -0,0 +1,115 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeMux(t *testing.T) {
+	mux := NewServeMux()
+
+	reply := func(name string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := FromRouteContext(r.Context())
+			w.Write([]byte(fmt.Sprintf("%s %v", name, ctx.URLParams.Values)))
+		}
+	}
+
+	mux.HandleFunc("GET /users/{id}", reply("get"))
+	mux.HandleFunc("DELETE /users/{id}", reply("delete"))
+	mux.HandleFunc("/files/{path...}", reply("files"))
+	mux.HandleFunc("/static/", reply("static"))
+	mux.HandleFunc("GET /static/app.js", reply("app"))
+	mux.HandleFunc("/{$}", reply("home"))
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	assert.Equal(t, "get [1]", serve(http.MethodGet, "/users/1").Body.String())
+	assert.Equal(t, http.StatusOK, serve(http.MethodHead, "/users/1").Code)
+	assert.Equal(t, "delete [2]", serve(http.MethodDelete, "/users/2").Body.String())
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/users/1").Code)
+
+	assert.Equal(t, "files [a/b.txt a/b.txt]", serve(http.MethodGet, "/files/a/b.txt").Body.String())
+	assert.Equal(t, "static [css/main.css]", serve(http.MethodPost, "/static/css/main.css").Body.String())
+	assert.Equal(t, "static []", serve(http.MethodGet, "/static/").Body.String())
+	assert.Equal(t, "app []", serve(http.MethodGet, "/static/app.js").Body.String())
+	assert.Equal(t, "static [app.js]", serve(http.MethodPost, "/static/app.js").Body.String())
+
+	assert.Equal(t, "home []", serve(http.MethodGet, "/").Body.String())
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/about").Code)
+
+	assert.Panics(t, func() { mux.HandleFunc("GET /users/{id}", reply("again")) })
+	assert.Panics(t, func() { mux.HandleFunc("/static/", reply("again")) })
+	assert.Panics(t, func() { mux.HandleFunc("example.com/", reply("host")) })
+}
+
+func TestServeMuxMethodPrecedence(t *testing.T) {
+	reply := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(name)) }
+	}
+	serve := func(mux *ServeMux, method, path string) string {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w.Body.String()
+	}
+
+	// the pattern with the method is more specific, in either order of registration.
+	for _, methodFirst := range []bool{false, true} {
+		mux := NewServeMux()
+		if methodFirst {
+			mux.Handle("GET /x", reply("get"))
+			mux.Handle("/x", reply("any"))
+		} else {
+			mux.Handle("/x", reply("any"))
+			mux.Handle("GET /x", reply("get"))
+		}
+
+		assert.Equal(t, "get", serve(mux, http.MethodGet, "/x"), methodFirst)
+		assert.Equal(t, "get", serve(mux, http.MethodHead, "/x"), methodFirst)
+		assert.Equal(t, "any", serve(mux, http.MethodPost, "/x"), methodFirst)
+
+		assert.Panics(t, func() { mux.Handle("GET /x", reply("again")) })
+		assert.Panics(t, func() { mux.Handle("/x", reply("again")) })
+
+		// the explicit HEAD pattern takes precedence over the GET one.
+		mux.Handle("HEAD /x", reply("head"))
+		assert.Equal(t, "head", serve(mux, http.MethodHead, "/x"), methodFirst)
+		assert.Equal(t, "get", serve(mux, http.MethodGet, "/x"), methodFirst)
+	}
+}
+
+func TestParseServeMuxPattern(t *testing.T) {
+	var cases = []struct {
+		pattern  string
+		method   string
+		path     string
+		wildcard string
+	}{
+		{"/", "", "/*", ""},
+		{"/{$}", "", "/", ""},
+		{"GET /users/{id}", "GET", "/users/{id}", ""},
+		{"POST\t /users/", "POST", "/users/*", ""},
+		{"/users/{$}", "", "/users/", ""},
+		{"/files/{rest...}", "", "/files/*", "rest"},
+	}
+	for _, c := range cases {
+		method, path, wildcard, err := parseServeMuxPattern(c.pattern)
+		assert.Nil(t, err, c.pattern)
+		assert.Equal(t, c.method, method, c.pattern)
+		assert.Equal(t, c.path, path, c.pattern)
+		assert.Equal(t, c.wildcard, wildcard, c.pattern)
+	}
+
+	for _, pattern := range []string{"example.com/", "FETCH /", "/{$}/users", "/{rest...}/users", ""} {
+		_, _, _, err := parseServeMuxPattern(pattern)
+		assert.Error(t, err, pattern)
+	}
+}
@@ -18,14 +18,25 @@ package web
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
+// maxWarmupRetry is the maximum delay of retrying the failed warm-up functions.
+const maxWarmupRetry = time.Minute
+
 // A Server defines parameters for running an HTTP server.
 type Server struct {
-	options Options
-	httpSvr *http.Server
+	options  Options
+	httpSvr  *http.Server
+	draining atomic.Bool
 	Router
+
+	// warmupCtx is the context of the warm-up functions, cancelled on Shutdown.
+	warmupCtx   context.Context
+	stopWarmups context.CancelFunc
 }
 
 // NewServer returns a new server instance.
@@ -55,6 +66,7 @@ func NewServer(options Options) *Server {
 		},
 		Router: router,
 	}
+	svr.warmupCtx, svr.stopWarmups = context.WithCancel(context.Background())
 
 	return svr
 }
@@ -67,13 +79,61 @@ func (s *Server) Addr() string {
 // Run listens on the TCP network address Addr and then
 // calls Serve to handle requests on incoming connections.
 // Accepted connections are configured to enable TCP keep-alives.
+// The warm-up functions of the router are started in background and retried until
+// they complete or the server shuts down, see Ready and Options.WarmupRetry.
 func (s *Server) Run() error {
+	go s.runWarmups(s.warmupCtx)
+
 	if nil != s.httpSvr.TLSConfig {
 		return s.httpSvr.ListenAndServeTLS(s.options.CertFile, s.options.KeyFile)
 	}
 	return s.httpSvr.ListenAndServe()
 }
 
+// runWarmups runs the warm-up functions of the router until they all complete, the failures are
+// logged by the logger of the router and retried with an exponential backoff.
+func (s *Server) runWarmups(ctx context.Context) {
+	retry := s.options.WarmupRetry
+	if retry <= 0 {
+		retry = time.Second
+	}
+
+	for {
+		err := RunWarmups(ctx, s.Router)
+		if nil == err || nil != ctx.Err() {
+			return
+		}
+		routerLogger(s.Router).ErrorContext(ctx, "warm-up failed", slog.Any("error", err), slog.Duration("retry", retry))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+		retry = min(2*retry, maxWarmupRetry)
+	}
+}
+
+// Ready reports whether all the warm-up functions of the server router completed,
+// and the server is not draining its connections.
+func (s *Server) Ready() bool {
+	return !s.draining.Load() && Ready(s.Router)
+}
+
+// SetDraining starts or stops draining the connections of the server, the draining server
+// keeps serving requests but closes the connections after their responses instead of
+// keeping them alive, and reports not ready, so that the load balancers can move the
+// clients to other servers before it shuts down.
+func (s *Server) SetDraining(draining bool) {
+	s.draining.Store(draining)
+	s.httpSvr.SetKeepAlivesEnabled(!draining)
+}
+
+// Draining reports whether the server is draining its connections, see SetDraining.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
 // Shutdown gracefully shuts down the server without interrupting any
 // active connections. Shutdown works by first closing all open
 // listeners, then closing all idle connections, and then waiting
@@ -81,6 +141,8 @@ func (s *Server) Run() error {
 // If the provided context expires before the shutdown is complete,
 // Shutdown returns the context's error, otherwise it returns any
 // error returned from closing the Server's underlying Listener(s).
+// The pending warm-up functions are cancelled as well.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopWarmups()
 	return s.httpSvr.Shutdown(ctx)
 }
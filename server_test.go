@@ -1,7 +1,13 @@
 package web
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,3 +19,39 @@ func TestNewServer(t *testing.T) {
 	assert.Equal(t, false, svr.options.IsTls())
 	assert.Nil(t, svr.options.TlsConfig())
 }
+
+func TestServerWarmupRetry(t *testing.T) {
+	var logs bytes.Buffer
+	var calls int
+	r := NewRouterWith(RouterOptions{Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	r.Warmup(func(ctx context.Context) error {
+		if calls++; calls < 3 {
+			return errors.New("database is unreachable")
+		}
+		return nil
+	})
+	r.Get("/", func(ctx context.Context) string { return "ok" })
+
+	svr := NewServer(Options{Router: r, WarmupRetry: time.Millisecond})
+	svr.runWarmups(svr.warmupCtx)
+	assert.Equal(t, 3, calls)
+	assert.True(t, svr.Ready())
+	assert.Equal(t, 2, strings.Count(logs.String(), "warm-up failed"))
+	assert.Contains(t, logs.String(), "database is unreachable")
+
+	// the pending warm-ups are cancelled on shutdown.
+	r = NewRouter()
+	r.Warmup(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	svr = NewServer(Options{Router: r})
+	done := make(chan struct{})
+	go func() {
+		svr.runWarmups(svr.warmupCtx)
+		close(done)
+	}()
+	assert.Nil(t, svr.Shutdown(context.Background()))
+	<-done
+	assert.False(t, svr.Ready())
+}
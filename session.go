@@ -0,0 +1,353 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrNoSessions is returned by the session helpers of the requests not served by the Sessions middleware.
+var ErrNoSessions = errors.New("web: sessions middleware is not in use")
+
+// Session is the server side state of a client, identified by the random ID of the session cookie.
+type Session struct {
+	ID string
+
+	// Principal is the logged-in principal, nil for the anonymous sessions.
+	Principal interface{}
+
+	// Values are the application values of the session, dropped on the login except for the
+	// values kept by KeepValues.
+	Values map[string]interface{}
+
+	// Remember reports whether the session is remembered beyond the browser session, see RememberMe.
+	Remember bool
+
+	ExpiresAt time.Time
+
+	// keep are the keys of the values carried over by LoginSession, see KeepValues.
+	keep []string
+}
+
+// SessionStore persists the sessions.
+type SessionStore interface {
+	// Load returns the session of the ID, or nil if it doesn't exist or has expired.
+	Load(ctx context.Context, id string) (*Session, error)
+
+	// Save creates or replaces the session.
+	Save(ctx context.Context, session *Session) error
+
+	// Delete removes the session of the ID.
+	Delete(ctx context.Context, id string) error
+}
+
+// memorySessionSweep is the interval of sweeping the expired sessions of the memory session store.
+const memorySessionSweep = time.Minute
+
+// NewMemorySessionStore returns a session store keeping the sessions in memory, the expired
+// sessions are removed when they are loaded, and swept once a minute when the sessions are saved,
+// measured by the clock of the router. The sessions are copied in and out of the store, so that
+// the requests of the same session never share their Values.
+func NewMemorySessionStore() SessionStore {
+	return &memorySessionStore{sessions: map[string]Session{}}
+}
+
+type memorySessionStore struct {
+	mu        sync.Mutex
+	sessions  map[string]Session
+	nextSweep time.Time
+}
+
+func (m *memorySessionStore) Load(ctx context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	if !ClockOf(ctx).Now().Before(session.ExpiresAt) {
+		delete(m.sessions, id)
+		return nil, nil
+	}
+	session.Values = maps.Clone(session.Values)
+	return &session, nil
+}
+
+func (m *memorySessionStore) Save(ctx context.Context, session *Session) error {
+	now := ClockOf(ctx).Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// the sessions never loaded again, e.g. of the anonymous clients, are swept once they expire.
+	if now.After(m.nextSweep) {
+		for id, s := range m.sessions {
+			if !now.Before(s.ExpiresAt) {
+				delete(m.sessions, id)
+			}
+		}
+		m.nextSweep = now.Add(memorySessionSweep)
+	}
+	saved := *session
+	saved.Values = maps.Clone(session.Values)
+	m.sessions[session.ID] = saved
+	return nil
+}
+
+func (m *memorySessionStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
+
+// Sessions is the configuration of the sessions middleware, the zero value keeps the sessions in memory.
+type Sessions struct {
+	// Store persists the sessions, NewMemorySessionStore by default.
+	Store SessionStore
+
+	// CookieName is the name of the session cookie, `session` by default.
+	CookieName string
+
+	// TTL is the lifetime of the sessions, 30 minutes by default,
+	// their cookies are removed when the browser is closed.
+	TTL time.Duration
+
+	// RememberTTL is the lifetime of the remembered sessions, 30 days by default, see RememberMe.
+	RememberTTL time.Duration
+
+	// Domain and Secure of the session cookie.
+	Domain string
+	Secure bool
+
+	// SameSite of the session cookie, http.SameSiteLaxMode by default.
+	SameSite http.SameSite
+}
+
+// sessionScope is the session state of the request served by the Sessions middleware.
+type sessionScope struct {
+	config  *Sessions
+	writer  http.ResponseWriter
+	session *Session
+}
+
+// Middleware returns the middleware loading the session of the request, see SessionOf, LoginSession and Logout.
+//
+//	sessions := web.Sessions{Secure: true}
+//	router.Use(sessions.Middleware())
+func (s Sessions) Middleware() MiddlewareFunc {
+	if nil == s.Store {
+		s.Store = NewMemorySessionStore()
+	}
+	if 0 == len(s.CookieName) {
+		s.CookieName = "session"
+	}
+	if s.TTL <= 0 {
+		s.TTL = 30 * time.Minute
+	}
+	if s.RememberTTL <= 0 {
+		s.RememberTTL = 30 * 24 * time.Hour
+	}
+	if 0 == s.SameSite {
+		s.SameSite = http.SameSiteLaxMode
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope := &sessionScope{config: &s, writer: w}
+			if cookie, err := r.Cookie(s.CookieName); nil == err && len(cookie.Value) > 0 {
+				session, err := s.Store.Load(r.Context(), cookie.Value)
+				if nil != err {
+					http.Error(w, "500 internal server error", http.StatusInternalServerError)
+					return
+				}
+				scope.session = session
+			}
+			next.ServeHTTP(w, r.WithContext(WithValue(r.Context(), scope)))
+		})
+	}
+}
+
+// SessionOf returns the session of the request, false if the request has no valid session.
+func SessionOf(ctx context.Context) (*Session, bool) {
+	scope, ok := Value[*sessionScope](ctx)
+	if !ok || nil == scope.session {
+		return nil, false
+	}
+	return scope.session, true
+}
+
+// StartSession returns the session of the request, an anonymous session is started if the request
+// has none, e.g. to keep the cart of the client before the login, see KeepValues.
+//
+//	session, err := web.StartSession(ctx)
+//	if nil != err {
+//		return err
+//	}
+//	session.Values["cart"] = cart
+//	return web.SaveSession(ctx)
+func StartSession(ctx context.Context) (*Session, error) {
+	scope, ok := Value[*sessionScope](ctx)
+	if !ok {
+		return nil, ErrNoSessions
+	}
+	if nil != scope.session {
+		return scope.session, nil
+	}
+
+	id, err := newSessionID()
+	if nil != err {
+		return nil, err
+	}
+	session := &Session{ID: id, Values: map[string]interface{}{}, ExpiresAt: ClockOf(ctx).Now().Add(scope.config.TTL)}
+	if err = scope.config.Store.Save(ctx, session); nil != err {
+		return nil, err
+	}
+	scope.session = session
+	http.SetCookie(scope.writer, scope.cookie(session.ID))
+	return session, nil
+}
+
+// SaveSession persists the changes of the session of the request, e.g. of its Values or Remember,
+// into the store, it does nothing if the request has no session.
+func SaveSession(ctx context.Context) error {
+	scope, ok := Value[*sessionScope](ctx)
+	if !ok {
+		return ErrNoSessions
+	}
+	if nil == scope.session {
+		return nil
+	}
+	return scope.config.Store.Save(ctx, scope.session)
+}
+
+// LoginOption configures the session created by LoginSession.
+type LoginOption func(session *Session)
+
+// RememberMe keeps the session beyond the browser session for the RememberTTL of the Sessions.
+func RememberMe() LoginOption {
+	return func(session *Session) {
+		session.Remember = true
+	}
+}
+
+// KeepValues carries the values of the keys over from the session before the login, e.g. the cart
+// of the anonymous session, see LoginSession.
+func KeepValues(keys ...string) LoginOption {
+	return func(session *Session) {
+		session.keep = append(session.keep, keys...)
+	}
+}
+
+// LoginSession starts a new session of the principal, the ID of the current session is discarded,
+// so that a session ID planted by an attacker before the login never becomes authenticated (session
+// fixation), and so are its values except for the ones kept by KeepValues, so that the values planted
+// before the login don't reach the authenticated session either.
+//
+//	if err := web.LoginSession(ctx, user, web.RememberMe(), web.KeepValues("cart")); nil != err {
+//		return err
+//	}
+func LoginSession(ctx context.Context, principal interface{}, opts ...LoginOption) error {
+	scope, ok := Value[*sessionScope](ctx)
+	if !ok {
+		return ErrNoSessions
+	}
+
+	id, err := newSessionID()
+	if nil != err {
+		return err
+	}
+
+	session := &Session{ID: id, Principal: principal, Values: map[string]interface{}{}}
+	for _, opt := range opts {
+		opt(session)
+	}
+	if nil != scope.session {
+		for _, k := range session.keep {
+			if v, ok := scope.session.Values[k]; ok {
+				session.Values[k] = v
+			}
+		}
+		if err = scope.config.Store.Delete(ctx, scope.session.ID); nil != err {
+			return err
+		}
+		scope.session = nil
+	}
+	session.keep = nil
+
+	ttl := scope.config.TTL
+	if session.Remember {
+		ttl = scope.config.RememberTTL
+	}
+	session.ExpiresAt = ClockOf(ctx).Now().Add(ttl)
+	if err = scope.config.Store.Save(ctx, session); nil != err {
+		return err
+	}
+	scope.session = session
+
+	cookie := scope.cookie(session.ID)
+	if session.Remember {
+		cookie.MaxAge = int(ttl / time.Second)
+	}
+	http.SetCookie(scope.writer, cookie)
+	return nil
+}
+
+// Logout ends the session of the request and removes its cookie.
+func Logout(ctx context.Context) error {
+	scope, ok := Value[*sessionScope](ctx)
+	if !ok {
+		return ErrNoSessions
+	}
+	if nil != scope.session {
+		if err := scope.config.Store.Delete(ctx, scope.session.ID); nil != err {
+			return err
+		}
+		scope.session = nil
+	}
+
+	cookie := scope.cookie("")
+	cookie.MaxAge = -1
+	http.SetCookie(scope.writer, cookie)
+	return nil
+}
+
+func (s *sessionScope) cookie(value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     s.config.CookieName,
+		Value:    value,
+		Path:     "/",
+		Domain:   s.config.Domain,
+		Secure:   s.config.Secure,
+		HttpOnly: true,
+		SameSite: s.config.SameSite,
+	}
+}
+
+// newSessionID returns a random session ID of 256 bits.
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); nil != err {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
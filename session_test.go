@@ -0,0 +1,152 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemorySessionStore()
+
+	r := NewRouter()
+	r.Clock(ClockFunc(func() time.Time { return now }))
+	r.Use(Sessions{Store: store}.Middleware())
+	r.Post("/login", func(ctx context.Context, req struct {
+		Remember bool `query:"remember"`
+		Keep     bool `query:"keep"`
+	}) error {
+		var opts []LoginOption
+		if req.Remember {
+			opts = append(opts, RememberMe())
+		}
+		if req.Keep {
+			opts = append(opts, KeepValues("cart"))
+		}
+		return LoginSession(ctx, "admin", opts...)
+	})
+	r.Post("/cart", func(ctx context.Context) error {
+		session, err := StartSession(ctx)
+		if nil != err {
+			return err
+		}
+		session.Values["cart"] = "book"
+		session.Values["role"] = "admin"
+		return SaveSession(ctx)
+	})
+	r.Get("/me", func(ctx context.Context) (interface{}, error) {
+		session, ok := SessionOf(ctx)
+		if !ok || nil == session.Principal {
+			return nil, Error(http.StatusUnauthorized, "")
+		}
+		return []interface{}{session.Principal, session.Values["cart"], session.Values["role"]}, nil
+	})
+	r.Post("/logout", func(ctx context.Context) error {
+		return Logout(ctx)
+	})
+
+	serve := func(method, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if nil != cookie {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	sessionCookie := func(w *httptest.ResponseRecorder) *http.Cookie {
+		for _, c := range w.Result().Cookies() {
+			if "session" == c.Name {
+				return c
+			}
+		}
+		return nil
+	}
+
+	assert.Contains(t, serve(http.MethodGet, "/me", nil).Body.String(), `"code":401`)
+
+	// the anonymous session gets a new ID on login, its values are dropped.
+	anonymous := sessionCookie(serve(http.MethodPost, "/cart", nil))
+	assert.NotNil(t, anonymous)
+	cookie := sessionCookie(serve(http.MethodPost, "/login", anonymous))
+	assert.Equal(t, `{"code":0,"data":["admin",null,null]}`+"\n", serve(http.MethodGet, "/me", cookie).Body.String())
+
+	// the values kept are carried over only.
+	anonymous = sessionCookie(serve(http.MethodPost, "/cart", nil))
+	w := serve(http.MethodPost, "/login?keep=true", anonymous)
+	cookie = sessionCookie(w)
+	assert.NotEqual(t, anonymous.Value, cookie.Value)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+	assert.Equal(t, 0, cookie.MaxAge)
+	assert.Equal(t, `{"code":0,"data":["admin","book",null]}`+"\n", serve(http.MethodGet, "/me", cookie).Body.String())
+	assert.Contains(t, serve(http.MethodGet, "/me", anonymous).Body.String(), `"code":401`)
+
+	// the session expires after the TTL.
+	now = now.Add(31 * time.Minute)
+	assert.Contains(t, serve(http.MethodGet, "/me", cookie).Body.String(), `"code":401`)
+
+	// the remembered session lasts for the RememberTTL.
+	cookie = sessionCookie(serve(http.MethodPost, "/login?remember=true", nil))
+	assert.Equal(t, 30*24*60*60, cookie.MaxAge)
+	now = now.Add(24 * time.Hour)
+	assert.Contains(t, serve(http.MethodGet, "/me", cookie).Body.String(), `"admin"`)
+
+	w = serve(http.MethodPost, "/logout", cookie)
+	assert.Equal(t, -1, sessionCookie(w).MaxAge)
+	assert.Contains(t, serve(http.MethodGet, "/me", cookie).Body.String(), `"code":401`)
+
+	// the anonymous session is started once, then its changes are saved.
+	anonymous = sessionCookie(serve(http.MethodPost, "/cart", nil))
+	assert.Nil(t, sessionCookie(serve(http.MethodPost, "/cart", anonymous)))
+
+	assert.ErrorIs(t, LoginSession(context.Background(), "admin"), ErrNoSessions)
+	assert.ErrorIs(t, SaveSession(context.Background()), ErrNoSessions)
+	_, err := StartSession(context.Background())
+	assert.ErrorIs(t, err, ErrNoSessions)
+	assert.ErrorIs(t, Logout(context.Background()), ErrNoSessions)
+}
+
+func TestMemorySessionStoreCopies(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySessionStore()
+
+	session := &Session{ID: "a", Values: map[string]interface{}{"cart": "book"}, ExpiresAt: time.Now().Add(time.Hour)}
+	assert.Nil(t, store.Save(ctx, session))
+	session.Values["cart"] = "pen"
+
+	loaded, _ := store.Load(ctx, "a")
+	assert.Equal(t, "book", loaded.Values["cart"])
+	loaded.Values["cart"] = "cup"
+
+	loaded, _ = store.Load(ctx, "a")
+	assert.Equal(t, "book", loaded.Values["cart"])
+}
+
+func TestMemorySessionStoreSweep(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemorySessionStore().(*memorySessionStore)
+
+	r := NewRouter()
+	r.Clock(ClockFunc(func() time.Time { return now }))
+	r.Use(Sessions{Store: store}.Middleware())
+	r.Post("/login", func(ctx context.Context) error {
+		return LoginSession(ctx, nil)
+	})
+	login := func() {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", nil))
+	}
+
+	// the sessions never loaded again are swept once they expire.
+	login()
+	login()
+	assert.Len(t, store.sessions, 2)
+	now = now.Add(31 * time.Minute)
+	login()
+	assert.Len(t, store.sessions, 1)
+}
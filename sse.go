@@ -0,0 +1,143 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-spring.dev/web/render"
+)
+
+// SSEvent is an event of the server-sent events stream.
+type SSEvent struct {
+	// ID sets the last event ID of the client.
+	ID string
+
+	// Event is the event type, the client dispatches the untyped events as "message".
+	Event string
+
+	// Data is the event payload, the strings and byte slices are sent as is,
+	// other values are encoded as JSON.
+	Data interface{}
+
+	// Retry is the reconnection time of the client.
+	Retry time.Duration
+}
+
+// SSESender sends server-sent events to the client, each event is flushed as soon as it's sent
+// and bounded by a write deadline, so that a stalled client can't block the handler forever.
+//
+//	router.Get("/events", func(ctx context.Context) {
+//		sender := web.FromContext(ctx).SSE()
+//		for message := range messages {
+//			if err := sender.Send(web.SSEvent{Event: "message", Data: message}); nil != err {
+//				return
+//			}
+//		}
+//	})
+type SSESender struct {
+	ctx     *Context
+	stream  *render.StreamWriter
+	timeout time.Duration
+	started bool
+}
+
+// SSE returns a sender of server-sent events to the client.
+func (c *Context) SSE() *SSESender {
+	return &SSESender{ctx: c}
+}
+
+// WriteTimeout sets the deadline of sending each event, see render.NewStreamWriter.
+func (s *SSESender) WriteTimeout(timeout time.Duration) *SSESender {
+	s.timeout = timeout
+	return s
+}
+
+// Send sends the event to the client, returns the error of the request context once the client goes away.
+func (s *SSESender) Send(event SSEvent) error {
+	var buf bytes.Buffer
+	if len(event.ID) > 0 {
+		writeSSEField(&buf, "id", event.ID)
+	}
+	if len(event.Event) > 0 {
+		writeSSEField(&buf, "event", event.Event)
+	}
+	if event.Retry > 0 {
+		writeSSEField(&buf, "retry", strconv.FormatInt(event.Retry.Milliseconds(), 10))
+	}
+
+	var data string
+	switch v := event.Data.(type) {
+	case nil:
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		b, err := json.Marshal(v)
+		if nil != err {
+			return err
+		}
+		data = string(b)
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		writeSSEField(&buf, "data", line)
+	}
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// Comment sends a comment line, which is ignored by the client and keeps the connection alive.
+func (s *SSESender) Comment(comment string) error {
+	var buf bytes.Buffer
+	for _, line := range strings.Split(comment, "\n") {
+		buf.WriteString(": " + line + "\n")
+	}
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+func (s *SSESender) write(p []byte) error {
+	if err := s.ctx.Request.Context().Err(); nil != err {
+		return err
+	}
+	if !s.started {
+		s.started = true
+		header := s.ctx.Writer.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+		// disable the response buffering of nginx.
+		header.Set("X-Accel-Buffering", "no")
+		s.ctx.Writer.WriteHeader(http.StatusOK)
+		s.stream = render.NewStreamWriter(s.ctx.Writer, s.timeout)
+	}
+	_, err := s.stream.Write(p)
+	return err
+}
+
+func writeSSEField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
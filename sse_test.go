@@ -0,0 +1,61 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSESender(t *testing.T) {
+	r := NewRouter()
+	r.Get("/events", func(ctx context.Context) {
+		sender := FromContext(ctx).SSE().WriteTimeout(time.Second)
+		assert.Nil(t, sender.Comment("hello"))
+		assert.Nil(t, sender.Send(SSEvent{ID: "1", Event: "greeting", Data: "hello\nworld", Retry: 3 * time.Second}))
+		assert.Nil(t, sender.Send(SSEvent{Data: map[string]int{"count": 1}}))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Equal(t, ": hello\n\n"+
+		"id: 1\nevent: greeting\nretry: 3000\ndata: hello\ndata: world\n\n"+
+		"data: {\"count\":1}\n\n", w.Body.String())
+}
+
+func TestSSESenderWriteDeadline(t *testing.T) {
+	stalled := make(chan error, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sender := (&Context{Writer: w, Request: r}).SSE().WriteTimeout(50 * time.Millisecond)
+		payload := make([]byte, 64*1024)
+		for i := range payload {
+			payload[i] = 'x'
+		}
+		for {
+			if err := sender.Send(SSEvent{Data: string(payload)}); nil != err {
+				stalled <- err
+				return
+			}
+		}
+	}))
+	defer ts.Close()
+
+	// the client never reads the response body.
+	resp, err := http.Get(ts.URL)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+
+	select {
+	case err := <-stalled:
+		assert.NotNil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stalled client blocks the sender")
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when dialing an address forbidden by the DialPolicy.
+var ErrForbiddenAddress = errors.New("forbidden address")
+
+// nonPublicPrefixes are the special purpose ranges not covered by the netip.Addr predicates: the
+// carrier-grade NAT range, which hosts cloud metadata endpoints as well, "this network", the IETF
+// protocol assignments, the benchmarking and the reserved ranges, and the IPv6 translation ranges
+// NAT64 and 6to4, which embed any IPv4 address, including the private ones.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// DialPolicy protects the outgoing requests to the user supplied URLs from server-side request
+// forgery. The policy is evaluated at dial time on the resolved IP addresses, so it can't be
+// bypassed by hostnames resolving (or rebinding) to internal addresses, or by redirects.
+//
+// The addresses in Deny are always forbidden, the addresses in Allow are permitted otherwise.
+// The other loopback, private, link-local (including the 169.254.169.254 metadata endpoint),
+// shared, reserved, multicast and unspecified addresses, and the NAT64 and 6to4 addresses, are
+// forbidden unless AllowPrivate is set. The reverse proxies of the routes loaded by LoadRoutes
+// dial by the policy of RouterOptions.DialPolicy.
+//
+//	client := (&web.DialPolicy{}).Client()
+//	resp, err := client.Get(userSuppliedURL)
+//	if errors.Is(err, web.ErrForbiddenAddress) {
+//		...
+//	}
+type DialPolicy struct {
+	// Allow are the address ranges permitted, e.g. the internal services the app may fetch.
+	Allow []netip.Prefix
+
+	// Deny are the address ranges forbidden.
+	Deny []netip.Prefix
+
+	// AllowPrivate permits the non-public addresses not in Deny.
+	AllowPrivate bool
+}
+
+// Check returns an error wrapping ErrForbiddenAddress if the IP address is forbidden.
+func (p *DialPolicy) Check(ip netip.Addr) error {
+	ip = ip.Unmap()
+	for _, prefix := range p.Deny {
+		if prefix.Contains(ip) {
+			return fmt.Errorf("%w: %s is denied", ErrForbiddenAddress, ip)
+		}
+	}
+	for _, prefix := range p.Allow {
+		if prefix.Contains(ip) {
+			return nil
+		}
+	}
+	if !p.AllowPrivate && !isPublicAddr(ip) {
+		return fmt.Errorf("%w: %s is not public", ErrForbiddenAddress, ip)
+	}
+	return nil
+}
+
+// Control checks the address to dial against the policy, it's the Control function of net.Dialer.
+func (p *DialPolicy) Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if nil != err {
+		return fmt.Errorf("%w: %v", ErrForbiddenAddress, err)
+	}
+	return p.Check(addrPort.Addr())
+}
+
+// Transport returns a transport dialing by the policy, the proxy from the environment is
+// not used since the policy would be evaluated on the address of the proxy instead.
+func (p *DialPolicy) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   p.Control,
+	}).DialContext
+	return transport
+}
+
+// Client returns a client dialing by the policy.
+func (p *DialPolicy) Client() *http.Client {
+	return &http.Client{Transport: p.Transport()}
+}
+
+func isPublicAddr(ip netip.Addr) bool {
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialPolicyCheck(t *testing.T) {
+	policy := &DialPolicy{}
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"100.100.100.200", "0.0.0.0", "::1", "fd00:ec2::254", "fe80::1", "::ffff:127.0.0.1", "224.0.0.1",
+		"0.1.2.3", "192.0.0.170", "198.18.0.1", "198.19.255.255", "255.255.255.255",
+		"64:ff9b::a00:1", "64:ff9b:1::a00:1", "2002:a00:1::1"} {
+		assert.ErrorIs(t, policy.Check(netip.MustParseAddr(addr)), ErrForbiddenAddress, addr)
+	}
+	for _, addr := range []string{"8.8.8.8", "2001:4860:4860::8888"} {
+		assert.Nil(t, policy.Check(netip.MustParseAddr(addr)), addr)
+	}
+
+	policy = &DialPolicy{
+		Allow: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		Deny:  []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32"), netip.MustParsePrefix("8.8.0.0/16")},
+	}
+	assert.Nil(t, policy.Check(netip.MustParseAddr("10.1.2.3")))
+	assert.ErrorIs(t, policy.Check(netip.MustParseAddr("10.0.0.1")), ErrForbiddenAddress)
+	assert.ErrorIs(t, policy.Check(netip.MustParseAddr("8.8.8.8")), ErrForbiddenAddress)
+	assert.ErrorIs(t, policy.Check(netip.MustParseAddr("192.168.1.1")), ErrForbiddenAddress)
+
+	policy = &DialPolicy{AllowPrivate: true, Deny: []netip.Prefix{netip.MustParsePrefix("169.254.169.254/32")}}
+	assert.Nil(t, policy.Check(netip.MustParseAddr("192.168.1.1")))
+	assert.ErrorIs(t, policy.Check(netip.MustParseAddr("169.254.169.254")), ErrForbiddenAddress)
+}
+
+func TestDialPolicyClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("internal"))
+	}))
+	defer ts.Close()
+
+	_, err := (&DialPolicy{}).Client().Get(ts.URL)
+	assert.True(t, errors.Is(err, ErrForbiddenAddress), err)
+
+	resp, err := (&DialPolicy{Allow: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}}).Client().Get(ts.URL)
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,223 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// StaticOption configures the static files serving.
+type StaticOption func(o *staticOptions)
+
+type staticOptions struct {
+	index   string
+	listing bool
+	spa     bool
+}
+
+// StaticIndex sets the index file served for the directories, defaults to `index.html`,
+// an empty name disables the index file.
+func StaticIndex(name string) StaticOption {
+	return func(o *staticOptions) {
+		o.index = name
+	}
+}
+
+// StaticListing enables or disables the directory listing, it's disabled by default.
+func StaticListing(enabled bool) StaticOption {
+	return func(o *staticOptions) {
+		o.listing = enabled
+	}
+}
+
+// StaticSPA enables the single-page application mode, the index file of the root directory
+// is served for the unknown paths, so that the client side routes can be reloaded.
+func StaticSPA() StaticOption {
+	return func(o *staticOptions) {
+		o.spa = true
+	}
+}
+
+// Static serves the files of the directory under the routing pattern.
+//
+//	router.Static("/assets", "./public", web.StaticListing(true))
+func (rg *routerGroup) Static(pattern, dir string, opts ...StaticOption) {
+	rg.staticFS(pattern, http.Dir(dir), opts...)
+}
+
+// StaticFS serves the files of the file system under the routing pattern, the file system
+// is usually an embed.FS to serve the assets embedded into the binary.
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	assets, _ := fs.Sub(dist, "dist")
+//	router.StaticFS("/", assets, web.StaticSPA())
+func (rg *routerGroup) StaticFS(pattern string, fsys fs.FS, opts ...StaticOption) {
+	rg.staticFS(pattern, http.FS(fsys), opts...)
+}
+
+// StaticFile serves a single file on the routing pattern.
+//
+//	router.StaticFile("/favicon.ico", "./public/favicon.ico")
+func (rg *routerGroup) StaticFile(pattern, file string) {
+	dir, name := path.Split(file)
+	files := http.Dir(dir)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !serveFile(w, r, files, "/"+name) {
+			rg.NotFoundHandler().ServeHTTP(w, r)
+		}
+	})
+	rg.register(mGET, pattern, handler)
+	rg.register(mHEAD, pattern, handler)
+}
+
+func (rg *routerGroup) staticFS(pattern string, files http.FileSystem, opts ...StaticOption) {
+	options := staticOptions{index: "index.html"}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.spa && 0 == len(options.index) {
+		panic("static single-page application mode requires an index file")
+	}
+
+	handler := &staticHandler{fs: files, options: options, notFound: rg.NotFoundHandler}
+
+	pattern = strings.TrimSuffix(pattern, "/")
+	if len(pattern) > 0 {
+		rg.register(mGET, pattern, handler)
+		rg.register(mHEAD, pattern, handler)
+	}
+	rg.register(mGET, pattern+"/*", handler)
+	rg.register(mHEAD, pattern+"/*", handler)
+}
+
+type staticHandler struct {
+	fs       http.FileSystem
+	options  staticOptions
+	notFound func() http.Handler
+}
+
+func (h *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var wildcard string
+	if ctx := FromRouteContext(r.Context()); nil != ctx {
+		wildcard, _ = ctx.URLParams.Get("*")
+	}
+	name := path.Clean("/" + wildcard)
+
+	f, err := h.fs.Open(name)
+	if nil != err {
+		h.fallback(w, r)
+		return
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if nil != err {
+		h.fallback(w, r)
+		return
+	}
+
+	if !stat.IsDir() {
+		http.ServeContent(w, r, stat.Name(), stat.ModTime(), f)
+		return
+	}
+
+	// redirect the directories to the canonical path with a trailing slash.
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		localRedirect(w, r, path.Base(r.URL.Path)+"/")
+		return
+	}
+
+	if len(h.options.index) > 0 && serveFile(w, r, h.fs, path.Join(name, h.options.index)) {
+		return
+	}
+
+	if h.options.listing {
+		dirList(w, r, f)
+		return
+	}
+
+	h.fallback(w, r)
+}
+
+// fallback serves the root index file in the single-page application mode, or not found.
+func (h *staticHandler) fallback(w http.ResponseWriter, r *http.Request) {
+	if h.options.spa && serveFile(w, r, h.fs, "/"+h.options.index) {
+		return
+	}
+	h.notFound().ServeHTTP(w, r)
+}
+
+// serveFile serves the regular file of the file system, reports false if it's not found.
+func serveFile(w http.ResponseWriter, r *http.Request, files http.FileSystem, name string) bool {
+	f, err := files.Open(name)
+	if nil != err {
+		return false
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if nil != err || stat.IsDir() {
+		return false
+	}
+
+	http.ServeContent(w, r, stat.Name(), stat.ModTime(), f)
+	return true
+}
+
+// dirList writes the directory listing in the same format as http.FileServer.
+func dirList(w http.ResponseWriter, r *http.Request, f http.File) {
+	entries, err := f.Readdir(-1)
+	if nil != err {
+		http.Error(w, "Error reading directory", http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	fmt.Fprintf(w, "<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		link := url.URL{Path: name}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", link.String(), html.EscapeString(name))
+	}
+	fmt.Fprintf(w, "</pre>\n")
+}
+
+func localRedirect(w http.ResponseWriter, r *http.Request, newPath string) {
+	if q := r.URL.RawQuery; q != "" {
+		newPath += "?" + q
+	}
+	w.Header().Set("Location", newPath)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
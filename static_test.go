@@ -0,0 +1,101 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterStatic(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("index"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "app.js"), []byte("app"), 0644))
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "css"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "css", "main.css"), []byte("main"), 0644))
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "docs"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "docs", "home.html"), []byte("home"), 0644))
+
+	r := NewRouter()
+	r.Group("/v1", func(r Router) {
+		r.Static("/assets", dir)
+	})
+	r.Static("/browse", dir, StaticListing(true), StaticIndex(""))
+	r.Static("/docs", filepath.Join(dir, "docs"), StaticIndex("home.html"))
+	r.StaticFile("/favicon.ico", filepath.Join(dir, "app.js"))
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := serve(http.MethodGet, "/v1/assets/app.js")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "app", w.Body.String())
+
+	assert.Equal(t, "main", serve(http.MethodGet, "/v1/assets/css/main.css").Body.String())
+	assert.Equal(t, "index", serve(http.MethodGet, "/v1/assets/").Body.String())
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/v1/assets/missing.js").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/v1/assets/css/").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/v1/assets/../static_test.go").Code)
+
+	w = serve(http.MethodGet, "/v1/assets")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "assets/", w.Header().Get("Location"))
+
+	w = serve(http.MethodGet, "/v1/assets/css")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "css/", w.Header().Get("Location"))
+
+	w = serve(http.MethodHead, "/v1/assets/app.js")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Header().Get("Content-Length"))
+
+	w = serve(http.MethodGet, "/browse/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(), `<a href="app.js">app.js</a>`), w.Body.String())
+
+	assert.Equal(t, "home", serve(http.MethodGet, "/docs/").Body.String())
+	assert.Equal(t, "app", serve(http.MethodGet, "/favicon.ico").Body.String())
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/favicon.ico").Code)
+}
+
+func TestRouterStaticFS(t *testing.T) {
+	dist := fstest.MapFS{
+		"index.html":    {Data: []byte("spa")},
+		"js/app.js":     {Data: []byte("app")},
+		"docs/doc.html": {Data: []byte("doc")},
+	}
+
+	r := NewRouter()
+	r.StaticFS("/app", dist, StaticSPA())
+	r.StaticFS("/plain", dist)
+	r.Get("/api/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, "app", serve("/app/js/app.js").Body.String())
+	assert.Equal(t, "spa", serve("/app/").Body.String())
+	assert.Equal(t, "spa", serve("/app/users/42").Body.String())
+	assert.Equal(t, "spa", serve("/app/docs/").Body.String())
+	assert.Equal(t, "users", serve("/api/users").Body.String())
+
+	assert.Equal(t, "doc", serve("/plain/docs/doc.html").Body.String())
+	assert.Equal(t, http.StatusNotFound, serve("/plain/users/42").Code)
+
+	assert.Panics(t, func() {
+		r.StaticFS("/other", dist, StaticSPA(), StaticIndex(""))
+	})
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"mime"
+	"net/http"
+	"reflect"
+
+	"go-spring.dev/web/render"
+)
+
+// isStreamType returns whether the handler result type `t` is streamed, i.e. a receive-only channel
+// or an iterator of the records, e.g. iter.Seq[T].
+func isStreamType(t reflect.Type) bool {
+	return reflect.Chan == t.Kind() && reflect.RecvDir == t.ChanDir() || render.IsSeq(t)
+}
+
+// streamRecordType returns the type of the records of the streamed result type `t`.
+func streamRecordType(t reflect.Type) reflect.Type {
+	if reflect.Func == t.Kind() {
+		return t.In(0).In(0)
+	}
+	return t.Elem()
+}
+
+// renderStream streams the records received from the channel, or yielded by the iterator, returned by
+// a typed handler until the channel is closed, or the iterator returns, as server-sent events if the
+// route produces `text/event-stream` by negotiation, see Produces, as a JSON array flushed in chunks
+// if it produces `application/json`, or as newline delimited JSON otherwise. The SSEvent records are
+// sent as is, other records are sent as the data of the events.
+//
+// The stream stops once the client goes away, so the producer of the channel should stop on the
+// cancellation of the request context and close the channel.
+func renderStream(ctx *Context, records reflect.Value) {
+	mediaType, _, _ := mime.ParseMediaType(NegotiatedType(ctx.Request))
+	if "text/event-stream" != mediaType {
+		var data interface{}
+		if !records.IsNil() {
+			data = records.Interface()
+		}
+		if "application/json" == mediaType {
+			_ = ctx.JSONStream(http.StatusOK, data)
+			return
+		}
+		_ = ctx.NDJSON(http.StatusOK, data)
+		return
+	}
+
+	sender := ctx.SSE()
+	if records.IsNil() {
+		return
+	}
+	send := func(record reflect.Value) bool {
+		event, ok := record.Interface().(SSEvent)
+		if !ok {
+			event = SSEvent{Data: record.Interface()}
+		}
+		return nil == sender.Send(event)
+	}
+	if reflect.Func == records.Kind() {
+		render.RangeSeq(records, send)
+		return
+	}
+	for {
+		record, ok := records.Recv()
+		if !ok || !send(record) {
+			return
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamResult(t *testing.T) {
+	type Todo struct {
+		ID int `json:"id"`
+	}
+
+	todos := func(ctx context.Context) <-chan Todo {
+		ch := make(chan Todo)
+		go func() {
+			defer close(ch)
+			for i := 1; i <= 2; i++ {
+				select {
+				case ch <- Todo{ID: i}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch
+	}
+
+	r := NewRouter()
+	r.Intercept(ResultCache(time.Minute))
+	r.Get("/todos", todos)
+	r.Get("/events", func(ctx context.Context) (<-chan Todo, error) {
+		return todos(ctx), nil
+	}).Apply(Produces("application/x-ndjson", "text/event-stream"))
+	r.Get("/typed", func(ctx context.Context) <-chan SSEvent {
+		ch := make(chan SSEvent, 1)
+		ch <- SSEvent{ID: "1", Event: "created", Data: "todo"}
+		close(ch)
+		return ch
+	}).Apply(Produces("text/event-stream"))
+	r.Get("/list", func(ctx context.Context) func(yield func(Todo) bool) {
+		return func(yield func(Todo) bool) {
+			for i := 1; i <= 3; i++ {
+				if !yield(Todo{ID: i}) {
+					return
+				}
+			}
+		}
+	}).Apply(Produces("application/json", "text/event-stream"))
+	r.Get("/array", todos).Apply(Produces("application/json"))
+	r.Get("/nil", func(ctx context.Context) <-chan Todo { return nil })
+	r.Get("/fail", func(ctx context.Context) (<-chan Todo, error) {
+		return nil, Error(http.StatusConflict, "busy")
+	})
+
+	// the streamed records are not cached.
+	for i := 0; i < 2; i++ {
+		resp, body := testHandler(t, r, "GET", "/todos", nil)
+		assert.Equal(t, "application/x-ndjson; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n", body)
+	}
+
+	_, body := testHandler(t, r, "GET", "/events", nil)
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n", body)
+
+	req := httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "data: {\"id\":1}\n\ndata: {\"id\":2}\n\n", w.Body.String())
+
+	// the records are streamed as a JSON array if the route produces it.
+	for i := 0; i < 2; i++ {
+		resp, body := testHandler(t, r, "GET", "/list", nil)
+		assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "[{\"id\":1},{\"id\":2},{\"id\":3}]\n", body)
+	}
+	_, body = testHandler(t, r, "GET", "/array", nil)
+	assert.Equal(t, "[{\"id\":1},{\"id\":2}]\n", body)
+
+	req = httptest.NewRequest("GET", "/list", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "data: {\"id\":1}\n\ndata: {\"id\":2}\n\ndata: {\"id\":3}\n\n", w.Body.String())
+
+	_, body = testHandler(t, r, "GET", "/typed", nil)
+	assert.Equal(t, "id: 1\nevent: created\ndata: todo\n\n", body)
+
+	resp, body := testHandler(t, r, "GET", "/nil", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "", body)
+
+	_, body = testHandler(t, r, "GET", "/fail", nil)
+	assert.Equal(t, "{\"code\":409,\"message\":\"busy\",\"data\":null}\n", body)
+
+	assert.PanicsWithError(t, "func(context.Context) <-chan func(): result type can't be streamed: json: unsupported type: func()", func() {
+		Bind(func(ctx context.Context) <-chan func() { return nil }, JsonRender())
+	})
+	assert.PanicsWithError(t, "func(context.Context) func(func(chan int) bool): result type can't be streamed: json: unsupported type: chan int", func() {
+		Bind(func(ctx context.Context) func(yield func(chan int) bool) { return nil }, JsonRender())
+	})
+}
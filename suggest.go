@@ -0,0 +1,122 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the maximum number of patterns suggested by SuggestNotFound.
+const maxSuggestions = 3
+
+// SuggestNotFound returns a not found handler that includes the nearest registered patterns
+// of the router in the 404 payload, e.g. `404 page not found, did you mean /api/v1/todos?`.
+// It walks the whole routing tree for each request, so it's meant for the development mode.
+//
+//	router.NotFound(web.SuggestNotFound(router))
+func SuggestNotFound(r Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		message := "404 page not found"
+		if suggestions := suggestPatterns(r, req.URL.Path); len(suggestions) > 0 {
+			message += ", did you mean " + strings.Join(suggestions, " or ") + "?"
+		}
+		http.Error(w, message, http.StatusNotFound)
+	}
+}
+
+// suggestPatterns returns the registered patterns nearest to the path.
+func suggestPatterns(r Routes, path string) []string {
+	type candidate struct {
+		pattern  string
+		distance int
+	}
+
+	var candidates []candidate
+	var visited = map[string]bool{}
+	_ = r.Walk(func(method, pattern string, handler http.Handler, mws Middlewares) error {
+		if visited[pattern] {
+			return nil
+		}
+		visited[pattern] = true
+
+		// too different paths are not worth suggesting.
+		if d := patternDistance(path, pattern); d <= len(path)/3+1 {
+			candidates = append(candidates, candidate{pattern: pattern, distance: d})
+		}
+		return nil
+	})
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].pattern < candidates[j].pattern
+	})
+
+	// only the nearest patterns are suggested.
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < maxSuggestions && candidates[i].distance == candidates[0].distance; i++ {
+		suggestions = append(suggestions, candidates[i].pattern)
+	}
+	return suggestions
+}
+
+// patternDistance returns the edit distance between the path and the routing pattern segment by segment,
+// the param segments match any path segment and the catch-all matches the remaining path.
+func patternDistance(path, pattern string) int {
+	ps := strings.Split(strings.Trim(path, "/"), "/")
+	ts := strings.Split(strings.Trim(pattern, "/"), "/")
+
+	distance := 0
+	for i := 0; i < len(ps) || i < len(ts); i++ {
+		switch {
+		case i >= len(ts):
+			distance += len(ps[i]) + 1
+		case "*" == ts[i]:
+			return distance
+		case i >= len(ps):
+			distance += len(ts[i]) + 1
+		case strings.HasPrefix(ts[i], "{") && strings.HasSuffix(ts[i], "}"):
+			// param matches any segment
+		default:
+			distance += levenshtein(ps[i], ts[i])
+		}
+	}
+	return distance
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,53 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestNotFound(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := NewRouter()
+	r.Group("/api/v1", func(r Router) {
+		r.Get("/todos", handler)
+		r.Post("/todos", handler)
+		r.Get("/todos/{id}", handler)
+		r.Get("/users", handler)
+	})
+	r.Get("/health", handler)
+	r.NotFound(SuggestNotFound(r))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := serve("/api/v1/todo")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "404 page not found, did you mean /api/v1/todos?\n", w.Body.String())
+
+	w = serve("/api/v2/todos/42")
+	assert.Equal(t, "404 page not found, did you mean /api/v1/todos/{id}?\n", w.Body.String())
+
+	w = serve("/helth")
+	assert.Equal(t, "404 page not found, did you mean /health?\n", w.Body.String())
+
+	w = serve("/api/v1/item")
+	assert.Equal(t, "404 page not found, did you mean /api/v1/users?\n", w.Body.String())
+
+	w = serve("/something/completely/different")
+	assert.Equal(t, "404 page not found\n", w.Body.String())
+}
+
+func TestPatternDistance(t *testing.T) {
+	assert.Equal(t, 0, patternDistance("/api/v1/todos/1", "/api/v1/todos/{id}"))
+	assert.Equal(t, 0, patternDistance("/assets/css/main.css", "/assets/*"))
+	assert.Equal(t, 1, patternDistance("/api/v1/todo", "/api/v1/todos"))
+	assert.Equal(t, 11, patternDistance("/api/v1/todos", "/api/v1/todos/{id}/items"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+}
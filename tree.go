@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync/atomic"
 )
 
 type methodTyp uint
@@ -88,8 +89,29 @@ type node struct {
 
 	// first byte of the prefix
 	label byte
+
+	// the highest priority of the routes in the subtree
+	priority int
+
+	// insertion sequence of the node, breaks ties between nodes with the same label
+	seq uint64
+
+	// search order of the child node types, nil for the default order
+	order *[ntCatchAll + 1]nodeTyp
+
+	// any route in the tree has an explicit priority, only used on the root node
+	prioritized bool
+
+	// leaf nodes of the static routes by their exact paths, only used on the root node
+	static map[string]*node
 }
 
+// nodeSeq generates the insertion sequence of nodes.
+var nodeSeq atomic.Uint64
+
+// defaultTypOrder is the default search order of the child node types.
+var defaultTypOrder = [ntCatchAll + 1]nodeTyp{ntStatic, ntRegexp, ntParam, ntCatchAll}
+
 // endpoints is a mapping of http method constants to handlers
 // for a given route.
 type endpoints map[methodTyp]*endpoint
@@ -103,6 +125,21 @@ type endpoint struct {
 
 	// parameter keys recorded on handler nodes
 	paramKeys []string
+
+	// metadata attached to the route
+	meta Metadata
+
+	// priority of the route among overlapping routes
+	priority int
+
+	// source location where the route is registered
+	source string
+
+	// the endpoint is registered by Mount, explicit routes are allowed to override it
+	mounted bool
+
+	// the inline router the route is registered with, nil for the routes of the router itself
+	inline *routerGroup
 }
 
 func (s endpoints) Value(method methodTyp) *endpoint {
@@ -115,14 +152,50 @@ func (s endpoints) Value(method methodTyp) *endpoint {
 }
 
 func (n *node) InsertRoute(method methodTyp, pattern string, handler http.Handler) *node {
+	hn := n.insertNode(pattern)
+	hn.setEndpoint(method, handler, pattern)
+	n.indexStatic(pattern, hn)
+	return hn
+}
+
+// indexStatic records the leaf node of the pattern on the root node if the pattern is static.
+func (n *node) indexStatic(pattern string, leaf *node) {
+	if strings.ContainsAny(pattern, "{*") {
+		return
+	}
+	if nil == n.static {
+		n.static = map[string]*node{}
+	}
+	n.static[pattern] = leaf
+}
+
+// reindexStatic rebuilds the static route index of the root node from the tree.
+func (n *node) reindexStatic() {
+	n.static = nil
+	var index func(nn *node)
+	index = func(nn *node) {
+		for _, ep := range nn.endpoints {
+			if len(ep.pattern) > 0 {
+				n.indexStatic(ep.pattern, nn)
+			}
+		}
+		for _, nds := range nn.children {
+			for _, child := range nds {
+				index(child)
+			}
+		}
+	}
+	index(n)
+}
+
+// insertNode inserts the nodes of the pattern into the tree and returns the leaf node of it.
+func (n *node) insertNode(pattern string) *node {
 	var parent *node
 	search := pattern
 
 	for {
 		// Handle key exhaustion
 		if len(search) == 0 {
-			// Insert or update the node's leaf handler
-			n.setEndpoint(method, handler, pattern)
 			return n
 		}
 
@@ -149,10 +222,7 @@ func (n *node) InsertRoute(method methodTyp, pattern string, handler http.Handle
 		// No edge, create one
 		if n == nil {
 			child := &node{label: label, tail: segTail, prefix: search}
-			hn := parent.addChild(child, search)
-			hn.setEndpoint(method, handler, pattern)
-
-			return hn
+			return parent.addChild(child, search)
 		}
 
 		// Found an edge to match the pattern
@@ -190,7 +260,6 @@ func (n *node) InsertRoute(method methodTyp, pattern string, handler http.Handle
 		// If the new key is a subset, set the method/handler on this node and finish.
 		search = search[commonPrefix:]
 		if len(search) == 0 {
-			child.setEndpoint(method, handler, pattern)
 			return child
 		}
 
@@ -200,9 +269,7 @@ func (n *node) InsertRoute(method methodTyp, pattern string, handler http.Handle
 			label:  search[0],
 			prefix: search,
 		}
-		hn := child.addChild(subchild, search)
-		hn.setEndpoint(method, handler, pattern)
-		return hn
+		return child.addChild(subchild, search)
 	}
 }
 
@@ -289,6 +356,9 @@ func (n *node) addChild(child *node, prefix string) *node {
 		}
 	}
 
+	if 0 == child.seq {
+		child.seq = nodeSeq.Add(1)
+	}
 	n.children[child.typ] = append(n.children[child.typ], child)
 	n.children[child.typ].Sort()
 	return hn
@@ -326,6 +396,8 @@ func (n *node) setEndpoint(method methodTyp, handler http.Handler, pattern strin
 	}
 
 	paramKeys := patParamKeys(pattern)
+	source := registrationSource()
+	mounted := method&mSTUB == mSTUB
 
 	if method&mSTUB == mSTUB {
 		n.endpoints.Value(mSTUB).handler = handler
@@ -335,18 +407,190 @@ func (n *node) setEndpoint(method methodTyp, handler http.Handler, pattern strin
 		h.handler = handler
 		h.pattern = pattern
 		h.paramKeys = paramKeys
+		h.source = source
+		h.mounted = mounted
 		for _, m := range methodMap {
 			h := n.endpoints.Value(m)
 			h.handler = handler
 			h.pattern = pattern
 			h.paramKeys = paramKeys
+			h.source = source
+			h.mounted = mounted
 		}
 	} else {
-		h := n.endpoints.Value(method)
-		h.handler = handler
-		h.pattern = pattern
-		h.paramKeys = paramKeys
+		for m := mCONNECT; m <= mTRACE; m <<= 1 {
+			if method&m != m {
+				continue
+			}
+			h := n.endpoints.Value(m)
+			h.handler = handler
+			h.pattern = pattern
+			h.paramKeys = paramKeys
+			h.source = source
+			h.mounted = mounted
+		}
+	}
+}
+
+// checkConflict panics if any method already has a handler registered explicitly on the node,
+// which means the new routing pattern either duplicates or shadows the registered one.
+func (n *node) checkConflict(method methodTyp, pattern string) {
+	for m := mCONNECT; m <= mTRACE; m <<= 1 {
+		if method&m != m {
+			continue
+		}
+		name := reverseMethodMap[m]
+		ep, ok := n.endpoints[m]
+		if !ok || ep.handler == nil || ep.mounted {
+			continue
+		}
+		if ep.pattern == pattern {
+			panic(fmt.Sprintf("routing pattern '%s %s' registered at %s is already registered at %s",
+				name, pattern, registrationSource(), ep.source))
+		}
+		panic(fmt.Sprintf("routing pattern '%s %s' registered at %s conflicts with '%s %s' registered at %s",
+			name, pattern, registrationSource(), name, ep.pattern, ep.source))
+	}
+}
+
+// removeRoute removes the endpoints of the method registered with the pattern from the subtree,
+// the mALL method removes the endpoints of all methods, reports whether any endpoint is removed.
+func (n *node) removeRoute(method methodTyp, pattern string) bool {
+	removed := false
+	for typ, nds := range n.children {
+		kept := nds[:0]
+		for _, child := range nds {
+			if child.removeRoute(method, pattern) {
+				removed = true
+			}
+			// prune the empty leaves, so that adding and removing routes doesn't grow the tree.
+			if child.isLeaf() || child.hasChildren() {
+				kept = append(kept, child)
+			}
+		}
+		for i := len(kept); i < len(nds); i++ {
+			nds[i] = nil
+		}
+		n.children[typ] = kept
+	}
+
+	for m, ep := range n.endpoints {
+		if ep.pattern != pattern || (method != mALL && method&m != m) {
+			continue
+		}
+		delete(n.endpoints, m)
+		if m != mALL {
+			removed = true
+		}
+	}
+
+	// a node without endpoints is not a leaf anymore.
+	if nil != n.endpoints {
+		for m, ep := range n.endpoints {
+			if m != mSTUB && ep.handler != nil {
+				return removed
+			}
+		}
+		n.endpoints = nil
+		n.subroutes = nil
+	}
+	return removed
+}
+
+func (n *node) setMetadata(method methodTyp, meta Metadata) {
+	if method&mALL == mALL {
+		n.endpoints.Value(mALL).meta = meta
+		for _, m := range methodMap {
+			n.endpoints.Value(m).meta = meta
+		}
+	} else {
+		for m := mCONNECT; m <= mTRACE; m <<= 1 {
+			if method&m == m {
+				n.endpoints.Value(m).meta = meta
+			}
+		}
+	}
+}
+
+func (n *node) setInline(method methodTyp, rg *routerGroup) {
+	if method&mALL == mALL {
+		n.endpoints.Value(mALL).inline = rg
+		for _, m := range methodMap {
+			n.endpoints.Value(m).inline = rg
+		}
+	} else {
+		for m := mCONNECT; m <= mTRACE; m <<= 1 {
+			if method&m == m {
+				n.endpoints.Value(m).inline = rg
+			}
+		}
+	}
+}
+
+func (n *node) setPriority(method methodTyp, priority int) {
+	if method&mALL == mALL {
+		n.endpoints.Value(mALL).priority = priority
+		for _, m := range methodMap {
+			n.endpoints.Value(m).priority = priority
+		}
+	} else {
+		for m := mCONNECT; m <= mTRACE; m <<= 1 {
+			if method&m == m {
+				n.endpoints.Value(m).priority = priority
+			}
+		}
+	}
+}
+
+// typOrder returns the search order of the child node types.
+func (n *node) typOrder() *[ntCatchAll + 1]nodeTyp {
+	if n.order == nil {
+		return &defaultTypOrder
+	}
+	return n.order
+}
+
+// updatePriority recomputes the priority of the subtree rooted at n and reorders
+// the child nodes, so that the subtrees with higher priority are searched first.
+// Subtrees with the same priority keep the default order: static, regexp, param, catchAll.
+func (n *node) updatePriority() int {
+	var priority int
+	var found bool
+	for _, h := range n.endpoints {
+		if h.handler != nil && (!found || h.priority > priority) {
+			priority, found = h.priority, true
+		}
 	}
+
+	var groups [ntCatchAll + 1]int
+	for typ, nds := range n.children {
+		if len(nds) == 0 {
+			continue
+		}
+		for i, child := range nds {
+			if p := child.updatePriority(); 0 == i || p > groups[typ] {
+				groups[typ] = p
+			}
+		}
+		if nodeTyp(typ) == ntRegexp || nodeTyp(typ) == ntParam {
+			nds.Sort()
+			sort.SliceStable(nds, func(i, j int) bool { return nds[i].priority > nds[j].priority })
+		}
+		if !found || groups[typ] > priority {
+			priority, found = groups[typ], true
+		}
+	}
+
+	order := defaultTypOrder
+	sort.SliceStable(order[:], func(i, j int) bool { return groups[order[i]] > groups[order[j]] })
+	if order == defaultTypOrder {
+		n.order = nil
+	} else {
+		n.order = &order
+	}
+
+	n.priority = priority
+	return priority
 }
 
 func (n *node) FindRoute(rctx *RouteContext, method methodTyp, path string) (*node, endpoints, http.Handler) {
@@ -355,6 +599,18 @@ func (n *node) FindRoute(rctx *RouteContext, method methodTyp, path string) (*no
 	rctx.routeParams.Keys = rctx.routeParams.Keys[:0]
 	rctx.routeParams.Values = rctx.routeParams.Values[:0]
 
+	// Serve the static routes by the exact path, unless the routes are ordered by priority.
+	if !n.prioritized {
+		if rn, ok := n.static[path]; ok {
+			if ep, ok := rn.endpoints[method]; ok && ep.handler != nil {
+				rctx.RoutePattern = ep.pattern
+				rctx.routePatterns = append(rctx.routePatterns, ep.pattern)
+				rctx.routeMetadata = ep.meta
+				return rn, rn.endpoints, ep.handler
+			}
+		}
+	}
+
 	// Find the routing handlers for the path
 	rn := n.findRoute(rctx, method, path)
 	if rn == nil {
@@ -371,6 +627,9 @@ func (n *node) FindRoute(rctx *RouteContext, method methodTyp, path string) (*no
 		rctx.routePatterns = append(rctx.routePatterns, rctx.RoutePattern)
 	}
 
+	// Record the metadata of the matched route
+	rctx.routeMetadata = rn.endpoints[method].meta
+
 	return rn, rn.endpoints, rn.endpoints[method].handler
 }
 
@@ -380,8 +639,8 @@ func (n *node) findRoute(rctx *RouteContext, method methodTyp, path string) *nod
 	nn := n
 	search := path
 
-	for t, nds := range nn.children {
-		ntyp := nodeTyp(t)
+	for _, ntyp := range nn.typOrder() {
+		nds := nn.children[ntyp]
 		if len(nds) == 0 {
 			continue
 		}
@@ -555,6 +814,15 @@ func (n *node) isLeaf() bool {
 	return n.endpoints != nil
 }
 
+func (n *node) hasChildren() bool {
+	for _, nds := range n.children {
+		if len(nds) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (n *node) findPattern(pattern string) bool {
 	nn := n
 	for _, nds := range nn.children {
@@ -622,10 +890,15 @@ func (n *node) routes() []Route {
 
 		for p, mh := range pats {
 			hs := make(map[string]http.Handler)
+			ms := make(map[string]Metadata)
 			if mh[mALL] != nil && mh[mALL].handler != nil {
 				hs["*"] = mh[mALL].handler
+				ms["*"] = mh[mALL].meta
 			}
 
+			var priority int
+			var found bool
+
 			for mt, h := range mh {
 				if h.handler == nil {
 					continue
@@ -635,9 +908,13 @@ func (n *node) routes() []Route {
 					continue
 				}
 				hs[m] = h.handler
+				ms[m] = h.meta
+				if !found || h.priority > priority {
+					priority, found = h.priority, true
+				}
 			}
 
-			rt := Route{subroutes, hs, p}
+			rt := Route{SubRoutes: subroutes, Handlers: hs, Metadata: ms, Pattern: p, Priority: priority}
 			rts = append(rts, rt)
 		}
 
@@ -779,13 +1056,27 @@ func methodTypString(method methodTyp) string {
 	return ""
 }
 
+// methodTypStrings returns the sorted method names contained in the method mask.
+func methodTypStrings(method methodTyp) []string {
+	var methods []string
+	for t, s := range reverseMethodMap {
+		if method&t == t {
+			methods = append(methods, s)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
 type nodes []*node
 
 // Sort the list of nodes by label
-func (ns nodes) Sort()              { sort.Sort(ns); ns.tailSort() }
-func (ns nodes) Len() int           { return len(ns) }
-func (ns nodes) Swap(i, j int)      { ns[i], ns[j] = ns[j], ns[i] }
-func (ns nodes) Less(i, j int) bool { return ns[i].label < ns[j].label }
+func (ns nodes) Sort()         { sort.Sort(ns); ns.tailSort() }
+func (ns nodes) Len() int      { return len(ns) }
+func (ns nodes) Swap(i, j int) { ns[i], ns[j] = ns[j], ns[i] }
+func (ns nodes) Less(i, j int) bool {
+	return ns[i].label < ns[j].label || (ns[i].label == ns[j].label && ns[i].seq < ns[j].seq)
+}
 
 // tailSort pushes nodes with '/' as the tail to the end of the list for param nodes.
 // The list order determines the traversal order.
@@ -819,11 +1110,18 @@ func (ns nodes) findEdge(label byte) *node {
 }
 
 // Route describes the details of a routing handler.
-// Handlers map key is an HTTP method
+// Handlers and Metadata map key is an HTTP method
 type Route struct {
 	SubRoutes Routes
 	Handlers  map[string]http.Handler
+	Metadata  map[string]Metadata
 	Pattern   string
+
+	// Priority decides the matching order when the route overlaps with others,
+	// e.g. `/files/latest`, `/files/{name}` and `/files/*`, higher priority routes
+	// are matched first. Routes with the same priority are matched in the default
+	// order: static segments, regexp params, params and finally catch-all wildcard.
+	Priority int
 }
 
 // WalkFunc is the type of the function called for each method and route visited by Walk.
@@ -839,6 +1137,7 @@ func walk(r Routes, walkFn WalkFunc, parentRoute string, parentMw ...func(http.H
 		mws := make([]func(http.Handler) http.Handler, len(parentMw))
 		copy(mws, parentMw)
 		mws = append(mws, r.Middlewares()...)
+		mws = mws[:len(mws):len(mws)]
 
 		if route.SubRoutes != nil {
 			if err := walk(route.SubRoutes, walkFn, parentRoute+route.Pattern, mws...); err != nil {
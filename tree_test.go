@@ -507,3 +507,61 @@ func BenchmarkTreeGet(b *testing.B) {
 		tr.FindRoute(mctx, mGET, "/ping/123/456")
 	}
 }
+
+func BenchmarkTreeGetStatic(b *testing.B) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tr := &node{}
+	tr.InsertRoute(mGET, "/ping", h)
+	tr.InsertRoute(mGET, "/ping/{id}", h)
+	tr.InsertRoute(mGET, "/ping/{id}/woop", h)
+	tr.InsertRoute(mGET, "/ping/all/woop", h)
+	tr.InsertRoute(mGET, "/ping/{id}/{opt}", h)
+
+	mctx := &RouteContext{}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		mctx.Reset()
+		tr.FindRoute(mctx, mGET, "/ping/all/woop")
+	}
+}
+
+func TestTreeStaticIndex(t *testing.T) {
+	hStatic := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	hParam := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tr := &node{}
+	tr.InsertRoute(mGET, "/ping/all/woop", hStatic)
+	tr.InsertRoute(mGET, "/ping/{id}/woop", hParam)
+	tr.InsertRoute(mPOST, "/ping/{id}/woop", hParam)
+
+	if _, ok := tr.static["/ping/all/woop"]; !ok || len(tr.static) != 1 {
+		t.Fatalf("unexpected static index: %v", tr.static)
+	}
+
+	tests := []struct {
+		method  methodTyp
+		path    string
+		pattern string
+		params  []string
+	}{
+		{mGET, "/ping/all/woop", "/ping/all/woop", nil},
+		{mPOST, "/ping/all/woop", "/ping/{id}/woop", []string{"all"}},
+		{mGET, "/ping/any/woop", "/ping/{id}/woop", []string{"any"}},
+	}
+	for _, tt := range tests {
+		rctx := &RouteContext{}
+		_, _, h := tr.FindRoute(rctx, tt.method, tt.path)
+		if h == nil || rctx.RoutePattern != tt.pattern || fmt.Sprint(rctx.URLParams.Values) != fmt.Sprint(tt.params) {
+			t.Fatalf("%s: unexpected route '%s' with params %v", tt.path, rctx.RoutePattern, rctx.URLParams.Values)
+		}
+	}
+
+	tr.removeRoute(mGET, "/ping/all/woop")
+	rctx := &RouteContext{}
+	if _, _, h := tr.FindRoute(rctx, mGET, "/ping/all/woop"); h == nil || rctx.RoutePattern != "/ping/{id}/woop" {
+		t.Fatalf("unexpected route '%s' after removal", rctx.RoutePattern)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+)
+
+// ValidatorKey is the route metadata key of the validator overriding the validator of the router.
+const ValidatorKey = "web.validator"
+
+// WithValidator overrides the validator of the router for the request structs bound for the route.
+//
+//	router.Post("/users", CreateUser).Apply(web.WithValidator(strict.Struct))
+func WithValidator(validator func(i interface{}) error) RouteOption {
+	return func(e Endpoint) {
+		e.Meta(ValidatorKey, validator)
+	}
+}
+
+// Validator sets the validator of the request structs bound for the routes of the router, overriding
+// the global one registered by binding.RegisterValidator, the routers without a validator inherit the
+// validator of their parent. The inline routers share the validator of the router they derive from.
+//
+//	router.Group("/admin").Validator(strict.Struct)
+func (rg *routerGroup) Validator(validator func(i interface{}) error) Router {
+	if rg.inline {
+		panic("the validator can't be set on inline routers, set it on the router they derive from")
+	}
+	rg.validator = validator
+	return rg
+}
+
+// validatorOf returns the validator of the route serving the request, or of the router serving it,
+// nil if neither sets one, so the global validator applies.
+func validatorOf(ctx context.Context) func(i interface{}) error {
+	if rctx := FromRouteContext(ctx); nil != rctx {
+		if validator, ok := rctx.routeMetadata[ValidatorKey].(func(i interface{}) error); ok {
+			return validator
+		}
+	}
+	for g := servingRouter(ctx); nil != g; g = g.parent {
+		if nil != g.validator {
+			return g.validator
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-spring.dev/web/binding"
+)
+
+func TestValidator(t *testing.T) {
+	type Param struct {
+		Name string `query:"name"`
+	}
+	reject := func(reason string) func(i interface{}) error {
+		return func(i interface{}) error {
+			return binding.BindingErrors{{Field: "name", Source: "validate", Reason: reason}}
+		}
+	}
+
+	binding.RegisterValidator(reject("global"))
+	defer binding.RegisterValidator(nil)
+
+	handler := func(ctx context.Context, p Param) string { return p.Name }
+
+	r := NewRouter()
+	r.Get("/public", handler)
+	r.Group("/admin", func(r Router) {
+		r.Validator(reject("admin"))
+		r.Get("/users", handler)
+		r.Get("/open", handler).Apply(WithValidator(func(i interface{}) error { return nil }))
+		r.Group("/nested", func(r Router) {
+			r.Get("/users", handler)
+		})
+	})
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/public?name=a", "global"},
+		{"/admin/users?name=a", "admin"},
+		{"/admin/nested/users?name=a", "admin"},
+		{"/admin/open?name=a", `"data":"a"`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		assert.Contains(t, w.Body.String(), tt.body, tt.path)
+	}
+
+	// the clones keep the validators.
+	w := httptest.NewRecorder()
+	r.Clone().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users?name=a", nil))
+	assert.Contains(t, w.Body.String(), "admin")
+
+	assert.Panics(t, func() { r.With().Validator(reject("inline")) })
+	assert.Nil(t, (&Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}).Validator())
+}
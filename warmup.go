@@ -0,0 +1,166 @@
+/*
+ * Copyright 2023 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// warmup holds the warm-up functions of a router group and gates its routes until they complete.
+type warmup struct {
+	mu    sync.Mutex
+	fns   []func(ctx context.Context) error
+	ready atomic.Bool
+	err   error
+}
+
+// run executes the warm-up functions unless they have already completed.
+func (w *warmup) run(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.ready.Load() {
+		return nil
+	}
+
+	for _, fn := range w.fns {
+		if err := fn(ctx); nil != err {
+			w.err = err
+			return err
+		}
+	}
+
+	w.err = nil
+	w.ready.Store(true)
+	return nil
+}
+
+// gate rejects the requests with 503 Service Unavailable until the warm-up completes.
+func (w *warmup) gate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !w.ready.Load() {
+			http.Error(writer, "503 service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// Warmup registers a warm-up function of the router, the routes of the router respond
+// 503 Service Unavailable until all of its warm-up functions complete successfully.
+// Like middlewares, the warm-up functions must be registered before the routes.
+func (rg *routerGroup) Warmup(fn func(ctx context.Context) error) Router {
+	if nil == rg.warmup {
+		if rg.handler != nil {
+			panic("warm-up functions must be defined before routes registers")
+		}
+
+		rg.warmup = &warmup{}
+		rg.Use(rg.warmup.gate)
+
+		owner := rg
+		for owner.inline {
+			owner = owner.parent
+		}
+		owner.warmups = append(owner.warmups, rg.warmup)
+	}
+
+	rg.warmup.fns = append(rg.warmup.fns, fn)
+	return rg
+}
+
+// RunWarmups runs the pending warm-up functions of the router and its mounted subrouters
+// concurrently, the routes become ready as soon as the warm-up of their group completes.
+// The failed warm-ups keep their routes unavailable and are retried on the next call.
+func RunWarmups(ctx context.Context, r Routes) error {
+	warmups := collectWarmups(r, nil, map[Routes]bool{})
+
+	var wg sync.WaitGroup
+	var errs = make([]error, len(warmups))
+	for i, w := range warmups {
+		wg.Add(1)
+		go func(i int, w *warmup) {
+			defer wg.Done()
+			errs[i] = w.run(ctx)
+		}(i, w)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Ready reports whether all the warm-up functions of the router and its mounted subrouters completed.
+func Ready(r Routes) bool {
+	for _, w := range collectWarmups(r, nil, map[Routes]bool{}) {
+		if !w.ready.Load() {
+			return false
+		}
+	}
+	return true
+}
+
+// Readiness returns a readiness probe handler of the router, it responds 200 OK once all
+// the warm-up functions completed, and 503 Service Unavailable before that. The errors of
+// the failed warm-ups are logged by the logger of the router rather than responded, since
+// the probes are usually public.
+func Readiness(r Routes) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		for _, w := range collectWarmups(r, nil, map[Routes]bool{}) {
+			if w.ready.Load() {
+				continue
+			}
+
+			reason := "warming up"
+			if w.mu.TryLock() {
+				if nil != w.err {
+					reason = "warm-up failed"
+					routerLogger(r).WarnContext(request.Context(), "not ready", slog.Any("error", w.err))
+				}
+				w.mu.Unlock()
+			}
+			http.Error(writer, reason, http.StatusServiceUnavailable)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		writer.WriteHeader(http.StatusOK)
+		writer.Write([]byte("ok"))
+	})
+}
+
+func collectWarmups(r Routes, warmups []*warmup, visited map[Routes]bool) []*warmup {
+	if visited[r] {
+		return warmups
+	}
+	visited[r] = true
+
+	if rg, ok := r.(*routerGroup); ok {
+		warmups = append(warmups, rg.warmups...)
+	}
+
+	for _, route := range r.Routes() {
+		if nil != route.SubRoutes {
+			warmups = collectWarmups(route.SubRoutes, warmups, visited)
+		}
+	}
+	return warmups
+}
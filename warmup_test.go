@@ -0,0 +1,94 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterWarmup(t *testing.T) {
+	var cacheLoaded bool
+	var failures = 1
+	var logs bytes.Buffer
+
+	r := NewRouterWith(RouterOptions{Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+	r.Group("/catalog", func(r Router) {
+		r.Warmup(func(ctx context.Context) error {
+			cacheLoaded = true
+			return nil
+		})
+		r.Get("/items", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("items"))
+		})
+	})
+	r.Group("/search", func(r Router) {
+		r.Warmup(func(ctx context.Context) error {
+			if failures > 0 {
+				failures--
+				return errors.New("index not loaded")
+			}
+			return nil
+		})
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("search"))
+		})
+	})
+
+	serve := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.False(t, Ready(r))
+	assert.Equal(t, "pong", serve(r, "/ping").Body.String())
+	assert.Equal(t, http.StatusServiceUnavailable, serve(r, "/catalog/items").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(Readiness(r), "/").Code)
+
+	err := RunWarmups(context.Background(), r)
+	assert.EqualError(t, err, "index not loaded")
+	assert.True(t, cacheLoaded)
+	assert.False(t, Ready(r))
+	assert.Equal(t, "items", serve(r, "/catalog/items").Body.String())
+	assert.Equal(t, http.StatusServiceUnavailable, serve(r, "/search/").Code)
+	assert.Equal(t, "warm-up failed\n", serve(Readiness(r), "/").Body.String())
+	assert.Contains(t, logs.String(), "index not loaded")
+
+	assert.Nil(t, RunWarmups(context.Background(), r))
+	assert.True(t, Ready(r))
+	assert.Equal(t, "search", serve(r, "/search/").Body.String())
+	assert.Equal(t, http.StatusOK, serve(Readiness(r), "/").Code)
+}
+
+func TestRouterWarmupInline(t *testing.T) {
+	r := NewRouter()
+	r.With().Warmup(func(ctx context.Context) error { return nil }).Get("/report", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("report"))
+	})
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	assert.Nil(t, RunWarmups(context.Background(), r))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report", nil))
+	assert.Equal(t, "report", w.Body.String())
+
+	assert.Panics(t, func() {
+		r.Warmup(func(ctx context.Context) error { return nil })
+	})
+}